@@ -42,6 +42,7 @@ OCSERV_PRE_LOGIN_BANNER="Welcome"                             # Default Ocserv p
 LANGUAGES="en:English,it:Italiano,zh-cn:中文(简体),zh-tw:中文(繁體),ru:Русский,fa:فارسی,ar:العربية"  # Supported languages
 SECRET_KEY=$(openssl rand -hex 32)                            # Secret key for app encryption (32 hex chars)
 JWT_SECRET=$(openssl rand -hex 32)                            # JWT signing secret (32 hex chars)
+AGENT_TOKEN=$(openssl rand -hex 32)                           # Shared secret of the ocserv webhook agent (32 hex chars)
 SSL_C=US                                                      # SSL Country iso2
 SSL_ST=CA                                                     # SSL State name
 SSL_L=SanFrancisco                                            # SSl City name
@@ -442,6 +443,7 @@ set_environment() {
 HOST="${HOST}"
 SECRET_KEY="${SECRET_KEY}"
 JWT_SECRET="${JWT_SECRET}"
+AGENT_TOKEN="${AGENT_TOKEN}"
 LANGUAGES="${LANGUAGES}"
 ALLOW_ORIGINS="https://${HOST}:3443"
 SSL_CN="${SSL_CN}"
@@ -651,6 +653,12 @@ main() {
         # shellcheck disable=SC1090
         source "$ENV_FILE"
         set +o allexport
+        # .env files of installs predating the webhook agent token lack it, and the agent refuses to start without one
+        if ! grep -q '^AGENT_TOKEN=' "$ENV_FILE"; then
+            AGENT_TOKEN="$(generate_secret)"
+            printf 'AGENT_TOKEN="%s"\n' "$AGENT_TOKEN" >> "$ENV_FILE"
+            print_message highlight "✅ AGENT_TOKEN added to $ENV_FILE"
+        fi
         print_message success "✅ Environment loaded"
     else
         print_message info "⚡ No .env found. Running interactive setup..."
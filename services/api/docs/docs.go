@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/customers/summary": {
-            "post": {
-                "description": "Customer summary account",
+        "/config-versions/{kind}/{name}": {
+            "get": {
+                "description": "Versions of a group, defaults group or user config, newest first. The defaults group is named defaults.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,25 +25,73 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Customers"
+                    "Config Versions"
                 ],
-                "summary": "Customer summary account",
+                "summary": "List of config versions",
                 "parameters": [
                     {
-                        "description": "customer username and password (same ocserv account).",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/customer.SummaryData"
-                        }
+                        "enum": [
+                            "group",
+                            "defaults",
+                            "user"
+                        ],
+                        "type": "string",
+                        "description": "config kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name or username",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/customer.SummaryResponse"
+                            "$ref": "#/definitions/config_version.ConfigVersionsResponse"
                         }
                     },
                     "400": {
@@ -52,18 +100,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/request.ErrorResponse"
                         }
                     },
-                    "429": {
-                        "description": "Too Many Requests",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/middlewares.TooManyRequests"
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
                         }
                     }
                 }
             }
         },
-        "/home": {
+        "/config-versions/{kind}/{name}/diff": {
             "get": {
-                "description": "Content of home",
+                "description": "Unified diff between the config files of two versions",
                 "consumes": [
                     "application/json"
                 ],
@@ -71,10 +125,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Home"
+                    "Config Versions"
                 ],
-                "summary": "Content of home",
+                "summary": "Diff two config versions",
                 "parameters": [
+                    {
+                        "enum": [
+                            "group",
+                            "defaults",
+                            "user"
+                        ],
+                        "type": "string",
+                        "description": "config kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name or username",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "from version",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "to version",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
@@ -87,7 +174,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/home.GetHomeResponse"
+                            "$ref": "#/definitions/config_version.ConfigVersionDiffResponse"
                         }
                     },
                     "400": {
@@ -101,13 +188,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/occtl/commands": {
+        "/config-versions/{kind}/{name}/{version}": {
             "get": {
-                "description": "Occtl Commands",
+                "description": "Config version detail with the rendered config file and its diff against the previous version",
                 "consumes": [
                     "application/json"
                 ],
@@ -115,36 +208,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "OCCTL"
+                    "Config Versions"
                 ],
-                "summary": "Occtl Commands",
+                "summary": "Config version detail",
                 "parameters": [
+                    {
+                        "enum": [
+                            "group",
+                            "defaults",
+                            "user"
+                        ],
+                        "type": "string",
+                        "description": "config kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
-                        "description": "Bearer TOKEN",
-                        "name": "Authorization",
-                        "in": "header",
+                        "description": "group name or username",
+                        "name": "name",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Command Action ID (1 to 15)",
-                        "name": "action",
-                        "in": "query",
+                        "description": "version number",
+                        "name": "version",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Optional parameter depending on command",
-                        "name": "value",
-                        "in": "query"
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ConfigVersion"
                         }
                     },
                     "400": {
@@ -158,42 +264,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
-                    }
-                }
-            }
-        },
-        "/occtl/server_info": {
-            "get": {
-                "description": "Server information",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "OCCTL"
-                ],
-                "summary": "Server information",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.ServerVersion"
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/request.ErrorResponse"
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
                         }
                     }
                 }
             }
         },
-        "/ocserv/groups": {
-            "get": {
-                "description": "List of Ocserv groups",
+        "/config-versions/{kind}/{name}/{version}/rollback": {
+            "post": {
+                "description": "Rewrite the group, defaults group or user config with the given version and reload ocserv.\nThe rollback is recorded as a new version.",
                 "consumes": [
                     "application/json"
                 ],
@@ -201,40 +284,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "Config Versions"
                 ],
-                "summary": "List of Ocserv groups",
+                "summary": "Roll back to a config version",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Page number, starting from 1",
-                        "name": "page",
-                        "in": "query"
+                        "enum": [
+                            "group",
+                            "defaults",
+                            "user"
+                        ],
+                        "type": "string",
+                        "description": "config kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Number of items per page",
-                        "name": "size",
-                        "in": "query"
+                        "type": "string",
+                        "description": "group name or username",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Field to order by",
-                        "name": "order",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "version number",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "enum": [
-                            "ASC",
-                            "DESC"
-                        ],
-                        "type": "string",
-                        "description": "Sort order, either ASC or DESC",
-                        "name": "sort",
-                        "in": "query"
+                        "description": "rollback note",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/config_version.RollbackConfigVersionData"
+                        }
                     },
                     {
                         "type": "string",
@@ -248,7 +334,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/ocserv_group.OcservGroupsResponse"
+                            "$ref": "#/definitions/models.ConfigVersion"
                         }
                     },
                     "400": {
@@ -262,11 +348,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/customers/summary": {
             "post": {
-                "description": "Ocserv Group creation",
+                "description": "Customer summary account",
                 "consumes": [
                     "application/json"
                 ],
@@ -274,32 +368,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "Customers"
                 ],
-                "summary": "Ocserv Group creation",
+                "summary": "Customer summary account",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Bearer TOKEN",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "description": "ocserv group create data",
+                        "description": "customer username and password (same ocserv account).",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ocserv_group.CreateOcservGroupData"
+                            "$ref": "#/definitions/customer.SummaryData"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OcservGroup"
+                            "$ref": "#/definitions/customer.SummaryResponse"
                         }
                     },
                     "400": {
@@ -308,18 +395,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/request.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
-                            "$ref": "#/definitions/middlewares.Unauthorized"
+                            "$ref": "#/definitions/middlewares.TooManyRequests"
                         }
                     }
                 }
             }
         },
-        "/ocserv/groups/defaults": {
+        "/home": {
             "get": {
-                "description": "Ocserv Defaults Group config",
+                "description": "Content of home",
                 "consumes": [
                     "application/json"
                 ],
@@ -327,9 +414,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "Home"
                 ],
-                "summary": "Ocserv Defaults Group config",
+                "summary": "Content of home",
                 "parameters": [
                     {
                         "type": "string",
@@ -343,8 +430,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/home.GetHomeResponse"
                         }
                     },
                     "400": {
@@ -360,9 +446,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update Ocserv Defaults Group",
+            }
+        },
+        "/ipam/allocate": {
+            "post": {
+                "description": "Give the ocserv user the next free address of its pool as explicit-ipv4, unless it already has one",
                 "consumes": [
                     "application/json"
                 ],
@@ -370,9 +458,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "IPAM"
                 ],
-                "summary": "Update Ocserv Defaults Group",
+                "summary": "Allocate explicit-ipv4",
                 "parameters": [
                     {
                         "type": "string",
@@ -382,18 +470,21 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "ocserv group default data",
+                        "description": "ocserv user to allocate an address to",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ocserv_group.UpdateOcservGroupData"
+                            "$ref": "#/definitions/ipam.AllocateData"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservUser"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -406,13 +497,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/groups/lookup": {
+        "/ipam/conflicts": {
             "get": {
-                "description": "List of Ocserv group names",
+                "description": "explicit-ipv4 addresses given to several users, outside the pool of their user or reserved in the pool",
                 "consumes": [
                     "application/json"
                 ],
@@ -420,9 +517,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "IPAM"
                 ],
-                "summary": "List of Ocserv group names",
+                "summary": "explicit-ipv4 conflicts",
                 "parameters": [
                     {
                         "type": "string",
@@ -438,7 +535,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/repository.IPAMConflict"
                             }
                         }
                     },
@@ -453,13 +550,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/groups/sync": {
-            "post": {
-                "description": "Ocserv Groups from file",
+        "/ipam/pools": {
+            "get": {
+                "description": "IPv4 pool of every group with its utilization by explicit-ipv4 addresses",
                 "consumes": [
                     "application/json"
                 ],
@@ -467,9 +570,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(UnsyncedGroup)"
+                    "IPAM"
                 ],
-                "summary": "Ocserv Groups from file",
+                "summary": "IPv4 pools",
                 "parameters": [
                     {
                         "type": "string",
@@ -477,15 +580,6 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "list of groups with config to sync in db",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ocserv_group.SyncGroupRequest"
-                        }
                     }
                 ],
                 "responses": {
@@ -494,7 +588,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "$ref": "#/definitions/repository.IPAMPool"
                             }
                         }
                     },
@@ -509,13 +603,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/groups/unsynced": {
+        "/ipam/pools/{group}": {
             "get": {
-                "description": "list of Unsynced Groups",
+                "description": "IPv4 pool of a group with its explicit-ipv4 allocations and the next free address",
                 "consumes": [
                     "application/json"
                 ],
@@ -523,10 +623,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(UnsyncedGroup)"
+                    "IPAM"
                 ],
-                "summary": "list of Unsynced Groups",
+                "summary": "IPv4 pool of a group",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name, or defaults",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
@@ -539,10 +646,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/group.UnsyncedGroup"
-                            }
+                            "$ref": "#/definitions/repository.IPAMPoolDetail"
                         }
                     },
                     "400": {
@@ -556,13 +660,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/groups/{id}": {
+        "/nodes": {
             "get": {
-                "description": "Ocserv group detail",
+                "description": "List of ocserv nodes managed through their webhook agent",
                 "consumes": [
                     "application/json"
                 ],
@@ -570,16 +680,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "Nodes"
                 ],
-                "summary": "Ocserv group detail",
+                "summary": "List of ocserv nodes",
                 "parameters": [
                     {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
                         "type": "integer",
-                        "description": "Ocserv Group ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -593,7 +727,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OcservGroup"
+                            "$ref": "#/definitions/node.NodesResponse"
                         }
                     },
                     "400": {
@@ -607,11 +741,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             },
-            "delete": {
-                "description": "Ocserv Group delete",
+            "post": {
+                "description": "Register an ocserv node by the URL of its webhook agent",
                 "consumes": [
                     "application/json"
                 ],
@@ -619,9 +759,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "Nodes"
                 ],
-                "summary": "Ocserv Group delete",
+                "summary": "Ocserv node creation",
                 "parameters": [
                     {
                         "type": "string",
@@ -631,16 +771,21 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Ocserv Group ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "node create data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/node.CreateNodeData"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Node"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -653,11 +798,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
-            },
-            "patch": {
-                "description": "Ocserv Group update",
+            }
+        },
+        "/nodes/lookup": {
+            "get": {
+                "description": "List of ocserv node names for assigning users and groups",
                 "consumes": [
                     "application/json"
                 ],
@@ -665,9 +818,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Groups)"
+                    "Nodes"
                 ],
-                "summary": "Ocserv Group update",
+                "summary": "List of ocserv node names",
                 "parameters": [
                     {
                         "type": "string",
@@ -675,29 +828,16 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Ocserv Group ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "ocserv group create data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ocserv_group.UpdateOcservGroupData"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OcservGroup"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Node"
+                            }
                         }
                     },
                     "400": {
@@ -711,13 +851,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/users": {
+        "/nodes/status": {
             "get": {
-                "description": "List of Ocserv Users",
+                "description": "Server status and online sessions of every active node",
                 "consumes": [
                     "application/json"
                 ],
@@ -725,48 +871,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Nodes"
                 ],
-                "summary": "List of Ocserv Users",
+                "summary": "Status of ocserv nodes",
                 "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Page number, starting from 1",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Number of items per page",
-                        "name": "size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Field to order by",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "ASC",
-                            "DESC"
-                        ],
-                        "type": "string",
-                        "description": "Sort order, either ASC or DESC",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "minLength": 2,
-                        "type": "string",
-                        "description": "ocserv username q search",
-                        "name": "q",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
@@ -779,7 +887,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/ocserv_user.OcservUsersResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.NodeStatus"
+                            }
                         }
                     },
                     "400": {
@@ -793,11 +904,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
-            },
-            "post": {
-                "description": "Ocserv User creation",
+            }
+        },
+        "/nodes/{id}": {
+            "get": {
+                "description": "Ocserv node detail",
                 "consumes": [
                     "application/json"
                 ],
@@ -805,33 +924,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Nodes"
                 ],
-                "summary": "Ocserv User creation",
+                "summary": "Ocserv node detail",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "ocserv user create data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ocserv_user.CreateOcservUserData"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/models.OcservUser"
-                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Node"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -844,13 +961,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
-            }
-        },
-        "/ocserv/users/ocpasswd": {
-            "get": {
-                "description": "Ocserv Users from ocpasswd file",
+            },
+            "delete": {
+                "description": "Remove a node from the dashboard. Users and groups on the node itself are left untouched.",
                 "consumes": [
                     "application/json"
                 ],
@@ -858,55 +979,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Ocpasswd)"
+                    "Nodes"
                 ],
-                "summary": "Ocserv Users from ocpasswd file",
+                "summary": "Ocserv node delete",
                 "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Page number, starting from 1",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Number of items per page",
-                        "name": "size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Field to order by",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "ASC",
-                            "DESC"
-                        ],
-                        "type": "string",
-                        "description": "Sort order, either ASC or DESC",
-                        "name": "sort",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/ocserv_user.OcservUsersSyncResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -919,13 +1013,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
-            }
-        },
-        "/ocserv/users/ocpasswd/sync": {
-            "post": {
-                "description": "Ocserv Users from ocpasswd file to db",
+            },
+            "patch": {
+                "description": "Ocserv node update",
                 "consumes": [
                     "application/json"
                 ],
@@ -933,9 +1031,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Ocpasswd)"
+                    "Nodes"
                 ],
-                "summary": "Ocserv Users from ocpasswd file to db",
+                "summary": "Ocserv node update",
                 "parameters": [
                     {
                         "type": "string",
@@ -945,12 +1043,19 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "list of users with config to sync in db",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "node update data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ocserv_user.SyncOcpasswdRequest"
+                            "$ref": "#/definitions/node.UpdateNodeData"
                         }
                     }
                 ],
@@ -958,10 +1063,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.Node"
                         }
                     },
                     "400": {
@@ -975,13 +1077,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/users/statistics": {
+        "/nodes/{id}/statistics": {
             "get": {
-                "description": "Ocserv Users Statistics",
+                "description": "Traffic of all users recorded on the node, in buckets of the given granularity",
                 "consumes": [
                     "application/json"
                 ],
@@ -989,9 +1097,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Statistics)"
+                    "Nodes"
                 ],
-                "summary": "Ocserv Users Statistics",
+                "summary": "Ocserv node traffic statistics",
                 "parameters": [
                     {
                         "type": "string",
@@ -1000,19 +1108,55 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "date_start",
                         "name": "date_start",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "date_end",
                         "name": "date_end",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "granularity",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the buckets, UTC when empty. Buckets of time zones off UTC by a fraction of an hour start up to 59 minutes after their label",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor header of the previous page of buckets",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of buckets per page, all when empty",
+                        "name": "size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1023,6 +1167,12 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.DailyTraffic"
                             }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page of buckets"
+                            }
                         }
                     },
                     "400": {
@@ -1036,13 +1186,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/users/total-bandwidth": {
+        "/occtl/commands": {
             "get": {
-                "description": "Ocserv Users TotalBandwidth calculating",
+                "description": "Occtl Commands",
                 "consumes": [
                     "application/json"
                 ],
@@ -1050,9 +1206,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Bandwidth)"
+                    "OCCTL"
                 ],
-                "summary": "Ocserv Users TotalBandwidth calculating",
+                "summary": "Occtl Commands",
                 "parameters": [
                     {
                         "type": "string",
@@ -1062,25 +1218,24 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "date_start",
-                        "name": "date_start",
+                        "type": "integer",
+                        "description": "Command Action ID (1 to 15)",
+                        "name": "action",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "date_end",
-                        "name": "date_end",
-                        "in": "query",
-                        "required": true
+                        "description": "Optional parameter depending on command",
+                        "name": "value",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/repository.TotalBandwidths"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -1098,9 +1253,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/ocserv/users/{uid}": {
+        "/occtl/server_info": {
             "get": {
-                "description": "Ocserv user detail",
+                "description": "Server information",
                 "consumes": [
                     "application/json"
                 ],
@@ -1108,30 +1263,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
-                ],
-                "summary": "Ocserv user detail",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Ocserv User UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Bearer TOKEN",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    }
+                    "OCCTL"
                 ],
+                "summary": "Server information",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OcservUser"
+                            "$ref": "#/definitions/models.ServerVersion"
                         }
                     },
                     "400": {
@@ -1139,17 +1278,13 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/request.ErrorResponse"
                         }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/middlewares.Unauthorized"
-                        }
                     }
                 }
-            },
-            "delete": {
-                "description": "Ocserv User delete",
+            }
+        },
+        "/ocserv/attributes": {
+            "get": {
+                "description": "List of the custom attributes users and groups can be given, with the type of their values",
                 "consumes": [
                     "application/json"
                 ],
@@ -1157,9 +1292,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Ocserv(Attributes)"
                 ],
-                "summary": "Ocserv User delete",
+                "summary": "List of custom attributes",
                 "parameters": [
                     {
                         "type": "string",
@@ -1167,18 +1302,17 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Ocserv User UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AttributeDefinition"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1194,8 +1328,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
-                "description": "Ocserv User update",
+            "post": {
+                "description": "Define a custom attribute of users and groups. Its values must be of the given type.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1203,9 +1337,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Ocserv(Attributes)"
                 ],
-                "summary": "Ocserv User update",
+                "summary": "Custom attribute creation",
                 "parameters": [
                     {
                         "type": "string",
@@ -1215,19 +1349,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Ocserv User UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "ocserv user update data",
+                        "description": "attribute data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/ocserv_user.UpdateOcservUserData"
+                            "$ref": "#/definitions/attribute.CreateAttributeData"
                         }
                     }
                 ],
@@ -1235,7 +1362,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.OcservUser"
+                            "$ref": "#/definitions/models.AttributeDefinition"
                         }
                     },
                     "400": {
@@ -1249,13 +1376,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/users/{uid}/activate": {
-            "post": {
-                "description": "Restore and activate expired Ocserv User accounts",
+        "/ocserv/attributes/{key}": {
+            "delete": {
+                "description": "Delete a custom attribute with its values on every user and group",
                 "consumes": [
                     "application/json"
                 ],
@@ -1263,9 +1396,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Ocserv(Attributes)"
                 ],
-                "summary": "Restore and activate expired Ocserv User accounts",
+                "summary": "Custom attribute delete",
                 "parameters": [
                     {
                         "type": "string",
@@ -1276,24 +1409,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Ocserv User UID",
-                        "name": "uid",
+                        "description": "Attribute key",
+                        "name": "key",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "list of ocserv users and expire time",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/ocserv_user.ActivateUserData"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1306,13 +1430,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/users/{uid}/lock": {
-            "post": {
-                "description": "Ocserv User locking",
+        "/ocserv/config": {
+            "get": {
+                "description": "Directives of the main ocserv.conf managed by the dashboard",
                 "consumes": [
                     "application/json"
                 ],
@@ -1320,9 +1450,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Ocserv(Config)"
                 ],
-                "summary": "Ocserv User locking",
+                "summary": "Main ocserv config",
                 "parameters": [
                     {
                         "type": "string",
@@ -1330,18 +1460,14 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Ocserv User UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservServerConfig"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1354,13 +1480,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
-            }
-        },
-        "/ocserv/users/{uid}/statistics": {
-            "get": {
-                "description": "Ocserv User Statistics",
+            },
+            "patch": {
+                "description": "Update ocserv.conf. The new version is checked with ocserv --test-config before it replaces the file,\nthe previous version is backed up and ocserv is reloaded. Omitted fields are left untouched,\nempty strings and lists remove the directive.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1368,9 +1498,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Ocserv(Config)"
                 ],
-                "summary": "Ocserv User Statistics",
+                "summary": "Update main ocserv config",
                 "parameters": [
                     {
                         "type": "string",
@@ -1380,30 +1510,20 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Ocserv User UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "date_start",
-                        "name": "date_start",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "date_end",
-                        "name": "date_end",
-                        "in": "query"
+                        "description": "ocserv config update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservServerConfig"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/ocserv_user.StatisticsResponse"
+                            "$ref": "#/definitions/ocserv_config.OcservConfigDiffResponse"
                         }
                     },
                     "400": {
@@ -1417,13 +1537,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/users/{uid}/unlock": {
+        "/ocserv/config/preview": {
             "post": {
-                "description": "Ocserv User unlocking",
+                "description": "Validate the update and return the diff it would apply to ocserv.conf, without writing it.\nOmitted fields are left untouched, empty strings and lists remove the directive.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1431,9 +1557,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Ocserv(Config)"
                 ],
-                "summary": "Ocserv User unlocking",
+                "summary": "Preview main ocserv config update",
                 "parameters": [
                     {
                         "type": "string",
@@ -1443,16 +1569,21 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Ocserv User UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
+                        "description": "ocserv config update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservServerConfig"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_config.OcservConfigDiffResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1465,13 +1596,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/ocserv/users/{username}/disconnect": {
-            "post": {
-                "description": "Disconnect Ocserv User",
+        "/ocserv/groups": {
+            "get": {
+                "description": "List of Ocserv groups",
                 "consumes": [
                     "application/json"
                 ],
@@ -1479,28 +1616,93 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Ocserv(Users)"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Disconnect Ocserv User",
+                "summary": "List of Ocserv groups",
                 "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Bearer TOKEN",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
                     },
                     {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
                         "type": "string",
-                        "description": "Ocserv User username",
-                        "name": "username",
-                        "in": "path",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page for cursor pagination ordered by id or name, empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields of the items to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the total records, true by default without a cursor",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "tags the groups all have, repeated or comma separated",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "comparisons of defined custom attributes such as department=sales or seats\u003e=10, with = != \u003e \u003e= \u003c \u003c=, only = and != for bool attributes",
+                        "name": "attr",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_group.OcservGroupsResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1515,11 +1717,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/system": {
-            "get": {
-                "description": "Get panel System Config",
+            },
+            "post": {
+                "description": "Ocserv Group creation",
                 "consumes": [
                     "application/json"
                 ],
@@ -1527,9 +1727,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Get panel System Config",
+                "summary": "Ocserv Group creation",
                 "parameters": [
                     {
                         "type": "string",
@@ -1537,13 +1737,22 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "ocserv group create data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_group.CreateOcservGroupData"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/system.GetSystemResponse"
+                            "$ref": "#/definitions/models.OcservGroup"
                         }
                     },
                     "400": {
@@ -1559,9 +1768,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update panel System Config",
+            }
+        },
+        "/ocserv/groups/defaults": {
+            "get": {
+                "description": "Ocserv Defaults Group config",
                 "consumes": [
                     "application/json"
                 ],
@@ -1569,19 +1780,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Update panel System Config",
+                "summary": "Ocserv Defaults Group config",
                 "parameters": [
-                    {
-                        "description": "update system config data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/system.PatchSystemUpdateData"
-                        }
-                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
@@ -1594,7 +1796,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/system.GetSystemResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1610,11 +1813,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/system/init": {
-            "get": {
-                "description": "Get panel System init Config",
+            },
+            "patch": {
+                "description": "Update Ocserv Defaults Group",
                 "consumes": [
                     "application/json"
                 ],
@@ -1622,28 +1823,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Get panel System init Config",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/system.GetSystemInitResponse"
-                        }
+                "summary": "Update Ocserv Defaults Group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
-                    "400": {
+                    {
+                        "description": "ocserv group default data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_group.UpdateOcservGroupData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/request.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
                     }
                 }
             }
         },
-        "/system/setup": {
+        "/ocserv/groups/labels": {
             "post": {
-                "description": "Setup user and system config",
+                "description": "Edit the tags and custom attributes of several groups at once. tags replaces the tags, then add_tags and remove_tags apply.\nattributes sets the given attributes and removes those set to null. Non-admin users only edit their own groups.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1651,25 +1873,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Setup user and system config",
+                "summary": "Edit the labels of Ocserv groups",
                 "parameters": [
                     {
-                        "description": "system setup data",
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "labels edit",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/system.SetupSystem"
+                            "$ref": "#/definitions/ocserv_group.EditOcservGroupsLabelsData"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/system.SetupSystemResponse"
+                            "$ref": "#/definitions/ocserv_group.EditLabelsResponse"
                         }
                     },
                     "400": {
@@ -1677,13 +1906,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/request.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
                     }
                 }
             }
         },
-        "/system/users": {
+        "/ocserv/groups/lookup": {
             "get": {
-                "description": "List of Admin or simple users",
+                "description": "List of Ocserv group names",
                 "consumes": [
                     "application/json"
                 ],
@@ -1691,41 +1926,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "List of Admin or simple users",
+                "summary": "List of Ocserv group names",
                 "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Page number, starting from 1",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Number of items per page",
-                        "name": "size",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Field to order by",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "ASC",
-                            "DESC"
-                        ],
-                        "type": "string",
-                        "description": "Sort order, either ASC or DESC",
-                        "name": "sort",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
@@ -1738,7 +1942,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/system.UsersResponse"
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
@@ -1752,17 +1959,13 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
-                    },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/middlewares.PermissionDenied"
-                        }
                     }
                 }
-            },
+            }
+        },
+        "/ocserv/groups/members/move": {
             "post": {
-                "description": "Create user Admin or simple",
+                "description": "Move Ocserv users to a group in the database, ocpasswd and the nodes. Either all users are moved or none.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1770,9 +1973,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Create user",
+                "summary": "Move Ocserv users to a group",
                 "parameters": [
                     {
                         "type": "string",
@@ -1782,20 +1985,23 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "create user data",
+                        "description": "users and target group",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/system.CreateUserData"
+                            "$ref": "#/definitions/ocserv_group.MoveMembersData"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.OcservUser"
+                            }
                         }
                     },
                     "400": {
@@ -1819,9 +2025,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/system/users/login": {
-            "post": {
-                "description": "Admin users login with Google captcha(captcha site key required in get config api)",
+        "/ocserv/groups/statistics": {
+            "get": {
+                "description": "Members, online members and traffic (GiB) per group. Traffic covers the optional date range.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1829,25 +2035,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Admin users login",
+                "summary": "Ocserv Groups Statistics",
                 "parameters": [
                     {
-                        "description": "login data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/system.LoginData"
-                        }
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_start",
+                        "name": "date_start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_end",
+                        "name": "date_end",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/system.UserLoginResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.GroupStatistics"
+                            }
                         }
                     },
                     "400": {
@@ -1855,13 +2074,25 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/request.ErrorResponse"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
                     }
                 }
             }
         },
-        "/system/users/lookup": {
-            "get": {
-                "description": "List of Users Lookup",
+        "/ocserv/groups/sync": {
+            "post": {
+                "description": "Ocserv Groups from file",
                 "consumes": [
                     "application/json"
                 ],
@@ -1869,9 +2100,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(UnsyncedGroup)"
                 ],
-                "summary": "List of Users Lookup",
+                "summary": "Ocserv Groups from file",
                 "parameters": [
                     {
                         "type": "string",
@@ -1879,6 +2110,15 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "list of groups with config to sync in db",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_group.SyncGroupRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -1887,7 +2127,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.UsersLookup"
+                                "type": "string"
                             }
                         }
                     },
@@ -1902,19 +2142,13 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
-                    },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/middlewares.PermissionDenied"
-                        }
                     }
                 }
             }
         },
-        "/system/users/password": {
-            "post": {
-                "description": "Change user password by self",
+        "/ocserv/groups/unsynced": {
+            "get": {
+                "description": "list of Unsynced Groups",
                 "consumes": [
                     "application/json"
                 ],
@@ -1922,19 +2156,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(UnsyncedGroup)"
                 ],
-                "summary": "Change user password by self",
+                "summary": "list of Unsynced Groups",
                 "parameters": [
-                    {
-                        "description": "user new password",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/system.ChangeUserPasswordBySelf"
-                        }
-                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
@@ -1947,7 +2172,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/system.UsersResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/group.UnsyncedGroup"
+                            }
                         }
                     },
                     "400": {
@@ -1965,9 +2193,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/system/users/profile": {
+        "/ocserv/groups/{id}": {
             "get": {
-                "description": "Get User Profile",
+                "description": "Ocserv group detail",
                 "consumes": [
                     "application/json"
                 ],
@@ -1975,10 +2203,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Get User Profile",
+                "summary": "Ocserv group detail",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ocserv Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Bearer TOKEN",
@@ -1991,7 +2226,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.OcservGroup"
                         }
                     },
                     "400": {
@@ -2007,11 +2242,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/system/users/{uid}": {
+            },
             "delete": {
-                "description": "Delete simple user",
+                "description": "Ocserv Group delete. Members are first moved to the reassign_to group (defaults when empty); the group is removed only once they are.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2019,23 +2252,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Delete simple user",
+                "summary": "Ocserv Group delete",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User UID",
-                        "name": "uid",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ocserv Group ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Bearer TOKEN",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "default": "defaults",
+                        "description": "Group receiving the members",
+                        "name": "reassign_to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2053,19 +2293,11 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
-                    },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/middlewares.PermissionDenied"
-                        }
                     }
                 }
-            }
-        },
-        "/system/users/{uid}/password": {
-            "post": {
-                "description": "Change user password by admin",
+            },
+            "patch": {
+                "description": "Ocserv Group update",
                 "consumes": [
                     "application/json"
                 ],
@@ -2073,25 +2305,107 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "System(Users)"
+                    "Ocserv(Groups)"
                 ],
-                "summary": "Change user password by admin",
+                "summary": "Ocserv Group update",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User UID",
-                        "name": "uid",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ocserv Group ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "user new password",
+                        "description": "ocserv group create data",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/system.ChangeUserPassword"
+                            "$ref": "#/definitions/ocserv_group.UpdateOcservGroupData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    }
+                }
+            }
+        },
+        "/ocserv/groups/{id}/members": {
+            "get": {
+                "description": "List of Ocserv group members. Non-admin users only see the ocserv users shared with them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Groups)"
+                ],
+                "summary": "List of Ocserv group members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ocserv Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -2105,7 +2419,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/system.UsersResponse"
+                            "$ref": "#/definitions/ocserv_group.OcservGroupMembersResponse"
                         }
                     },
                     "400": {
@@ -2119,82 +2433,6517 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/middlewares.Unauthorized"
                         }
+                    }
+                }
+            }
+        },
+        "/ocserv/users": {
+            "get": {
+                "description": "List of Ocserv Users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "List of Ocserv Users",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "username",
+                            "group",
+                            "created_at",
+                            "expire_at",
+                            "traffic_size",
+                            "rx",
+                            "tx",
+                            "usage"
+                        ],
+                        "type": "string",
+                        "description": "Field to order by, id by default. usage orders by the percent of the quota used in the current period, top-ups included, as usage_above filters",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page for cursor pagination ordered by id, username, created_at, traffic_size, rx or tx, empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields of the items to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the total records, true by default without a cursor",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "minLength": 2,
+                        "type": "string",
+                        "description": "ocserv username q search",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "Free",
+                            "MonthlyTransmit",
+                            "MonthlyReceive",
+                            "TotallyTransmit",
+                            "TotallyReceive",
+                            "Custom"
+                        ],
+                        "type": "string",
+                        "description": "traffic type",
+                        "name": "traffic_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "locked users",
+                        "name": "locked",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "users expired before today",
+                        "name": "expired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "deactivated users",
+                        "name": "deactivated",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "users connected now",
+                        "name": "online",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expire date on or before, YYYY-MM-DD",
+                        "name": "expire_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expire date on or after, YYYY-MM-DD",
+                        "name": "expire_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "percent of the quota used in the current period, active top-ups included",
+                        "name": "usage_above",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created on or after, YYYY-MM-DD",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created on or before, YYYY-MM-DD",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "description search",
+                        "name": "description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "tags the users all have, repeated or comma separated",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "comparisons of defined custom attributes such as department=sales or seats\u003e=10, with = != \u003e \u003e= \u003c \u003c=, only = and != for bool attributes",
+                        "name": "attr",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                            "$ref": "#/definitions/ocserv_user.OcservUsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "customer.ModelCustomer": {
-            "type": "object",
+            },
+            "post": {
+                "description": "Ocserv User creation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User creation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "ocserv user create data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.CreateOcservUserData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/export": {
+            "get": {
+                "description": "Export Ocserv Users matching the filters of the list as CSV or XLSX. The CSV can be imported again.\nPasswords are left empty, and generated again on import, unless an admin sets with_passwords.\nCells starting with = + - or @ are prefixed with a quote so that spreadsheets do not run them as formulas.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Export Ocserv Users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "file format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "export the passwords in plain text, admins only",
+                        "name": "with_passwords",
+                        "in": "query"
+                    },
+                    {
+                        "minLength": 2,
+                        "type": "string",
+                        "description": "ocserv username q search",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "Free",
+                            "MonthlyTransmit",
+                            "MonthlyReceive",
+                            "TotallyTransmit",
+                            "TotallyReceive",
+                            "Custom"
+                        ],
+                        "type": "string",
+                        "description": "traffic type",
+                        "name": "traffic_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "locked users",
+                        "name": "locked",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "users expired before today",
+                        "name": "expired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "deactivated users",
+                        "name": "deactivated",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "users connected now",
+                        "name": "online",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expire date on or before, YYYY-MM-DD",
+                        "name": "expire_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expire date on or after, YYYY-MM-DD",
+                        "name": "expire_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "percent of the quota used in the current period, active top-ups included",
+                        "name": "usage_above",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created on or after, YYYY-MM-DD",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created on or before, YYYY-MM-DD",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "description search",
+                        "name": "description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "tags the users all have, repeated or comma separated",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "comparisons of defined custom attributes such as department=sales or seats\u003e=10, with = != \u003e \u003e= \u003c \u003c=, only = and != for bool attributes",
+                        "name": "attr",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/import": {
+            "post": {
+                "description": "Create Ocserv Users from a CSV file with the columns username, password, group, traffic_type, traffic_size (GiB), traffic_direction, traffic_period, expire_at (YYYY-MM-DD), description,\nplan (name of an active plan filling the group, traffic, expiry and config left empty), tags (comma separated) and attributes (JSON object of defined custom attributes).\nOnly username is required; an empty password is generated. Rows are validated one by one and invalid rows are skipped.\nWith dry_run=true nothing is created and the validated rows are returned as a preview. The file is limited to 8 MiB and 5000 rows.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Import Ocserv Users from CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "validate only",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.ImportOcservUsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/labels": {
+            "post": {
+                "description": "Edit the tags and custom attributes of several users at once. tags replaces the tags, then add_tags and remove_tags apply.\nattributes sets the given attributes and removes those set to null. Non-admin users only edit the users shared with them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Edit the labels of Ocserv Users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "labels edit",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.EditOcservUsersLabelsData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.EditLabelsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/ocpasswd": {
+            "get": {
+                "description": "Ocserv Users from ocpasswd file",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Ocpasswd)"
+                ],
+                "summary": "Ocserv Users from ocpasswd file",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.OcservUsersSyncResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/ocpasswd/sync": {
+            "post": {
+                "description": "Ocserv Users from ocpasswd file to db",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Ocpasswd)"
+                ],
+                "summary": "Ocserv Users from ocpasswd file to db",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "list of users with config to sync in db",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.SyncOcpasswdRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/statistics": {
+            "get": {
+                "description": "Ocserv Users Statistics",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Statistics)"
+                ],
+                "summary": "Ocserv Users Statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_start",
+                        "name": "date_start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_end",
+                        "name": "date_end",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "granularity",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the buckets, UTC when empty. Buckets of time zones off UTC by a fraction of an hour start up to 59 minutes after their label",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor header of the previous page of buckets",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of buckets per page, all when empty",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DailyTraffic"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page of buckets"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/total-bandwidth": {
+            "get": {
+                "description": "Ocserv Users TotalBandwidth calculating",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Bandwidth)"
+                ],
+                "summary": "Ocserv Users TotalBandwidth calculating",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_start",
+                        "name": "date_start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_end",
+                        "name": "date_end",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.TotalBandwidths"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}": {
+            "get": {
+                "description": "Ocserv user detail",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv user detail",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.OcservUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Ocserv User delete",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Ocserv User update",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ocserv user update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.UpdateOcservUserData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/activate": {
+            "post": {
+                "description": "Restore and activate expired Ocserv User accounts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Restore and activate expired Ocserv User accounts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "list of ocserv users and expire time",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.ActivateUserData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/effective-config": {
+            "get": {
+                "description": "Settings ocserv applies to the user, merged from ocserv.conf, the group file (or defaults/group.conf when the user has no group file) and the user file, with the layer each value comes from",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv user effective config",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.EffectiveConfig"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/lock": {
+            "post": {
+                "description": "Ocserv User locking",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User locking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/owners": {
+            "get": {
+                "description": "Dashboard users sharing the ownership of the Ocserv User",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User owners",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UsersLookup"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a dashboard user to the owners of the Ocserv User",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Share Ocserv User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "dashboard user to share with",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.OwnerData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UsersLookup"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/owners/transfer": {
+            "post": {
+                "description": "Make a dashboard user the primary and only owner of the Ocserv User. Only admins and the primary owner can transfer it. Reseller quotas of the new owner apply.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Transfer Ocserv User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new owner",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.OwnerData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/owners/{owner_uid}": {
+            "delete": {
+                "description": "Remove a dashboard user from the owners of the Ocserv User. The primary owner cannot be removed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Unshare Ocserv User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner User UID",
+                        "name": "owner_uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/renew": {
+            "post": {
+                "description": "Apply the group and traffic of the plan, extend the expiry by the plan duration, reset the counters and unlock the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Renew Ocserv User with a plan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "plan to renew with",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.RenewOcservUserData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OcservUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/scheduled-actions": {
+            "get": {
+                "description": "Actions scheduled on the user, pending ones and the history of executed ones with their result",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "List of Ocserv User scheduled actions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "done",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "action status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.ScheduledActionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Schedule a lock, unlock, delete, group change, plan change or traffic reset of the user. The user expiry service runs it once due, or as soon as it starts again if it was down, and records the result.\nchange_plan renews the user on the plan as the renew endpoint does.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Schedule an action on Ocserv User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "scheduled action",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.ScheduledActionData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduledAction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/scheduled-actions/{id}": {
+            "get": {
+                "description": "Ocserv User scheduled action",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User scheduled action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Scheduled action ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduledAction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancel a pending action, or remove an executed one from the history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Delete Ocserv User scheduled action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Scheduled action ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change the action, target or run time of a pending action",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Update Ocserv User scheduled action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Scheduled action ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "scheduled action update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.UpdateScheduledActionData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduledAction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/statistics": {
+            "get": {
+                "description": "Ocserv User Statistics",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User Statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_start",
+                        "name": "date_start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_end",
+                        "name": "date_end",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "granularity",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of the buckets, UTC when empty. Buckets of time zones off UTC by a fraction of an hour start up to 59 minutes after their label",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor header of the previous page of buckets",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of buckets per page, all when empty",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.StatisticsResponse"
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page of buckets"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/top-ups": {
+            "get": {
+                "description": "Ledger of the traffic top-ups of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "List of Ocserv User top-ups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.TopUpsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add extra traffic on top of the traffic size of the user. Users locked or throttled for exceeding their quota are restored once back under it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Top up Ocserv User traffic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "top-up data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.TopUpData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/ocserv_user.TopUpResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{uid}/unlock": {
+            "post": {
+                "description": "Ocserv User unlocking",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Ocserv User unlocking",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/ocserv/users/{username}/disconnect": {
+            "post": {
+                "description": "Disconnect Ocserv User",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Ocserv(Users)"
+                ],
+                "summary": "Disconnect Ocserv User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ocserv User username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans": {
+            "get": {
+                "description": "List of plans users can be provisioned or renewed with",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "List of plans",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/plan.PlansResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Plan creation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "Plan creation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "plan create data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/plan.CreatePlanData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/lookup": {
+            "get": {
+                "description": "List of active plans for user creation and renewal",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "List of active plans",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Plan"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/{id}": {
+            "get": {
+                "description": "Plan detail",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "Plan detail",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Plan delete. Users provisioned with the plan keep their settings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "Plan delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Plan update. Users already provisioned with the plan are not changed until renewed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Plans"
+                ],
+                "summary": "Plan update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Plan ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "plan update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/plan.UpdatePlanData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports": {
+            "get": {
+                "description": "List of the usage reports generated by report schedules. Staff see their own reports.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "List of usage reports",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Report schedule ID",
+                        "name": "schedule_id",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/report.UsageReportsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/deliveries": {
+            "get": {
+                "description": "Names of the deliveries scheduled reports can be sent with. The webhook delivery is enabled by REPORT_WEBHOOK_HOSTS, the hosts webhook targets may post to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/export": {
+            "get": {
+                "description": "Export the traffic of every user and the daily traffic of all of them over a date range as CSV, XLSX or PDF. Staff export the users they own.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Export traffic statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_start",
+                        "name": "date_start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "date_end",
+                        "name": "date_end",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "file format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/schedules": {
+            "get": {
+                "description": "List of report schedules. Staff see the schedules of their own reports.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "List of report schedules",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/report.ReportSchedulesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Schedule a usage report of the previous period, generated at the start of every period by the user expiry service. Reports of staff cover the users they own; admins may set the owner or leave it empty for every user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report schedule creation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "report schedule create data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/report.CreateReportScheduleData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/schedules/{id}": {
+            "get": {
+                "description": "Report schedule detail",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report schedule detail",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Report schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Report schedule delete. The reports it generated are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report schedule delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Report schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Report schedule update. Changing the period or reactivating the schedule moves its next run to the start of the next period.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Report schedule update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Report schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "report schedule update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/report.UpdateReportScheduleData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportSchedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/{id}": {
+            "delete": {
+                "description": "Usage report delete",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Usage report delete",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Usage report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/{id}/download": {
+            "get": {
+                "description": "Download the file of a generated usage report",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Usage report download",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Usage report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/resellers": {
+            "get": {
+                "description": "List of staff users with reseller quotas",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Resellers"
+                ],
+                "summary": "List of resellers",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reseller.ResellersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/resellers/usage": {
+            "get": {
+                "description": "Quotas, allocated users and traffic of the logged-in reseller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Resellers"
+                ],
+                "summary": "Reseller usage of the current user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.ResellerUsage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/resellers/{uid}": {
+            "put": {
+                "description": "Turn a staff user into a reseller or replace the quotas of an existing reseller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Resellers"
+                ],
+                "summary": "Set reseller quotas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "reseller quotas",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/reseller.SaveResellerData"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reseller"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove the quotas and top-up history of a reseller. The staff user and its ocserv users are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Resellers"
+                ],
+                "summary": "Remove reseller quotas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/resellers/{uid}/top-ups": {
+            "get": {
+                "description": "Ledger of the quota top-ups of a reseller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Resellers"
+                ],
+                "summary": "List of reseller top-ups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reseller.TopUpsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add users and traffic to the quotas of a reseller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Resellers"
+                ],
+                "summary": "Top up reseller quotas",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "top-up data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/reseller.TopUpData"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reseller"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/resellers/{uid}/usage": {
+            "get": {
+                "description": "Quotas, allocated users and traffic of a reseller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Resellers"
+                ],
+                "summary": "Reseller usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.ResellerUsage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/system": {
+            "get": {
+                "description": "Get panel System Config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Get panel System Config",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.GetSystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update panel System Config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Update panel System Config",
+                "parameters": [
+                    {
+                        "description": "update system config data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/system.PatchSystemUpdateData"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.GetSystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/drift": {
+            "get": {
+                "description": "Compare ocserv users and groups in the database with ocpasswd and the user and group config files",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Check drift between the database and ocserv files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.DriftReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/drift/repair": {
+            "post": {
+                "description": "Repair drift taking the database (db) or the ocserv files (files) as the truth, optionally limited to some kinds or names. With source db, users missing in the database are only deleted from ocpasswd with delete_missing_in_db",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Repair drift between the database and ocserv files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "repair drift data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/system.RepairDriftData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.DriftRepairReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/init": {
+            "get": {
+                "description": "Get panel System init Config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Get panel System init Config",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.GetSystemInitResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/setup": {
+            "post": {
+                "description": "Setup user and system config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Setup user and system config",
+                "parameters": [
+                    {
+                        "description": "system setup data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/system.SetupSystem"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/system.SetupSystemResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/users": {
+            "get": {
+                "description": "List of Admin or simple users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "List of Admin or simple users",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to order by",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ASC",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, either ASC or DESC",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page for cursor pagination ordered by id, username or created_at, empty for the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields of the items to return",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count the total records, true by default without a cursor",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.UsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create user Admin or simple",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "create user data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/system.CreateUserData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/users/login": {
+            "post": {
+                "description": "Admin users login with Google captcha(captcha site key required in get config api)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "Admin users login",
+                "parameters": [
+                    {
+                        "description": "login data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/system.LoginData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.UserLoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/users/lookup": {
+            "get": {
+                "description": "List of Users Lookup",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "List of Users Lookup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UsersLookup"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/users/password": {
+            "post": {
+                "description": "Change user password by self",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "Change user password by self",
+                "parameters": [
+                    {
+                        "description": "user new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/system.ChangeUserPasswordBySelf"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.UsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/users/profile": {
+            "get": {
+                "description": "Get User Profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "Get User Profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/users/{uid}": {
+            "delete": {
+                "description": "Delete simple user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "Delete simple user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        },
+        "/system/users/{uid}/password": {
+            "post": {
+                "description": "Change user password by admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System(Users)"
+                ],
+                "summary": "Change user password by admin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/system.ChangeUserPassword"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.UsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/request.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.Unauthorized"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.PermissionDenied"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "attribute.CreateAttributeData": {
+            "type": "object",
+            "required": [
+                "key",
+                "type"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "Department of the user"
+                },
+                "key": {
+                    "description": "lowercase letters, digits and _",
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 1,
+                    "example": "department"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "string",
+                        "number",
+                        "bool",
+                        "date"
+                    ],
+                    "example": "string"
+                }
+            }
+        },
+        "config_version.ConfigVersionDiffResponse": {
+            "type": "object",
+            "required": [
+                "diff",
+                "from",
+                "to"
+            ],
+            "properties": {
+                "diff": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "config_version.ConfigVersionsResponse": {
+            "type": "object",
+            "required": [
+                "meta"
+            ],
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConfigVersion"
+                    }
+                }
+            }
+        },
+        "config_version.RollbackConfigVersionData": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 1024
+                }
+            }
+        },
+        "customer.ModelCustomer": {
+            "type": "object",
+            "required": [
+                "deactivated_at",
+                "expire_at",
+                "is_locked",
+                "owner",
+                "rx",
+                "traffic_size",
+                "traffic_type",
+                "tx",
+                "username"
+            ],
+            "properties": {
+                "deactivated_at": {
+                    "type": "string"
+                },
+                "expire_at": {
+                    "type": "string"
+                },
+                "is_locked": {
+                    "type": "boolean"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "rx": {
+                    "description": "Receive in bytes",
+                    "type": "integer"
+                },
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ]
+                },
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ]
+                },
+                "traffic_size": {
+                    "description": "in GiB  \u003e\u003e x * 1024 ** 3",
+                    "type": "integer"
+                },
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ]
+                },
+                "tx": {
+                    "description": "Transmit in bytes",
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "customer.SummaryData": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 2
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 2
+                }
+            }
+        },
+        "customer.SummaryResponse": {
+            "type": "object",
+            "required": [
+                "ocserv_user",
+                "usage"
+            ],
+            "properties": {
+                "ocserv_user": {
+                    "$ref": "#/definitions/customer.ModelCustomer"
+                },
+                "usage": {
+                    "$ref": "#/definitions/customer.UsageResponse"
+                }
+            }
+        },
+        "customer.UsageResponse": {
+            "type": "object",
+            "required": [
+                "bandwidths",
+                "date_end",
+                "date_start"
+            ],
+            "properties": {
+                "bandwidths": {
+                    "$ref": "#/definitions/repository.TotalBandwidths"
+                },
+                "date_end": {
+                    "type": "string"
+                },
+                "date_start": {
+                    "type": "string"
+                },
+                "traffic": {
+                    "description": "nil for free users",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TrafficUsage"
+                        }
+                    ]
+                }
+            }
+        },
+        "group.UnsyncedGroup": {
+            "type": "object",
+            "required": [
+                "config",
+                "name"
+            ],
+            "properties": {
+                "config": {
+                    "$ref": "#/definitions/models.OcservGroupConfig"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "home.CurrentStats": {
+            "type": "object",
+            "properties": {
+                "Authentication failures": {
+                    "type": "integer"
+                },
+                "Average auth time": {
+                    "type": "string"
+                },
+                "Average session time": {
+                    "type": "string"
+                },
+                "Closed due to error sessions": {
+                    "type": "integer"
+                },
+                "Last stats reset": {
+                    "type": "string"
+                },
+                "Max auth time": {
+                    "type": "string"
+                },
+                "Max session time": {
+                    "type": "string"
+                },
+                "RX": {
+                    "type": "string"
+                },
+                "Sessions handled": {
+                    "type": "integer"
+                },
+                "TX": {
+                    "type": "string"
+                },
+                "Timed out (idle) sessions": {
+                    "type": "integer"
+                },
+                "Timed out sessions": {
+                    "type": "integer"
+                },
+                "_Last stats reset": {
+                    "type": "string"
+                },
+                "raw_avg_auth_time": {
+                    "type": "integer"
+                },
+                "raw_avg_session_time": {
+                    "type": "integer"
+                },
+                "raw_last_stats_reset": {
+                    "type": "integer"
+                },
+                "raw_max_auth_time": {
+                    "type": "integer"
+                },
+                "raw_max_session_time": {
+                    "type": "integer"
+                },
+                "raw_rx": {
+                    "description": "raw fields if you want",
+                    "type": "integer"
+                },
+                "raw_tx": {
+                    "type": "integer"
+                }
+            }
+        },
+        "home.GeneralInfo": {
+            "type": "object",
+            "properties": {
+                "Active sessions": {
+                    "type": "integer"
+                },
+                "IPs in ban list": {
+                    "type": "integer"
+                },
+                "Median latency": {
+                    "type": "string"
+                },
+                "STDEV latency": {
+                    "type": "string"
+                },
+                "Sec-mod PID": {
+                    "type": "integer"
+                },
+                "Sec-mod instance count": {
+                    "type": "integer"
+                },
+                "Server PID": {
+                    "type": "integer"
+                },
+                "Status": {
+                    "type": "string"
+                },
+                "Total authentication failures": {
+                    "type": "integer"
+                },
+                "Total sessions": {
+                    "type": "integer"
+                },
+                "Up since": {
+                    "type": "string"
+                },
+                "_Up since": {
+                    "type": "string"
+                },
+                "raw_median_latency": {
+                    "description": "raw fields if you want",
+                    "type": "integer"
+                },
+                "raw_stdev_latency": {
+                    "type": "integer"
+                },
+                "raw_up_since": {
+                    "type": "integer"
+                },
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "home.GetHomeResponse": {
+            "type": "object",
+            "required": [
+                "server_status"
+            ],
+            "properties": {
+                "ip_bans": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IPBanPoints"
+                    }
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.NodeStatus"
+                    }
+                },
+                "server_status": {
+                    "$ref": "#/definitions/home.ServerStatusResponse"
+                },
+                "statistics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DailyTraffic"
+                    }
+                },
+                "top_bandwidth_user": {
+                    "$ref": "#/definitions/repository.TopBandwidthUsers"
+                },
+                "total_bandwidth": {
+                    "$ref": "#/definitions/repository.TotalBandwidths"
+                },
+                "users": {
+                    "$ref": "#/definitions/home.GetHomeUser"
+                }
+            }
+        },
+        "home.GetHomeUser": {
+            "type": "object",
+            "properties": {
+                "online_users_session": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OnlineUserSession"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "home.ServerStatusResponse": {
+            "type": "object",
+            "properties": {
+                "current_stats": {
+                    "$ref": "#/definitions/home.CurrentStats"
+                },
+                "general_info": {
+                    "$ref": "#/definitions/home.GeneralInfo"
+                }
+            }
+        },
+        "ipam.AllocateData": {
+            "type": "object",
+            "required": [
+                "uid"
+            ],
+            "properties": {
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "middlewares.PermissionDenied": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "middlewares.TooManyRequests": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "middlewares.Unauthorized": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AccessSchedule": {
+            "type": "object",
+            "required": [
+                "windows"
+            ],
+            "properties": {
+                "timezone": {
+                    "description": "IANA name, UTC when empty",
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScheduleWindow"
+                    }
+                }
+            }
+        },
+        "models.AttributeDefinition": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "key",
+                "type"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "department"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "string",
+                        "number",
+                        "bool",
+                        "date"
+                    ]
+                }
+            }
+        },
+        "models.Attributes": {
+            "type": "object",
+            "additionalProperties": true
+        },
+        "models.ConfigVersion": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "kind",
+                "name",
+                "version"
+            ],
+            "properties": {
+                "author": {
+                    "description": "empty for the baseline and for the changes of the services",
+                    "type": "string"
+                },
+                "content": {
+                    "description": "rendered config file",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "diff": {
+                    "description": "unified diff against the previous version",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "group",
+                        "defaults",
+                        "user"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DailyTraffic": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "start of the bucket: YYYY-MM-DD, YYYY-MM-DD HH:00 for hours, YYYY-MM for months",
+                    "type": "string"
+                },
+                "rx": {
+                    "description": "in GiB",
+                    "type": "number"
+                },
+                "tx": {
+                    "description": "in GiB",
+                    "type": "number"
+                }
+            }
+        },
+        "models.IPBanPoints": {
+            "type": "object",
+            "properties": {
+                "IP": {
+                    "type": "string"
+                },
+                "Score": {
+                    "type": "integer"
+                },
+                "Since": {
+                    "type": "string"
+                },
+                "_Since": {
+                    "type": "string"
+                },
+                "family": {
+                    "description": "ipv4 or ipv6",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Node": {
+            "type": "object",
+            "required": [
+                "agent_url",
+                "name"
+            ],
+            "properties": {
+                "agent_url": {
+                    "description": "Example: 'https://10.0.0.2:8888'",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NodeBandwidths": {
+            "type": "object",
+            "required": [
+                "rx",
+                "tx"
+            ],
+            "properties": {
+                "node": {
+                    "description": "empty for the local ocserv",
+                    "type": "string"
+                },
+                "node_id": {
+                    "type": "integer"
+                },
+                "rx": {
+                    "type": "number"
+                },
+                "tx": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.OcservGroup": {
+            "type": "object",
+            "required": [
+                "name",
+                "owner"
+            ],
+            "properties": {
+                "attributes": {
+                    "description": "see LabelAttribute",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
+                },
+                "config": {
+                    "$ref": "#/definitions/models.OcservGroupConfig"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Node"
+                    }
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "description": "Schedule applies to the members of the group without a schedule of their own.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AccessSchedule"
+                        }
+                    ]
+                },
+                "tags": {
+                    "description": "see LabelTag",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.OcservGroupConfig": {
+            "type": "object",
+            "properties": {
+                "cgroup": {
+                    "description": "Linux control group to assign the VPN worker process to. Format: 'controller,subsystem:name'. Example: 'cpuset,cpu:test'",
+                    "type": "string"
+                },
+                "deny-roaming": {
+                    "description": "Disconnect client if its IP changes (e.g., due to network switch). Example: true",
+                    "type": "boolean"
+                },
+                "dns": {
+                    "description": "DNS servers to assign to the client, IPv4 or IPv6. Example: ['8.8.8.8', '2001:4860:4860::8888']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dpd": {
+                    "description": "Dead Peer Detection timeout in seconds. Example: 90",
+                    "type": "integer"
+                },
+                "explicit-ipv4": {
+                    "description": "Static IPv4 address to assign to the client. Example: '192.168.100.10'",
+                    "type": "string"
+                },
+                "explicit-ipv6": {
+                    "description": "Static IPv6 address to assign to the client. Example: 'fd00:24::10'",
+                    "type": "string"
+                },
+                "hostname": {
+                    "description": "Hostname assigned to the client. Example: 'alice-laptop'",
+                    "type": "string"
+                },
+                "idle-timeout": {
+                    "description": "Time in seconds before disconnecting idle clients. Example: 600",
+                    "type": "integer"
+                },
+                "interim-update": {
+                    "description": "Interval in seconds between interim accounting updates. Example: 300",
+                    "type": "integer"
+                },
+                "ipv4-network": {
+                    "description": "The pool of addresses from which to assign to the client. Example: '192.168.1.0/24'",
+                    "type": "string"
+                },
+                "ipv6-network": {
+                    "description": "The pool of IPv6 addresses from which to assign to the client. Example: 'fd00:24::/48'",
+                    "type": "string"
+                },
+                "ipv6-subnet-prefix": {
+                    "description": "Prefix length of the IPv6 subnet given to each client. Example: 128",
+                    "type": "integer"
+                },
+                "iroute": {
+                    "description": "Networks behind the client, routed to it by the server. Example: ['10.0.0.0/8']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "keepalive": {
+                    "description": "Interval in seconds to send keep-alive pings. Example: 60",
+                    "type": "integer"
+                },
+                "max-same-clients": {
+                    "description": "Maximum simultaneous logins per user. Example: 2",
+                    "type": "integer"
+                },
+                "mobile-dpd": {
+                    "description": "DPD timeout specifically for mobile clients. Example: 300",
+                    "type": "integer"
+                },
+                "mobile-idle-timeout": {
+                    "description": "Idle timeout in seconds for mobile clients. Example: 900",
+                    "type": "integer"
+                },
+                "mtu": {
+                    "description": "Tunnel interface MTU to avoid fragmentation. Example: 1400",
+                    "type": "integer"
+                },
+                "nbns": {
+                    "description": "NetBIOS Name Servers (WINS) for Windows clients. Example: ['192.168.1.1']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "net-priority": {
+                    "description": "Priority for routes; lower is higher priority. Example: 1",
+                    "type": "integer"
+                },
+                "no-route": {
+                    "description": "List of networks to exclude from VPN routing. Example: ['192.168.0.0/16', '10.0.0.0/8']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "no-udp": {
+                    "description": "Disables UDP, enforcing TCP-only VPN connection. Example: true",
+                    "type": "boolean"
+                },
+                "rekey-time": {
+                    "description": "Rekey time in seconds; triggers key renegotiation. Example: 86400 for 24 hours",
+                    "type": "integer"
+                },
+                "restrict-user-to-ports": {
+                    "description": "Comma-separated list of allowed or blocked ports/protocols. Supports 'tcp(port)', 'udp(port)', 'icmp()', 'icmpv6()', and negation with '!()'. Example: 'tcp(443), udp(53)' or '!(tcp(22), udp(1194))'",
+                    "type": "string"
+                },
+                "restrict-user-to-routes": {
+                    "description": "Allow client access only to defined routes. Example: true",
+                    "type": "boolean"
+                },
+                "route": {
+                    "description": "Routes pushed to the client for routing traffic, IPv4 or IPv6. Example: ['0.0.0.0/0', '10.10.0.0/16', 'fd00::/8']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rx-data-per-sec": {
+                    "description": "Maximum receive bandwidth in bytes per second. Example: '100000' for 100 KB/s",
+                    "type": "integer"
+                },
+                "session-timeout": {
+                    "description": "Maximum session time in seconds before forced disconnect. Example: 3600",
+                    "type": "integer"
+                },
+                "split-dns": {
+                    "description": "List of domains over which the provided DNS servers should be used. Example: ['example.com', 'internal.company.com']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stats-report-time": {
+                    "description": "Interval in seconds for stats reporting. Example: 300",
+                    "type": "integer"
+                },
+                "tunnel-all-dns": {
+                    "description": "Force all DNS traffic through the VPN tunnel. Example: true",
+                    "type": "boolean"
+                },
+                "tx-data-per-sec": {
+                    "description": "Maximum transmit bandwidth in bytes per second. Example: '200000' for 200 KB/s",
+                    "type": "integer"
+                },
+                "user-profile": {
+                    "description": "XML profile file sent to AnyConnect clients. Example: '/etc/ocserv/profile.xml'",
+                    "type": "string"
+                }
+            }
+        },
+        "models.OcservServerConfig": {
+            "type": "object",
+            "properties": {
+                "auth": {
+                    "description": "Authentication methods, one per entry. Example: ['plain[passwd=/etc/ocserv/ocpasswd]']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "banner": {
+                    "description": "Banner shown to clients after login. Example: 'Welcome'",
+                    "type": "string"
+                },
+                "ca-cert": {
+                    "description": "CA certificate used for certificate authentication. Example: '/etc/ocserv/certs/ca-cert.pem'",
+                    "type": "string"
+                },
+                "camouflage": {
+                    "description": "Hide the VPN behind a web server unless the URL carries the camouflage secret. Example: true",
+                    "type": "boolean"
+                },
+                "camouflage_realm": {
+                    "description": "Realm shown by browsers when camouflage is enabled. Example: 'Restricted Content'",
+                    "type": "string"
+                },
+                "camouflage_secret": {
+                    "description": "Secret expected in the URL when camouflage is enabled. Example: 'mysecretkey'",
+                    "type": "string"
+                },
+                "dns": {
+                    "description": "DNS servers pushed to clients, IPv4 or IPv6. Example: ['8.8.8.8', '2001:4860:4860::8888']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ipv4-network": {
+                    "description": "The pool of addresses that leases will be given from. Example: '172.16.24.0/24'",
+                    "type": "string"
+                },
+                "ipv6-network": {
+                    "description": "The pool of IPv6 addresses that leases will be given from. Example: 'fd00:24::/48'",
+                    "type": "string"
+                },
+                "ipv6-subnet-prefix": {
+                    "description": "Prefix length of the IPv6 subnet given to each client. Example: 128",
+                    "type": "integer"
+                },
+                "max-clients": {
+                    "description": "Maximum number of connected clients; 0 is unlimited. Example: 1024",
+                    "type": "integer"
+                },
+                "max-same-clients": {
+                    "description": "Default maximum simultaneous logins per user. Example: 2",
+                    "type": "integer"
+                },
+                "no-route": {
+                    "description": "Networks excluded from VPN routing. Example: ['192.168.0.0/16']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "pre-login-banner": {
+                    "description": "Banner shown to clients before login. Example: 'Authorized users only'",
+                    "type": "string"
+                },
+                "route": {
+                    "description": "Routes pushed to clients, IPv4 or IPv6. Example: ['default'] or ['10.10.0.0/16', 'fd00::/8']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "server-cert": {
+                    "description": "Server certificate file. Example: '/etc/ocserv/certs/cert.pem'",
+                    "type": "string"
+                },
+                "server-key": {
+                    "description": "Server private key file. Example: '/etc/ocserv/certs/cert.key'",
+                    "type": "string"
+                },
+                "tcp-port": {
+                    "description": "TCP port to listen on. Example: 443",
+                    "type": "integer"
+                },
+                "tunnel-all-dns": {
+                    "description": "Force all DNS traffic through the VPN tunnel. Example: true",
+                    "type": "boolean"
+                },
+                "udp-port": {
+                    "description": "UDP port to listen on for DTLS. Example: 443",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.OcservUser": {
+            "type": "object",
+            "required": [
+                "created_at",
+                "group",
+                "is_locked",
+                "is_online",
+                "owner",
+                "password",
+                "rx",
+                "traffic_size",
+                "traffic_type",
+                "tx",
+                "uid",
+                "username"
+            ],
+            "properties": {
+                "attributes": {
+                    "description": "see LabelAttribute",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
+                },
+                "config": {
+                    "$ref": "#/definitions/models.OcservUserConfig"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deactivated_at": {
+                    "type": "string"
+                },
+                "deactivated_reason": {
+                    "description": "empty while active",
+                    "type": "string",
+                    "enum": [
+                        "expired",
+                        "quota"
+                    ]
+                },
+                "description": {
+                    "type": "string"
+                },
+                "expire_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "is_locked": {
+                    "type": "boolean"
+                },
+                "is_online": {
+                    "type": "boolean"
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Node"
+                    }
+                },
+                "over_quota_action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "throttle"
+                    ]
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "plan_id": {
+                    "type": "integer"
+                },
+                "rx": {
+                    "description": "Receive in bytes",
+                    "type": "integer"
+                },
+                "schedule": {
+                    "description": "falls back to the group schedule when nil",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AccessSchedule"
+                        }
+                    ]
+                },
+                "schedule_locked_at": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "see LabelTag",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "throttle_rate": {
+                    "description": "rx/tx bytes per second while throttled",
+                    "type": "integer"
+                },
+                "throttled_at": {
+                    "type": "string"
+                },
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ]
+                },
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ]
+                },
+                "traffic_reset_at": {
+                    "description": "last reset of the counters, the usage of the period counts from it",
+                    "type": "string"
+                },
+                "traffic_reset_day": {
+                    "description": "day of month monthly periods start on, 0 for the default",
+                    "type": "integer"
+                },
+                "traffic_size": {
+                    "description": "in GiB  \u003e\u003e x * 1024 ** 3",
+                    "type": "integer"
+                },
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ]
+                },
+                "tx": {
+                    "description": "Transmit in bytes",
+                    "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OcservUserConfig": {
+            "type": "object",
+            "properties": {
+                "cgroup": {
+                    "description": "Linux control group to assign the VPN worker process to. Format: 'controller,subsystem:name'. Example: 'cpuset,cpu:test'",
+                    "type": "string"
+                },
+                "deny-roaming": {
+                    "description": "Disconnect user if its IP changes (e.g., due to network switch). Example: true",
+                    "type": "boolean"
+                },
+                "dns": {
+                    "description": "DNS servers to assign to the user, IPv4 or IPv6. Example: ['8.8.8.8', '2001:4860:4860::8888']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dpd": {
+                    "description": "Dead Peer Detection timeout in seconds. Example: 90",
+                    "type": "integer"
+                },
+                "explicit-ipv4": {
+                    "description": "Static IPv4 address to assign to the user. Example: '192.168.100.10'",
+                    "type": "string"
+                },
+                "explicit-ipv6": {
+                    "description": "Static IPv6 address to assign to the user. Example: 'fd00:24::10'",
+                    "type": "string"
+                },
+                "hostname": {
+                    "description": "Hostname assigned to the user. Example: 'alice-laptop'",
+                    "type": "string"
+                },
+                "idle-timeout": {
+                    "description": "Time in seconds before disconnecting idle users. Example: 600",
+                    "type": "integer"
+                },
+                "interim-update": {
+                    "description": "Interval in seconds between interim accounting updates. Example: 300",
+                    "type": "integer"
+                },
+                "ipv4-network": {
+                    "description": "The pool of addresses from which to assign to the user. Example: '192.168.1.0/24'",
+                    "type": "string"
+                },
+                "ipv6-network": {
+                    "description": "The pool of IPv6 addresses from which to assign to the user. Example: 'fd00:24::/48'",
+                    "type": "string"
+                },
+                "ipv6-subnet-prefix": {
+                    "description": "Prefix length of the IPv6 subnet given to each client. Example: 128",
+                    "type": "integer"
+                },
+                "iroute": {
+                    "description": "Networks behind the user, routed to it by the server. Example: ['10.0.0.0/8']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "keepalive": {
+                    "description": "Interval in seconds to send keep-alive pings. Example: 60",
+                    "type": "integer"
+                },
+                "max-same-clients": {
+                    "description": "Maximum simultaneous logins of the user. Example: 2",
+                    "type": "integer"
+                },
+                "mobile-dpd": {
+                    "description": "DPD timeout specifically for mobile clients. Example: 300",
+                    "type": "integer"
+                },
+                "mobile-idle-timeout": {
+                    "description": "Idle timeout in seconds for mobile users. Example: 900",
+                    "type": "integer"
+                },
+                "mtu": {
+                    "description": "Tunnel interface MTU to avoid fragmentation. Example: 1400",
+                    "type": "integer"
+                },
+                "nbns": {
+                    "description": "NetBIOS Name Servers (WINS) for Windows clients. Example: ['192.168.1.1']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "net-priority": {
+                    "description": "Priority for routes; lower is higher priority. Example: 1",
+                    "type": "integer"
+                },
+                "no-route": {
+                    "description": "List of networks to exclude from VPN routing. Example: ['192.168.0.0/16', '10.0.0.0/8']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "no-udp": {
+                    "description": "Disables UDP, enforcing TCP-only VPN connection. Example: true",
+                    "type": "boolean"
+                },
+                "rekey-time": {
+                    "description": "Rekey time in seconds; triggers key renegotiation. Example: 86400 for 24 hours",
+                    "type": "integer"
+                },
+                "restrict-user-to-ports": {
+                    "description": "Comma-separated list of allowed or blocked ports/protocols. Supports 'tcp(port)', 'udp(port)', 'icmp()', 'icmpv6()', and negation with '!()'. Example: 'tcp(443), udp(53)' or '!(tcp(22), udp(1194))'",
+                    "type": "string"
+                },
+                "restrict-user-to-routes": {
+                    "description": "Allow user access only to defined routes. Example: true",
+                    "type": "boolean"
+                },
+                "route": {
+                    "description": "Routes pushed to the user for routing traffic, IPv4 or IPv6. Example: ['0.0.0.0/0', '10.10.0.0/16', 'fd00::/8']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rx-data-per-sec": {
+                    "description": "Maximum receive bandwidth in bytes per second. Example: '100000' for 100 KB/s",
+                    "type": "integer"
+                },
+                "session-timeout": {
+                    "description": "Maximum session time in seconds before forced disconnect. Example: 3600",
+                    "type": "integer"
+                },
+                "split-dns": {
+                    "description": "List of domains over which the provided DNS servers should be used. Example: ['example.com', 'internal.company.com']",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stats-report-time": {
+                    "description": "Interval in seconds for stats reporting. Example: 300",
+                    "type": "integer"
+                },
+                "tunnel-all-dns": {
+                    "description": "Force all DNS traffic through the VPN tunnel. Example: true",
+                    "type": "boolean"
+                },
+                "tx-data-per-sec": {
+                    "description": "Maximum transmit bandwidth in bytes per second. Example: '200000' for 200 KB/s",
+                    "type": "integer"
+                },
+                "user-profile": {
+                    "description": "XML profile file sent to AnyConnect clients. Example: '/etc/ocserv/profile.xml'",
+                    "type": "string"
+                }
+            }
+        },
+        "models.OnlineUserSession": {
+            "type": "object",
+            "properties": {
+                "Average RX": {
+                    "type": "string"
+                },
+                "Average TX": {
+                    "type": "string"
+                },
+                "Groupname": {
+                    "type": "string"
+                },
+                "IPv4": {
+                    "type": "string"
+                },
+                "IPv6": {
+                    "type": "string"
+                },
+                "Remote IP": {
+                    "type": "string"
+                },
+                "Username": {
+                    "type": "string"
+                },
+                "_Connected at": {
+                    "type": "string"
+                },
+                "node": {
+                    "description": "empty for the local ocserv",
+                    "type": "string"
+                },
+                "remote_family": {
+                    "description": "ipv4 or ipv6",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Plan": {
+            "type": "object",
+            "required": [
+                "duration_days",
+                "group",
+                "is_active",
+                "name",
+                "traffic_size",
+                "traffic_type"
+            ],
+            "properties": {
+                "config": {
+                    "$ref": "#/definitions/models.OcservUserConfig"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "Example: 'USD'",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "duration_days": {
+                    "type": "integer"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "over_quota_action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "throttle"
+                    ]
+                },
+                "price": {
+                    "type": "number"
+                },
+                "throttle_rate": {
+                    "description": "rx/tx bytes per second while throttled",
+                    "type": "integer"
+                },
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ]
+                },
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ]
+                },
+                "traffic_size": {
+                    "description": "in GiB",
+                    "type": "integer"
+                },
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReportSchedule": {
+            "type": "object",
+            "required": [
+                "delivery",
+                "format",
+                "is_active",
+                "name",
+                "next_run_at",
+                "period"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "delivery": {
+                    "type": "string",
+                    "example": "store"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "xlsx",
+                        "pdf"
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "empty for global reports",
+                    "type": "string"
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly"
+                    ]
+                },
+                "target": {
+                    "description": "destination of the delivery, such as a webhook URL on a host of REPORT_WEBHOOK_HOSTS",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Reseller": {
+            "type": "object",
+            "required": [
+                "max_users",
+                "traffic_pool"
+            ],
+            "properties": {
+                "allowed_groups": {
+                    "description": "empty allows every group",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allowed_traffic_types": {
+                    "description": "empty allows every traffic type",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "max_users": {
+                    "type": "integer"
+                },
+                "traffic_pool": {
+                    "description": "in GiB, shared by the traffic size of all owned users",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.ResellerTopUp": {
+            "type": "object",
+            "required": [
+                "author",
+                "traffic",
+                "users"
+            ],
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "traffic": {
+                    "description": "in GiB",
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ScheduleState": {
+            "type": "object",
+            "required": [
+                "allowed",
+                "schedule",
+                "source"
+            ],
+            "properties": {
+                "allowed": {
+                    "type": "boolean"
+                },
+                "locked_at": {
+                    "description": "set while the user is locked by the schedule",
+                    "type": "string"
+                },
+                "next_change": {
+                    "description": "nil when the schedule never changes state",
+                    "type": "string"
+                },
+                "schedule": {
+                    "$ref": "#/definitions/models.AccessSchedule"
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "group"
+                    ]
+                }
+            }
+        },
+        "models.ScheduleWindow": {
+            "type": "object",
+            "required": [
+                "days",
+                "end",
+                "start"
+            ],
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "mon",
+                            "tue",
+                            "wed",
+                            "thu",
+                            "fri",
+                            "sat",
+                            "sun"
+                        ]
+                    },
+                    "example": [
+                        "mon",
+                        "tue",
+                        "wed",
+                        "thu",
+                        "fri"
+                    ]
+                },
+                "end": {
+                    "description": "HH:MM",
+                    "type": "string",
+                    "example": "17:00"
+                },
+                "start": {
+                    "description": "HH:MM",
+                    "type": "string",
+                    "example": "09:00"
+                }
+            }
+        },
+        "models.ScheduledAction": {
+            "type": "object",
+            "required": [
+                "action",
+                "created_by",
+                "run_at",
+                "status"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "unlock",
+                        "delete",
+                        "change_group",
+                        "change_plan",
+                        "reset_traffic"
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "executed_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "description": "group of change_group",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "plan_id": {
+                    "description": "plan of change_plan",
+                    "type": "integer"
+                },
+                "result": {
+                    "description": "error of failed actions",
+                    "type": "string"
+                },
+                "run_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "running",
+                        "done",
+                        "failed"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ServerVersion": {
+            "type": "object",
+            "properties": {
+                "occtl_version": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.System": {
+            "type": "object",
+            "properties": {
+                "_": {
+                    "type": "integer"
+                },
+                "google_captcha_secret": {
+                    "type": "string"
+                },
+                "google_captcha_site_key": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TrafficTopUp": {
+            "type": "object",
+            "required": [
+                "amount",
+                "author"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "in GiB",
+                    "type": "integer"
+                },
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expire_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TrafficUsage": {
+            "type": "object",
+            "required": [
+                "direction",
+                "exceeded",
+                "limit",
+                "period",
+                "rx",
+                "top_up",
+                "tx",
+                "used"
+            ],
+            "properties": {
+                "direction": {
+                    "type": "string"
+                },
+                "exceeded": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "description": "in bytes",
+                    "type": "integer"
+                },
+                "period": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "description": "nil for lifetime policies",
+                    "type": "string"
+                },
+                "reset_at": {
+                    "description": "nil for lifetime policies",
+                    "type": "string"
+                },
+                "rx": {
+                    "description": "in bytes",
+                    "type": "integer"
+                },
+                "top_up": {
+                    "description": "bytes of the active top-ups, included in Limit",
+                    "type": "integer"
+                },
+                "tx": {
+                    "description": "in bytes",
+                    "type": "integer"
+                },
+                "used": {
+                    "description": "bytes counted against the limit",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UsageReport": {
+            "type": "object",
+            "required": [
+                "date_end",
+                "date_start",
+                "filename",
+                "format",
+                "size"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "date_end": {
+                    "type": "string"
+                },
+                "date_start": {
+                    "type": "string"
+                },
+                "delivery_error": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "xlsx",
+                        "pdf"
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "description": "nil for reports generated on demand",
+                    "type": "integer"
+                },
+                "size": {
+                    "description": "in bytes",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "required": [
+                "is_admin",
+                "last_login",
+                "uid",
+                "username"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "last_login": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UsersLookup": {
+            "type": "object",
+            "required": [
+                "uid",
+                "username"
+            ],
+            "properties": {
+                "uid": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "node.CreateNodeData": {
+            "type": "object",
+            "required": [
+                "agent_url",
+                "name",
+                "token"
+            ],
+            "properties": {
+                "agent_url": {
+                    "type": "string",
+                    "example": "https://10.0.0.2:8888"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "Frankfurt node"
+                },
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 2,
+                    "example": "de-1"
+                },
+                "token": {
+                    "description": "AGENT_TOKEN of the agent",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 16,
+                    "example": "agent-shared-secret"
+                }
+            }
+        },
+        "node.NodesResponse": {
+            "type": "object",
+            "required": [
+                "meta"
+            ],
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Node"
+                    }
+                }
+            }
+        },
+        "node.UpdateNodeData": {
+            "type": "object",
+            "properties": {
+                "agent_url": {
+                    "type": "string",
+                    "example": "https://10.0.0.2:8888"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "Frankfurt node"
+                },
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "token": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 16,
+                    "example": "agent-shared-secret"
+                }
+            }
+        },
+        "ocserv_config.OcservConfigDiffResponse": {
+            "type": "object",
             "required": [
-                "deactivated_at",
-                "expire_at",
-                "is_locked",
-                "owner",
-                "rx",
-                "traffic_size",
-                "traffic_type",
-                "tx",
-                "username"
+                "changed",
+                "diff"
             ],
             "properties": {
-                "deactivated_at": {
-                    "type": "string"
+                "changed": {
+                    "type": "boolean"
                 },
-                "expire_at": {
+                "diff": {
                     "type": "string"
+                }
+            }
+        },
+        "ocserv_group.CreateOcservGroupData": {
+            "type": "object",
+            "required": [
+                "config",
+                "name"
+            ],
+            "properties": {
+                "attributes": {
+                    "description": "values of defined custom attributes",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
                 },
-                "is_locked": {
-                    "type": "boolean"
+                "config": {
+                    "$ref": "#/definitions/models.OcservGroupConfig"
                 },
-                "owner": {
+                "name": {
                     "type": "string"
                 },
-                "rx": {
-                    "description": "Receive in bytes",
-                    "type": "integer"
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
                 },
-                "traffic_size": {
-                    "description": "in GiB  \u003e\u003e x * 1024 ** 3",
+                "schedule": {
+                    "$ref": "#/definitions/models.AccessSchedule"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "eu"
+                    ]
+                }
+            }
+        },
+        "ocserv_group.EditLabelsResponse": {
+            "type": "object",
+            "required": [
+                "edited"
+            ],
+            "properties": {
+                "edited": {
                     "type": "integer"
+                }
+            }
+        },
+        "ocserv_group.EditOcservGroupsLabelsData": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "add_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "eu"
+                    ]
                 },
-                "traffic_type": {
+                "attributes": {
+                    "description": "sets the given attributes, null removes one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
+                },
+                "ids": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "remove_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "legacy"
+                    ]
+                },
+                "tags": {
+                    "description": "replaces the tags",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "eu"
+                    ]
+                }
+            }
+        },
+        "ocserv_group.MoveMembersData": {
+            "type": "object",
+            "required": [
+                "group",
+                "uids"
+            ],
+            "properties": {
+                "group": {
                     "type": "string",
-                    "enum": [
-                        "Free",
-                        "MonthlyTransmit",
-                        "MonthlyReceive",
-                        "TotallyTransmit",
-                        "TotallyReceive"
+                    "example": "defaults"
+                },
+                "uids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "ocserv_group.OcservGroupMembersResponse": {
+            "type": "object",
+            "required": [
+                "meta"
+            ],
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OcservUser"
+                    }
+                }
+            }
+        },
+        "ocserv_group.OcservGroupsResponse": {
+            "type": "object",
+            "required": [
+                "meta"
+            ],
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OcservGroup"
+                    }
+                }
+            }
+        },
+        "ocserv_group.SyncGroupRequest": {
+            "type": "object",
+            "required": [
+                "groups"
+            ],
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/group.UnsyncedGroup"
+                    }
+                }
+            }
+        },
+        "ocserv_group.UpdateOcservGroupData": {
+            "type": "object",
+            "required": [
+                "config"
+            ],
+            "properties": {
+                "attributes": {
+                    "description": "sets the given attributes, null removes one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
                     ]
                 },
-                "tx": {
-                    "description": "Transmit in bytes",
-                    "type": "integer"
+                "config": {
+                    "$ref": "#/definitions/models.OcservGroupConfig"
                 },
-                "username": {
-                    "type": "string"
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
+                },
+                "schedule": {
+                    "description": "a schedule without windows clears it",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AccessSchedule"
+                        }
+                    ]
+                },
+                "tags": {
+                    "description": "replaces the tags",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "eu"
+                    ]
                 }
             }
         },
-        "customer.SummaryData": {
+        "ocserv_user.ActivateUserData": {
+            "type": "object",
+            "properties": {
+                "expire_at": {
+                    "type": "string",
+                    "example": "2025-12-31"
+                }
+            }
+        },
+        "ocserv_user.CreateOcservUserData": {
             "type": "object",
             "required": [
                 "password",
                 "username"
             ],
             "properties": {
+                "attributes": {
+                    "description": "values of defined custom attributes",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
+                },
+                "config": {
+                    "$ref": "#/definitions/models.OcservUserConfig"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "User for testing VPN access"
+                },
+                "expire_at": {
+                    "type": "string",
+                    "example": "2025-12-31"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
+                },
+                "over_quota_action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "throttle"
+                    ],
+                    "example": "throttle"
+                },
                 "password": {
                     "type": "string",
-                    "maxLength": 32,
-                    "minLength": 2
+                    "maxLength": 32,
+                    "minLength": 2
+                },
+                "plan_id": {
+                    "description": "fills group, traffic, expiry and config left empty",
+                    "type": "integer",
+                    "example": 1
+                },
+                "schedule": {
+                    "description": "defaults to the schedule of the group",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AccessSchedule"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "vip",
+                        "eu"
+                    ]
+                },
+                "throttle_rate": {
+                    "description": "rx/tx bytes per second once over quota",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 65536
+                },
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ],
+                    "example": "both"
+                },
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ],
+                    "example": "monthly"
+                },
+                "traffic_reset_day": {
+                    "description": "day of month monthly periods start on, 0 for the signup day",
+                    "type": "integer",
+                    "maximum": 31,
+                    "minimum": 0,
+                    "example": 15
+                },
+                "traffic_size": {
+                    "description": "10 GiB",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10737418240
+                },
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ],
+                    "example": "MonthlyTransmit"
                 },
                 "username": {
                     "type": "string",
@@ -2203,489 +8952,826 @@ const docTemplate = `{
                 }
             }
         },
-        "customer.SummaryResponse": {
+        "ocserv_user.EditLabelsResponse": {
             "type": "object",
             "required": [
-                "ocserv_user",
-                "usage"
+                "edited"
             ],
             "properties": {
-                "ocserv_user": {
-                    "$ref": "#/definitions/customer.ModelCustomer"
-                },
-                "usage": {
-                    "$ref": "#/definitions/customer.UsageResponse"
+                "edited": {
+                    "type": "integer"
                 }
             }
         },
-        "customer.UsageResponse": {
+        "ocserv_user.EditOcservUsersLabelsData": {
             "type": "object",
             "required": [
-                "bandwidths",
-                "date_end",
-                "date_start"
+                "uids"
             ],
             "properties": {
-                "bandwidths": {
-                    "$ref": "#/definitions/repository.TotalBandwidths"
+                "add_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "vip"
+                    ]
                 },
-                "date_end": {
-                    "type": "string"
+                "attributes": {
+                    "description": "sets the given attributes, null removes one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
                 },
-                "date_start": {
-                    "type": "string"
+                "remove_tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "trial"
+                    ]
+                },
+                "tags": {
+                    "description": "replaces the tags",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "vip",
+                        "eu"
+                    ]
+                },
+                "uids": {
+                    "type": "array",
+                    "maxItems": 1000,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "group.UnsyncedGroup": {
+        "ocserv_user.ImportOcservUserRow": {
             "type": "object",
             "required": [
-                "config",
-                "name"
+                "created",
+                "expire_at",
+                "group",
+                "line",
+                "password",
+                "password_generated",
+                "traffic_size",
+                "traffic_type",
+                "username"
             ],
             "properties": {
-                "config": {
-                    "$ref": "#/definitions/models.OcservGroupConfig"
+                "attributes": {
+                    "$ref": "#/definitions/models.Attributes"
                 },
-                "name": {
-                    "type": "string"
+                "created": {
+                    "type": "boolean"
                 },
-                "path": {
+                "description": {
                     "type": "string"
-                }
-            }
-        },
-        "home.CurrentStats": {
-            "type": "object",
-            "properties": {
-                "Authentication failures": {
-                    "type": "integer"
                 },
-                "Average auth time": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expire_at": {
                     "type": "string"
                 },
-                "Average session time": {
+                "group": {
                     "type": "string"
                 },
-                "Closed due to error sessions": {
+                "line": {
                     "type": "integer"
                 },
-                "Last stats reset": {
-                    "type": "string"
-                },
-                "Max auth time": {
+                "password": {
                     "type": "string"
                 },
-                "Max session time": {
-                    "type": "string"
+                "password_generated": {
+                    "type": "boolean"
                 },
-                "RX": {
+                "plan": {
+                    "description": "name of an active plan",
                     "type": "string"
                 },
-                "Sessions handled": {
-                    "type": "integer"
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "TX": {
+                "traffic_direction": {
                     "type": "string"
                 },
-                "Timed out (idle) sessions": {
-                    "type": "integer"
-                },
-                "Timed out sessions": {
-                    "type": "integer"
-                },
-                "_Last stats reset": {
+                "traffic_period": {
                     "type": "string"
                 },
-                "raw_avg_auth_time": {
+                "traffic_size": {
+                    "description": "in GiB",
                     "type": "integer"
                 },
-                "raw_avg_session_time": {
-                    "type": "integer"
+                "traffic_type": {
+                    "type": "string"
                 },
-                "raw_last_stats_reset": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "ocserv_user.ImportOcservUsersResponse": {
+            "type": "object",
+            "required": [
+                "created",
+                "dry_run",
+                "rows",
+                "total",
+                "valid"
+            ],
+            "properties": {
+                "created": {
                     "type": "integer"
                 },
-                "raw_max_auth_time": {
-                    "type": "integer"
+                "dry_run": {
+                    "type": "boolean"
                 },
-                "raw_max_session_time": {
-                    "type": "integer"
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ocserv_user.ImportOcservUserRow"
+                    }
                 },
-                "raw_rx": {
-                    "description": "raw fields if you want",
+                "total": {
                     "type": "integer"
                 },
-                "raw_tx": {
+                "valid": {
                     "type": "integer"
                 }
             }
         },
-        "home.GeneralInfo": {
+        "ocserv_user.OcservUserResponse": {
             "type": "object",
+            "required": [
+                "created_at",
+                "group",
+                "is_locked",
+                "is_online",
+                "owner",
+                "password",
+                "rx",
+                "traffic_size",
+                "traffic_type",
+                "tx",
+                "uid",
+                "username"
+            ],
             "properties": {
-                "Active sessions": {
-                    "type": "integer"
+                "attributes": {
+                    "description": "see LabelAttribute",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
                 },
-                "IPs in ban list": {
-                    "type": "integer"
+                "config": {
+                    "$ref": "#/definitions/models.OcservUserConfig"
                 },
-                "Median latency": {
+                "created_at": {
                     "type": "string"
                 },
-                "STDEV latency": {
+                "deactivated_at": {
                     "type": "string"
                 },
-                "Sec-mod PID": {
-                    "type": "integer"
+                "deactivated_reason": {
+                    "description": "empty while active",
+                    "type": "string",
+                    "enum": [
+                        "expired",
+                        "quota"
+                    ]
                 },
-                "Sec-mod instance count": {
+                "description": {
+                    "type": "string"
+                },
+                "expire_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "is_locked": {
+                    "type": "boolean"
+                },
+                "is_online": {
+                    "type": "boolean"
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Node"
+                    }
+                },
+                "over_quota_action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "throttle"
+                    ]
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "plan_id": {
                     "type": "integer"
                 },
-                "Server PID": {
+                "rx": {
+                    "description": "Receive in bytes",
                     "type": "integer"
                 },
-                "Status": {
+                "schedule": {
+                    "description": "falls back to the group schedule when nil",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AccessSchedule"
+                        }
+                    ]
+                },
+                "schedule_locked_at": {
                     "type": "string"
                 },
-                "Total authentication failures": {
-                    "type": "integer"
+                "schedule_state": {
+                    "description": "nil without a user or group schedule",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ScheduleState"
+                        }
+                    ]
                 },
-                "Total sessions": {
+                "tags": {
+                    "description": "see LabelTag",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "throttle_rate": {
+                    "description": "rx/tx bytes per second while throttled",
                     "type": "integer"
                 },
-                "Up since": {
+                "throttled_at": {
                     "type": "string"
                 },
-                "_Up since": {
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ]
+                },
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ]
+                },
+                "traffic_reset_at": {
+                    "description": "last reset of the counters, the usage of the period counts from it",
                     "type": "string"
                 },
-                "raw_median_latency": {
-                    "description": "raw fields if you want",
+                "traffic_reset_day": {
+                    "description": "day of month monthly periods start on, 0 for the default",
                     "type": "integer"
                 },
-                "raw_stdev_latency": {
+                "traffic_size": {
+                    "description": "in GiB  \u003e\u003e x * 1024 ** 3",
                     "type": "integer"
                 },
-                "raw_up_since": {
-                    "type": "integer"
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ]
                 },
-                "uptime": {
+                "tx": {
+                    "description": "Transmit in bytes",
                     "type": "integer"
+                },
+                "uid": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "home.GetHomeResponse": {
+        "ocserv_user.OcservUsersResponse": {
             "type": "object",
             "required": [
-                "server_status"
+                "meta"
             ],
             "properties": {
-                "ip_bans": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.IPBanPoints"
-                    }
-                },
-                "server_status": {
-                    "$ref": "#/definitions/home.ServerStatusResponse"
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
                 },
-                "statistics": {
+                "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.DailyTraffic"
+                        "$ref": "#/definitions/models.OcservUser"
                     }
-                },
-                "top_bandwidth_user": {
-                    "$ref": "#/definitions/repository.TopBandwidthUsers"
-                },
-                "total_bandwidth": {
-                    "$ref": "#/definitions/repository.TotalBandwidths"
-                },
-                "users": {
-                    "$ref": "#/definitions/home.GetHomeUser"
                 }
             }
         },
-        "home.GetHomeUser": {
+        "ocserv_user.OcservUsersSyncResponse": {
             "type": "object",
+            "required": [
+                "meta"
+            ],
             "properties": {
-                "online_users_session": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.OnlineUserSession"
+                        "$ref": "#/definitions/user.Ocpasswd"
                     }
-                },
-                "total": {
-                    "type": "integer"
                 }
             }
         },
-        "home.ServerStatusResponse": {
+        "ocserv_user.OwnerData": {
             "type": "object",
+            "required": [
+                "user_uid"
+            ],
             "properties": {
-                "current_stats": {
-                    "$ref": "#/definitions/home.CurrentStats"
-                },
-                "general_info": {
-                    "$ref": "#/definitions/home.GeneralInfo"
+                "user_uid": {
+                    "type": "string",
+                    "example": "01K8Z4F2V9XQ3M6P7R0S1T2U3W"
                 }
             }
         },
-        "middlewares.PermissionDenied": {
+        "ocserv_user.RenewOcservUserData": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "plan_id": {
+                    "description": "defaults to the current plan of the user",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "middlewares.TooManyRequests": {
+        "ocserv_user.ScheduledActionData": {
             "type": "object",
+            "required": [
+                "action",
+                "run_at"
+            ],
             "properties": {
-                "error": {
-                    "type": "string"
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "unlock",
+                        "delete",
+                        "change_group",
+                        "change_plan",
+                        "reset_traffic"
+                    ],
+                    "example": "change_group"
+                },
+                "group": {
+                    "description": "group of change_group",
+                    "type": "string",
+                    "example": "staff"
+                },
+                "plan_id": {
+                    "description": "plan of change_plan",
+                    "type": "integer",
+                    "example": 1
+                },
+                "run_at": {
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
                 }
             }
         },
-        "middlewares.Unauthorized": {
+        "ocserv_user.ScheduledActionsResponse": {
             "type": "object",
+            "required": [
+                "meta"
+            ],
             "properties": {
-                "error": {
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ScheduledAction"
+                    }
                 }
             }
         },
-        "models.DailyTraffic": {
+        "ocserv_user.StatisticsResponse": {
             "type": "object",
+            "required": [
+                "statistics",
+                "total_bandwidths"
+            ],
             "properties": {
-                "date": {
-                    "description": "Format: YYYY-MM-DD",
-                    "type": "string"
+                "node_bandwidths": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NodeBandwidths"
+                    }
                 },
-                "rx": {
-                    "description": "in GiB",
-                    "type": "number"
+                "statistics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DailyTraffic"
+                    }
                 },
-                "tx": {
-                    "description": "in GiB",
-                    "type": "number"
+                "total_bandwidths": {
+                    "$ref": "#/definitions/repository.TotalBandwidths"
                 }
             }
         },
-        "models.IPBanPoints": {
+        "ocserv_user.SyncOcpasswdRequest": {
             "type": "object",
+            "required": [
+                "users"
+            ],
             "properties": {
-                "IP": {
-                    "type": "string"
+                "config": {
+                    "$ref": "#/definitions/models.OcservUserConfig"
                 },
-                "Score": {
-                    "type": "integer"
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "User for testing VPN access"
                 },
-                "Since": {
-                    "type": "string"
+                "expire_at": {
+                    "type": "string",
+                    "example": "2025-12-31"
                 },
-                "_Since": {
-                    "type": "string"
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ],
+                    "example": "both"
+                },
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ],
+                    "example": "monthly"
+                },
+                "traffic_size": {
+                    "description": "10 GiB",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10737418240
+                },
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ],
+                    "example": "MonthlyTransmit"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user.Ocpasswd"
+                    }
                 }
             }
         },
-        "models.OcservGroup": {
+        "ocserv_user.TopUpData": {
             "type": "object",
             "required": [
-                "name",
-                "owner"
+                "amount"
             ],
             "properties": {
-                "config": {
-                    "$ref": "#/definitions/models.OcservGroupConfig"
-                },
-                "id": {
-                    "type": "integer"
+                "amount": {
+                    "description": "in GiB",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 10
                 },
-                "name": {
-                    "type": "string"
+                "expire_at": {
+                    "description": "defaults to the end of the current traffic period",
+                    "type": "string",
+                    "example": "2025-12-31"
                 },
-                "owner": {
-                    "type": "string"
+                "note": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "Paid invoice 1024"
                 }
             }
         },
-        "models.OcservGroupConfig": {
+        "ocserv_user.TopUpResponse": {
             "type": "object",
+            "required": [
+                "top_up"
+            ],
             "properties": {
-                "cgroup": {
-                    "description": "Linux control group to assign the VPN worker process to. Format: 'controller,subsystem:name'. Example: 'cpuset,cpu:test'",
-                    "type": "string"
+                "top_up": {
+                    "$ref": "#/definitions/models.TrafficTopUp"
                 },
-                "deny-roaming": {
-                    "description": "Disconnect client if its IP changes (e.g., due to network switch). Example: true",
-                    "type": "boolean"
+                "traffic": {
+                    "$ref": "#/definitions/models.TrafficUsage"
+                }
+            }
+        },
+        "ocserv_user.TopUpsResponse": {
+            "type": "object",
+            "required": [
+                "meta"
+            ],
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
                 },
-                "dns": {
-                    "description": "Comma-separated list of DNS servers to assign to the client. Example: '8.8.8.8,1.1.1.1'",
+                "result": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.TrafficTopUp"
                     }
+                }
+            }
+        },
+        "ocserv_user.UpdateOcservUserData": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "description": "sets the given attributes, null removes one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Attributes"
+                        }
+                    ]
                 },
-                "dpd": {
-                    "description": "Dead Peer Detection timeout in seconds. Example: 90",
-                    "type": "integer"
-                },
-                "explicit-ipv4": {
-                    "description": "Static IPv4 address to assign to client. Example: '192.168.100.10'",
-                    "type": "string"
-                },
-                "idle-timeout": {
-                    "description": "Time in seconds before disconnecting idle clients. Example: 600",
-                    "type": "integer"
-                },
-                "ipv4-network": {
-                    "description": "The pool of addresses that leases will be given from. Example: '192.168.1.0/24'",
-                    "type": "string"
-                },
-                "iroute": {
-                    "description": "Internal route available only via VPN. Format: 'IP/prefix'. Example: '10.0.0.0/8'",
-                    "type": "string"
-                },
-                "keepalive": {
-                    "description": "Interval in seconds to send keep-alive pings. Example: 60",
-                    "type": "integer"
-                },
-                "max-same-clients": {
-                    "description": "Maximum simultaneous logins per user. Example: 2",
-                    "type": "integer"
-                },
-                "mobile-dpd": {
-                    "description": "DPD timeout specifically for mobile clients. Example: 300",
-                    "type": "integer"
-                },
-                "mobile-idle-timeout": {
-                    "description": "Idle timeout for mobile clients. Example: 900",
-                    "type": "integer"
-                },
-                "mtu": {
-                    "description": "Tunnel interface MTU to avoid fragmentation. Example: 1400",
-                    "type": "integer"
+                "config": {
+                    "$ref": "#/definitions/models.OcservUserConfig"
                 },
-                "nbns": {
-                    "description": "NetBIOS Name Servers (WINS) for Windows clients. Example: '192.168.1.1'",
-                    "type": "string"
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "User for testing VPN access"
                 },
-                "net-priority": {
-                    "description": "Priority for routes; lower is higher priority. Example: 1",
-                    "type": "integer"
+                "expire_at": {
+                    "type": "string",
+                    "example": "2025-12-31"
                 },
-                "no-route": {
-                    "description": "List of networks to exclude from VPN routing. Each entry should be in 'IP/prefix' format. Example: ['192.168.0.0/16', '10.0.0.0/8']",
+                "group": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "nodes": {
                     "type": "array",
                     "items": {
-                        "type": "string"
-                    }
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
                 },
-                "no-udp": {
-                    "description": "Disables UDP, enforcing TCP-only VPN connection. Example: true",
-                    "type": "boolean"
+                "over_quota_action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "throttle"
+                    ],
+                    "example": "throttle"
                 },
-                "restrict-user-to-ports": {
-                    "description": "Comma-separated list of allowed (or blocked, if negated) protocols and ports. Supports 'tcp(port)', 'udp(port)', 'icmp()', 'icmpv6()', and negation with '!()'. Example: 'tcp(443), tcp(80), udp(53)', or '!(tcp(22), udp(1194))'",
-                    "type": "string"
+                "password": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 2
                 },
-                "restrict-user-to-routes": {
-                    "description": "Allow client access only to defined routes. Example: true",
-                    "type": "boolean"
+                "schedule": {
+                    "description": "a schedule without windows falls back to the group schedule",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AccessSchedule"
+                        }
+                    ]
                 },
-                "route": {
-                    "description": "Routes pushed to the client for routing traffic. Example: ['0.0.0.0/0', '10.10.0.0/16']",
+                "tags": {
+                    "description": "replaces the tags",
                     "type": "array",
                     "items": {
                         "type": "string"
-                    }
+                    },
+                    "example": [
+                        "vip",
+                        "eu"
+                    ]
                 },
-                "rx-data-per-sec": {
-                    "description": "Maximum receive bandwidth in bytes per second. Example: '100000' for 100 KB/s",
-                    "type": "integer"
+                "throttle_rate": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 65536
                 },
-                "session-timeout": {
-                    "description": "Max session time in seconds before forced disconnect. Example: 3600",
-                    "type": "integer"
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ],
+                    "example": "both"
                 },
-                "split-dns": {
-                    "description": "List of domains over which the provided DNS servers should be used. Example: ['example.com', 'internal.company.com']",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ],
+                    "example": "monthly"
                 },
-                "stats-report-time": {
-                    "description": "Interval in seconds for stats reporting. Example: 300",
-                    "type": "integer"
+                "traffic_reset_day": {
+                    "type": "integer",
+                    "maximum": 31,
+                    "minimum": 0,
+                    "example": 15
                 },
-                "tunnel-all-dns": {
-                    "description": "Force all DNS traffic through the VPN tunnel. Example: true",
-                    "type": "boolean"
+                "traffic_size": {
+                    "description": "10 GiB",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10737418240
                 },
-                "tx-data-per-sec": {
-                    "description": "Maximum transmit bandwidth in bytes per second. Example: '200000' for 200 KB/s",
-                    "type": "integer"
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ],
+                    "example": "MonthlyTransmit"
                 }
             }
         },
-        "models.OcservUser": {
+        "ocserv_user.UpdateScheduledActionData": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "unlock",
+                        "delete",
+                        "change_group",
+                        "change_plan",
+                        "reset_traffic"
+                    ],
+                    "example": "change_group"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "staff"
+                },
+                "plan_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "run_at": {
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                }
+            }
+        },
+        "plan.CreatePlanData": {
             "type": "object",
             "required": [
-                "created_at",
+                "duration_days",
                 "group",
-                "is_locked",
-                "is_online",
-                "owner",
-                "password",
-                "rx",
-                "traffic_size",
-                "traffic_type",
-                "tx",
-                "uid",
-                "username"
+                "name",
+                "traffic_type"
             ],
             "properties": {
                 "config": {
                     "$ref": "#/definitions/models.OcservUserConfig"
                 },
-                "created_at": {
-                    "type": "string"
-                },
-                "deactivated_at": {
-                    "type": "string"
+                "currency": {
+                    "type": "string",
+                    "maxLength": 8,
+                    "example": "USD"
                 },
                 "description": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "Monthly plan with 50 GiB"
                 },
-                "expire_at": {
-                    "type": "string"
+                "duration_days": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 30
                 },
                 "group": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "defaults"
                 },
-                "is_locked": {
-                    "type": "boolean"
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "is_online": {
-                    "type": "boolean"
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 2,
+                    "example": "Monthly 50GiB"
                 },
-                "owner": {
-                    "type": "string"
+                "over_quota_action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "throttle"
+                    ],
+                    "example": "throttle"
                 },
-                "password": {
-                    "type": "string"
+                "price": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 5.5
                 },
-                "rx": {
-                    "description": "Receive in bytes",
-                    "type": "integer"
+                "throttle_rate": {
+                    "description": "rx/tx bytes per second once over quota",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 65536
+                },
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ],
+                    "example": "both"
+                },
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ],
+                    "example": "monthly"
                 },
                 "traffic_size": {
-                    "description": "in GiB  \u003e\u003e x * 1024 ** 3",
-                    "type": "integer"
+                    "description": "in GiB",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 50
                 },
                 "traffic_type": {
                     "type": "string",
@@ -2694,441 +9780,584 @@ const docTemplate = `{
                         "MonthlyTransmit",
                         "MonthlyReceive",
                         "TotallyTransmit",
-                        "TotallyReceive"
-                    ]
-                },
-                "tx": {
-                    "description": "Transmit in bytes",
-                    "type": "integer"
-                },
-                "uid": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
-                },
-                "username": {
-                    "type": "string"
+                        "TotallyReceive",
+                        "Custom"
+                    ],
+                    "example": "MonthlyTransmit"
                 }
             }
         },
-        "models.OcservUserConfig": {
+        "plan.PlansResponse": {
             "type": "object",
+            "required": [
+                "meta"
+            ],
             "properties": {
-                "dns": {
-                    "description": "Comma-separated list of DNS servers to assign to the user. Example: '8.8.8.8,1.1.1.1'",
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.Plan"
                     }
+                }
+            }
+        },
+        "plan.UpdatePlanData": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "$ref": "#/definitions/models.OcservUserConfig"
                 },
-                "explicit-ipv4": {
-                    "description": "Static IPv4 address to assign to the user. Example: '192.168.100.10'",
-                    "type": "string"
+                "currency": {
+                    "type": "string",
+                    "maxLength": 8,
+                    "example": "USD"
                 },
-                "idle-timeout": {
-                    "description": "Time in seconds before disconnecting idle users. Example: 600",
-                    "type": "integer"
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "Monthly plan with 50 GiB"
                 },
-                "ipv4-network": {
-                    "description": "The pool of addresses from which to assign to the user. Example: '192.168.1.0/24'",
-                    "type": "string"
+                "duration_days": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 30
                 },
-                "iroute": {
-                    "description": "Internal route available only via VPN. Example: '10.0.0.0/8'",
-                    "type": "string"
+                "group": {
+                    "type": "string",
+                    "example": "defaults"
                 },
-                "mobile-idle-timeout": {
-                    "description": "Idle timeout in seconds for mobile users. Example: 900",
-                    "type": "integer"
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "nbns": {
-                    "description": "NetBIOS Name Servers (WINS) for Windows clients. Example: '192.168.1.1'",
-                    "type": "string"
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 2,
+                    "example": "Monthly 50GiB"
                 },
-                "no-route": {
-                    "description": "List of networks to exclude from VPN routing. Example: ['192.168.0.0/16', '10.0.0.0/8']",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "over_quota_action": {
+                    "type": "string",
+                    "enum": [
+                        "lock",
+                        "throttle"
+                    ],
+                    "example": "throttle"
                 },
-                "rekey-time": {
-                    "description": "Rekey time in seconds; triggers key renegotiation. Example: 86400 for 24 hours",
-                    "type": "integer"
+                "price": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 5.5
                 },
-                "restrict-to-ports": {
-                    "description": "Comma-separated list of allowed or blocked ports/protocols. Supports 'tcp(port)', 'udp(port)', 'icmp()', 'icmpv6()', and negation with '!()'. Example: 'tcp(443), udp(53)' or '!(tcp(22), udp(1194))'",
-                    "type": "string"
+                "throttle_rate": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 65536
                 },
-                "restrict-to-routes": {
-                    "description": "Allow user access only to defined routes. Example: true",
-                    "type": "boolean"
+                "traffic_direction": {
+                    "type": "string",
+                    "enum": [
+                        "rx",
+                        "tx",
+                        "both"
+                    ],
+                    "example": "both"
                 },
-                "route": {
-                    "description": "Routes pushed to the user for routing traffic. Example: ['0.0.0.0/0', '10.10.0.0/16']",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "traffic_period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly",
+                        "lifetime"
+                    ],
+                    "example": "monthly"
                 },
-                "session-timeout": {
-                    "description": "Maximum session time in seconds before forced disconnect. Example: 3600",
-                    "type": "integer"
+                "traffic_size": {
+                    "description": "in GiB",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 50
                 },
-                "split-dns": {
-                    "description": "List of domains over which the provided DNS servers should be used. Example: ['example.com', 'internal.company.com']",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "traffic_type": {
+                    "type": "string",
+                    "enum": [
+                        "Free",
+                        "MonthlyTransmit",
+                        "MonthlyReceive",
+                        "TotallyTransmit",
+                        "TotallyReceive",
+                        "Custom"
+                    ],
+                    "example": "MonthlyTransmit"
                 }
             }
         },
-        "models.OnlineUserSession": {
+        "report.CreateReportScheduleData": {
             "type": "object",
+            "required": [
+                "format",
+                "name",
+                "period"
+            ],
             "properties": {
-                "Average RX": {
-                    "type": "string"
+                "delivery": {
+                    "description": "store when empty",
+                    "type": "string",
+                    "example": "store"
                 },
-                "Average TX": {
-                    "type": "string"
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "xlsx",
+                        "pdf"
+                    ],
+                    "example": "pdf"
                 },
-                "Groupname": {
-                    "type": "string"
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "Username": {
-                    "type": "string"
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 2,
+                    "example": "Monthly usage"
                 },
-                "_Connected at": {
-                    "type": "string"
+                "owner": {
+                    "description": "admins only, empty for a report of every user",
+                    "type": "string",
+                    "maxLength": 16,
+                    "example": "reseller1"
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly"
+                    ],
+                    "example": "monthly"
+                },
+                "target": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://example.com/reports"
                 }
             }
         },
-        "models.ServerVersion": {
+        "report.ReportSchedulesResponse": {
             "type": "object",
+            "required": [
+                "meta"
+            ],
             "properties": {
-                "occtl_version": {
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
                 },
-                "version": {
-                    "type": "string"
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReportSchedule"
+                    }
                 }
             }
         },
-        "models.System": {
+        "report.UpdateReportScheduleData": {
             "type": "object",
             "properties": {
-                "_": {
-                    "type": "integer"
+                "delivery": {
+                    "type": "string",
+                    "example": "webhook"
                 },
-                "google_captcha_secret": {
-                    "type": "string"
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "csv",
+                        "xlsx",
+                        "pdf"
+                    ],
+                    "example": "pdf"
                 },
-                "google_captcha_site_key": {
-                    "type": "string"
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 2,
+                    "example": "Monthly usage"
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "weekly",
+                        "monthly"
+                    ],
+                    "example": "monthly"
+                },
+                "target": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://example.com/reports"
                 }
             }
         },
-        "models.User": {
+        "report.UsageReportsResponse": {
             "type": "object",
             "required": [
-                "is_admin",
-                "last_login",
-                "uid",
-                "username"
+                "meta"
             ],
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "is_admin": {
-                    "type": "boolean"
-                },
-                "last_login": {
-                    "type": "string"
-                },
-                "uid": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
                 },
-                "username": {
-                    "type": "string"
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageReport"
+                    }
                 }
             }
         },
-        "models.UsersLookup": {
+        "repository.Drift": {
             "type": "object",
             "required": [
-                "uid",
-                "username"
+                "kind",
+                "name"
             ],
             "properties": {
-                "uid": {
+                "db": {
+                    "description": "database side, if it has a comparable value",
                     "type": "string"
                 },
-                "username": {
+                "file": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "missing_in_ocpasswd",
+                        "missing_in_db",
+                        "lock_mismatch",
+                        "group_mismatch",
+                        "user_config_mismatch",
+                        "group_config_mismatch",
+                        "orphan_user_config",
+                        "orphan_group_config"
+                    ]
+                },
+                "name": {
+                    "description": "username or group name",
                     "type": "string"
                 }
             }
         },
-        "ocserv_group.CreateOcservGroupData": {
+        "repository.DriftRepair": {
             "type": "object",
             "required": [
-                "config",
-                "name"
+                "drift",
+                "repaired"
             ],
             "properties": {
-                "config": {
-                    "$ref": "#/definitions/models.OcservGroupConfig"
+                "drift": {
+                    "$ref": "#/definitions/repository.Drift"
                 },
-                "name": {
+                "error": {
                     "type": "string"
+                },
+                "repaired": {
+                    "type": "boolean"
                 }
             }
         },
-        "ocserv_group.OcservGroupsResponse": {
+        "repository.DriftRepairReport": {
             "type": "object",
             "required": [
-                "meta"
+                "failed",
+                "repaired",
+                "results",
+                "source"
             ],
             "properties": {
-                "meta": {
-                    "$ref": "#/definitions/request.Meta"
+                "failed": {
+                    "type": "integer"
                 },
-                "result": {
+                "repaired": {
+                    "type": "integer"
+                },
+                "results": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.OcservGroup"
+                        "$ref": "#/definitions/repository.DriftRepair"
                     }
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "db",
+                        "files"
+                    ]
                 }
             }
         },
-        "ocserv_group.SyncGroupRequest": {
+        "repository.DriftReport": {
             "type": "object",
             "required": [
-                "groups"
+                "checked_at",
+                "drifts",
+                "total"
             ],
             "properties": {
-                "groups": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "drifts": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/group.UnsyncedGroup"
+                        "$ref": "#/definitions/repository.Drift"
                     }
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "ocserv_group.UpdateOcservGroupData": {
+        "repository.GroupStatistics": {
             "type": "object",
             "required": [
-                "config"
+                "group",
+                "members",
+                "online",
+                "rx",
+                "tx"
             ],
             "properties": {
-                "config": {
-                    "$ref": "#/definitions/models.OcservGroupConfig"
-                }
-            }
-        },
-        "ocserv_user.ActivateUserData": {
-            "type": "object",
-            "properties": {
-                "expire_at": {
-                    "type": "string",
-                    "example": "2025-12-31"
+                "group": {
+                    "type": "string"
+                },
+                "members": {
+                    "type": "integer"
+                },
+                "online": {
+                    "type": "integer"
+                },
+                "rx": {
+                    "type": "number"
+                },
+                "tx": {
+                    "type": "number"
                 }
             }
         },
-        "ocserv_user.CreateOcservUserData": {
+        "repository.IPAMAllocation": {
             "type": "object",
             "required": [
-                "config",
                 "group",
-                "password",
-                "traffic_type",
+                "ip",
+                "uid",
                 "username"
             ],
             "properties": {
-                "config": {
-                    "$ref": "#/definitions/models.OcservUserConfig"
-                },
-                "description": {
-                    "type": "string",
-                    "maxLength": 1024,
-                    "example": "User for testing VPN access"
-                },
-                "expire_at": {
-                    "type": "string",
-                    "example": "2025-12-31"
-                },
                 "group": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 32,
-                    "minLength": 2
-                },
-                "traffic_size": {
-                    "description": "10 GiB",
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 10737418240
+                "ip": {
+                    "type": "string"
                 },
-                "traffic_type": {
-                    "type": "string",
-                    "enum": [
-                        "Free",
-                        "MonthlyTransmit",
-                        "MonthlyReceive",
-                        "TotallyTransmit",
-                        "TotallyReceive"
-                    ],
-                    "example": "MonthlyTransmit"
+                "uid": {
+                    "type": "string"
                 },
                 "username": {
-                    "type": "string",
-                    "maxLength": 32,
-                    "minLength": 2
+                    "type": "string"
                 }
             }
         },
-        "ocserv_user.OcservUsersResponse": {
+        "repository.IPAMConflict": {
             "type": "object",
             "required": [
-                "meta"
+                "ip",
+                "kind",
+                "users"
             ],
             "properties": {
-                "meta": {
-                    "$ref": "#/definitions/request.Meta"
+                "ip": {
+                    "type": "string"
                 },
-                "result": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "duplicate_address",
+                        "outside_pool",
+                        "reserved_address"
+                    ]
+                },
+                "network": {
+                    "type": "string"
+                },
+                "users": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.OcservUser"
+                        "type": "string"
                     }
                 }
             }
         },
-        "ocserv_user.OcservUsersSyncResponse": {
+        "repository.IPAMPool": {
             "type": "object",
             "required": [
-                "meta"
+                "allocated",
+                "free",
+                "group",
+                "network",
+                "size",
+                "source",
+                "utilization"
             ],
             "properties": {
-                "meta": {
-                    "$ref": "#/definitions/request.Meta"
+                "allocated": {
+                    "description": "distinct explicit-ipv4 addresses in the pool",
+                    "type": "integer"
                 },
-                "result": {
+                "free": {
+                    "type": "integer"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "network": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "addresses that can be leased to clients",
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "group",
+                        "defaults",
+                        "server"
+                    ]
+                },
+                "utilization": {
+                    "description": "percent of size given as explicit-ipv4",
+                    "type": "number"
+                }
+            }
+        },
+        "repository.IPAMPoolDetail": {
+            "type": "object",
+            "required": [
+                "allocations",
+                "pool"
+            ],
+            "properties": {
+                "allocations": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/user.Ocpasswd"
+                        "$ref": "#/definitions/repository.IPAMAllocation"
                     }
+                },
+                "next_free": {
+                    "description": "empty when the pool is full",
+                    "type": "string"
+                },
+                "pool": {
+                    "$ref": "#/definitions/repository.IPAMPool"
                 }
             }
         },
-        "ocserv_user.StatisticsResponse": {
+        "repository.NodeStatus": {
             "type": "object",
             "required": [
-                "statistics",
-                "total_bandwidths"
+                "node",
+                "online"
             ],
             "properties": {
-                "statistics": {
+                "error": {
+                    "type": "string"
+                },
+                "node": {
+                    "$ref": "#/definitions/models.Node"
+                },
+                "online": {
+                    "type": "boolean"
+                },
+                "sessions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.DailyTraffic"
+                        "$ref": "#/definitions/models.OnlineUserSession"
                     }
                 },
-                "total_bandwidths": {
-                    "$ref": "#/definitions/repository.TotalBandwidths"
+                "status": {
+                    "type": "object",
+                    "additionalProperties": true
                 }
             }
         },
-        "ocserv_user.SyncOcpasswdRequest": {
+        "repository.ResellerGroupUsage": {
             "type": "object",
             "required": [
+                "group",
                 "users"
             ],
             "properties": {
-                "config": {
-                    "$ref": "#/definitions/models.OcservUserConfig"
-                },
-                "description": {
-                    "type": "string",
-                    "maxLength": 1024,
-                    "example": "User for testing VPN access"
-                },
-                "expire_at": {
-                    "type": "string",
-                    "example": "2025-12-31"
-                },
-                "traffic_size": {
-                    "description": "10 GiB",
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 10737418240
-                },
-                "traffic_type": {
-                    "type": "string",
-                    "enum": [
-                        "Free",
-                        "MonthlyTransmit",
-                        "MonthlyReceive",
-                        "TotallyTransmit",
-                        "TotallyReceive"
-                    ],
-                    "example": "MonthlyTransmit"
+                "group": {
+                    "type": "string"
                 },
                 "users": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/user.Ocpasswd"
-                    }
+                    "type": "integer"
                 }
             }
         },
-        "ocserv_user.UpdateOcservUserData": {
+        "repository.ResellerUsage": {
             "type": "object",
+            "required": [
+                "remaining_traffic",
+                "remaining_users",
+                "reseller",
+                "rx",
+                "traffic",
+                "tx",
+                "users"
+            ],
             "properties": {
-                "config": {
-                    "$ref": "#/definitions/models.OcservUserConfig"
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.ResellerGroupUsage"
+                    }
                 },
-                "description": {
-                    "type": "string",
-                    "maxLength": 1024,
-                    "example": "User for testing VPN access"
+                "remaining_traffic": {
+                    "type": "integer"
                 },
-                "expire_at": {
-                    "type": "string",
-                    "example": "2025-12-31"
+                "remaining_users": {
+                    "type": "integer"
                 },
-                "group": {
-                    "type": "string",
-                    "example": "default"
+                "reseller": {
+                    "$ref": "#/definitions/models.Reseller"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 32,
-                    "minLength": 2
+                "rx": {
+                    "description": "consumed GiB",
+                    "type": "number"
                 },
-                "traffic_size": {
-                    "description": "10 GiB",
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 10737418240
+                "traffic": {
+                    "description": "allocated GiB",
+                    "type": "integer"
                 },
-                "traffic_type": {
-                    "type": "string",
-                    "enum": [
-                        "Free",
-                        "MonthlyTransmit",
-                        "MonthlyReceive",
-                        "TotallyTransmit",
-                        "TotallyReceive"
-                    ],
-                    "example": "MonthlyTransmit"
+                "tx": {
+                    "description": "consumed GiB",
+                    "type": "number"
+                },
+                "users": {
+                    "type": "integer"
                 }
             }
         },
@@ -3193,6 +10422,10 @@ const docTemplate = `{
                 "total_records"
             ],
             "properties": {
+                "next_cursor": {
+                    "description": "cursor mode only, empty on the last page",
+                    "type": "string"
+                },
                 "page": {
                     "type": "integer"
                 },
@@ -3200,10 +10433,100 @@ const docTemplate = `{
                     "type": "integer"
                 },
                 "total_records": {
+                    "description": "-1 when the total is not counted",
                     "type": "integer"
                 }
             }
         },
+        "reseller.ResellersResponse": {
+            "type": "object",
+            "required": [
+                "meta"
+            ],
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Reseller"
+                    }
+                }
+            }
+        },
+        "reseller.SaveResellerData": {
+            "type": "object",
+            "properties": {
+                "allowed_groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "defaults"
+                    ]
+                },
+                "allowed_traffic_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "MonthlyTransmit"
+                    ]
+                },
+                "max_users": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 50
+                },
+                "traffic_pool": {
+                    "description": "in GiB",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 500
+                }
+            }
+        },
+        "reseller.TopUpData": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 1024,
+                    "example": "Paid invoice 1024"
+                },
+                "traffic": {
+                    "description": "in GiB",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 100
+                },
+                "users": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 10
+                }
+            }
+        },
+        "reseller.TopUpsResponse": {
+            "type": "object",
+            "required": [
+                "meta"
+            ],
+            "properties": {
+                "meta": {
+                    "$ref": "#/definitions/request.Meta"
+                },
+                "result": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ResellerTopUp"
+                    }
+                }
+            }
+        },
         "system.ChangeUserPassword": {
             "type": "object",
             "required": [
@@ -3306,6 +10629,37 @@ const docTemplate = `{
                 }
             }
         },
+        "system.RepairDriftData": {
+            "type": "object",
+            "required": [
+                "source"
+            ],
+            "properties": {
+                "delete_missing_in_db": {
+                    "description": "with source db, delete the ocpasswd entries of users missing in the database",
+                    "type": "boolean"
+                },
+                "kinds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "db",
+                        "files"
+                    ]
+                }
+            }
+        },
         "system.SetupSystem": {
             "type": "object",
             "required": [
@@ -3382,12 +10736,97 @@ const docTemplate = `{
                 }
             }
         },
+        "user.EffectiveConfig": {
+            "type": "object",
+            "properties": {
+                "directives": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user.EffectiveDirective"
+                    }
+                },
+                "group": {
+                    "type": "string"
+                },
+                "layers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user.EffectiveConfigLayer"
+                    }
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.EffectiveConfigLayer": {
+            "type": "object",
+            "properties": {
+                "exists": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "enum": [
+                        "global",
+                        "defaults",
+                        "group",
+                        "user"
+                    ]
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.EffectiveDirective": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "overridden": {
+                    "description": "values of earlier layers replaced by a later one",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user.EffectiveValue"
+                    }
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/user.EffectiveValue"
+                    }
+                }
+            }
+        },
+        "user.EffectiveValue": {
+            "type": "object",
+            "properties": {
+                "source": {
+                    "description": "layer the value comes from",
+                    "type": "string",
+                    "enum": [
+                        "global",
+                        "defaults",
+                        "group",
+                        "user"
+                    ]
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
         "user.Ocpasswd": {
             "type": "object",
             "properties": {
                 "group": {
                     "type": "string"
                 },
+                "locked": {
+                    "type": "boolean"
+                },
                 "username": {
                     "type": "string"
                 }
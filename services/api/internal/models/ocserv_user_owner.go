@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// OcservUserOwner links an ocserv user to a dashboard user sharing its ownership.
+// The owner column of the ocserv user keeps the primary owner, whose reseller quotas it uses.
+type OcservUserOwner struct {
+	OcservUserID uint      `json:"-" gorm:"primaryKey"`
+	UserID       uint      `json:"-" gorm:"primaryKey;index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"slices"
+	"time"
+)
+
+// Reseller holds the quotas of a staff user selling VPN accounts.
+// Staff users without a Reseller row are not limited.
+type Reseller struct {
+	ID                  uint                        `json:"-" gorm:"primaryKey;autoIncrement"`
+	UserID              uint                        `json:"-" gorm:"not null;uniqueIndex"`
+	MaxUsers            int                         `json:"max_users" gorm:"not null;default:0" validate:"required"`
+	TrafficPool         int                         `json:"traffic_pool" gorm:"not null;default:0" validate:"required"`  // in GiB, shared by the traffic size of all owned users
+	AllowedGroups       *commonModels.CSVStringList `json:"allowed_groups" gorm:"type:text" validate:"omitempty"`        // empty allows every group
+	AllowedTrafficTypes *commonModels.CSVStringList `json:"allowed_traffic_types" gorm:"type:text" validate:"omitempty"` // empty allows every traffic type
+	CreatedAt           time.Time                   `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time                   `json:"updated_at" gorm:"autoUpdateTime"`
+	User                User                        `json:"user"`
+}
+
+// ResellerTopUp is a ledger row of quota added to a reseller by an admin.
+type ResellerTopUp struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ResellerID uint      `json:"-" gorm:"not null;index"`
+	Users      int       `json:"users" gorm:"not null;default:0" validate:"required"`
+	Traffic    int       `json:"traffic" gorm:"not null;default:0" validate:"required"` // in GiB
+	Author     string    `json:"author" gorm:"type:varchar(16);not null" validate:"required"`
+	Note       string    `json:"note" gorm:"type:text" validate:"omitempty"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// allows reports whether the given list permits value. An empty list permits everything.
+func allows(list *commonModels.CSVStringList, value string) bool {
+	if list == nil || len(*list) == 0 {
+		return true
+	}
+	return slices.Contains(*list, value)
+}
+
+func (r *Reseller) AllowsGroup(group string) bool {
+	return allows(r.AllowedGroups, group)
+}
+
+func (r *Reseller) AllowsTrafficType(trafficType string) bool {
+	return allows(r.AllowedTrafficTypes, trafficType)
+}
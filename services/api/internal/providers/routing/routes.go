@@ -2,11 +2,19 @@ package routing
 
 import (
 	"github.com/labstack/echo/v4"
+	attributeRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/attribute"
+	configVersionRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/config_version"
 	customerRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/customer"
 	homeRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/home"
+	ipamRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/ipam"
+	nodeRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/node"
 	occtlRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/occtl"
+	ocservConfigRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/ocserv_config"
 	ocservGroupRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/ocserv_group"
 	ocservUserRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/ocserv_user"
+	planRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/plan"
+	reportRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/report"
+	resellerRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/reseller"
 	systemRoutes "github.com/mmtaee/ocserv-users-management/api/internal/services/system"
 )
 
@@ -15,8 +23,18 @@ func Register(e *echo.Echo) {
 	systemRoutes.Routes(group)
 	ocservGroupRoutes.Routes(group)
 	ocservUserRoutes.Routes(group)
+	ocservConfigRoutes.Routes(group)
+	configVersionRoutes.Routes(group)
+	ipamRoutes.Routes(group)
 	occtlRoutes.Routes(group)
 	homeRoutes.Routes(group)
+	nodeRoutes.Routes(group)
+	planRoutes.Routes(group)
+	reportRoutes.Routes(group)
+	attributeRoutes.Routes(group)
+
+	// resellers
+	resellerRoutes.Routes(group)
 
 	// customers
 	customerRoutes.Routes(group)
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/configversion"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"gorm.io/gorm"
+)
+
+type ConfigVersionRepository struct {
+	db *gorm.DB
+}
+
+type ConfigVersionRepositoryInterface interface {
+	Record(ctx context.Context, kind, name string, previous, current interface{}, author, note string) (*models.ConfigVersion, error)
+	RecordChange(ctx context.Context, kind, name string, previous, current interface{}, author, note string)
+	Versions(ctx context.Context, kind, name string, pagination *request.Pagination) ([]models.ConfigVersion, int64, error)
+	GetVersion(ctx context.Context, kind, name string, version int) (*models.ConfigVersion, error)
+}
+
+func NewConfigVersionRepository() *ConfigVersionRepository {
+	return &ConfigVersionRepository{
+		db: database.GetConnection(),
+	}
+}
+
+// Record stores current as the next version of the config, see configversion.Record.
+func (r *ConfigVersionRepository) Record(
+	ctx context.Context, kind, name string, previous, current interface{}, author, note string,
+) (*models.ConfigVersion, error) {
+	return configversion.Record(r.db.WithContext(ctx), kind, name, previous, current, author, note)
+}
+
+// RecordChange records a change already applied to the config files, logging a failure.
+func (r *ConfigVersionRepository) RecordChange(ctx context.Context, kind, name string, previous, current interface{}, author, note string) {
+	configversion.RecordChange(r.db.WithContext(ctx), kind, name, previous, current, author, note)
+}
+
+func (r *ConfigVersionRepository) Versions(
+	ctx context.Context, kind, name string, pagination *request.Pagination,
+) ([]models.ConfigVersion, int64, error) {
+	var totalRecords int64
+
+	where := r.db.WithContext(ctx).Model(&models.ConfigVersion{}).Where("kind = ? AND name = ?", kind, name)
+	if err := where.Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var versions []models.ConfigVersion
+	txPaginator := request.Paginator(ctx, r.db, pagination)
+	err := txPaginator.Model(&versions).Where("kind = ? AND name = ?", kind, name).Find(&versions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return versions, totalRecords, nil
+}
+
+func (r *ConfigVersionRepository) GetVersion(ctx context.Context, kind, name string, version int) (*models.ConfigVersion, error) {
+	var configVersion models.ConfigVersion
+	err := r.db.WithContext(ctx).
+		Where("kind = ? AND name = ? AND version = ?", kind, name, version).
+		First(&configVersion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &configVersion, nil
+}
@@ -0,0 +1,483 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	apiModels "github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/group"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/occtl"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"gorm.io/gorm"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources a drift repair takes as the truth.
+const (
+	DriftSourceDB    = "db"
+	DriftSourceFiles = "files"
+)
+
+// Kinds of drift between the database and the ocserv files.
+const (
+	DriftMissingInOcpasswd   = "missing_in_ocpasswd"   // user in the database but not in ocpasswd
+	DriftMissingInDB         = "missing_in_db"         // user in ocpasswd but not in the database
+	DriftLockMismatch        = "lock_mismatch"         // locked on one side only
+	DriftGroupMismatch       = "group_mismatch"        // user group differs
+	DriftUserConfigMismatch  = "user_config_mismatch"  // user config file differs from the stored config
+	DriftGroupConfigMismatch = "group_config_mismatch" // group config file differs from the stored config
+	DriftOrphanUserConfig    = "orphan_user_config"    // user config file without a user in the database
+	DriftOrphanGroupConfig   = "orphan_group_config"   // group config file without a group in the database
+)
+
+type Drift struct {
+	Kind string `json:"kind" validate:"required" enums:"missing_in_ocpasswd,missing_in_db,lock_mismatch,group_mismatch,user_config_mismatch,group_config_mismatch,orphan_user_config,orphan_group_config"`
+	Name string `json:"name" validate:"required"` // username or group name
+	DB   string `json:"db" validate:"omitempty"`  // database side, if it has a comparable value
+	File string `json:"file" validate:"omitempty"`
+}
+
+type DriftReport struct {
+	CheckedAt time.Time `json:"checked_at" validate:"required"`
+	Total     int       `json:"total" validate:"required"`
+	Drifts    []Drift   `json:"drifts" validate:"required"`
+}
+
+type DriftRepair struct {
+	Drift    Drift  `json:"drift" validate:"required"`
+	Repaired bool   `json:"repaired" validate:"required"`
+	Error    string `json:"error,omitempty" validate:"omitempty"`
+}
+
+type DriftRepairReport struct {
+	Source   string        `json:"source" validate:"required" enums:"db,files"`
+	Repaired int           `json:"repaired" validate:"required"`
+	Failed   int           `json:"failed" validate:"required"`
+	Results  []DriftRepair `json:"results" validate:"required"`
+}
+
+// DriftFilter narrows a repair to some kinds or names. Empty lists match everything.
+// DeleteMissingInDB opts in to deleting the ocpasswd entries of users missing in the database
+// when the database is the truth; without it they are reported as not repaired.
+type DriftFilter struct {
+	Kinds             []string
+	Names             []string
+	DeleteMissingInDB bool
+}
+
+func (f DriftFilter) match(d Drift) bool {
+	return (len(f.Kinds) == 0 || slices.Contains(f.Kinds, d.Kind)) &&
+		(len(f.Names) == 0 || slices.Contains(f.Names, d.Name))
+}
+
+type DriftRepository struct {
+	db                    *gorm.DB
+	commonOcservUserRepo  user.OcservUserInterface
+	commonOcservGroupRepo group.OcservGroupInterface
+	commonOcservOcctlRepo occtl.OcservOcctlInterface
+}
+
+type DriftRepositoryInterface interface {
+	Check(ctx context.Context) (*DriftReport, error)
+	Repair(ctx context.Context, source string, filter DriftFilter, owner string) (*DriftRepairReport, error)
+}
+
+func NewDriftRepository() *DriftRepository {
+	return &DriftRepository{
+		db:                    database.GetConnection(),
+		commonOcservUserRepo:  user.NewOcservUser(),
+		commonOcservGroupRepo: group.NewOcservGroup(),
+		commonOcservOcctlRepo: occtl.NewOcservOcctl(),
+	}
+}
+
+// Check compares the users and groups in the database with ocpasswd and the group and user config directories.
+func (r *DriftRepository) Check(ctx context.Context) (*DriftReport, error) {
+	var users []models.OcservUser
+	if err := r.db.WithContext(ctx).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	var groups []models.OcservGroup
+	if err := r.db.WithContext(ctx).Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	ocpasswd, _, err := r.commonOcservUserRepo.Ocpasswd(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read ocpasswd: %w", err)
+	}
+	passwd := make(map[string]user.Ocpasswd, len(*ocpasswd))
+	for _, u := range *ocpasswd {
+		passwd[u.Username] = u
+	}
+
+	var drifts []Drift
+	dbUsers := make(map[string]bool, len(users))
+	for _, u := range users {
+		dbUsers[u.Username] = true
+
+		entry, ok := passwd[u.Username]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: DriftMissingInOcpasswd, Name: u.Username})
+		} else {
+			if entry.Locked != u.IsLocked {
+				drifts = append(drifts, Drift{
+					Kind: DriftLockMismatch,
+					Name: u.Username,
+					DB:   strconv.FormatBool(u.IsLocked),
+					File: strconv.FormatBool(entry.Locked),
+				})
+			}
+			if ocpasswdGroup(entry.Group) != ocpasswdGroup(u.Group) {
+				drifts = append(drifts, Drift{Kind: DriftGroupMismatch, Name: u.Username, DB: u.Group, File: entry.Group})
+			}
+		}
+
+		matches, err := utils.ConfigMatches(utils.UserConfigFilePathCreator(u.Username), utils.ToMap(u.AppliedConfig()), utils.UserConfigKeys)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			drifts = append(drifts, Drift{Kind: DriftUserConfigMismatch, Name: u.Username})
+		}
+	}
+
+	for _, u := range *ocpasswd {
+		if !dbUsers[u.Username] {
+			drifts = append(drifts, Drift{Kind: DriftMissingInDB, Name: u.Username, File: u.Group})
+		}
+	}
+
+	dbGroups := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		dbGroups[g.Name] = true
+		matches, err := utils.ConfigMatches(utils.GroupConfigFilePathCreator(g.Name), utils.ToMap(g.Config), utils.GroupConfigKeys)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			drifts = append(drifts, Drift{Kind: DriftGroupConfigMismatch, Name: g.Name})
+		}
+	}
+
+	userFiles, err := configFiles(utils.ConfigUserBaseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range userFiles {
+		if !dbUsers[name] {
+			drifts = append(drifts, Drift{Kind: DriftOrphanUserConfig, Name: name})
+		}
+	}
+
+	groupFiles, err := configFiles(utils.ConfigGroupBaseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range groupFiles {
+		if !dbGroups[name] {
+			drifts = append(drifts, Drift{Kind: DriftOrphanGroupConfig, Name: name})
+		}
+	}
+
+	if drifts == nil {
+		drifts = []Drift{}
+	}
+	return &DriftReport{CheckedAt: time.Now(), Total: len(drifts), Drifts: drifts}, nil
+}
+
+// Repair checks for drift and fixes the drifts matching the filter, taking either the database
+// or the files as the truth. Users created from ocpasswd get the given owner. Each drift is
+// repaired on its own, so one failure does not stop the others.
+func (r *DriftRepository) Repair(ctx context.Context, source string, filter DriftFilter, owner string) (*DriftRepairReport, error) {
+	if source != DriftSourceDB && source != DriftSourceFiles {
+		return nil, fmt.Errorf("source must be %s or %s", DriftSourceDB, DriftSourceFiles)
+	}
+
+	report, err := r.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DriftRepairReport{Source: source, Results: []DriftRepair{}}
+	for _, d := range report.Drifts {
+		if !filter.match(d) {
+			continue
+		}
+
+		if source == DriftSourceDB {
+			if d.Kind == DriftMissingInDB && !filter.DeleteMissingInDB {
+				err = errors.New("deleting users missing in the database is not enabled")
+			} else {
+				err = r.repairFiles(ctx, d)
+			}
+		} else {
+			err = r.repairDB(ctx, d, owner)
+		}
+
+		repair := DriftRepair{Drift: d, Repaired: err == nil}
+		if err != nil {
+			repair.Error = err.Error()
+			result.Failed++
+		} else {
+			result.Repaired++
+		}
+		result.Results = append(result.Results, repair)
+	}
+
+	if result.Repaired > 0 {
+		go func() {
+			_, _ = r.commonOcservOcctlRepo.ReloadConfigs()
+		}()
+	}
+	return result, nil
+}
+
+// repairFiles rewrites the ocserv files from the database. Existing ocpasswd entries are never
+// recreated, since the database may not know the password of users adopted from ocpasswd.
+func (r *DriftRepository) repairFiles(ctx context.Context, d Drift) error {
+	switch d.Kind {
+	case DriftMissingInOcpasswd:
+		u, err := r.user(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+		if u.Password == "" || u.Password == models.OcpasswdPassword {
+			return errors.New("the database does not know the password of the user; set a new one or repair from files")
+		}
+		if err = r.commonOcservUserRepo.Create(u.Group, u.Username, u.Password, u.AppliedConfig()); err != nil {
+			return err
+		}
+		if u.IsLocked {
+			_, err = r.commonOcservUserRepo.Lock(u.Username)
+		}
+		return err
+	case DriftGroupMismatch:
+		u, err := r.user(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+		return r.commonOcservUserRepo.SetGroup(u.Group, u.Username)
+	case DriftLockMismatch:
+		u, err := r.user(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+		if u.IsLocked {
+			_, err = r.commonOcservUserRepo.Lock(u.Username)
+		} else {
+			_, err = r.commonOcservUserRepo.UnLock(u.Username)
+		}
+		return err
+	case DriftMissingInDB:
+		_, err := r.commonOcservUserRepo.Delete(d.Name)
+		return err
+	case DriftUserConfigMismatch:
+		u, err := r.user(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+		if u.Config == nil {
+			return removeConfigFile(utils.UserConfigFilePathCreator(u.Username))
+		}
+		return r.commonOcservUserRepo.CreateConfig(u.Username, u.AppliedConfig())
+	case DriftGroupConfigMismatch:
+		var g models.OcservGroup
+		if err := r.db.WithContext(ctx).Where("name = ?", d.Name).First(&g).Error; err != nil {
+			return err
+		}
+		return r.commonOcservGroupRepo.Create(g.Name, g.Config)
+	case DriftOrphanUserConfig:
+		return removeConfigFile(utils.UserConfigFilePathCreator(d.Name))
+	case DriftOrphanGroupConfig:
+		return r.commonOcservGroupRepo.Delete(d.Name)
+	}
+	return fmt.Errorf("unknown drift kind %s", d.Kind)
+}
+
+// repairDB updates the database from the ocserv files.
+func (r *DriftRepository) repairDB(ctx context.Context, d Drift, owner string) error {
+	switch d.Kind {
+	case DriftMissingInOcpasswd:
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var u models.OcservUser
+			if err := tx.Where("username = ?", d.Name).First(&u).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&u).Association("Nodes").Clear(); err != nil {
+				return err
+			}
+			if err := tx.Where("ocserv_user_id = ?", u.ID).Delete(&apiModels.OcservUserOwner{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&u).Error
+		})
+	case DriftMissingInDB:
+		entry, err := r.ocpasswdUser(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+		config, err := fileUserConfig(d.Name)
+		if err != nil {
+			return err
+		}
+		expireAt := time.Now().AddDate(0, 0, 30)
+		u := models.OcservUser{
+			Username:    entry.Username,
+			Password:    models.OcpasswdPassword,
+			Group:       entry.Group,
+			Owner:       owner,
+			IsLocked:    entry.Locked,
+			ExpireAt:    &expireAt,
+			TrafficType: models.Free,
+			Config:      config,
+		}
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err = tx.Create(&u).Error; err != nil {
+				return err
+			}
+			return SyncPrimaryOwners(tx, u.ID)
+		})
+	case DriftLockMismatch:
+		entry, err := r.ocpasswdUser(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+		return r.db.WithContext(ctx).Model(&models.OcservUser{}).
+			Where("username = ?", d.Name).
+			Update("is_locked", entry.Locked).Error
+	case DriftGroupMismatch:
+		entry, err := r.ocpasswdUser(ctx, d.Name)
+		if err != nil {
+			return err
+		}
+		return r.db.WithContext(ctx).Model(&models.OcservUser{}).
+			Where("username = ?", d.Name).
+			Update("group", entry.Group).Error
+	case DriftUserConfigMismatch, DriftOrphanUserConfig:
+		// orphan configs are only adopted by users created from ocpasswd earlier in the repair
+		u, err := r.user(ctx, d.Name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user is missing in the database; repair missing_in_db first or remove the file with source db")
+		}
+		if err != nil {
+			return err
+		}
+		config, err := fileUserConfig(d.Name)
+		if err != nil {
+			return err
+		}
+		u.Config = config
+		return r.db.WithContext(ctx).Model(u).Select("config").Updates(u).Error
+	case DriftGroupConfigMismatch, DriftOrphanGroupConfig:
+		config, err := fileGroupConfig(d.Name)
+		if err != nil {
+			return err
+		}
+		var g models.OcservGroup
+		if err = r.db.WithContext(ctx).Where("name = ?", d.Name).Limit(1).Find(&g).Error; err != nil {
+			return err
+		}
+		if g.ID == 0 {
+			return r.db.WithContext(ctx).Create(&models.OcservGroup{Name: d.Name, Owner: owner, Config: config}).Error
+		}
+		g.Config = config
+		return r.db.WithContext(ctx).Model(&g).Select("config").Updates(&g).Error
+	}
+	return fmt.Errorf("unknown drift kind %s", d.Kind)
+}
+
+func (r *DriftRepository) user(ctx context.Context, username string) (*models.OcservUser, error) {
+	var u models.OcservUser
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *DriftRepository) ocpasswdUser(ctx context.Context, username string) (*user.Ocpasswd, error) {
+	ocpasswd, _, err := r.commonOcservUserRepo.Ocpasswd(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range *ocpasswd {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user %s not found in ocpasswd", username)
+}
+
+// ocpasswdGroup normalizes the group of an ocpasswd entry or a user, where * and empty mean defaults.
+func ocpasswdGroup(name string) string {
+	if name == "" || name == "*" {
+		return "defaults"
+	}
+	return name
+}
+
+// configFiles lists the config files of a directory, skipping temp files left by atomic writes.
+func configFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+func removeConfigFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// fileUserConfig parses the config file of a user; a missing or empty file yields nil.
+func fileUserConfig(username string) (*models.OcservUserConfig, error) {
+	values, err := utils.ParseOcservConfigFile(utils.UserConfigFilePathCreator(username))
+	if os.IsNotExist(err) || (err == nil && len(values) == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := utils.UserConfigToModel(values)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// fileGroupConfig parses the config file of a group; a missing or empty file yields nil.
+func fileGroupConfig(name string) (*models.OcservGroupConfig, error) {
+	values, err := utils.ParseOcservConfigFile(utils.GroupConfigFilePathCreator(name))
+	if os.IsNotExist(err) || (err == nil && len(values) == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := utils.GroupConfigToModel(values)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupOcservFiles points the ocserv files to a temporary directory holding the given ocpasswd.
+func setupOcservFiles(t *testing.T, ocpasswd string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := []*string{&utils.OcpasswdPath, &utils.ConfigUserBaseDir, &utils.ConfigGroupBaseDir}
+	previous := make([]string, len(paths))
+	for i, p := range paths {
+		previous[i] = *p
+	}
+	t.Cleanup(func() {
+		for i, p := range paths {
+			*p = previous[i]
+		}
+	})
+
+	utils.OcpasswdPath = filepath.Join(dir, "ocpasswd")
+	utils.ConfigUserBaseDir = filepath.Join(dir, "users") + "/"
+	utils.ConfigGroupBaseDir = filepath.Join(dir, "groups") + "/"
+	require.NoError(t, os.WriteFile(utils.OcpasswdPath, []byte(ocpasswd), 0600))
+	return utils.OcpasswdPath
+}
+
+func TestDriftRepairFromDB(t *testing.T) {
+	db := setupDB(t, &models.User{}, &models.OcservUserOwner{}, &commonModels.Node{}, &commonModels.OcservGroup{}, &commonModels.OcservUser{})
+	ocpasswd := setupOcservFiles(t, "alice:*:$5$alicehash\ncarol:*:!$5$carolhash\n")
+
+	require.NoError(t, db.Create(&commonModels.OcservGroup{Name: "vip", Owner: "admin"}).Error)
+	require.NoError(t, db.Create(&commonModels.OcservUser{
+		UID: "alice", Owner: "admin", Username: "alice", Password: "secret", Group: "vip", TrafficType: commonModels.Free,
+	}).Error)
+	require.NoError(t, db.Create(&commonModels.OcservUser{
+		UID: "bob", Owner: "admin", Username: "bob", Password: commonModels.OcpasswdPassword, Group: "defaults", TrafficType: commonModels.Free,
+	}).Error)
+
+	report, err := repository.NewDriftRepository().Repair(context.Background(), repository.DriftSourceDB, repository.DriftFilter{}, "admin")
+	require.NoError(t, err)
+
+	results := make(map[string]repository.DriftRepair, len(report.Results))
+	for _, result := range report.Results {
+		results[result.Drift.Kind+" "+result.Drift.Name] = result
+	}
+	assert.Len(t, results, 3)
+	assert.True(t, results["group_mismatch alice"].Repaired)
+	assert.False(t, results["missing_in_ocpasswd bob"].Repaired, "placeholder password is not written to ocpasswd")
+	assert.Contains(t, results["missing_in_ocpasswd bob"].Error, "password")
+	assert.False(t, results["missing_in_db carol"].Repaired, "deletion is opt-in")
+	assert.Equal(t, 1, report.Repaired)
+	assert.Equal(t, 2, report.Failed)
+
+	content, err := os.ReadFile(ocpasswd)
+	require.NoError(t, err)
+	assert.Equal(t, "alice:vip:$5$alicehash\ncarol:*:!$5$carolhash\n", string(content), "hashes and locks are kept")
+}
+
+func TestDriftRepairFilter(t *testing.T) {
+	db := setupDB(t, &models.User{}, &models.OcservUserOwner{}, &commonModels.Node{}, &commonModels.OcservGroup{}, &commonModels.OcservUser{})
+	ocpasswd := setupOcservFiles(t, "alice:*:$5$alicehash\n")
+
+	require.NoError(t, db.Create(&commonModels.OcservGroup{Name: "vip", Owner: "admin"}).Error)
+	require.NoError(t, db.Create(&commonModels.OcservUser{
+		UID: "alice", Owner: "admin", Username: "alice", Password: "secret", Group: "vip", TrafficType: commonModels.Free,
+	}).Error)
+
+	filter := repository.DriftFilter{Kinds: []string{repository.DriftLockMismatch}}
+	report, err := repository.NewDriftRepository().Repair(context.Background(), repository.DriftSourceDB, filter, "admin")
+	require.NoError(t, err)
+	assert.Empty(t, report.Results)
+
+	content, err := os.ReadFile(ocpasswd)
+	require.NoError(t, err)
+	assert.Equal(t, "alice:*:$5$alicehash\n", string(content))
+}
@@ -0,0 +1,492 @@
+package repository
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/group"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/server"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"gorm.io/gorm"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sources of the ipv4-network a pool is taken from.
+const (
+	PoolSourceUser     = "user"
+	PoolSourceGroup    = "group"
+	PoolSourceDefaults = "defaults"
+	PoolSourceServer   = "server"
+)
+
+// Kinds of explicit-ipv4 conflicts.
+const (
+	ConflictDuplicateAddress = "duplicate_address" // the same address is given to several users
+	ConflictOutsidePool      = "outside_pool"      // the address is not in the pool of the user
+	ConflictReservedAddress  = "reserved_address"  // network, server or broadcast address of the pool
+)
+
+type IPAMPool struct {
+	Group       string  `json:"group" validate:"required"`
+	Network     string  `json:"network" validate:"required"`
+	Source      string  `json:"source" validate:"required" enums:"group,defaults,server"`
+	Size        int64   `json:"size" validate:"required"`      // addresses that can be leased to clients
+	Allocated   int     `json:"allocated" validate:"required"` // distinct explicit-ipv4 addresses in the pool
+	Free        int64   `json:"free" validate:"required"`
+	Utilization float64 `json:"utilization" validate:"required"` // percent of size given as explicit-ipv4
+}
+
+type IPAMAllocation struct {
+	UID      string `json:"uid" validate:"required"`
+	Username string `json:"username" validate:"required"`
+	Group    string `json:"group" validate:"required"`
+	IP       string `json:"ip" validate:"required"`
+}
+
+type IPAMPoolDetail struct {
+	Pool        IPAMPool         `json:"pool" validate:"required"`
+	NextFree    string           `json:"next_free" validate:"omitempty"` // empty when the pool is full
+	Allocations []IPAMAllocation `json:"allocations" validate:"required"`
+}
+
+type IPAMConflict struct {
+	Kind    string   `json:"kind" validate:"required" enums:"duplicate_address,outside_pool,reserved_address"`
+	IP      string   `json:"ip" validate:"required"`
+	Network string   `json:"network" validate:"omitempty"`
+	Users   []string `json:"users" validate:"required"`
+}
+
+// ipamMutex serializes allocations, so concurrent requests never pick the same address.
+var ipamMutex sync.Mutex
+
+type IPAMRepository struct {
+	db                     *gorm.DB
+	ocservUserRepo         OcservUserRepositoryInterface
+	commonOcservGroupRepo  group.OcservGroupInterface
+	commonOcservServerRepo server.OcservServerInterface
+}
+
+type IPAMRepositoryInterface interface {
+	Pools(ctx context.Context) ([]IPAMPool, error)
+	Pool(ctx context.Context, groupName string) (*IPAMPoolDetail, error)
+	Conflicts(ctx context.Context) ([]IPAMConflict, error)
+	SaveUser(ctx context.Context, ocservUser *models.OcservUser, save func() error) error
+	MoveUsers(ctx context.Context, groupName string, move func(check MoveCheck) error) error
+	Allocate(ctx context.Context, ocservUser *models.OcservUser) (*models.OcservUser, error)
+}
+
+func NewIPAMRepository() *IPAMRepository {
+	return &IPAMRepository{
+		db:                     database.GetConnection(),
+		ocservUserRepo:         NewtOcservUserRepository(),
+		commonOcservGroupRepo:  group.NewOcservGroup(),
+		commonOcservServerRepo: server.NewOcservServer(server.ExecRunner{}),
+	}
+}
+
+// ipamPool is a pool resolved for a group or a user.
+type ipamPool struct {
+	network *net.IPNet
+	source  string
+}
+
+// ipamState holds the pools and the explicit-ipv4 addresses of all users.
+type ipamState struct {
+	server   *net.IPNet
+	defaults *net.IPNet
+	groups   map[string]*net.IPNet // nil when the group has no ipv4-network
+	users    []models.OcservUser   // users with an explicit-ipv4
+}
+
+func (r *IPAMRepository) load(ctx context.Context) (*ipamState, error) {
+	state := &ipamState{groups: make(map[string]*net.IPNet)}
+
+	if config, err := r.commonOcservServerRepo.Config(); err == nil && config.IPv4Network != nil {
+		state.server = ipv4Network(*config.IPv4Network)
+	}
+	if config, err := r.commonOcservGroupRepo.DefaultsGroup(); err == nil && config != nil && config.IPv4Network != nil {
+		state.defaults = ipv4Network(*config.IPv4Network)
+	}
+
+	var groups []models.OcservGroup
+	if err := r.db.WithContext(ctx).Select("name", "config").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		var network *net.IPNet
+		if g.Config != nil && g.Config.IPv4Network != nil {
+			network = ipv4Network(*g.Config.IPv4Network)
+		}
+		state.groups[g.Name] = network
+	}
+
+	var users []models.OcservUser
+	err := r.db.WithContext(ctx).
+		Select("id", "uid", "username", "group", "config").
+		Where("config LIKE ?", `%"explicit-ipv4":"%`).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if explicitIPv4(&u) != nil {
+			state.users = append(state.users, u)
+		}
+	}
+	return state, nil
+}
+
+// groupPool returns the pool leases of a group come from: its own ipv4-network, else the
+// one of the defaults group, else the one of ocserv.conf.
+func (s *ipamState) groupPool(name string) ipamPool {
+	if name != "" && name != "defaults" {
+		if network := s.groups[name]; network != nil {
+			return ipamPool{network: network, source: PoolSourceGroup}
+		}
+	}
+	if s.defaults != nil {
+		return ipamPool{network: s.defaults, source: PoolSourceDefaults}
+	}
+	return ipamPool{network: s.server, source: PoolSourceServer}
+}
+
+// userPool returns the pool of a user, whose own ipv4-network takes precedence over the group.
+func (s *ipamState) userPool(u *models.OcservUser) ipamPool {
+	if u.Config != nil && u.Config.IPv4Network != nil {
+		if network := ipv4Network(*u.Config.IPv4Network); network != nil {
+			return ipamPool{network: network, source: PoolSourceUser}
+		}
+	}
+	return s.groupPool(u.Group)
+}
+
+// allocated returns the explicit addresses of the users whose pool is the network.
+func (s *ipamState) allocated(network *net.IPNet) []IPAMAllocation {
+	allocations := make([]IPAMAllocation, 0)
+	for i := range s.users {
+		u := &s.users[i]
+		pool := s.userPool(u)
+		if pool.network == nil || pool.network.String() != network.String() {
+			continue
+		}
+		ip := explicitIPv4(u)
+		if !network.Contains(ip) {
+			continue
+		}
+		allocations = append(allocations, IPAMAllocation{UID: u.UID, Username: u.Username, Group: u.Group, IP: ip.String()})
+	}
+	sort.Slice(allocations, func(i, j int) bool {
+		return ipToUint(net.ParseIP(allocations[i].IP)) < ipToUint(net.ParseIP(allocations[j].IP))
+	})
+	return allocations
+}
+
+func (s *ipamState) pool(name string) (*IPAMPool, ipamPool, error) {
+	pool := s.groupPool(name)
+	if pool.network == nil {
+		return nil, pool, fmt.Errorf("no ipv4-network is set for group %s", name)
+	}
+
+	addresses := make(map[string]bool)
+	for _, allocation := range s.allocated(pool.network) {
+		addresses[allocation.IP] = true
+	}
+
+	size := poolSize(pool.network)
+	allocated := len(addresses)
+	result := &IPAMPool{
+		Group:     name,
+		Network:   pool.network.String(),
+		Source:    pool.source,
+		Size:      size,
+		Allocated: allocated,
+		Free:      max(size-int64(allocated), 0),
+	}
+	if size > 0 {
+		result.Utilization = float64(allocated) * 100 / float64(size)
+	}
+	return result, pool, nil
+}
+
+// Pools lists the pool of every group with an ipv4-network, including the defaults group.
+func (r *IPAMRepository) Pools(ctx context.Context) ([]IPAMPool, error) {
+	state, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{"defaults"}
+	for name := range state.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+
+	pools := make([]IPAMPool, 0, len(names))
+	for _, name := range names {
+		if pool, _, err := state.pool(name); err == nil {
+			pools = append(pools, *pool)
+		}
+	}
+	return pools, nil
+}
+
+// Pool returns the pool of a group with its allocations and the next free address.
+func (r *IPAMRepository) Pool(ctx context.Context, groupName string) (*IPAMPoolDetail, error) {
+	state, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := state.groups[groupName]; !ok && groupName != "defaults" {
+		return nil, fmt.Errorf("group %s not found", groupName)
+	}
+
+	pool, resolved, err := state.pool(groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations := state.allocated(resolved.network)
+	detail := &IPAMPoolDetail{Pool: *pool, Allocations: allocations}
+	if ip := nextFree(resolved.network, state.used()); ip != nil {
+		detail.NextFree = ip.String()
+	}
+	return detail, nil
+}
+
+// Conflicts reports explicit-ipv4 addresses given to several users, outside the pool of their
+// user or reserved in the pool.
+func (r *IPAMRepository) Conflicts(ctx context.Context) ([]IPAMConflict, error) {
+	state, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]IPAMConflict, 0)
+	owners := make(map[string][]string)
+	for i := range state.users {
+		u := &state.users[i]
+		ip := explicitIPv4(u)
+		owners[ip.String()] = append(owners[ip.String()], u.Username)
+
+		pool := state.userPool(u)
+		if pool.network == nil {
+			continue
+		}
+		if kind := checkAddress(ip, pool.network); kind != "" {
+			conflicts = append(conflicts, IPAMConflict{
+				Kind:    kind,
+				IP:      ip.String(),
+				Network: pool.network.String(),
+				Users:   []string{u.Username},
+			})
+		}
+	}
+
+	for ip, users := range owners {
+		if len(users) > 1 {
+			sort.Strings(users)
+			conflicts = append(conflicts, IPAMConflict{Kind: ConflictDuplicateAddress, IP: ip, Users: users})
+		}
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].Kind != conflicts[j].Kind {
+			return conflicts[i].Kind < conflicts[j].Kind
+		}
+		return ipToUint(net.ParseIP(conflicts[i].IP)) < ipToUint(net.ParseIP(conflicts[j].IP))
+	})
+	return conflicts, nil
+}
+
+// SaveUser checks the explicit-ipv4 of the user with checkUser and runs save, both while
+// allocations are held off, so that no allocation or other save takes the address in between.
+func (r *IPAMRepository) SaveUser(ctx context.Context, ocservUser *models.OcservUser, save func() error) error {
+	ipamMutex.Lock()
+	defer ipamMutex.Unlock()
+
+	if err := r.checkUser(ctx, ocservUser); err != nil {
+		return err
+	}
+	return save()
+}
+
+// checkUser rejects an explicit-ipv4 of the user that is used by another user, outside the
+// pool of the user or reserved in the pool.
+func (r *IPAMRepository) checkUser(ctx context.Context, ocservUser *models.OcservUser) error {
+	if explicitIPv4(ocservUser) == nil {
+		return nil
+	}
+
+	state, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+	return state.checkUser(ocservUser)
+}
+
+// MoveCheck is given the users a move selected, before they are moved. It rejects the move of
+// users whose explicit-ipv4 would not fit the pool of the target group.
+type MoveCheck func(users []models.OcservUser) error
+
+// MoveUsers runs move, which moves users to the group and hands them to its check first, while
+// allocations are held off. The pools are loaded before move runs, the check reads no database.
+func (r *IPAMRepository) MoveUsers(ctx context.Context, groupName string, move func(check MoveCheck) error) error {
+	ipamMutex.Lock()
+	defer ipamMutex.Unlock()
+
+	state, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+	return move(func(users []models.OcservUser) error {
+		for _, u := range users {
+			u.Group = groupName
+			if err2 := state.checkUser(&u); err2 != nil {
+				return fmt.Errorf("user %s cannot move to %s: %w", u.Username, groupName, err2)
+			}
+		}
+		return nil
+	})
+}
+
+// checkUser is checkUser of the repository on the loaded state.
+func (s *ipamState) checkUser(ocservUser *models.OcservUser) error {
+	ip := explicitIPv4(ocservUser)
+	if ip == nil {
+		return nil
+	}
+
+	for i := range s.users {
+		u := &s.users[i]
+		if u.Username != ocservUser.Username && explicitIPv4(u).Equal(ip) {
+			return fmt.Errorf("explicit-ipv4 %s is already assigned to %s", ip, u.Username)
+		}
+	}
+
+	pool := s.userPool(ocservUser)
+	if pool.network == nil {
+		return nil
+	}
+	switch checkAddress(ip, pool.network) {
+	case ConflictOutsidePool:
+		return fmt.Errorf("explicit-ipv4 %s is outside the %s pool %s", ip, pool.source, pool.network)
+	case ConflictReservedAddress:
+		return fmt.Errorf("explicit-ipv4 %s is reserved in the pool %s", ip, pool.network)
+	}
+	return nil
+}
+
+// Allocate gives the user the next free address of its pool as explicit-ipv4 and saves its config.
+// A user that already has an explicit-ipv4 keeps it.
+func (r *IPAMRepository) Allocate(ctx context.Context, ocservUser *models.OcservUser) (*models.OcservUser, error) {
+	ipamMutex.Lock()
+	defer ipamMutex.Unlock()
+
+	if ocservUser.Config != nil && ocservUser.Config.ExplicitIPv4 != nil && *ocservUser.Config.ExplicitIPv4 != "" {
+		return nil, fmt.Errorf("the user already has the explicit-ipv4 %s; remove it to allocate another", *ocservUser.Config.ExplicitIPv4)
+	}
+
+	state, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := state.userPool(ocservUser)
+	if pool.network == nil {
+		return nil, errors.New("no ipv4-network is set for the user, its group or ocserv.conf")
+	}
+	ip := nextFree(pool.network, state.used())
+	if ip == nil {
+		return nil, fmt.Errorf("the pool %s is full", pool.network)
+	}
+
+	config := models.OcservUserConfig{}
+	if ocservUser.Config != nil {
+		config = *ocservUser.Config
+	}
+	address := ip.String()
+	config.ExplicitIPv4 = &address
+	ocservUser.Config = &config
+
+	return r.ocservUserRepo.UpdateConfig(ctx, ocservUser)
+}
+
+func (s *ipamState) used() map[uint32]bool {
+	used := make(map[uint32]bool, len(s.users))
+	for i := range s.users {
+		used[ipToUint(explicitIPv4(&s.users[i]))] = true
+	}
+	return used
+}
+
+// checkAddress returns the conflict of an address with its pool, or an empty string.
+func checkAddress(ip net.IP, network *net.IPNet) string {
+	if !network.Contains(ip) {
+		return ConflictOutsidePool
+	}
+	first, last := poolRange(network)
+	if n := ipToUint(ip); n < first || n > last {
+		return ConflictReservedAddress
+	}
+	return ""
+}
+
+// poolRange returns the first and last address ocserv leases from a network: the network
+// address and the first host, used by the server, are skipped as well as the broadcast address.
+func poolRange(network *net.IPNet) (uint32, uint32) {
+	base := ipToUint(network.IP)
+	ones, bits := network.Mask.Size()
+	broadcast := base | (uint32(1)<<(bits-ones) - 1)
+	return base + 2, broadcast - 1
+}
+
+func poolSize(network *net.IPNet) int64 {
+	first, last := poolRange(network)
+	if last < first {
+		return 0
+	}
+	return int64(last-first) + 1
+}
+
+func nextFree(network *net.IPNet, used map[uint32]bool) net.IP {
+	first, last := poolRange(network)
+	for n := first; n <= last && n >= first; n++ {
+		if !used[n] {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, n)
+			return ip
+		}
+	}
+	return nil
+}
+
+// ipv4Network parses an IPv4 pool, or returns nil for anything else, such as a bare address
+// completed by ipv4-netmask in ocserv.conf.
+func ipv4Network(s string) *net.IPNet {
+	network, err := utils.ParseIPNet(strings.TrimSpace(s))
+	if err != nil || network.IP.To4() == nil {
+		return nil
+	}
+	network.IP = network.IP.To4()
+	return network
+}
+
+func explicitIPv4(u *models.OcservUser) net.IP {
+	if u.Config == nil || u.Config.ExplicitIPv4 == nil {
+		return nil
+	}
+	return net.ParseIP(strings.TrimSpace(*u.Config.ExplicitIPv4)).To4()
+}
+
+func ipToUint(ip net.IP) uint32 {
+	if ip = ip.To4(); ip == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(ip)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestPoolRange(t *testing.T) {
+	tests := []struct {
+		network string
+		first   string
+		last    string
+		size    int64
+	}{
+		{"192.168.1.0/24", "192.168.1.2", "192.168.1.254", 253},
+		{"10.0.0.0/8", "10.0.0.2", "10.255.255.254", 1<<24 - 3},
+		{"192.168.1.0/30", "192.168.1.2", "192.168.1.2", 1},
+		{"192.168.1.0/31", "192.168.1.2", "192.168.1.0", 0},
+		{"192.168.1.0/255.255.255.0", "192.168.1.2", "192.168.1.254", 253},
+	}
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			network := ipv4Network(tt.network)
+			first, last := poolRange(network)
+			assert.Equal(t, ipToUint(net.ParseIP(tt.first)), first)
+			assert.Equal(t, ipToUint(net.ParseIP(tt.last)), last)
+			assert.Equal(t, tt.size, poolSize(network))
+		})
+	}
+}
+
+func TestNextFree(t *testing.T) {
+	used := func(ips ...string) map[uint32]bool {
+		m := make(map[uint32]bool)
+		for _, ip := range ips {
+			m[ipToUint(net.ParseIP(ip))] = true
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		network string
+		used    map[uint32]bool
+		want    string
+	}{
+		{"empty pool", "192.168.1.0/24", used(), "192.168.1.2"},
+		{"skips used addresses", "192.168.1.0/24", used("192.168.1.2", "192.168.1.3", "192.168.1.5"), "192.168.1.4"},
+		{"ignores addresses of other pools", "192.168.1.0/24", used("10.0.0.2"), "192.168.1.2"},
+		{"last address", "192.168.1.0/30", used(), "192.168.1.2"},
+		{"full", "192.168.1.0/30", used("192.168.1.2"), ""},
+		{"no leasable address", "192.168.1.0/31", used(), ""},
+		{"top of the address space", "255.255.255.0/30", used(), "255.255.255.2"},
+		{"full at the top of the address space", "255.255.255.252/30", used("255.255.255.254"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := nextFree(ipv4Network(tt.network), tt.used)
+			if tt.want == "" {
+				assert.Nil(t, ip)
+				return
+			}
+			assert.Equal(t, tt.want, ip.String())
+		})
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	network := ipv4Network("192.168.1.0/24")
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"192.168.1.2", ""},
+		{"192.168.1.254", ""},
+		{"192.168.1.0", ConflictReservedAddress},
+		{"192.168.1.1", ConflictReservedAddress},
+		{"192.168.1.255", ConflictReservedAddress},
+		{"192.168.2.10", ConflictOutsidePool},
+		{"10.0.0.1", ConflictOutsidePool},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkAddress(net.ParseIP(tt.ip).To4(), network))
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// labelChunk bounds the ids of a single label query.
+const labelChunk = 1000
+
+// LabelRepository manages the custom attribute definitions and edits the tags and attributes of users
+// and groups. The labels are loaded with the users and groups by their own repositories.
+type LabelRepository struct {
+	db *gorm.DB
+}
+
+type AttributeDefinitionCRUD interface {
+	Definitions(ctx context.Context) ([]models.AttributeDefinition, error)
+	CreateDefinition(ctx context.Context, definition *models.AttributeDefinition) (*models.AttributeDefinition, error)
+	DeleteDefinition(ctx context.Context, key string) error
+}
+
+type LabelEditor interface {
+	CheckEdit(ctx context.Context, edit *LabelEdit) error
+	CheckFilter(ctx context.Context, filter *LabelFilter) error
+	EditUsers(ctx context.Context, uids []string, owner string, edit *LabelEdit) (int, error)
+	EditGroups(ctx context.Context, ids []uint, owner string, edit *LabelEdit) (int, error)
+}
+
+type LabelRepositoryInterface interface {
+	AttributeDefinitionCRUD
+	LabelEditor
+}
+
+func NewLabelRepository() *LabelRepository {
+	return &LabelRepository{
+		db: database.GetConnection(),
+	}
+}
+
+// LabelEdit changes the tags and custom attributes of users or groups. Tags replaces the tags when
+// non-nil, then AddTags and RemoveTags apply. Attributes sets the attributes it holds and removes
+// those set to nil, leaving the others as they are.
+type LabelEdit struct {
+	Tags       *[]string
+	AddTags    []string
+	RemoveTags []string
+	Attributes models.Attributes
+}
+
+// empty reports whether the edit changes nothing.
+func (e *LabelEdit) empty() bool {
+	return e == nil || (e.Tags == nil && len(e.AddTags) == 0 && len(e.RemoveTags) == 0 && len(e.Attributes) == 0)
+}
+
+// LabelFilter filters users or groups by tags and custom attributes. Every tag and attribute filter
+// must match.
+type LabelFilter struct {
+	Tags       []string
+	Attributes []AttributeFilter
+}
+
+// AttributeFilter compares a custom attribute with a value. Number attributes compare as numbers,
+// others with the text of the attribute, which orders dates. Attributes a user or group does not have
+// only match !=.
+type AttributeFilter struct {
+	Key    string
+	Op     string   // one of = != > >= < <=
+	Value  string   // the canonical text of the value, set by LabelRepository.CheckFilter
+	Number *float64 // the value of number attributes, set by LabelRepository.CheckFilter
+}
+
+var attributeFilterPattern = regexp.MustCompile(`^([a-z][a-z0-9_]*)(!=|>=|<=|=|>|<)(.*)$`)
+
+// ParseLabelFilter parses the tag and attr query params of a list: tags, repeated or comma separated,
+// and attribute comparisons such as department=sales or seats>=10. The attributes are then checked by
+// LabelRepository.CheckFilter.
+func ParseLabelFilter(tags, attributes []string) (*LabelFilter, error) {
+	var filter LabelFilter
+	for _, param := range tags {
+		for _, tag := range strings.Split(param, ",") {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+	for _, param := range attributes {
+		match := attributeFilterPattern.FindStringSubmatch(strings.TrimSpace(param))
+		if match == nil {
+			return nil, fmt.Errorf("invalid attribute filter %q", param)
+		}
+		filter.Attributes = append(filter.Attributes, AttributeFilter{Key: match[1], Op: match[2], Value: match[3]})
+	}
+	return &filter, nil
+}
+
+// labelsFilter applies the label filter to a query on the table of kind, whose id column is idColumn.
+func labelsFilter(db *gorm.DB, kind, idColumn string, filter *LabelFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	for _, tag := range filter.Tags {
+		db = db.Where("EXISTS (SELECT 1 FROM label_tags lt WHERE lt.kind = ? AND lt.target_id = "+idColumn+
+			" AND lt.name = ?)", kind, tag)
+	}
+	for _, a := range filter.Attributes {
+		column, value := "la.value", interface{}(a.Value)
+		if a.Number != nil {
+			column, value = "la.number", *a.Number
+		}
+		op := a.Op
+		if op == "!=" {
+			op = "="
+		}
+		cond, args := column+" "+op+" ?", []interface{}{value}
+
+		exists := "EXISTS (SELECT 1 FROM label_attributes la WHERE la.kind = ? AND la.target_id = " + idColumn +
+			" AND la.key = ? AND " + cond + ")"
+		if a.Op == "!=" {
+			exists = "NOT " + exists
+		}
+		db = db.Where(exists, append([]interface{}{kind, a.Key}, args...)...)
+	}
+	return db
+}
+
+// chunks splits ids into slices of at most labelChunk ids.
+func chunks(ids []uint) [][]uint {
+	var result [][]uint
+	for len(ids) > labelChunk {
+		result = append(result, ids[:labelChunk])
+		ids = ids[labelChunk:]
+	}
+	if len(ids) > 0 {
+		result = append(result, ids)
+	}
+	return result
+}
+
+// labelsOf loads the tags and typed attributes of the users or groups with the ids.
+func labelsOf(db *gorm.DB, kind string, ids []uint) (map[uint][]string, map[uint]models.Attributes, error) {
+	tags := make(map[uint][]string)
+	attributes := make(map[uint]models.Attributes)
+
+	for _, chunk := range chunks(ids) {
+		var tagRows []models.LabelTag
+		err := db.Where("kind = ? AND target_id IN ?", kind, chunk).Order("name").Find(&tagRows).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range tagRows {
+			tags[t.TargetID] = append(tags[t.TargetID], t.Name)
+		}
+
+		var attributeRows []struct {
+			models.LabelAttribute `gorm:"embedded"`
+			Type                  string
+		}
+		err = db.Table("label_attributes AS la").
+			Select("la.*, d.type AS type").
+			Joins("JOIN attribute_definitions d ON d.key = la.key").
+			Where("la.kind = ? AND la.target_id IN ?", kind, chunk).
+			Scan(&attributeRows).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, a := range attributeRows {
+			if attributes[a.TargetID] == nil {
+				attributes[a.TargetID] = models.Attributes{}
+			}
+			attributes[a.TargetID][a.Key] = a.Typed(a.Type)
+		}
+	}
+	return tags, attributes, nil
+}
+
+// userLabels fills the tags and attributes of the users.
+func userLabels(db *gorm.DB, users []models.OcservUser) error {
+	ids := make([]uint, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	tags, attributes, err := labelsOf(db, models.LabelKindUser, ids)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		users[i].Tags, users[i].Attributes = []string{}, models.Attributes{}
+		if t, ok := tags[users[i].ID]; ok {
+			users[i].Tags = t
+		}
+		if a, ok := attributes[users[i].ID]; ok {
+			users[i].Attributes = a
+		}
+	}
+	return nil
+}
+
+// groupLabels fills the tags and attributes of the groups.
+func groupLabels(db *gorm.DB, groups []models.OcservGroup) error {
+	ids := make([]uint, len(groups))
+	for i := range groups {
+		ids[i] = groups[i].ID
+	}
+	tags, attributes, err := labelsOf(db, models.LabelKindGroup, ids)
+	if err != nil {
+		return err
+	}
+	for i := range groups {
+		groups[i].Tags, groups[i].Attributes = []string{}, models.Attributes{}
+		if t, ok := tags[groups[i].ID]; ok {
+			groups[i].Tags = t
+		}
+		if a, ok := attributes[groups[i].ID]; ok {
+			groups[i].Attributes = a
+		}
+	}
+	return nil
+}
+
+// deleteLabels deletes the tags and attributes of a deleted user or group.
+func deleteLabels(tx *gorm.DB, kind string, id uint) error {
+	if err := tx.Where("kind = ? AND target_id = ?", kind, id).Delete(&models.LabelTag{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("kind = ? AND target_id = ?", kind, id).Delete(&models.LabelAttribute{}).Error
+}
+
+func (r *LabelRepository) Definitions(ctx context.Context) ([]models.AttributeDefinition, error) {
+	var definitions []models.AttributeDefinition
+	if err := r.db.WithContext(ctx).Order("key").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+func (r *LabelRepository) CreateDefinition(ctx context.Context, definition *models.AttributeDefinition) (*models.AttributeDefinition, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Create(definition).Error; err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// DeleteDefinition deletes the attribute definition with the values of the attribute.
+func (r *LabelRepository) DeleteDefinition(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("key = ?", key).Delete(&models.AttributeDefinition{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("key = ?", key).Delete(&models.LabelAttribute{}).Error
+	})
+}
+
+// CheckEdit validates the edit and normalizes its tags and attribute values in place. Attributes must
+// be defined and their values of the type of their definition.
+func (r *LabelRepository) CheckEdit(ctx context.Context, edit *LabelEdit) error {
+	var err error
+	if edit.Tags != nil {
+		tags, err := models.NormalizeTags(*edit.Tags)
+		if err != nil {
+			return err
+		}
+		edit.Tags = &tags
+	}
+	if edit.AddTags, err = models.NormalizeTags(edit.AddTags); err != nil {
+		return err
+	}
+	if edit.RemoveTags, err = models.NormalizeTags(edit.RemoveTags); err != nil {
+		return err
+	}
+	if len(edit.Attributes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(edit.Attributes))
+	for key := range edit.Attributes {
+		keys = append(keys, key)
+	}
+	var definitions []models.AttributeDefinition
+	if err = r.db.WithContext(ctx).Where("key IN ?", keys).Find(&definitions).Error; err != nil {
+		return err
+	}
+	defined := make(map[string]*models.AttributeDefinition, len(definitions))
+	for i := range definitions {
+		defined[definitions[i].Key] = &definitions[i]
+	}
+
+	for key, value := range edit.Attributes {
+		definition, ok := defined[key]
+		if !ok {
+			return fmt.Errorf("attribute %s is not defined", key)
+		}
+		if value == nil {
+			continue
+		}
+		if edit.Attributes[key], err = definition.Normalize(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckFilter checks the attributes of the filter are defined and normalizes their values in place, as
+// CheckEdit does, so they compare with the stored values. Bool attributes only compare with = and !=.
+func (r *LabelRepository) CheckFilter(ctx context.Context, filter *LabelFilter) error {
+	if filter == nil || len(filter.Attributes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(filter.Attributes))
+	for _, a := range filter.Attributes {
+		keys = append(keys, a.Key)
+	}
+	var definitions []models.AttributeDefinition
+	if err := r.db.WithContext(ctx).Where("key IN ?", keys).Find(&definitions).Error; err != nil {
+		return err
+	}
+	defined := make(map[string]*models.AttributeDefinition, len(definitions))
+	for i := range definitions {
+		defined[definitions[i].Key] = &definitions[i]
+	}
+
+	for i := range filter.Attributes {
+		a := &filter.Attributes[i]
+		definition, ok := defined[a.Key]
+		if !ok {
+			return fmt.Errorf("attribute %s is not defined", a.Key)
+		}
+		if definition.Type == models.AttributeBool && a.Op != "=" && a.Op != "!=" {
+			return fmt.Errorf("attribute %s only compares with = and !=", a.Key)
+		}
+		value, err := definition.Normalize(a.Value)
+		if err != nil {
+			return err
+		}
+		stored := models.NewLabelAttribute("", 0, a.Key, value)
+		a.Value, a.Number = stored.Value, stored.Number
+	}
+	return nil
+}
+
+// EditUsers applies the edit to the users with the uids, limited to the users shared with owner
+// unless it is empty. It returns the number of users edited.
+func (r *LabelRepository) EditUsers(ctx context.Context, uids []string, owner string, edit *LabelEdit) (int, error) {
+	if err := r.CheckEdit(ctx, edit); err != nil {
+		return 0, err
+	}
+
+	var ids []uint
+	query := r.db.WithContext(ctx).Model(&models.OcservUser{}).Where("uid IN ?", uids)
+	if owner != "" {
+		query = ownedBy(query, owner)
+	}
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return editLabels(tx, models.LabelKindUser, ids, edit)
+	})
+	return len(ids), err
+}
+
+// EditGroups applies the edit to the groups with the ids, limited to the groups of owner unless it
+// is empty. It returns the number of groups edited.
+func (r *LabelRepository) EditGroups(ctx context.Context, ids []uint, owner string, edit *LabelEdit) (int, error) {
+	if err := r.CheckEdit(ctx, edit); err != nil {
+		return 0, err
+	}
+
+	var groupIDs []uint
+	query := r.db.WithContext(ctx).Model(&models.OcservGroup{}).Where("id IN ?", ids)
+	if owner != "" {
+		query = query.Where("owner = ?", owner)
+	}
+	if err := query.Pluck("id", &groupIDs).Error; err != nil {
+		return 0, err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return editLabels(tx, models.LabelKindGroup, groupIDs, edit)
+	})
+	return len(groupIDs), err
+}
+
+// editLabels applies an edit checked by CheckEdit to the users or groups with the ids.
+func editLabels(tx *gorm.DB, kind string, ids []uint, edit *LabelEdit) error {
+	if len(ids) == 0 {
+		return errors.New("nothing to edit")
+	}
+
+	for _, chunk := range chunks(ids) {
+		if edit.Tags != nil {
+			if err := tx.Where("kind = ? AND target_id IN ?", kind, chunk).Delete(&models.LabelTag{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(edit.RemoveTags) > 0 {
+			err := tx.Where("kind = ? AND target_id IN ? AND name IN ?", kind, chunk, edit.RemoveTags).
+				Delete(&models.LabelTag{}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var add []string
+		if edit.Tags != nil {
+			add = append(add, *edit.Tags...)
+		}
+		add = append(add, edit.AddTags...)
+		var tags []models.LabelTag
+		for _, id := range chunk {
+			for _, name := range add {
+				if slices.Contains(edit.RemoveTags, name) {
+					continue
+				}
+				tags = append(tags, models.LabelTag{Kind: kind, TargetID: id, Name: name})
+			}
+		}
+		if len(tags) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(tags, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		for key, value := range edit.Attributes {
+			err := tx.Where("kind = ? AND key = ? AND target_id IN ?", kind, key, chunk).
+				Delete(&models.LabelAttribute{}).Error
+			if err != nil {
+				return err
+			}
+			if value == nil {
+				continue
+			}
+			attributes := make([]models.LabelAttribute, len(chunk))
+			for i, id := range chunk {
+				attributes[i] = models.NewLabelAttribute(kind, id, key, value)
+			}
+			if err = tx.CreateInBatches(attributes, 500).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
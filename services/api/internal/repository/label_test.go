@@ -0,0 +1,100 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseLabelFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		tags       []string
+		attributes []string
+		want       *repository.LabelFilter
+		err        string
+	}{
+		{name: "none", want: &repository.LabelFilter{}},
+		{
+			name: "tags",
+			tags: []string{"VIP, gold", "", " team:sales "},
+			want: &repository.LabelFilter{Tags: []string{"vip", "gold", "team:sales"}},
+		},
+		{
+			name:       "attributes",
+			attributes: []string{"department=sales", "seats>=10", "seats<5", "trial!=true", " joined>2026-01-01 ", "note="},
+			want: &repository.LabelFilter{Attributes: []repository.AttributeFilter{
+				{Key: "department", Op: "=", Value: "sales"},
+				{Key: "seats", Op: ">=", Value: "10"},
+				{Key: "seats", Op: "<", Value: "5"},
+				{Key: "trial", Op: "!=", Value: "true"},
+				{Key: "joined", Op: ">", Value: "2026-01-01"},
+				{Key: "note", Op: "=", Value: ""},
+			}},
+		},
+		{name: "value with operators", attributes: []string{"formula=a<=b"}, want: &repository.LabelFilter{
+			Attributes: []repository.AttributeFilter{{Key: "formula", Op: "=", Value: "a<=b"}},
+		}},
+		{name: "no operator", attributes: []string{"department"}, err: `invalid attribute filter "department"`},
+		{name: "invalid key", attributes: []string{"Department=sales"}, err: `invalid attribute filter "Department=sales"`},
+		{name: "no key", attributes: []string{"=sales"}, err: `invalid attribute filter "=sales"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := repository.ParseLabelFilter(tt.tags, tt.attributes)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, filter)
+		})
+	}
+}
+
+func TestCheckFilter(t *testing.T) {
+	db := setupDB(t, &commonModels.AttributeDefinition{})
+	require.NoError(t, db.Create([]commonModels.AttributeDefinition{
+		{Key: "department", Type: commonModels.AttributeString},
+		{Key: "seats", Type: commonModels.AttributeNumber},
+		{Key: "trial", Type: commonModels.AttributeBool},
+		{Key: "joined", Type: commonModels.AttributeDate},
+	}).Error)
+	ten := 10.0
+
+	tests := []struct {
+		name      string
+		attribute string
+		want      repository.AttributeFilter
+		err       string
+	}{
+		{name: "string", attribute: "department=Sales", want: repository.AttributeFilter{Key: "department", Op: "=", Value: "Sales"}},
+		{name: "number", attribute: "seats>=10.0", want: repository.AttributeFilter{Key: "seats", Op: ">=", Value: "10", Number: &ten}},
+		{name: "bool", attribute: "trial!=TRUE", want: repository.AttributeFilter{Key: "trial", Op: "!=", Value: "true"}},
+		{name: "date", attribute: "joined<2026-01-01", want: repository.AttributeFilter{Key: "joined", Op: "<", Value: "2026-01-01"}},
+		{name: "undefined", attribute: "region=eu", err: "attribute region is not defined"},
+		{name: "invalid number", attribute: "seats>many", err: "attribute seats must be a number"},
+		{name: "invalid bool", attribute: "trial=yes", err: "attribute trial must be a bool"},
+		{name: "ordered bool", attribute: "trial>false", err: "attribute trial only compares with = and !="},
+		{name: "invalid date", attribute: "joined>2026-13-01", err: "attribute joined must be a date formatted as YYYY-MM-DD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := repository.ParseLabelFilter(nil, []string{tt.attribute})
+			require.NoError(t, err)
+
+			err = repository.NewLabelRepository().CheckFilter(context.Background(), filter)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []repository.AttributeFilter{tt.want}, filter.Attributes)
+		})
+	}
+
+	assert.NoError(t, repository.NewLabelRepository().CheckFilter(context.Background(), nil))
+}
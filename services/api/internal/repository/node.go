@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"gorm.io/gorm"
+	"slices"
+	"sync"
+)
+
+type NodeStatus struct {
+	Node     models.Node                 `json:"node" validate:"required"`
+	Online   bool                        `json:"online" validate:"required"`
+	Error    string                      `json:"error,omitempty" validate:"omitempty"`
+	Status   map[string]interface{}      `json:"status" validate:"omitempty"`
+	Sessions *[]models.OnlineUserSession `json:"sessions" validate:"omitempty"`
+}
+
+type NodeRepository struct {
+	db *gorm.DB
+}
+
+type NodeCRUD interface {
+	Nodes(ctx context.Context, pagination *request.Pagination) ([]models.Node, int64, error)
+	NodesLookup(ctx context.Context) ([]models.Node, error)
+	GetByID(ctx context.Context, id string) (*models.Node, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]models.Node, error)
+	Create(ctx context.Context, n *models.Node) (*models.Node, error)
+	Update(ctx context.Context, n *models.Node) (*models.Node, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type NodeMonitor interface {
+	NodesStatus(ctx context.Context) ([]NodeStatus, error)
+	NodeStatistics(ctx context.Context, id string, series *TrafficSeries) ([]models.DailyTraffic, error)
+}
+
+type NodeRepositoryInterface interface {
+	NodeCRUD
+	NodeMonitor
+}
+
+func NewNodeRepository() *NodeRepository {
+	return &NodeRepository{
+		db: database.GetConnection(),
+	}
+}
+
+func (r *NodeRepository) Nodes(ctx context.Context, pagination *request.Pagination) ([]models.Node, int64, error) {
+	var totalRecords int64
+
+	if err := r.db.WithContext(ctx).Model(&models.Node{}).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var nodes []models.Node
+	txPaginator := request.Paginator(ctx, r.db, pagination)
+	if err := txPaginator.Model(&nodes).Find(&nodes).Error; err != nil {
+		return nil, 0, err
+	}
+	return nodes, totalRecords, nil
+}
+
+func (r *NodeRepository) NodesLookup(ctx context.Context) ([]models.Node, error) {
+	var nodes []models.Node
+	err := r.db.WithContext(ctx).Select("id", "name", "is_active").Order("name").Find(&nodes).Error
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (r *NodeRepository) GetByID(ctx context.Context, id string) (*models.Node, error) {
+	var n models.Node
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&n).Error
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *NodeRepository) GetByIDs(ctx context.Context, ids []uint) ([]models.Node, error) {
+	nodes := make([]models.Node, 0, len(ids))
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+
+	unique := slices.Compact(slices.Sorted(slices.Values(ids)))
+	err := r.db.WithContext(ctx).Where("id IN ?", unique).Find(&nodes).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) != len(unique) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return nodes, nil
+}
+
+func (r *NodeRepository) Create(ctx context.Context, n *models.Node) (*models.Node, error) {
+	err := r.db.WithContext(ctx).Create(n).Error
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (r *NodeRepository) Update(ctx context.Context, n *models.Node) (*models.Node, error) {
+	err := r.db.WithContext(ctx).Save(n).Error
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (r *NodeRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var n models.Node
+		if err := tx.Where("id = ?", id).First(&n).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM ocserv_user_nodes WHERE node_id = ?", n.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM ocserv_group_nodes WHERE node_id = ?", n.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&n).Error
+	})
+}
+
+// NodesStatus asks the agent of every active node for its status and online sessions concurrently.
+// Unreachable nodes are reported as offline with the error instead of failing the whole call.
+func (r *NodeRepository) NodesStatus(ctx context.Context) ([]NodeStatus, error) {
+	var nodes []models.Node
+	if err := r.db.WithContext(ctx).Where("is_active = ?", true).Order("name").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]NodeStatus, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n models.Node) {
+			defer wg.Done()
+
+			agent := node.NewAgent(n)
+			ns := NodeStatus{Node: n}
+
+			status, err := agent.ShowStatus()
+			if err != nil {
+				ns.Error = err.Error()
+				result[i] = ns
+				return
+			}
+			ns.Online = true
+			ns.Status = status
+
+			sessions, err := agent.OnlineSessions()
+			if err != nil {
+				ns.Error = err.Error()
+			}
+			ns.Sessions = sessions
+			result[i] = ns
+		}(i, n)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+func (r *NodeRepository) NodeStatistics(ctx context.Context, id string, series *TrafficSeries) ([]models.DailyTraffic, error) {
+	return trafficSeries(r.db.WithContext(ctx), series, func(db *gorm.DB) *gorm.DB {
+		return db.Where("t.node_id = ?", id)
+	})
+}
@@ -0,0 +1,34 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestNodeGetByIDs(t *testing.T) {
+	db := setupDB(t, &models.Node{})
+	for _, name := range []string{"a", "b", "c"} {
+		assert.NoError(t, db.Create(&models.Node{Name: name, AgentURL: "https://" + name, IsActive: true}).Error)
+	}
+	repo := repository.NewNodeRepository()
+	ctx := context.Background()
+
+	nodes, err := repo.GetByIDs(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+
+	nodes, err = repo.GetByIDs(ctx, []uint{3, 1})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+
+	nodes, err = repo.GetByIDs(ctx, []uint{2, 2, 1, 2})
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+
+	_, err = repo.GetByIDs(ctx, []uint{1, 4})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
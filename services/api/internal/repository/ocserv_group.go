@@ -2,27 +2,48 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
 	"github.com/mmtaee/ocserv-users-management/common/models"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/group"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/occtl"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
 	"gorm.io/gorm"
+	"time"
 )
 
+// GroupStatistics is the membership and traffic summary of a group. Traffic is in GiB.
+type GroupStatistics struct {
+	Group   string  `json:"group" validate:"required"`
+	Members int64   `json:"members" validate:"required"`
+	Online  int64   `json:"online" validate:"required"`
+	RX      float64 `json:"rx" validate:"required"`
+	TX      float64 `json:"tx" validate:"required"`
+}
+
 type OcservGroupRepository struct {
 	db                    *gorm.DB
 	commonOcservGroupRepo group.OcservGroupInterface
+	commonOcservUserRepo  user.OcservUserInterface
 	commonOcservOcctlRepo occtl.OcservOcctlInterface
 }
 
 type OcservGroupCRUD interface {
-	Groups(ctx context.Context, pagination *request.Pagination, owner string) ([]models.OcservGroup, int64, error)
+	Groups(ctx context.Context, pagination *request.Pagination, owner string, labels *LabelFilter) ([]models.OcservGroup, int64, error)
 	GroupsLookup(ctx context.Context, owner string) ([]string, error)
 	GetByID(ctx context.Context, id string) (*models.OcservGroup, error)
+	GetByName(ctx context.Context, name string) (*models.OcservGroup, error)
 	Create(ctx context.Context, ocservGroup *models.OcservGroup) (*models.OcservGroup, error)
 	Update(ctx context.Context, ocservGroup *models.OcservGroup) (*models.OcservGroup, error)
-	Delete(ctx context.Context, id string) (*models.OcservGroup, error)
+	Delete(ctx context.Context, id string, reassignTo string, check MoveCheck) (*models.OcservGroup, error)
+}
+
+type OcservGroupMembers interface {
+	Members(ctx context.Context, groupName string, pagination *request.Pagination, owner string) ([]models.OcservUser, int64, error)
+	Statistics(ctx context.Context, dateStart, dateEnd *time.Time, online []string) ([]GroupStatistics, error)
 }
 
 type OcservDefaultGroup interface {
@@ -37,6 +58,7 @@ type OcservGroupSync interface {
 
 type OcservGroupRepositoryInterface interface {
 	OcservGroupCRUD
+	OcservGroupMembers
 	OcservDefaultGroup
 	OcservGroupSync
 }
@@ -45,33 +67,34 @@ func NewOcservGroupRepository() *OcservGroupRepository {
 	return &OcservGroupRepository{
 		db:                    database.GetConnection(),
 		commonOcservGroupRepo: group.NewOcservGroup(),
+		commonOcservUserRepo:  user.NewOcservUser(),
 		commonOcservOcctlRepo: occtl.NewOcservOcctl(),
 	}
 }
 
-func (o *OcservGroupRepository) Groups(ctx context.Context, pagination *request.Pagination, owner string) (
+// ocservGroupsKeyset lists the orders of the group list supporting cursor pagination.
+var ocservGroupsKeyset = request.OrderBy{
+	"id":   "id",
+	"name": "name",
+}
+
+func (o *OcservGroupRepository) Groups(ctx context.Context, pagination *request.Pagination, owner string, labels *LabelFilter) (
 	[]models.OcservGroup, int64, error,
 ) {
-	var totalRecords int64
+	var ocservGroups []models.OcservGroup
 
-	totalQuery := o.db.WithContext(ctx).Model(&models.OcservGroup{})
-	if owner != "" {
-		totalQuery = totalQuery.Where("owner = ?", owner)
-	}
-	err := totalQuery.Count(&totalRecords).Error
+	totalRecords, err := request.Paginate(ctx, o.db, pagination, nil, ocservGroupsKeyset, &ocservGroups,
+		func(db *gorm.DB) *gorm.DB {
+			if owner != "" {
+				db = db.Where("owner = ?", owner)
+			}
+			return labelsFilter(db, models.LabelKindGroup, "ocserv_groups.id", labels)
+		},
+	)
 	if err != nil {
 		return nil, 0, err
 	}
-
-	var ocservGroups []models.OcservGroup
-	txPaginator := request.Paginator(ctx, o.db, pagination)
-
-	query := txPaginator.Model(&ocservGroups)
-	if owner != "" {
-		query = query.Where("owner = ?", owner)
-	}
-	err = query.Find(&ocservGroups).Error
-	if err != nil {
+	if err = groupLabels(o.db.WithContext(ctx), ocservGroups); err != nil {
 		return nil, 0, err
 	}
 	return ocservGroups, totalRecords, nil
@@ -99,7 +122,20 @@ func (o *OcservGroupRepository) GroupsLookup(ctx context.Context, owner string)
 
 func (o *OcservGroupRepository) GetByID(ctx context.Context, id string) (*models.OcservGroup, error) {
 	var ocservGroup models.OcservGroup
-	err := o.db.WithContext(ctx).Where("id = ?", id).First(&ocservGroup).Error
+	err := o.db.WithContext(ctx).Preload("Nodes").Where("id = ?", id).First(&ocservGroup).Error
+	if err != nil {
+		return nil, err
+	}
+	groups := []models.OcservGroup{ocservGroup}
+	if err = groupLabels(o.db.WithContext(ctx), groups); err != nil {
+		return nil, err
+	}
+	return &groups[0], nil
+}
+
+func (o *OcservGroupRepository) GetByName(ctx context.Context, name string) (*models.OcservGroup, error) {
+	var ocservGroup models.OcservGroup
+	err := o.db.WithContext(ctx).Where("name = ?", name).First(&ocservGroup).Error
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +150,15 @@ func (o *OcservGroupRepository) Create(ctx context.Context, ocservGroup *models.
 		if err := o.commonOcservGroupRepo.Create(ocservGroup.Name, ocservGroup.Config); err != nil {
 			return err
 		}
-		return nil
+		err := node.FanOut(ocservGroup.Nodes, func(agent node.AgentInterface) error {
+			return agent.CreateGroup(ocservGroup.Name, ocservGroup.Config)
+		})
+		if err != nil {
+			// the transaction rolls back, the local ocserv must not keep the group either; a file left
+			// behind shows as an orphan group config in the drift report
+			_ = o.commonOcservGroupRepo.Delete(ocservGroup.Name)
+		}
+		return err
 	})
 
 	if err != nil {
@@ -128,15 +172,47 @@ func (o *OcservGroupRepository) Create(ctx context.Context, ocservGroup *models.
 	return ocservGroup, nil
 }
 
+// Update saves the group and rewrites its config on the local ocserv and on every assigned node.
+// A non-nil Nodes slice replaces the node assignment; nodes dropped from it have the group removed.
 func (o *OcservGroupRepository) Update(ctx context.Context, ocservGroup *models.OcservGroup) (*models.OcservGroup, error) {
 	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(ocservGroup).Save(ocservGroup).Error; err != nil {
+		var previous []models.Node
+		if err := tx.Model(ocservGroup).Association("Nodes").Find(&previous); err != nil {
 			return err
 		}
+		var saved models.OcservGroup
+		if err := tx.First(&saved, ocservGroup.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(ocservGroup).Omit("Nodes").Save(ocservGroup).Error; err != nil {
+			return err
+		}
+
+		nodes := previous
+		if ocservGroup.Nodes != nil {
+			if err := tx.Model(ocservGroup).Association("Nodes").Replace(ocservGroup.Nodes); err != nil {
+				return err
+			}
+			nodes = ocservGroup.Nodes
+		}
+
 		if err := o.commonOcservGroupRepo.Create(ocservGroup.Name, ocservGroup.Config); err != nil {
 			return err
 		}
-		return nil
+		err := node.FanOut(nodes, func(agent node.AgentInterface) error {
+			return agent.CreateGroup(ocservGroup.Name, ocservGroup.Config)
+		})
+		if err == nil {
+			err = node.FanOut(removedNodes(previous, nodes), func(agent node.AgentInterface) error {
+				return agent.DeleteGroup(ocservGroup.Name)
+			})
+		}
+		if err != nil {
+			// the transaction rolls back, the local ocserv goes back to the saved config; a failed
+			// rewrite shows as a group config mismatch in the drift report
+			_ = o.commonOcservGroupRepo.Create(saved.Name, saved.Config)
+		}
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -149,29 +225,183 @@ func (o *OcservGroupRepository) Update(ctx context.Context, ocservGroup *models.
 	return ocservGroup, nil
 }
 
-func (o *OcservGroupRepository) Delete(ctx context.Context, id string) (*models.OcservGroup, error) {
+// Delete moves the members of the group to reassignTo, then removes the group. The steps are not
+// one transaction, each leaves a consistent state when the next fails: the members are moved in the
+// database, then in ocpasswd and on the nodes as MoveUsers does, the group is removed from the
+// database and last its config file is removed from the local ocserv and the nodes. A file left
+// behind shows as an orphan group config in the drift report. The members are given to check, from
+// IPAMRepository.MoveUsers, before they are moved.
+func (o *OcservGroupRepository) Delete(ctx context.Context, id string, reassignTo string, check MoveCheck) (
+	*models.OcservGroup, error,
+) {
 	var ocservGroup models.OcservGroup
+	var members []models.OcservUser
 	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("id = ?", id).First(&ocservGroup).Error; err != nil {
+		if err := tx.Preload("Nodes").Where("id = ?", id).First(&ocservGroup).Error; err != nil {
 			return err
 		}
-
-		if err := tx.Delete(&ocservGroup).Error; err != nil {
+		if reassignTo == ocservGroup.Name {
+			return errors.New("members cannot be reassigned to the deleted group")
+		}
+		if err := groupExists(tx, reassignTo); err != nil {
 			return err
 		}
 
-		if err := o.commonOcservGroupRepo.Delete(ocservGroup.Name); err != nil {
+		if err := tx.Where("`group` = ?", ocservGroup.Name).Find(&members).Error; err != nil {
 			return err
 		}
-
+		if err := check(members); err != nil {
+			return err
+		}
+		return moveUsers(tx, members, reassignTo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = applyMove(o.db.WithContext(ctx), o.commonOcservUserRepo, members, reassignTo); err != nil {
+		return nil, fmt.Errorf("move members to %s: %w", reassignTo, err)
+	}
+	// the moved members take their new group on reload, whether or not the group is then removed
+	defer func() {
 		go func() {
 			_, _ = o.commonOcservOcctlRepo.ReloadConfigs()
 		}()
+	}()
 
-		return nil
+	err = o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err2 := tx.Model(&ocservGroup).Association("Nodes").Clear(); err2 != nil {
+			return err2
+		}
+		if err2 := tx.Delete(&ocservGroup).Error; err2 != nil {
+			return err2
+		}
+		return deleteLabels(tx, models.LabelKindGroup, ocservGroup.ID)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = o.commonOcservGroupRepo.Delete(ocservGroup.Name); err != nil {
+		return nil, err
+	}
+	err = node.FanOut(ocservGroup.Nodes, func(agent node.AgentInterface) error {
+		return agent.DeleteGroup(ocservGroup.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ocservGroup, nil
+}
+
+// Members returns the users of the group, limited to the users shared with owner when set.
+func (o *OcservGroupRepository) Members(
+	ctx context.Context, groupName string, pagination *request.Pagination, owner string,
+) ([]models.OcservUser, int64, error) {
+	var totalRecords int64
+
+	applyFilters := func(db *gorm.DB) *gorm.DB {
+		return usersFilter(db, &OcservUsersFilter{Owner: owner, Group: groupName})
+	}
+
+	if err := applyFilters(o.db.WithContext(ctx).Model(&models.OcservUser{})).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var members []models.OcservUser
+	txPaginator := request.Paginator(ctx, o.db, pagination)
+	if err := applyFilters(txPaginator.Model(&members)).Find(&members).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := userLabels(o.db.WithContext(ctx), members); err != nil {
+		return nil, 0, err
+	}
+	return members, totalRecords, nil
+}
+
+// Statistics returns the member count, the number of online members and the traffic in the date
+// range of every group, defaults first. online holds the usernames connected to ocserv.
+func (o *OcservGroupRepository) Statistics(
+	ctx context.Context, dateStart, dateEnd *time.Time, online []string,
+) ([]GroupStatistics, error) {
+	var names []string
+	if err := o.db.WithContext(ctx).Model(&models.OcservGroup{}).Order("name").Pluck("name", &names).Error; err != nil {
+		return nil, err
+	}
 
-	return &ocservGroup, err
+	stats := make([]GroupStatistics, 0, len(names)+1)
+	index := make(map[string]int, len(names)+1)
+	for _, name := range append([]string{"defaults"}, names...) {
+		index[name] = len(stats)
+		stats = append(stats, GroupStatistics{Group: name})
+	}
+
+	// users may still reference a group missing from the database, e.g. one created by hand
+	row := func(name string) *GroupStatistics {
+		i, ok := index[name]
+		if !ok {
+			i = len(stats)
+			index[name] = i
+			stats = append(stats, GroupStatistics{Group: name})
+		}
+		return &stats[i]
+	}
+
+	type groupCount struct {
+		Group string
+		Count int64
+	}
+
+	var members []groupCount
+	err := o.db.WithContext(ctx).
+		Model(&models.OcservUser{}).
+		Select("`group` AS `group`, COUNT(*) AS count").
+		Group("`group`").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		row(m.Group).Members = m.Count
+	}
+
+	if len(online) > 0 {
+		var onlineMembers []groupCount
+		err = o.db.WithContext(ctx).
+			Model(&models.OcservUser{}).
+			Select("`group` AS `group`, COUNT(*) AS count").
+			Where("username IN ?", online).
+			Group("`group`").
+			Scan(&onlineMembers).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range onlineMembers {
+			row(m.Group).Online = m.Count
+		}
+	}
+
+	var traffic []struct {
+		Group string
+		RX    float64
+		TX    float64
+	}
+	query := trafficRollup(o.db.WithContext(ctx), dateStart, dateEnd).
+		Joins("JOIN ocserv_users ou ON ou.id = t.oc_user_id").
+		Select(
+			"ou.`group` AS `group`, " +
+				"SUM(t.rx) / 1073741824.0 AS rx, " +
+				"SUM(t.tx) / 1073741824.0 AS tx",
+		)
+	if err = query.Group("ou.`group`").Scan(&traffic).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range traffic {
+		r := row(t.Group)
+		r.RX = t.RX
+		r.TX = t.TX
+	}
+
+	return stats, nil
 }
 
 func (o *OcservGroupRepository) DefaultGroup() (*models.OcservGroupConfig, error) {
@@ -188,6 +418,18 @@ func (o *OcservGroupRepository) UpdateDefaultGroup(groupConfig *models.OcservGro
 		return err
 	}
 
+	// the defaults group applies to every user, so it is pushed to all nodes
+	var nodes []models.Node
+	if err = o.db.Where("is_active = ?", true).Find(&nodes).Error; err != nil {
+		return err
+	}
+	err = node.FanOut(nodes, func(agent node.AgentInterface) error {
+		return agent.UpdateDefaultsGroup(groupConfig)
+	})
+	if err != nil {
+		return err
+	}
+
 	go func() {
 		_, _ = o.commonOcservOcctlRepo.ReloadConfigs()
 	}()
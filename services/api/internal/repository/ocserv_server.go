@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/occtl"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/server"
+)
+
+type OcservServerRepository struct {
+	commonOcservServerRepo server.OcservServerInterface
+	commonOcservOcctlRepo  occtl.OcservOcctlInterface
+}
+
+type OcservServerRepositoryInterface interface {
+	Config() (*models.OcservServerConfig, error)
+	Preview(config *models.OcservServerConfig) (string, error)
+	Update(config *models.OcservServerConfig) (string, error)
+}
+
+func NewOcservServerRepository() *OcservServerRepository {
+	return &OcservServerRepository{
+		commonOcservServerRepo: server.NewOcservServer(server.ExecRunner{}),
+		commonOcservOcctlRepo:  occtl.NewOcservOcctl(),
+	}
+}
+
+func (o *OcservServerRepository) Config() (*models.OcservServerConfig, error) {
+	return o.commonOcservServerRepo.Config()
+}
+
+func (o *OcservServerRepository) Preview(config *models.OcservServerConfig) (string, error) {
+	return o.commonOcservServerRepo.Preview(config)
+}
+
+// Update writes ocserv.conf once ocserv accepted it and reloads ocserv when anything changed.
+func (o *OcservServerRepository) Update(config *models.OcservServerConfig) (string, error) {
+	diff, err := o.commonOcservServerRepo.Update(config)
+	if err != nil {
+		return "", err
+	}
+
+	if diff != "" {
+		go func() {
+			_, _ = o.commonOcservOcctlRepo.ReloadConfigs()
+		}()
+	}
+	return diff, nil
+}
@@ -2,13 +2,16 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
 	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/occtl"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/configversion"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
 	"gorm.io/gorm"
-	"strings"
 	"time"
 )
 
@@ -34,24 +37,30 @@ type OcservUserRepository struct {
 }
 
 type OcservUserCRUD interface {
-	Users(ctx context.Context, pagination *request.Pagination, owner string, q string) ([]models.OcservUser, int64, error)
-	Create(ctx context.Context, user *models.OcservUser) (*models.OcservUser, error)
+	Users(ctx context.Context, pagination *request.Pagination, filter *OcservUsersFilter) ([]models.OcservUser, int64, error)
+	UsersExport(ctx context.Context, filter *OcservUsersFilter) ([]models.OcservUser, error)
+	Create(ctx context.Context, user *models.OcservUser, labels *LabelEdit) (*models.OcservUser, error)
 	GetByUID(ctx context.Context, uid string) (*models.OcservUser, error)
 	GetByUsername(ctx context.Context, username string) (*models.OcservUser, error)
-	Update(ctx context.Context, ocservUser *models.OcservUser) (*models.OcservUser, error)
+	Update(ctx context.Context, ocservUser *models.OcservUser, labels *LabelEdit) (*models.OcservUser, error)
+	UpdateConfig(ctx context.Context, ocservUser *models.OcservUser) (*models.OcservUser, error)
 	Delete(ctx context.Context, uid string) (string, error)
+	EffectiveConfig(ctx context.Context, ocservUser *models.OcservUser) (*user.EffectiveConfig, error)
 }
 
 type OcservUserStats interface {
 	TenDaysStats(ctx context.Context) ([]models.DailyTraffic, error)
-	UserStatistics(ctx context.Context, uid string, dateStart, dateEnd *time.Time) ([]models.DailyTraffic, error)
-	Statistics(ctx context.Context, dateStart, dateEnd *time.Time) ([]models.DailyTraffic, error)
+	UserStatistics(ctx context.Context, uid string, series *TrafficSeries) ([]models.DailyTraffic, error)
+	Statistics(ctx context.Context, series *TrafficSeries) ([]models.DailyTraffic, error)
 	TotalUsers(ctx context.Context) (int64, error)
 	TopBandwidthUser(ctx context.Context) (TopBandwidthUsers, error)
 	TotalBandwidthUser(ctx context.Context, uid string) (TotalBandwidths, error)
 	TotalBandwidth(ctx context.Context) (TotalBandwidths, error)
 	TotalBandwidthDateRange(ctx context.Context, dateStart, dateEnd *time.Time) (TotalBandwidths, error)
 	TotalBandwidthUserDateRange(ctx context.Context, id string, dateStart, dateEnd *time.Time) (TotalBandwidths, error)
+	NodeBandwidthsUser(ctx context.Context, uid string) ([]models.NodeBandwidths, error)
+	TrafficUsage(ctx context.Context, ocservUser *models.OcservUser) (*models.TrafficUsage, error)
+	ScheduleState(ctx context.Context, ocservUser *models.OcservUser) (*models.ScheduleState, error)
 }
 
 type OcservUserPassword interface {
@@ -60,13 +69,19 @@ type OcservUserPassword interface {
 }
 
 type OcservUserGroup interface {
-	UpdateUsersByDeleteGroup(ctx context.Context, groupName string) ([]models.OcservUser, error)
+	MoveUsers(ctx context.Context, uids []string, groupName string, check MoveCheck) ([]models.OcservUser, error)
 }
 
 type OcservUserActions interface {
 	Lock(ctx context.Context, uid string) error
 	UnLock(ctx context.Context, uid string) error
 	RestoreExpired(ctx context.Context, uid string, expireAt time.Time) error
+	Renew(ctx context.Context, ocservUser *models.OcservUser, plan *models.Plan) (*models.OcservUser, error)
+}
+
+type OcservUserTopUps interface {
+	TopUp(ctx context.Context, ocservUser *models.OcservUser, topUp *models.TrafficTopUp) (*models.TrafficUsage, error)
+	TopUps(ctx context.Context, ocservUser *models.OcservUser, pagination *request.Pagination) ([]models.TrafficTopUp, int64, error)
 }
 
 type OcservUserRepositoryInterface interface {
@@ -75,6 +90,8 @@ type OcservUserRepositoryInterface interface {
 	OcservUserPassword
 	OcservUserGroup
 	OcservUserActions
+	OcservUserTopUps
+	OcservUserOwnership
 }
 
 func NewtOcservUserRepository() *OcservUserRepository {
@@ -85,46 +102,76 @@ func NewtOcservUserRepository() *OcservUserRepository {
 	}
 }
 
-func (o *OcservUserRepository) Users(ctx context.Context, pagination *request.Pagination, owner string, q string) (
+func (o *OcservUserRepository) Users(ctx context.Context, pagination *request.Pagination, filter *OcservUsersFilter) (
 	[]models.OcservUser, int64, error,
 ) {
-	var totalRecords int64
-
-	applyFilters := func(db *gorm.DB) *gorm.DB {
-		if owner != "" {
-			db = db.Where("owner = ?", owner)
-		}
-		if len(q) >= 2 {
-			db = db.Where("LOWER(username) LIKE ?", "%"+strings.ToLower(q)+"%")
-		}
-		return db
-	}
+	var ocservUser []models.OcservUser
 
-	totalQuery := applyFilters(o.db.WithContext(ctx).Model(&models.OcservUser{}))
-	if err := totalQuery.Count(&totalRecords).Error; err != nil {
+	totalRecords, err := request.Paginate(ctx, o.db, pagination, OcservUsersOrderBy, OcservUsersKeyset, &ocservUser,
+		func(db *gorm.DB) *gorm.DB {
+			return usersFilter(db, filter)
+		},
+	)
+	if err != nil {
 		return nil, 0, err
 	}
-
-	var ocservUser []models.OcservUser
-	txPaginator := request.Paginator(ctx, o.db, pagination)
-
-	query := applyFilters(txPaginator.Model(&ocservUser))
-	if err := query.Find(&ocservUser).Error; err != nil {
+	if err = userLabels(o.db.WithContext(ctx), ocservUser); err != nil {
 		return nil, 0, err
 	}
 
 	return ocservUser, totalRecords, nil
 }
 
-func (o *OcservUserRepository) Create(ctx context.Context, ocservUser *models.OcservUser) (*models.OcservUser, error) {
+// UsersExport returns every user matching the filters of Users, ordered by username.
+func (o *OcservUserRepository) UsersExport(ctx context.Context, filter *OcservUsersFilter) ([]models.OcservUser, error) {
+	var ocservUsers []models.OcservUser
+	err := usersFilter(o.db.WithContext(ctx).Model(&models.OcservUser{}), filter).
+		Order("username").
+		Find(&ocservUsers).Error
+	if err != nil {
+		return nil, err
+	}
+	if err = userLabels(o.db.WithContext(ctx), ocservUsers); err != nil {
+		return nil, err
+	}
+	return ocservUsers, nil
+}
+
+// Create saves the user, within the reseller quotas of its owner, with the labels, an edit checked by
+// LabelRepository.CheckEdit or nil, and writes it on the local ocserv and on its nodes.
+func (o *OcservUserRepository) Create(ctx context.Context, ocservUser *models.OcservUser, labels *LabelEdit) (
+	*models.OcservUser, error,
+) {
 	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := CheckResellerQuota(tx, ocservUser.Owner, ocservUser); err != nil {
+			return err
+		}
+		// the group may come from a plan, whose group may have been deleted since
+		if err := groupExists(tx, ocservUser.Group); err != nil {
+			return err
+		}
 		if err := tx.Create(ocservUser).Error; err != nil {
 			return err
 		}
-		if err := o.commonOcservUserRepo.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.Config); err != nil {
+		if err := SyncPrimaryOwners(tx, ocservUser.ID); err != nil {
 			return err
 		}
-		return nil
+		if !labels.empty() {
+			if err := editLabels(tx, models.LabelKindUser, []uint{ocservUser.ID}, labels); err != nil {
+				return err
+			}
+		}
+		if err := o.commonOcservUserRepo.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.AppliedConfig()); err != nil {
+			return err
+		}
+		err := node.FanOut(ocservUser.Nodes, func(agent node.AgentInterface) error {
+			return agent.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.AppliedConfig())
+		})
+		if err != nil {
+			// the transaction rolls back, the local ocserv must not keep the user either
+			o.removeLocal(ocservUser)
+		}
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -141,11 +188,15 @@ func (o *OcservUserRepository) Create(ctx context.Context, ocservUser *models.Oc
 
 func (o *OcservUserRepository) GetByUID(ctx context.Context, uid string) (*models.OcservUser, error) {
 	var ocservUser models.OcservUser
-	err := o.db.WithContext(ctx).Where("uid = ?", uid).First(&ocservUser).Error
+	err := o.db.WithContext(ctx).Preload("Nodes").Where("uid = ?", uid).First(&ocservUser).Error
 	if err != nil {
 		return nil, err
 	}
-	return &ocservUser, nil
+	users := []models.OcservUser{ocservUser}
+	if err = userLabels(o.db.WithContext(ctx), users); err != nil {
+		return nil, err
+	}
+	return &users[0], nil
 }
 
 func (o *OcservUserRepository) GetByUsername(ctx context.Context, username string) (*models.OcservUser, error) {
@@ -157,15 +208,58 @@ func (o *OcservUserRepository) GetByUsername(ctx context.Context, username strin
 	return &ocservUser, nil
 }
 
-func (o *OcservUserRepository) Update(ctx context.Context, ocservUser *models.OcservUser) (*models.OcservUser, error) {
+// Update saves the user, within the reseller quotas of its owner, and rewrites it on the local ocserv and
+// on every assigned node. A non-nil Nodes slice replaces the node assignment; nodes dropped from it have
+// the user removed. The labels, an edit checked by LabelRepository.CheckEdit or nil, are saved with the user.
+func (o *OcservUserRepository) Update(ctx context.Context, ocservUser *models.OcservUser, labels *LabelEdit) (
+	*models.OcservUser, error,
+) {
 	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(&ocservUser).Error; err != nil {
+		if err := CheckResellerQuota(tx, ocservUser.Owner, ocservUser); err != nil {
 			return err
 		}
-		if err := o.commonOcservUserRepo.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.Config); err != nil {
+		previous, err := node.UserNodes(tx, ocservUser.ID)
+		if err != nil {
 			return err
 		}
-		return nil
+		var saved models.OcservUser
+		if err = tx.First(&saved, ocservUser.ID).Error; err != nil {
+			return err
+		}
+		if err = tx.Omit("Nodes").Save(&ocservUser).Error; err != nil {
+			return err
+		}
+
+		nodes := previous
+		if ocservUser.Nodes != nil {
+			if err = tx.Model(ocservUser).Association("Nodes").Replace(ocservUser.Nodes); err != nil {
+				return err
+			}
+			nodes = ocservUser.Nodes
+		}
+		if !labels.empty() {
+			if err = editLabels(tx, models.LabelKindUser, []uint{ocservUser.ID}, labels); err != nil {
+				return err
+			}
+		}
+
+		if err = o.commonOcservUserRepo.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.AppliedConfig()); err != nil {
+			return err
+		}
+		err = node.FanOut(nodes, func(agent node.AgentInterface) error {
+			return agent.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.AppliedConfig())
+		})
+		if err == nil {
+			err = node.FanOut(removedNodes(previous, nodes), func(agent node.AgentInterface) error {
+				_, err2 := agent.Delete(ocservUser.Username)
+				return err2
+			})
+		}
+		if err != nil {
+			// the transaction rolls back, the local ocserv goes back to the saved user
+			o.restoreLocal(&saved, ocservUser)
+		}
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -180,6 +274,30 @@ func (o *OcservUserRepository) Update(ctx context.Context, ocservUser *models.Oc
 	return ocservUser, nil
 }
 
+// UpdateConfig saves only the config of the user and rewrites its config file on the local ocserv
+// and on every assigned node, leaving the ocpasswd entries untouched.
+func (o *OcservUserRepository) UpdateConfig(ctx context.Context, ocservUser *models.OcservUser) (*models.OcservUser, error) {
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(ocservUser).Select("config").Updates(ocservUser).Error; err != nil {
+			return err
+		}
+		if err := o.commonOcservUserRepo.CreateConfig(ocservUser.Username, ocservUser.AppliedConfig()); err != nil {
+			return err
+		}
+		return o.fanOutUser(tx, ocservUser.ID, func(agent node.AgentInterface) error {
+			return agent.UpdateConfig(ocservUser.Username, ocservUser.AppliedConfig())
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		_, _ = o.commonOcservOcctlRepo.ReloadConfigs()
+	}()
+	return ocservUser, nil
+}
+
 func (o *OcservUserRepository) Lock(ctx context.Context, uid string) error {
 	var ocservUser models.OcservUser
 	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -196,7 +314,10 @@ func (o *OcservUserRepository) Lock(ctx context.Context, uid string) error {
 		if _, err := o.commonOcservUserRepo.Lock(ocservUser.Username); err != nil {
 			return err
 		}
-		return nil
+		return o.fanOutUser(tx, ocservUser.ID, func(agent node.AgentInterface) error {
+			_, err := agent.Lock(ocservUser.Username)
+			return err
+		})
 	})
 	return err
 }
@@ -217,7 +338,10 @@ func (o *OcservUserRepository) UnLock(ctx context.Context, uid string) error {
 		if _, err := o.commonOcservUserRepo.UnLock(ocservUser.Username); err != nil {
 			return err
 		}
-		return nil
+		return o.fanOutUser(tx, ocservUser.ID, func(agent node.AgentInterface) error {
+			_, err := agent.UnLock(ocservUser.Username)
+			return err
+		})
 	})
 	return err
 }
@@ -228,13 +352,20 @@ func (o *OcservUserRepository) Delete(ctx context.Context, uid string) (string,
 		if err := tx.Where("uid = ?", uid).First(&ocservUser).Error; err != nil {
 			return err
 		}
-		if err := tx.Delete(&ocservUser).Error; err != nil {
+		nodes, err := node.UserNodes(tx, ocservUser.ID)
+		if err != nil {
 			return err
 		}
-		if _, err := o.commonOcservUserRepo.Delete(ocservUser.Username); err != nil {
+		if err = models.DeleteOcservUser(tx, &ocservUser); err != nil {
 			return err
 		}
-		return nil
+		if _, err = o.commonOcservUserRepo.Delete(ocservUser.Username); err != nil {
+			return err
+		}
+		return node.FanOut(nodes, func(agent node.AgentInterface) error {
+			_, err2 := agent.Delete(ocservUser.Username)
+			return err2
+		})
 	})
 
 	go func() {
@@ -244,103 +375,148 @@ func (o *OcservUserRepository) Delete(ctx context.Context, uid string) (string,
 	return ocservUser.Username, err
 }
 
-func (o *OcservUserRepository) TenDaysStats(ctx context.Context) ([]models.DailyTraffic, error) {
-	var results []models.DailyTraffic
-
-	start := time.Now().AddDate(0, 0, -10).Truncate(24 * time.Hour)
-
-	err := o.db.WithContext(ctx).
-		Model(&models.OcservUserTrafficStatistics{}).
-		Select(`
-		DATE(created_at) AS date,
-		SUM(rx) / 1073741824.0 AS rx,
-		SUM(tx) / 1073741824.0 AS tx`).
-		Where("created_at >= ?", start).
-		Group("DATE(created_at)").
-		Order("DATE(created_at)").
-		Scan(&results).Error
-
-	if err != nil {
+// EffectiveConfig resolves the settings ocserv applies to the user from ocserv.conf and the defaults, group and user config files.
+func (o *OcservUserRepository) EffectiveConfig(ctx context.Context, ocservUser *models.OcservUser) (*user.EffectiveConfig, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	return results, nil
+	return o.commonOcservUserRepo.EffectiveConfig(ocservUser.Username, ocservUser.Group)
 }
 
-func (o *OcservUserRepository) UpdateUsersByDeleteGroup(ctx context.Context, groupName string) ([]models.OcservUser, error) {
+func (o *OcservUserRepository) TenDaysStats(ctx context.Context) ([]models.DailyTraffic, error) {
+	start := time.Now().AddDate(0, 0, -10).Truncate(24 * time.Hour)
+	series := &TrafficSeries{DateStart: &start, Granularity: models.GranularityDay, Location: time.UTC}
+	return trafficSeries(o.db.WithContext(ctx), series, nil)
+}
+
+// MoveUsers assigns the users to the group in the database, then in ocpasswd and on their nodes.
+// The users are given to check, from IPAMRepository.MoveUsers, before they are moved. The database
+// change is committed first and undone when ocpasswd cannot be rewritten; nodes failing to follow
+// are reported in the error, the users stay moved on the others.
+func (o *OcservUserRepository) MoveUsers(ctx context.Context, uids []string, groupName string, check MoveCheck) (
+	[]models.OcservUser, error,
+) {
 	var users []models.OcservUser
 
 	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("`group` = ?", groupName).Select("id", "group", "username").Find(&users).Error; err != nil {
+		if err := groupExists(tx, groupName); err != nil {
 			return err
 		}
-
-		if err := tx.Model(&models.OcservUser{}).
-			Where("`group` = ?", groupName).
-			Update("group", "defaults").Error; err != nil {
+		if err := tx.Where("uid IN ?", uids).Find(&users).Error; err != nil {
 			return err
 		}
-
-		return nil
+		if missing := len(uids) - len(users); missing > 0 {
+			return fmt.Errorf("%d of the given users not found", missing)
+		}
+		if err := check(users); err != nil {
+			return err
+		}
+		return moveUsers(tx, users, groupName)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyMove(o.db.WithContext(ctx), o.commonOcservUserRepo, users, groupName)
+	if err != nil {
+		return nil, err
+	}
 
 	go func() {
 		_, _ = o.commonOcservOcctlRepo.ReloadConfigs()
 	}()
 
-	return users, err
+	return users, nil
 }
 
-func (o *OcservUserRepository) UserStatistics(ctx context.Context, uid string, dateStart, dateEnd *time.Time) ([]models.DailyTraffic, error) {
-	var results []models.DailyTraffic
-
-	query := o.db.WithContext(ctx).
-		Model(&models.OcservUserTrafficStatistics{}).
-		Joins("JOIN ocserv_users ou ON ou.id = ocserv_user_traffic_statistics.oc_user_id").
-		Where("ou.uid = ?", uid).
-		Select(`
-		DATE(ocserv_user_traffic_statistics.created_at) AS date,
-		SUM(ocserv_user_traffic_statistics.rx) / 1073741824.0 AS rx,
-		SUM(ocserv_user_traffic_statistics.tx) / 1073741824.0 AS tx
-	`)
+// moveUsers sets the group of the users in the database. applyMove follows once it is committed.
+func moveUsers(tx *gorm.DB, users []models.OcservUser, groupName string) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return tx.Model(&models.OcservUser{}).Where("id IN ?", ids).Update("group", groupName).Error
+}
 
-	if dateStart != nil {
-		query = query.Where("ocserv_user_traffic_statistics.created_at >= ?", *dateStart)
+// applyMove sets the group of the users, already moved in the database, in ocpasswd and recreates
+// them on the nodes serving them so the agents pick up the new group. The users still hold their
+// previous group: when ocpasswd cannot be rewritten they are moved back to it in the database.
+// Every node is tried, and the node failures are returned together.
+func applyMove(db *gorm.DB, commonOcservUserRepo user.OcservUserInterface, users []models.OcservUser, groupName string) error {
+	if len(users) == 0 {
+		return nil
 	}
-	if dateEnd != nil {
-		query = query.Where("ocserv_user_traffic_statistics.created_at <= ?", *dateEnd)
+
+	usernames := make([]string, 0, len(users))
+	for _, u := range users {
+		usernames = append(usernames, u.Username)
+	}
+	if err := commonOcservUserRepo.SetGroup(groupName, usernames...); err != nil {
+		for _, u := range users {
+			err2 := db.Model(&models.OcservUser{}).
+				Where("id = ? AND `group` = ?", u.ID, groupName).
+				Update("group", u.Group).Error
+			if err2 != nil {
+				err = errors.Join(err, fmt.Errorf("move %s back in the database: %w", u.Username, err2))
+			}
+		}
+		return err
 	}
 
-	err := query.
-		Group("DATE(ocserv_user_traffic_statistics.created_at)").
-		Order("DATE(ocserv_user_traffic_statistics.created_at)").
-		Scan(&results).Error
+	var errs []error
+	for i := range users {
+		users[i].Group = groupName
+		ocservUser := users[i]
 
-	if err != nil {
-		return nil, err
+		nodes, err := node.UserNodes(db, ocservUser.ID)
+		if err == nil {
+			err = node.FanOut(nodes, func(agent node.AgentInterface) error {
+				return agent.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.AppliedConfig())
+			})
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", ocservUser.Username, err))
+		}
 	}
-	return results, nil
+	return errors.Join(errs...)
 }
 
-func (o *OcservUserRepository) Statistics(ctx context.Context, dateStart, dateEnd *time.Time) ([]models.DailyTraffic, error) {
-	var results []models.DailyTraffic
-	err := o.db.WithContext(ctx).
-		Model(&models.OcservUserTrafficStatistics{}).
-		Joins("JOIN ocserv_users ou ON ou.id = ocserv_user_traffic_statistics.oc_user_id").
-		Select(`
-		DATE(ocserv_user_traffic_statistics.created_at) AS date,
-		SUM(ocserv_user_traffic_statistics.rx) / 1073741824.0 AS rx,
-		SUM(ocserv_user_traffic_statistics.tx) / 1073741824.0 AS tx
-	`).
-		Where("ocserv_user_traffic_statistics.created_at >= ?", *dateStart).
-		Where("ocserv_user_traffic_statistics.created_at <= ?", *dateEnd).
-		Group("DATE(ocserv_user_traffic_statistics.created_at)").
-		Order("DATE(ocserv_user_traffic_statistics.created_at)").
-		Scan(&results).Error
-
-	if err != nil {
-		return nil, err
+// groupExists returns an error unless the group is "defaults" or a group of the database.
+func groupExists(tx *gorm.DB, groupName string) error {
+	if groupName == "defaults" {
+		return nil
 	}
-	return results, nil
+	var count int64
+	if err := tx.Model(&models.OcservGroup{}).Where("name = ?", groupName).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("group %s not found", groupName)
+	}
+	return nil
+}
+
+// TrafficUsage returns the usage of the current period of the user traffic policy, or nil for Free users.
+func (o *OcservUserRepository) TrafficUsage(ctx context.Context, ocservUser *models.OcservUser) (*models.TrafficUsage, error) {
+	return ocservUser.TrafficUsage(o.db.WithContext(ctx), time.Now())
+}
+
+// ScheduleState returns the state of the access schedule of the user or its group, or nil without one.
+func (o *OcservUserRepository) ScheduleState(ctx context.Context, ocservUser *models.OcservUser) (*models.ScheduleState, error) {
+	return ocservUser.ScheduleState(o.db.WithContext(ctx), time.Now())
+}
+
+func (o *OcservUserRepository) UserStatistics(ctx context.Context, uid string, series *TrafficSeries) ([]models.DailyTraffic, error) {
+	return trafficSeries(o.db.WithContext(ctx), series, func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN ocserv_users ou ON ou.id = t.oc_user_id").Where("ou.uid = ?", uid)
+	})
+}
+
+func (o *OcservUserRepository) Statistics(ctx context.Context, series *TrafficSeries) ([]models.DailyTraffic, error) {
+	return trafficSeries(o.db.WithContext(ctx), series, nil)
 }
 
 func (o *OcservUserRepository) TotalUsers(ctx context.Context) (int64, error) {
@@ -389,95 +565,46 @@ func (o *OcservUserRepository) TopBandwidthUser(ctx context.Context) (TopBandwid
 }
 
 func (o *OcservUserRepository) TotalBandwidthUser(ctx context.Context, uid string) (TotalBandwidths, error) {
-	var total TotalBandwidths
-
-	//err := o.db.WithContext(ctx).
-	//	Model(&models.OcservUserTrafficStatistics{}).
-	//	Joins("JOIN ocserv_users ou ON ou.id = ocserv_user_traffic_statistics.oc_user_id").
-	//	Where("ou.uid = ?", uid).
-	//	Select(`
-	//    COALESCE(SUM(rx),0) / 1073741824.0 AS rx,
-	//    COALESCE(SUM(tx),0) / 1073741824.0 AS tx`).
-	//	Scan(&total).Error
-
-	err := o.db.WithContext(ctx).
-		Table("ocserv_user_traffic_statistics AS t").
-		Joins("JOIN ocserv_users ou ON ou.id = t.oc_user_id").
-		Where("ou.uid = ?", uid).
-		Select(`
-            COALESCE(SUM(t.rx),0) / 1073741824.0 AS rx,
-            COALESCE(SUM(t.tx),0) / 1073741824.0 AS tx
-        `).
-		Scan(&total).Error
-
-	if err != nil {
-		return total, err
-	}
-	return total, nil
+	return trafficTotals(
+		trafficRollup(o.db.WithContext(ctx), nil, nil).
+			Joins("JOIN ocserv_users ou ON ou.id = t.oc_user_id").
+			Where("ou.uid = ?", uid),
+	)
 }
 
 func (o *OcservUserRepository) TotalBandwidth(ctx context.Context) (TotalBandwidths, error) {
-	var total TotalBandwidths
-
-	err := o.db.WithContext(ctx).
-		Model(&models.OcservUserTrafficStatistics{}).
-		Select(`
-        COALESCE(SUM(rx),0) / 1073741824.0 AS rx,
-        COALESCE(SUM(tx),0) / 1073741824.0 AS tx`).
-		Scan(&total).Error
-	if err != nil {
-		return total, err
-	}
-	return total, nil
+	return trafficTotals(trafficRollup(o.db.WithContext(ctx), nil, nil))
 }
 
 func (o *OcservUserRepository) TotalBandwidthDateRange(ctx context.Context, dateStart, dateEnd *time.Time) (TotalBandwidths, error) {
-	var total TotalBandwidths
-
-	query := o.db.WithContext(ctx).
-		Model(&models.OcservUserTrafficStatistics{}).
-		Select(`
-			COALESCE(SUM(rx),0) / 1073741824.0 AS rx,
-			COALESCE(SUM(tx),0) / 1073741824.0 AS tx`)
-
-	// Apply filters based on dateStart and dateEnd
-	if dateStart != nil {
-		query = query.Where("created_at >= ?", *dateStart)
-	}
-	if dateEnd != nil {
-		query = query.Where("created_at <= ?", *dateEnd)
-	}
-
-	err := query.Scan(&total).Error
-	if err != nil {
-		return total, err
-	}
-	return total, nil
+	return trafficTotals(trafficRollup(o.db.WithContext(ctx), dateStart, dateEnd))
 }
 
 func (o *OcservUserRepository) TotalBandwidthUserDateRange(ctx context.Context, uid string, dateStart, dateEnd *time.Time) (TotalBandwidths, error) {
-	var total TotalBandwidths
+	return trafficTotals(trafficRollup(o.db.WithContext(ctx), dateStart, dateEnd).Where("t.oc_user_id = ?", uid))
+}
 
-	query := o.db.WithContext(ctx).
-		Model(&models.OcservUserTrafficStatistics{}).
-		Where("oc_user_id = ? ", uid).
-		Select(`
-			COALESCE(SUM(rx),0) / 1073741824.0 AS rx,
-			COALESCE(SUM(tx),0) / 1073741824.0 AS tx`)
+func (o *OcservUserRepository) NodeBandwidthsUser(ctx context.Context, uid string) ([]models.NodeBandwidths, error) {
+	var results []models.NodeBandwidths
 
-	// Apply filters based on dateStart and dateEnd
-	if dateStart != nil {
-		query = query.Where("created_at >= ?", *dateStart)
-	}
-	if dateEnd != nil {
-		query = query.Where("created_at <= ?", *dateEnd)
-	}
+	err := trafficRollup(o.db.WithContext(ctx), nil, nil).
+		Joins("JOIN ocserv_users ou ON ou.id = t.oc_user_id").
+		Joins("LEFT JOIN nodes n ON n.id = t.node_id").
+		Where("ou.uid = ?", uid).
+		Select(`
+            NULLIF(t.node_id, 0) AS node_id,
+            COALESCE(n.name, '') AS node,
+            COALESCE(SUM(t.rx),0) / 1073741824.0 AS rx,
+            COALESCE(SUM(t.tx),0) / 1073741824.0 AS tx
+        `).
+		Group("t.node_id, n.name").
+		Order("n.name").
+		Scan(&results).Error
 
-	err := query.Scan(&total).Error
 	if err != nil {
-		return total, err
+		return nil, err
 	}
-	return total, nil
+	return results, nil
 }
 
 func (o *OcservUserRepository) Ocpasswd(ctx context.Context, pagination *request.Pagination) ([]user.Ocpasswd, int, error) {
@@ -543,6 +670,14 @@ func (o *OcservUserRepository) OcpasswdSyncToDB(ctx context.Context, users []mod
 			return err
 		}
 
+		ids := make([]uint, 0, len(users))
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		if err := SyncPrimaryOwners(tx, ids...); err != nil {
+			return err
+		}
+
 		//for _, i := range users {
 		//	if err := o.commonOcservUserRepo.Create(i.Group, i.Username, i.Password, i.Config); err != nil {
 		//		return err
@@ -580,15 +715,24 @@ func (o *OcservUserRepository) RestoreExpired(ctx context.Context, uid string, e
 		if _, err := o.commonOcservUserRepo.UnLock(u.Username); err != nil {
 			return err
 		}
+		err := o.fanOutUser(tx, u.ID, func(agent node.AgentInterface) error {
+			_, err := agent.UnLock(u.Username)
+			return err
+		})
+		if err != nil {
+			return err
+		}
 
 		if err := tx.
 			Model(&u).
 			Updates(map[string]interface{}{
-				"expire_at":      expireAt,
-				"deactivated_at": nil,
-				"is_locked":      false,
-				"rx":             0,
-				"tx":             0,
+				"expire_at":          expireAt,
+				"deactivated_at":     nil,
+				"deactivated_reason": "",
+				"is_locked":          false,
+				"rx":                 0,
+				"tx":                 0,
+				"traffic_reset_at":   time.Now(),
 			}).Error; err != nil {
 			return err
 		}
@@ -596,3 +740,194 @@ func (o *OcservUserRepository) RestoreExpired(ctx context.Context, uid string, e
 		return nil
 	})
 }
+
+// Renew applies the plan to the user, within the reseller quotas of its owner, see
+// models.OcservUser.ApplyPlan.
+func (o *OcservUserRepository) Renew(ctx context.Context, ocservUser *models.OcservUser, plan *models.Plan) (*models.OcservUser, error) {
+	ocservUser.ApplyPlan(plan, time.Now())
+
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := CheckResellerQuota(tx, ocservUser.Owner, ocservUser); err != nil {
+			return err
+		}
+		if err := groupExists(tx, ocservUser.Group); err != nil {
+			return err
+		}
+		if err := tx.Omit("Nodes").Save(ocservUser).Error; err != nil {
+			return err
+		}
+		if err := o.commonOcservUserRepo.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.AppliedConfig()); err != nil {
+			return err
+		}
+		if _, err := o.commonOcservUserRepo.UnLock(ocservUser.Username); err != nil {
+			return err
+		}
+		return o.fanOutUser(tx, ocservUser.ID, func(agent node.AgentInterface) error {
+			if err := agent.Create(ocservUser.Group, ocservUser.Username, ocservUser.Password, ocservUser.AppliedConfig()); err != nil {
+				return err
+			}
+			_, err := agent.UnLock(ocservUser.Username)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		_, _ = o.commonOcservOcctlRepo.ReloadConfigs()
+	}()
+
+	return ocservUser, nil
+}
+
+// TopUp records the top-up in the ledger and returns the traffic usage it results in. A user locked
+// or throttled for exceeding its quota, and back under it, is unlocked or gets its full rates back.
+func (o *OcservUserRepository) TopUp(ctx context.Context, ocservUser *models.OcservUser, topUp *models.TrafficTopUp) (*models.TrafficUsage, error) {
+	var usage *models.TrafficUsage
+	var throttled bool
+	now := time.Now()
+	today := now.Truncate(24 * time.Hour)
+
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		topUp.OcUserID = ocservUser.ID
+		if err := tx.Create(topUp).Error; err != nil {
+			return err
+		}
+
+		var err error
+		usage, err = ocservUser.TrafficUsage(tx, now)
+		if err != nil || usage == nil || usage.Exceeded {
+			return err
+		}
+
+		// only the users deactivated for their quota are unlocked, expired users stay locked
+		locked := ocservUser.DeactivatedReason == models.DeactivatedQuota &&
+			(ocservUser.ExpireAt == nil || ocservUser.ExpireAt.After(today))
+		throttled = ocservUser.ThrottledAt != nil
+		if !locked && !throttled {
+			return nil
+		}
+
+		updates := map[string]interface{}{"throttled_at": nil}
+		if locked {
+			updates["is_locked"] = false
+			updates["deactivated_at"] = nil
+			updates["deactivated_reason"] = ""
+		}
+		if err = tx.Model(ocservUser).Updates(updates).Error; err != nil {
+			return err
+		}
+		ocservUser.ThrottledAt = nil
+		if locked {
+			ocservUser.IsLocked = false
+			ocservUser.DeactivatedAt = nil
+			ocservUser.DeactivatedReason = ""
+		}
+
+		if throttled {
+			if err = o.commonOcservUserRepo.CreateConfig(ocservUser.Username, ocservUser.Config); err != nil {
+				return err
+			}
+		}
+		if locked {
+			if _, err = o.commonOcservUserRepo.UnLock(ocservUser.Username); err != nil {
+				return err
+			}
+		}
+		return o.fanOutUser(tx, ocservUser.ID, func(agent node.AgentInterface) error {
+			if throttled {
+				if err2 := agent.UpdateConfig(ocservUser.Username, ocservUser.Config); err2 != nil {
+					return err2
+				}
+				_, _ = agent.DisconnectUser(ocservUser.Username)
+			}
+			if locked {
+				_, err2 := agent.UnLock(ocservUser.Username)
+				return err2
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if throttled {
+		configversion.RecordChange(
+			o.db.WithContext(ctx), models.ConfigKindUser, ocservUser.Username,
+			ocservUser.Config, ocservUser.Config, "", "throttle lifted",
+		)
+		// the full rates apply to the next session of the user
+		go func() {
+			_, _ = o.commonOcservOcctlRepo.ReloadConfigs()
+			_, _ = o.commonOcservOcctlRepo.DisconnectUser(ocservUser.Username)
+		}()
+	}
+	return usage, nil
+}
+
+func (o *OcservUserRepository) TopUps(
+	ctx context.Context, ocservUser *models.OcservUser, pagination *request.Pagination,
+) ([]models.TrafficTopUp, int64, error) {
+	var totalRecords int64
+
+	if err := o.db.WithContext(ctx).Model(&models.TrafficTopUp{}).Where("oc_user_id = ?", ocservUser.ID).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var topUps []models.TrafficTopUp
+	txPaginator := request.Paginator(ctx, o.db, pagination)
+	if err := txPaginator.Model(&topUps).Where("oc_user_id = ?", ocservUser.ID).Find(&topUps).Error; err != nil {
+		return nil, 0, err
+	}
+	return topUps, totalRecords, nil
+}
+
+// fanOutUser runs fn against the agents of the nodes the user is assigned to.
+func (o *OcservUserRepository) fanOutUser(tx *gorm.DB, userID uint, fn func(agent node.AgentInterface) error) error {
+	nodes, err := node.UserNodes(tx, userID)
+	if err != nil {
+		return err
+	}
+	return node.FanOut(nodes, fn)
+}
+
+// removeLocal undoes the local write of a created user whose fan-out failed. Errors are ignored, what
+// is left behind shows in the drift report.
+func (o *OcservUserRepository) removeLocal(ocservUser *models.OcservUser) {
+	_, _ = o.commonOcservUserRepo.Delete(ocservUser.Username)
+	if ocservUser.AppliedConfig() != nil {
+		_ = o.commonOcservUserRepo.DeleteConfig(ocservUser.Username)
+	}
+}
+
+// restoreLocal undoes the local write of an updated user whose fan-out failed, rewriting the saved user.
+// Errors are ignored, what is left behind shows in the drift report.
+func (o *OcservUserRepository) restoreLocal(saved, ocservUser *models.OcservUser) {
+	if err := o.commonOcservUserRepo.Create(saved.Group, saved.Username, saved.Password, saved.AppliedConfig()); err != nil {
+		return
+	}
+	if saved.AppliedConfig() == nil && ocservUser.AppliedConfig() != nil {
+		_ = o.commonOcservUserRepo.DeleteConfig(saved.Username)
+	}
+	if saved.IsLocked {
+		_, _ = o.commonOcservUserRepo.Lock(saved.Username)
+	}
+}
+
+// removedNodes returns the nodes of previous that are missing from current.
+func removedNodes(previous, current []models.Node) []models.Node {
+	keep := make(map[uint]struct{}, len(current))
+	for _, n := range current {
+		keep[n.ID] = struct{}{}
+	}
+
+	var removed []models.Node
+	for _, n := range previous {
+		if _, ok := keep[n.ID]; !ok {
+			removed = append(removed, n)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// usagePercent is the SQL of the share of its quota a user consumed in the current period, in percent,
+// counted in the direction of its policy as models.OcservUser.TrafficUsage does: lifetime quotas from
+// the rx and tx counters, periodic ones from the hourly traffic since periodFrom. The quota is extended
+// by the active top-ups, see activeTopUps. It is NULL for Free users.
+const usagePercent = "(CASE WHEN " + userPeriod + " = 'lifetime' THEN " + usedCounters + " ELSE " +
+	"(SELECT COALESCE(SUM(" + usedHourly + "), 0) FROM ocserv_user_traffic_hourly AS hourly " +
+	"WHERE hourly.oc_user_id = ocserv_users.id AND julianday(hourly.start) >= " + periodFrom + ") END) * 100.0 / " +
+	"((NULLIF(ocserv_users.traffic_size, 0) + " + activeTopUps + ") * 1073741824.0)"
+
+// userPeriod and userDirection are the SQL of the period and direction of the policy of a user; the
+// rows saved before the policy columns leave them to the traffic type.
+const (
+	userPeriod = "(CASE WHEN ocserv_users.traffic_period <> '' THEN ocserv_users.traffic_period " +
+		"WHEN ocserv_users.traffic_type IN ('MonthlyTransmit', 'MonthlyReceive') THEN 'monthly' " +
+		"WHEN ocserv_users.traffic_type IN ('TotallyTransmit', 'TotallyReceive') THEN 'lifetime' END)"
+	userDirection = "(CASE WHEN ocserv_users.traffic_direction <> '' THEN ocserv_users.traffic_direction " +
+		"WHEN ocserv_users.traffic_type IN ('MonthlyReceive', 'TotallyReceive') THEN 'rx' " +
+		"WHEN ocserv_users.traffic_type IN ('MonthlyTransmit', 'TotallyTransmit') THEN 'tx' END)"
+)
+
+// usedCounters and usedHourly are the SQL of the bytes counted against the quota, from the counters of
+// a user and from a row of its hourly traffic.
+const (
+	usedCounters = "(CASE " + userDirection + " WHEN 'rx' THEN ocserv_users.rx WHEN 'tx' THEN ocserv_users.tx " +
+		"ELSE ocserv_users.rx + ocserv_users.tx END)"
+	usedHourly = "(CASE " + userDirection + " WHEN 'rx' THEN hourly.rx WHEN 'tx' THEN hourly.tx " +
+		"ELSE hourly.rx + hourly.tx END)"
+)
+
+// periodFrom is the SQL of the julian day the usage of the current period counts from: the start of
+// the period, or the last reset of the counters if later. The period is approximated by the last day,
+// week or month, as its start depends on the policy of each user; lifetime quotas start at 0. SQLite
+// parses the time zones of the stored times.
+const periodFrom = "MAX(CASE " + userPeriod + " " +
+	"WHEN 'daily' THEN julianday('now', '-1 day') " +
+	"WHEN 'weekly' THEN julianday('now', '-7 days') " +
+	"WHEN 'monthly' THEN julianday('now', '-1 month') " +
+	"ELSE 0 END, COALESCE(julianday(ocserv_users.traffic_reset_at), 0))"
+
+// activeTopUps is the SQL of the GiB of the active top-ups of a user, as models.OcservUser.TrafficUsage
+// counts them: the unexpired ones, and the ones without expiry added since periodFrom.
+const activeTopUps = "(SELECT COALESCE(SUM(top_ups.amount), 0) FROM traffic_top_ups AS top_ups " +
+	"WHERE top_ups.oc_user_id = ocserv_users.id AND (julianday(top_ups.expire_at) > julianday('now') OR " +
+	"(top_ups.expire_at IS NULL AND julianday(top_ups.created_at) >= " + periodFrom + ")))"
+
+// OcservUsersOrderBy lists the orders of the user list.
+var OcservUsersOrderBy = request.OrderBy{
+	"id":           "id",
+	"username":     "username",
+	"group":        "`group`",
+	"created_at":   "created_at",
+	"expire_at":    "expire_at",
+	"traffic_size": "traffic_size",
+	"rx":           "rx",
+	"tx":           "tx",
+	"usage":        usagePercent,
+}
+
+// OcservUsersKeyset lists the orders of the user list supporting cursor pagination.
+var OcservUsersKeyset = request.OrderBy{
+	"id":           "id",
+	"username":     "username",
+	"created_at":   "created_at",
+	"traffic_size": "traffic_size",
+	"rx":           "rx",
+	"tx":           "tx",
+}
+
+// OcservUsersFilter filters the user list. Zero values do not filter; the date bounds are inclusive days.
+type OcservUsersFilter struct {
+	Owner         string // users shared with the staff user
+	Q             string // username substring, at least 2 characters
+	Group         string
+	TrafficType   string
+	Locked        *bool
+	Expired       *bool
+	Deactivated   *bool
+	Online        *bool
+	Connected     []string // usernames connected now, required by Online
+	ExpireBefore  *time.Time
+	ExpireAfter   *time.Time
+	UsageAbove    *float64 // percent of the quota, see usagePercent
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Description   string // description substring
+	Labels        *LabelFilter
+}
+
+// usersFilter applies the filters of the user list.
+func usersFilter(db *gorm.DB, filter *OcservUsersFilter) *gorm.DB {
+	if filter == nil {
+		return db
+	}
+	if filter.Owner != "" {
+		db = ownedBy(db, filter.Owner)
+	}
+	if len(filter.Q) >= 2 {
+		db = db.Where("LOWER(username) LIKE ?", "%"+strings.ToLower(filter.Q)+"%")
+	}
+	if filter.Group != "" {
+		db = db.Where("`group` = ?", filter.Group)
+	}
+	if filter.TrafficType != "" {
+		db = db.Where("traffic_type = ?", filter.TrafficType)
+	}
+	if filter.Locked != nil {
+		db = db.Where("is_locked = ?", *filter.Locked)
+	}
+	if filter.Expired != nil {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		if *filter.Expired {
+			db = db.Where("expire_at IS NOT NULL AND expire_at < ?", today)
+		} else {
+			db = db.Where("expire_at IS NULL OR expire_at >= ?", today)
+		}
+	}
+	if filter.Deactivated != nil {
+		if *filter.Deactivated {
+			db = db.Where("deactivated_at IS NOT NULL")
+		} else {
+			db = db.Where("deactivated_at IS NULL")
+		}
+	}
+	if filter.Online != nil {
+		switch {
+		case *filter.Online:
+			db = db.Where("username IN ?", append([]string{""}, filter.Connected...))
+		case len(filter.Connected) > 0:
+			db = db.Where("username NOT IN ?", filter.Connected)
+		}
+	}
+	if filter.ExpireBefore != nil {
+		db = db.Where("expire_at < ?", filter.ExpireBefore.AddDate(0, 0, 1))
+	}
+	if filter.ExpireAfter != nil {
+		db = db.Where("expire_at >= ?", *filter.ExpireAfter)
+	}
+	if filter.UsageAbove != nil {
+		db = db.Where(usagePercent+" > ?", *filter.UsageAbove)
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		db = db.Where("created_at < ?", filter.CreatedBefore.AddDate(0, 0, 1))
+	}
+	if filter.Description != "" {
+		db = db.Where("LOWER(description) LIKE ?", "%"+strings.ToLower(filter.Description)+"%")
+	}
+	return labelsFilter(db, models.LabelKindUser, "ocserv_users.id", filter.Labels)
+}
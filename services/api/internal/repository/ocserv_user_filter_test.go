@@ -0,0 +1,178 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const gib = 1 << 30
+
+func TestUsersFilter(t *testing.T) {
+	db := setupDB(
+		t, &models.User{}, &models.OcservUserOwner{}, &commonModels.OcservUser{}, &commonModels.TrafficTopUp{},
+		&commonModels.AttributeDefinition{}, &commonModels.LabelTag{}, &commonModels.LabelAttribute{},
+		&commonModels.OcservUserTrafficHourly{},
+	)
+	require.NoError(t, db.Create(&models.User{UID: "staff", Username: "staff"}).Error)
+
+	now := time.Now()
+	yesterday, nextMonth := now.AddDate(0, 0, -1), now.AddDate(0, 1, 0)
+	users := []commonModels.OcservUser{
+		{
+			Username: "alice", Group: "defaults", TrafficType: commonModels.Custom, TrafficDirection: commonModels.TrafficTX,
+			TrafficPeriod: commonModels.TrafficMonthly, TrafficSize: 10, Tx: 50 * gib, ExpireAt: &nextMonth,
+			Description: "VIP client",
+		},
+		{
+			Username: "bob", Group: "vip", TrafficType: commonModels.Custom, TrafficDirection: commonModels.TrafficBoth,
+			TrafficPeriod: commonModels.TrafficLifetime, TrafficSize: 10, Rx: 3 * gib, Tx: 3 * gib, IsLocked: true,
+			ExpireAt: &yesterday, DeactivatedAt: &yesterday,
+		},
+		{Username: "carol", Group: "defaults", TrafficType: commonModels.Free, Rx: 100 * gib},
+	}
+	for i := range users {
+		users[i].UID, users[i].Owner, users[i].Password = users[i].Username, "admin", "secret"
+		require.NoError(t, db.Create(&users[i]).Error)
+	}
+	alice, bob := users[0], users[1]
+
+	// the monthly usage of alice is summed from her hourly traffic of the period, not from her counters
+	hour := commonModels.BucketStart(now.UTC(), commonModels.GranularityHour)
+	require.NoError(t, db.Create([]commonModels.OcservUserTrafficHourly{
+		{TrafficBucket: commonModels.TrafficBucket{OcUserID: alice.ID, Start: hour.Add(-time.Hour), Rx: 20 * gib, Tx: 9 * gib}},
+		{TrafficBucket: commonModels.TrafficBucket{OcUserID: alice.ID, Start: hour.AddDate(0, -2, 0), Tx: 40 * gib}},
+	}).Error)
+
+	require.NoError(t, db.Create(&models.OcservUserOwner{OcservUserID: bob.ID, UserID: 1}).Error)
+	require.NoError(t, db.Create(&commonModels.AttributeDefinition{Key: "seats", Type: commonModels.AttributeNumber}).Error)
+	require.NoError(t, db.Create(&commonModels.LabelTag{Kind: commonModels.LabelKindUser, TargetID: alice.ID, Name: "gold"}).Error)
+	require.NoError(t, db.Create([]commonModels.LabelAttribute{
+		commonModels.NewLabelAttribute(commonModels.LabelKindUser, alice.ID, "seats", float64(3)),
+		commonModels.NewLabelAttribute(commonModels.LabelKindUser, bob.ID, "seats", float64(1)),
+	}).Error)
+
+	yes, no := true, false
+	above := func(percent float64) *float64 { return &percent }
+	tomorrow := now.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name   string
+		filter *repository.OcservUsersFilter
+		want   []string
+	}{
+		{name: "none", want: []string{"alice", "bob", "carol"}},
+		{name: "owner", filter: &repository.OcservUsersFilter{Owner: "staff"}, want: []string{"bob"}},
+		{name: "username", filter: &repository.OcservUsersFilter{Q: "AL"}, want: []string{"alice"}},
+		{name: "short username", filter: &repository.OcservUsersFilter{Q: "a"}, want: []string{"alice", "bob", "carol"}},
+		{name: "group", filter: &repository.OcservUsersFilter{Group: "vip"}, want: []string{"bob"}},
+		{name: "traffic type", filter: &repository.OcservUsersFilter{TrafficType: commonModels.Free}, want: []string{"carol"}},
+		{name: "locked", filter: &repository.OcservUsersFilter{Locked: &yes}, want: []string{"bob"}},
+		{name: "expired", filter: &repository.OcservUsersFilter{Expired: &yes}, want: []string{"bob"}},
+		{name: "not expired", filter: &repository.OcservUsersFilter{Expired: &no}, want: []string{"alice", "carol"}},
+		{name: "deactivated", filter: &repository.OcservUsersFilter{Deactivated: &yes}, want: []string{"bob"}},
+		{
+			name:   "online",
+			filter: &repository.OcservUsersFilter{Online: &yes, Connected: []string{"carol"}},
+			want:   []string{"carol"},
+		},
+		{name: "online without connections", filter: &repository.OcservUsersFilter{Online: &yes}, want: []string{}},
+		{
+			name:   "offline",
+			filter: &repository.OcservUsersFilter{Online: &no, Connected: []string{"carol"}},
+			want:   []string{"alice", "bob"},
+		},
+		{name: "expire before", filter: &repository.OcservUsersFilter{ExpireBefore: &now}, want: []string{"bob"}},
+		{name: "expire after", filter: &repository.OcservUsersFilter{ExpireAfter: &now}, want: []string{"alice"}},
+		{name: "created after", filter: &repository.OcservUsersFilter{CreatedAfter: &tomorrow}, want: []string{}},
+		{name: "created before", filter: &repository.OcservUsersFilter{CreatedBefore: &now}, want: []string{"alice", "bob", "carol"}},
+		{name: "description", filter: &repository.OcservUsersFilter{Description: "vip"}, want: []string{"alice"}},
+		{name: "usage above", filter: &repository.OcservUsersFilter{UsageAbove: above(50)}, want: []string{"alice", "bob"}},
+		{
+			name:   "tag",
+			filter: &repository.OcservUsersFilter{Labels: &repository.LabelFilter{Tags: []string{"gold"}}},
+			want:   []string{"alice"},
+		},
+		{
+			name: "attribute",
+			filter: &repository.OcservUsersFilter{Labels: &repository.LabelFilter{
+				Attributes: []repository.AttributeFilter{{Key: "seats", Op: ">", Value: "2"}},
+			}},
+			want: []string{"alice"},
+		},
+		{
+			// compared as numbers, not as text
+			name: "number attribute",
+			filter: &repository.OcservUsersFilter{Labels: &repository.LabelFilter{
+				Attributes: []repository.AttributeFilter{{Key: "seats", Op: "<", Value: "10"}},
+			}},
+			want: []string{"alice", "bob"},
+		},
+		{
+			name: "attribute not equal",
+			filter: &repository.OcservUsersFilter{Labels: &repository.LabelFilter{
+				Attributes: []repository.AttributeFilter{{Key: "seats", Op: "!=", Value: "3"}},
+			}},
+			want: []string{"bob", "carol"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.filter != nil {
+				require.NoError(t, repository.NewLabelRepository().CheckFilter(context.Background(), tt.filter.Labels))
+			}
+			pagination := &request.Pagination{Page: 1, PageSize: 10, Order: "username", Sort: "ASC"}
+			result, total, err := repository.NewtOcservUserRepository().Users(context.Background(), pagination, tt.filter)
+			require.NoError(t, err)
+
+			usernames := []string{}
+			for _, u := range result {
+				usernames = append(usernames, u.Username)
+			}
+			assert.Equal(t, tt.want, usernames)
+			assert.EqualValues(t, len(tt.want), total)
+		})
+	}
+
+	t.Run("usage with top-ups", func(t *testing.T) {
+		// alice used 9 of 10 GiB and bob 6 of 10; a 10 GiB top-up halves the usage of alice
+		require.NoError(t, db.Create(&commonModels.TrafficTopUp{OcUserID: alice.ID, Amount: 10, Author: "admin"}).Error)
+		// expired top-ups do not count
+		require.NoError(t, db.Create(&commonModels.TrafficTopUp{
+			OcUserID: bob.ID, Amount: 10, ExpireAt: &yesterday, Author: "admin",
+		}).Error)
+
+		repo := repository.NewtOcservUserRepository()
+		result, _, err := repo.Users(context.Background(), &request.Pagination{
+			Page: 1, PageSize: 10, Order: "username", Sort: "ASC",
+		}, &repository.OcservUsersFilter{UsageAbove: above(50)})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, "bob", result[0].Username)
+
+		result, _, err = repo.Users(context.Background(), &request.Pagination{
+			Page: 1, PageSize: 10, Order: "usage", Sort: "DESC",
+		}, &repository.OcservUsersFilter{TrafficType: commonModels.Custom})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, "bob", result[0].Username)
+		assert.Equal(t, "alice", result[1].Username)
+	})
+
+	t.Run("usage after a reset", func(t *testing.T) {
+		require.NoError(t, db.Model(&alice).Update("traffic_reset_at", now).Error)
+
+		result, _, err := repository.NewtOcservUserRepository().Users(context.Background(), &request.Pagination{
+			Page: 1, PageSize: 10, Order: "usage", Sort: "ASC",
+		}, &repository.OcservUsersFilter{TrafficType: commonModels.Custom, UsageAbove: above(0)})
+		require.NoError(t, err)
+		require.Len(t, result, 1, "the traffic of alice before the reset no longer counts")
+		assert.Equal(t, "bob", result[0].Username)
+	})
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	apiModels "github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"gorm.io/gorm"
+)
+
+type OcservUserOwnership interface {
+	Owners(ctx context.Context, ocservUser *models.OcservUser) ([]apiModels.UsersLookup, error)
+	IsOwner(ctx context.Context, ocservUser *models.OcservUser, username string) (bool, error)
+	AddOwner(ctx context.Context, ocservUser *models.OcservUser, owner *apiModels.User) error
+	RemoveOwner(ctx context.Context, ocservUser *models.OcservUser, owner *apiModels.User) error
+	TransferOwner(ctx context.Context, ocservUser *models.OcservUser, owner *apiModels.User) error
+}
+
+// SyncPrimaryOwners adds the owner column of the given ocserv users (or of all of them when no ids
+// are given) to the owners table. Existing rows are kept, so it is safe to run on every start.
+func SyncPrimaryOwners(db *gorm.DB, ids ...uint) error {
+	query := `
+	INSERT INTO ocserv_user_owners (ocserv_user_id, user_id, created_at)
+	SELECT ocserv_users.id, users.id, CURRENT_TIMESTAMP
+	FROM ocserv_users
+	JOIN users ON users.username = ocserv_users.owner
+	WHERE NOT EXISTS (
+		SELECT 1 FROM ocserv_user_owners
+		WHERE ocserv_user_owners.ocserv_user_id = ocserv_users.id AND ocserv_user_owners.user_id = users.id
+	)`
+	if len(ids) == 0 {
+		return db.Exec(query).Error
+	}
+	return db.Exec(query+" AND ocserv_users.id IN ?", ids).Error
+}
+
+// ownedBy filters ocserv users shared with the given dashboard username.
+func ownedBy(db *gorm.DB, username string) *gorm.DB {
+	return db.Where(`id IN (
+		SELECT ocserv_user_owners.ocserv_user_id FROM ocserv_user_owners
+		JOIN users ON users.id = ocserv_user_owners.user_id
+		WHERE users.username = ?)`, username)
+}
+
+func (o *OcservUserRepository) Owners(ctx context.Context, ocservUser *models.OcservUser) ([]apiModels.UsersLookup, error) {
+	var owners []apiModels.UsersLookup
+	err := o.db.WithContext(ctx).
+		Model(&apiModels.User{}).
+		Select("users.uid", "users.username").
+		Joins("JOIN ocserv_user_owners ON ocserv_user_owners.user_id = users.id").
+		Where("ocserv_user_owners.ocserv_user_id = ?", ocservUser.ID).
+		Order("ocserv_user_owners.created_at").
+		Scan(&owners).Error
+	if err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
+
+func (o *OcservUserRepository) IsOwner(ctx context.Context, ocservUser *models.OcservUser, username string) (bool, error) {
+	var count int64
+	err := ownedBy(o.db.WithContext(ctx).Model(&models.OcservUser{}), username).
+		Where("id = ?", ocservUser.ID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (o *OcservUserRepository) AddOwner(ctx context.Context, ocservUser *models.OcservUser, owner *apiModels.User) error {
+	return o.db.WithContext(ctx).
+		Where(apiModels.OcservUserOwner{OcservUserID: ocservUser.ID, UserID: owner.ID}).
+		FirstOrCreate(&apiModels.OcservUserOwner{}).Error
+}
+
+func (o *OcservUserRepository) RemoveOwner(ctx context.Context, ocservUser *models.OcservUser, owner *apiModels.User) error {
+	if ocservUser.Owner == owner.Username {
+		return errors.New("the primary owner cannot be removed, transfer the user instead")
+	}
+	return o.db.WithContext(ctx).
+		Where("ocserv_user_id = ? AND user_id = ?", ocservUser.ID, owner.ID).
+		Delete(&apiModels.OcservUserOwner{}).Error
+}
+
+// TransferOwner makes owner the primary and only owner of the ocserv user, within its reseller quotas.
+func (o *OcservUserRepository) TransferOwner(ctx context.Context, ocservUser *models.OcservUser, owner *apiModels.User) error {
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// for the new owner the user counts as a newly created one
+		transferred := *ocservUser
+		transferred.ID = 0
+		if err := CheckResellerQuota(tx, owner.Username, &transferred); err != nil {
+			return err
+		}
+		if err := tx.Where("ocserv_user_id = ?", ocservUser.ID).Delete(&apiModels.OcservUserOwner{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&apiModels.OcservUserOwner{OcservUserID: ocservUser.ID, UserID: owner.ID}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(ocservUser).Update("owner", owner.Username).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,258 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenewDeletedPlanGroup(t *testing.T) {
+	db := setupDB(t, &models.User{}, &models.Reseller{}, &commonModels.Node{}, &commonModels.OcservGroup{}, &commonModels.OcservUser{})
+	assert.NoError(t, createOcservUser(db, "admin", "alice", 10))
+
+	var alice commonModels.OcservUser
+	assert.NoError(t, db.Where("username = ?", "alice").First(&alice).Error)
+
+	plan := &commonModels.Plan{ID: 1, Group: "removed", TrafficType: commonModels.Free, DurationDays: 30, IsActive: true}
+	_, err := repository.NewtOcservUserRepository().Renew(context.Background(), &alice, plan)
+	assert.EqualError(t, err, "group removed not found")
+
+	var saved commonModels.OcservUser
+	assert.NoError(t, db.Where("username = ?", "alice").First(&saved).Error)
+	assert.Equal(t, "defaults", saved.Group)
+}
+
+func TestMoveUsers(t *testing.T) {
+	db := setupDB(t, &models.User{}, &models.Reseller{}, &commonModels.Node{}, &commonModels.OcservGroup{}, &commonModels.OcservUser{})
+	ocpasswd := setupOcservFiles(t, "alice:*:$5$alicehash\nbob:*:!$5$bobhash\n")
+	assert.NoError(t, createOcservUser(db, "admin", "alice", 10))
+	assert.NoError(t, createOcservUser(db, "admin", "bob", 10))
+	assert.NoError(t, db.Create(&commonModels.OcservGroup{Name: "vip", Owner: "admin"}).Error)
+
+	var users []commonModels.OcservUser
+	err := repository.NewIPAMRepository().MoveUsers(context.Background(), "vip", func(check repository.MoveCheck) error {
+		var err2 error
+		users, err2 = repository.NewtOcservUserRepository().MoveUsers(context.Background(), []string{"alice", "bob"}, "vip", check)
+		return err2
+	})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	content, err := os.ReadFile(ocpasswd)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice:vip:$5$alicehash\nbob:vip:!$5$bobhash\n", string(content))
+
+	var count int64
+	assert.NoError(t, db.Model(&commonModels.OcservUser{}).Where("`group` = ?", "vip").Count(&count).Error)
+	assert.EqualValues(t, 2, count)
+
+	t.Run("explicit-ipv4 outside the pool of the group", func(t *testing.T) {
+		network, ip := "192.168.1.0/24", "10.0.0.5"
+		require.NoError(t, db.Create(&commonModels.OcservGroup{
+			Name: "lan", Owner: "admin", Config: &commonModels.OcservGroupConfig{IPv4Network: &network},
+		}).Error)
+		require.NoError(t, db.Model(&commonModels.OcservUser{}).Where("username = ?", "alice").
+			Update("config", &commonModels.OcservUserConfig{ExplicitIPv4: &ip}).Error)
+
+		err := repository.NewIPAMRepository().MoveUsers(context.Background(), "lan", func(check repository.MoveCheck) error {
+			_, err2 := repository.NewtOcservUserRepository().MoveUsers(context.Background(), []string{"alice", "bob"}, "lan", check)
+			return err2
+		})
+		assert.EqualError(t, err, "user alice cannot move to lan: explicit-ipv4 10.0.0.5 is outside the group pool 192.168.1.0/24")
+		assert.NoError(t, db.Model(&commonModels.OcservUser{}).Where("`group` = ?", "vip").Count(&count).Error)
+		assert.EqualValues(t, 2, count, "no user is moved")
+	})
+}
+
+func TestMoveUsersOcpasswdFailure(t *testing.T) {
+	db := setupDB(t, &models.User{}, &models.Reseller{}, &commonModels.Node{}, &commonModels.OcservGroup{}, &commonModels.OcservUser{})
+	ocpasswd := setupOcservFiles(t, "")
+	assert.NoError(t, os.Remove(ocpasswd))
+	assert.NoError(t, createOcservUser(db, "admin", "alice", 10))
+	assert.NoError(t, db.Create(&commonModels.OcservGroup{Name: "vip", Owner: "admin"}).Error)
+
+	anyUsers := func([]commonModels.OcservUser) error { return nil }
+	_, err := repository.NewtOcservUserRepository().MoveUsers(context.Background(), []string{"alice"}, "vip", anyUsers)
+	assert.Error(t, err)
+
+	var alice commonModels.OcservUser
+	assert.NoError(t, db.Where("username = ?", "alice").First(&alice).Error)
+	assert.Equal(t, "defaults", alice.Group, "the database change is undone")
+}
+
+func TestTopUpUnlocksQuotaDeactivation(t *testing.T) {
+	db := setupDB(
+		t, &models.User{}, &models.Reseller{}, &commonModels.Node{}, &commonModels.OcservUser{}, &commonModels.TrafficTopUp{},
+	)
+	setupOcservFiles(t, "alice:defaults:!$5$alicehash\nbob:defaults:!$5$bobhash\n")
+	previous := utils.OcpasswdExec
+	utils.OcpasswdExec = "true"
+	t.Cleanup(func() { utils.OcpasswdExec = previous })
+
+	deactivatedAt := time.Now().AddDate(0, 0, -1)
+	expireAt := time.Now().AddDate(0, 1, 0)
+	for username, reason := range map[string]string{
+		"alice": commonModels.DeactivatedQuota,
+		// deactivated when it expired, then given a later expiry without being reactivated
+		"bob": commonModels.DeactivatedExpired,
+	} {
+		require.NoError(t, db.Create(&commonModels.OcservUser{
+			UID:               username,
+			Owner:             "admin",
+			Username:          username,
+			Password:          "secret",
+			Group:             "defaults",
+			TrafficType:       commonModels.TotallyTransmit,
+			TrafficSize:       1,
+			Tx:                2 << 30,
+			IsLocked:          true,
+			ExpireAt:          &expireAt,
+			DeactivatedAt:     &deactivatedAt,
+			DeactivatedReason: reason,
+		}).Error)
+	}
+
+	repo := repository.NewtOcservUserRepository()
+	for username, locked := range map[string]bool{"alice": false, "bob": true} {
+		var u commonModels.OcservUser
+		require.NoError(t, db.Where("username = ?", username).First(&u).Error)
+
+		usage, err := repo.TopUp(context.Background(), &u, &commonModels.TrafficTopUp{Amount: 5, Author: "admin"})
+		require.NoError(t, err)
+		assert.False(t, usage.Exceeded)
+
+		var saved commonModels.OcservUser
+		require.NoError(t, db.Where("username = ?", username).First(&saved).Error)
+		assert.Equal(t, locked, saved.IsLocked, username)
+		assert.Equal(t, locked, saved.DeactivatedAt != nil, username)
+	}
+}
+
+func TestCreateWithLabels(t *testing.T) {
+	db := setupDB(
+		t, &models.User{}, &models.Reseller{}, &models.OcservUserOwner{}, &commonModels.Node{},
+		&commonModels.OcservUser{}, &commonModels.LabelTag{}, &commonModels.LabelAttribute{},
+	)
+	setupOcservFiles(t, "")
+	previous := utils.OcpasswdExec
+	t.Cleanup(func() { utils.OcpasswdExec = previous })
+
+	newUser := func(username string) *commonModels.OcservUser {
+		return &commonModels.OcservUser{
+			UID: username, Owner: "admin", Username: username, Password: "secret", Group: "defaults",
+			TrafficType: commonModels.Free,
+		}
+	}
+	labels := &repository.LabelEdit{AddTags: []string{"vip"}, Attributes: commonModels.Attributes{"seats": 2.0}}
+	repo := repository.NewtOcservUserRepository()
+
+	utils.OcpasswdExec = "true"
+	alice, err := repo.Create(context.Background(), newUser("alice"), labels)
+	require.NoError(t, err)
+	var tags, attributes int64
+	require.NoError(t, db.Model(&commonModels.LabelTag{}).Where("target_id = ?", alice.ID).Count(&tags).Error)
+	require.NoError(t, db.Model(&commonModels.LabelAttribute{}).Where("target_id = ?", alice.ID).Count(&attributes).Error)
+	assert.EqualValues(t, 1, tags)
+	assert.EqualValues(t, 1, attributes)
+
+	// the labels are rolled back with the user when ocpasswd fails
+	utils.OcpasswdExec = "false"
+	_, err = repo.Create(context.Background(), newUser("bob"), labels)
+	require.Error(t, err)
+	var users int64
+	require.NoError(t, db.Model(&commonModels.OcservUser{}).Count(&users).Error)
+	require.NoError(t, db.Model(&commonModels.LabelTag{}).Count(&tags).Error)
+	require.NoError(t, db.Model(&commonModels.LabelAttribute{}).Count(&attributes).Error)
+	assert.EqualValues(t, 1, users)
+	assert.EqualValues(t, 1, tags)
+	assert.EqualValues(t, 1, attributes)
+}
+
+func TestNodeFailureUndoesLocalWrite(t *testing.T) {
+	db := setupDB(
+		t, &models.User{}, &models.Reseller{}, &models.OcservUserOwner{}, &commonModels.Node{},
+		&commonModels.OcservUser{}, &commonModels.OcservGroup{}, &commonModels.LabelTag{}, &commonModels.LabelAttribute{},
+	)
+	setupOcservFiles(t, "")
+	require.NoError(t, os.MkdirAll(utils.ConfigUserBaseDir, 0750))
+	require.NoError(t, os.MkdirAll(utils.ConfigGroupBaseDir, 0750))
+
+	// the fake ocpasswd records its calls
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "ocpasswd")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" >> "+calls+"\n"), 0700))
+	previous := utils.OcpasswdExec
+	utils.OcpasswdExec = script
+	t.Cleanup(func() { utils.OcpasswdExec = previous })
+	recorded := func() []string {
+		content, _ := os.ReadFile(calls)
+		_ = os.Remove(calls)
+		return strings.Fields(strings.ReplaceAll(string(content), " ", "_"))
+	}
+
+	// agents are only reached over https, so the node fails
+	edge := commonModels.Node{Name: "edge", AgentURL: "http://127.0.0.1:8888", IsActive: true}
+	require.NoError(t, db.Create(&edge).Error)
+	ipv4 := "192.168.100.10"
+	repo := repository.NewtOcservUserRepository()
+
+	t.Run("create", func(t *testing.T) {
+		_, err := repo.Create(context.Background(), &commonModels.OcservUser{
+			UID: "alice", Owner: "admin", Username: "alice", Password: "secret", Group: "defaults",
+			TrafficType: commonModels.Free, Config: &commonModels.OcservUserConfig{ExplicitIPv4: &ipv4},
+			Nodes: []commonModels.Node{edge},
+		}, nil)
+		require.ErrorContains(t, err, "node edge")
+
+		assert.Equal(t, []string{"-c_" + utils.OcpasswdPath + "_alice", "-d_-c_" + utils.OcpasswdPath + "_alice"}, recorded())
+		assert.NoFileExists(t, utils.UserConfigFilePathCreator("alice"))
+		var users int64
+		require.NoError(t, db.Model(&commonModels.OcservUser{}).Count(&users).Error)
+		assert.Zero(t, users)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		bob := commonModels.OcservUser{
+			UID: "bob", Owner: "admin", Username: "bob", Password: "secret", Group: "defaults",
+			TrafficType: commonModels.Free, IsLocked: true,
+		}
+		require.NoError(t, db.Create(&bob).Error)
+
+		updated := bob
+		updated.Password, updated.Config = "changed", &commonModels.OcservUserConfig{ExplicitIPv4: &ipv4}
+		updated.Nodes = []commonModels.Node{edge}
+		_, err := repo.Update(context.Background(), &updated, nil)
+		require.ErrorContains(t, err, "node edge")
+
+		// the saved user is written back, with its lock
+		path := utils.OcpasswdPath
+		assert.Equal(t, []string{"-c_" + path + "_bob", "-c_" + path + "_bob", "-l_-c_" + path + "_bob"}, recorded())
+		config, err := os.ReadFile(utils.UserConfigFilePathCreator("bob"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(config), ipv4)
+		var saved commonModels.OcservUser
+		require.NoError(t, db.Preload("Nodes").First(&saved, bob.ID).Error)
+		assert.Equal(t, "secret", saved.Password)
+		assert.Empty(t, saved.Nodes)
+	})
+
+	t.Run("group", func(t *testing.T) {
+		groups := repository.NewOcservGroupRepository()
+		_, err := groups.Create(context.Background(), &commonModels.OcservGroup{
+			Name: "vip", Owner: "admin", Config: &commonModels.OcservGroupConfig{ExplicitIPv4: &ipv4},
+			Nodes: []commonModels.Node{edge},
+		})
+		require.ErrorContains(t, err, "node edge")
+		assert.NoFileExists(t, utils.GroupConfigFilePathCreator("vip"))
+	})
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"gorm.io/gorm"
+)
+
+type PlanRepository struct {
+	db *gorm.DB
+}
+
+type PlanCRUD interface {
+	Plans(ctx context.Context, pagination *request.Pagination) ([]models.Plan, int64, error)
+	PlansLookup(ctx context.Context) ([]models.Plan, error)
+	GetByID(ctx context.Context, id uint) (*models.Plan, error)
+	Create(ctx context.Context, plan *models.Plan) (*models.Plan, error)
+	Update(ctx context.Context, plan *models.Plan) (*models.Plan, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type PlanRepositoryInterface interface {
+	PlanCRUD
+}
+
+func NewPlanRepository() *PlanRepository {
+	return &PlanRepository{
+		db: database.GetConnection(),
+	}
+}
+
+func (r *PlanRepository) Plans(ctx context.Context, pagination *request.Pagination) ([]models.Plan, int64, error) {
+	var totalRecords int64
+
+	if err := r.db.WithContext(ctx).Model(&models.Plan{}).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var plans []models.Plan
+	txPaginator := request.Paginator(ctx, r.db, pagination)
+	if err := txPaginator.Model(&plans).Find(&plans).Error; err != nil {
+		return nil, 0, err
+	}
+	return plans, totalRecords, nil
+}
+
+// PlansLookup returns the active plans that users can be provisioned with.
+func (r *PlanRepository) PlansLookup(ctx context.Context) ([]models.Plan, error) {
+	var plans []models.Plan
+	err := r.db.WithContext(ctx).Where("is_active = ?", true).Order("name").Find(&plans).Error
+	if err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (r *PlanRepository) GetByID(ctx context.Context, id uint) (*models.Plan, error) {
+	var plan models.Plan
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *PlanRepository) Create(ctx context.Context, plan *models.Plan) (*models.Plan, error) {
+	err := r.db.WithContext(ctx).Create(plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (r *PlanRepository) Update(ctx context.Context, plan *models.Plan) (*models.Plan, error) {
+	err := r.db.WithContext(ctx).Save(plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Delete removes the plan. Users provisioned with it keep their settings.
+func (r *PlanRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var plan models.Plan
+		if err := tx.Where("id = ?", id).First(&plan).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OcservUser{}).Where("plan_id = ?", plan.ID).Update("plan_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&plan).Error
+	})
+}
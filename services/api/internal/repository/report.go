@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	apiModels "github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/report"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReportRepository stores report schedules and generated usage reports. Its methods take the owner
+// the caller is limited to, empty for admins who see every schedule and report.
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+type ReportScheduleCRUD interface {
+	Schedules(ctx context.Context, owner string, pagination *request.Pagination) ([]models.ReportSchedule, int64, error)
+	GetSchedule(ctx context.Context, id uint, owner string) (*models.ReportSchedule, error)
+	CreateSchedule(ctx context.Context, schedule *models.ReportSchedule) (*models.ReportSchedule, error)
+	UpdateSchedule(ctx context.Context, schedule *models.ReportSchedule) (*models.ReportSchedule, error)
+	DeleteSchedule(ctx context.Context, id uint, owner string) error
+}
+
+type UsageReports interface {
+	Reports(ctx context.Context, owner string, scheduleID *uint, pagination *request.Pagination) ([]models.UsageReport, int64, error)
+	GetReport(ctx context.Context, id uint, owner string) (*models.UsageReport, error)
+	DeleteReport(ctx context.Context, id uint, owner string) error
+	Export(ctx context.Context, owner, format string, dateStart, dateEnd time.Time) (*models.UsageReport, error)
+}
+
+type ReportRepositoryInterface interface {
+	ReportScheduleCRUD
+	UsageReports
+}
+
+func NewReportRepository() *ReportRepository {
+	return &ReportRepository{
+		db: database.GetConnection(),
+	}
+}
+
+// ownedReports limits the query to the schedules or reports of owner, unless owner is empty.
+func ownedReports(db *gorm.DB, owner string) *gorm.DB {
+	if owner == "" {
+		return db
+	}
+	return db.Where("owner = ?", owner)
+}
+
+func (r *ReportRepository) Schedules(ctx context.Context, owner string, pagination *request.Pagination) ([]models.ReportSchedule, int64, error) {
+	var totalRecords int64
+
+	err := ownedReports(r.db.WithContext(ctx).Model(&models.ReportSchedule{}), owner).Count(&totalRecords).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var schedules []models.ReportSchedule
+	txPaginator := ownedReports(request.Paginator(ctx, r.db, pagination), owner)
+	if err = txPaginator.Model(&schedules).Find(&schedules).Error; err != nil {
+		return nil, 0, err
+	}
+	return schedules, totalRecords, nil
+}
+
+func (r *ReportRepository) GetSchedule(ctx context.Context, id uint, owner string) (*models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	err := ownedReports(r.db.WithContext(ctx), owner).Where("id = ?", id).First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *ReportRepository) CreateSchedule(ctx context.Context, schedule *models.ReportSchedule) (*models.ReportSchedule, error) {
+	if schedule.Owner != "" {
+		var count int64
+		err := r.db.WithContext(ctx).Model(&apiModels.User{}).Where("username = ?", schedule.Owner).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errors.New("report owner not found")
+		}
+	}
+
+	if err := r.db.WithContext(ctx).Create(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (r *ReportRepository) UpdateSchedule(ctx context.Context, schedule *models.ReportSchedule) (*models.ReportSchedule, error) {
+	if err := r.db.WithContext(ctx).Save(schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule deletes the schedule. The reports it generated are kept for download.
+func (r *ReportRepository) DeleteSchedule(ctx context.Context, id uint, owner string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := ownedReports(tx, owner).Where("id = ?", id).Delete(&models.ReportSchedule{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.UsageReport{}).Where("schedule_id = ?", id).Update("schedule_id", nil).Error
+	})
+}
+
+// Reports returns the stored reports without their content, optionally only those of a schedule.
+func (r *ReportRepository) Reports(ctx context.Context, owner string, scheduleID *uint, pagination *request.Pagination) ([]models.UsageReport, int64, error) {
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = ownedReports(db, owner)
+		if scheduleID != nil {
+			db = db.Where("schedule_id = ?", *scheduleID)
+		}
+		return db
+	}
+
+	var totalRecords int64
+	if err := filter(r.db.WithContext(ctx).Model(&models.UsageReport{})).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reports []models.UsageReport
+	txPaginator := filter(request.Paginator(ctx, r.db, pagination))
+	if err := txPaginator.Model(&reports).Omit("content").Find(&reports).Error; err != nil {
+		return nil, 0, err
+	}
+	return reports, totalRecords, nil
+}
+
+// GetReport returns the stored report with its content.
+func (r *ReportRepository) GetReport(ctx context.Context, id uint, owner string) (*models.UsageReport, error) {
+	var usageReport models.UsageReport
+	err := ownedReports(r.db.WithContext(ctx), owner).Where("id = ?", id).First(&usageReport).Error
+	if err != nil {
+		return nil, err
+	}
+	return &usageReport, nil
+}
+
+func (r *ReportRepository) DeleteReport(ctx context.Context, id uint, owner string) error {
+	result := ownedReports(r.db.WithContext(ctx), owner).Where("id = ?", id).Delete(&models.UsageReport{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Export builds the report of owner over the range without storing it.
+func (r *ReportRepository) Export(ctx context.Context, owner, format string, dateStart, dateEnd time.Time) (*models.UsageReport, error) {
+	return report.Build(ctx, r.db, owner, format, dateStart, dateEnd)
+}
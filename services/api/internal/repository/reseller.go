@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"gorm.io/gorm"
+)
+
+type ResellerGroupUsage struct {
+	Group string `json:"group" validate:"required"`
+	Users int64  `json:"users" validate:"required"`
+}
+
+type ResellerUsage struct {
+	Reseller         models.Reseller      `json:"reseller" validate:"required"`
+	Users            int64                `json:"users" validate:"required"`
+	RemainingUsers   int64                `json:"remaining_users" validate:"required"`
+	Traffic          int64                `json:"traffic" validate:"required"` // allocated GiB
+	RemainingTraffic int64                `json:"remaining_traffic" validate:"required"`
+	Rx               float64              `json:"rx" validate:"required"` // consumed GiB
+	Tx               float64              `json:"tx" validate:"required"` // consumed GiB
+	Groups           []ResellerGroupUsage `json:"groups" validate:"omitempty"`
+}
+
+// ResellerAllocation is quota allocated to users that are not saved yet, such as the previous rows of
+// an import preview.
+type ResellerAllocation struct {
+	Users   int64
+	Traffic int64 // GiB
+}
+
+// Add allocates the quota of the ocserv user.
+func (a *ResellerAllocation) Add(ocservUser *commonModels.OcservUser) {
+	a.Users++
+	a.Traffic += trafficAllocation(ocservUser.TrafficType, ocservUser.TrafficSize)
+}
+
+type ResellerRepository struct {
+	db *gorm.DB
+}
+
+type ResellerCRUD interface {
+	Resellers(ctx context.Context, pagination *request.Pagination) ([]models.Reseller, int64, error)
+	GetByUserID(ctx context.Context, userID uint) (*models.Reseller, error)
+	Save(ctx context.Context, reseller *models.Reseller) (*models.Reseller, error)
+	Delete(ctx context.Context, userID uint) error
+}
+
+type ResellerQuota interface {
+	Check(ctx context.Context, owner string, ocservUser *commonModels.OcservUser) error
+	CheckPending(ctx context.Context, owner string, ocservUser *commonModels.OcservUser, pending ResellerAllocation) error
+	Usage(ctx context.Context, reseller *models.Reseller) (*ResellerUsage, error)
+	TopUp(ctx context.Context, reseller *models.Reseller, topUp *models.ResellerTopUp) (*models.Reseller, error)
+	TopUps(ctx context.Context, resellerID uint, pagination *request.Pagination) ([]models.ResellerTopUp, int64, error)
+}
+
+type ResellerRepositoryInterface interface {
+	ResellerCRUD
+	ResellerQuota
+}
+
+func NewResellerRepository() *ResellerRepository {
+	return &ResellerRepository{
+		db: database.GetConnection(),
+	}
+}
+
+func (r *ResellerRepository) Resellers(ctx context.Context, pagination *request.Pagination) ([]models.Reseller, int64, error) {
+	var totalRecords int64
+
+	if err := r.db.WithContext(ctx).Model(&models.Reseller{}).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var resellers []models.Reseller
+	txPaginator := request.Paginator(ctx, r.db, pagination)
+	if err := txPaginator.Model(&resellers).Preload("User").Find(&resellers).Error; err != nil {
+		return nil, 0, err
+	}
+	return resellers, totalRecords, nil
+}
+
+func (r *ResellerRepository) GetByUserID(ctx context.Context, userID uint) (*models.Reseller, error) {
+	var reseller models.Reseller
+	err := r.db.WithContext(ctx).Preload("User").Where("user_id = ?", userID).First(&reseller).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reseller, nil
+}
+
+func (r *ResellerRepository) Save(ctx context.Context, reseller *models.Reseller) (*models.Reseller, error) {
+	err := r.db.WithContext(ctx).Omit("User").Save(reseller).Error
+	if err != nil {
+		return nil, err
+	}
+	return reseller, nil
+}
+
+// Delete removes the quotas and top-up ledger of the staff user, making it an unlimited staff again.
+func (r *ResellerRepository) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var reseller models.Reseller
+		if err := tx.Where("user_id = ?", userID).First(&reseller).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("reseller_id = ?", reseller.ID).Delete(&models.ResellerTopUp{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&reseller).Error
+	})
+}
+
+// Check validates the ocserv user against the quotas of its owner, see CheckResellerQuota. The writes
+// of ocserv users check again in their own transaction, this serves the checks done ahead of them.
+func (r *ResellerRepository) Check(ctx context.Context, owner string, ocservUser *commonModels.OcservUser) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return CheckResellerQuota(tx, owner, ocservUser)
+	})
+}
+
+// CheckPending is Check counting the pending allocation in as if it was saved.
+func (r *ResellerRepository) CheckPending(
+	ctx context.Context, owner string, ocservUser *commonModels.OcservUser, pending ResellerAllocation,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return checkResellerQuota(tx, owner, ocservUser, pending)
+	})
+}
+
+// CheckResellerQuota validates the ocserv user against the quotas of its owner. Owners that are not
+// resellers pass. The user count is checked on creation only, and the traffic pool only when the
+// allocation grows, so that lowering a quota does not block unrelated edits of existing users.
+//
+// The reseller row is locked first, so that a check and the write of the user done in the same
+// transaction cannot interleave with those of another request of the reseller.
+func CheckResellerQuota(tx *gorm.DB, owner string, ocservUser *commonModels.OcservUser) error {
+	return checkResellerQuota(tx, owner, ocservUser, ResellerAllocation{})
+}
+
+func checkResellerQuota(tx *gorm.DB, owner string, ocservUser *commonModels.OcservUser, pending ResellerAllocation) error {
+	result := tx.Model(&models.Reseller{}).
+		Where("user_id = (SELECT id FROM users WHERE username = ?)", owner).
+		UpdateColumn("updated_at", gorm.Expr("updated_at"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	var reseller models.Reseller
+	err := tx.Joins("JOIN users ON users.id = resellers.user_id").
+		Where("users.username = ?", owner).
+		First(&reseller).Error
+	if err != nil {
+		return err
+	}
+
+	if !reseller.AllowsGroup(ocservUser.Group) {
+		return fmt.Errorf("group %s is not allowed for reseller %s", ocservUser.Group, owner)
+	}
+	if !reseller.AllowsTrafficType(ocservUser.TrafficType) {
+		return fmt.Errorf("traffic type %s is not allowed for reseller %s", ocservUser.TrafficType, owner)
+	}
+
+	var allocated struct {
+		Users   int64
+		Traffic int64
+	}
+	err = tx.Model(&commonModels.OcservUser{}).
+		Select("COUNT(*) AS users, COALESCE(SUM(CASE WHEN traffic_type <> ? THEN traffic_size ELSE 0 END), 0) AS traffic", commonModels.Free).
+		Where("owner = ? AND id <> ?", owner, ocservUser.ID).
+		Scan(&allocated).Error
+	if err != nil {
+		return err
+	}
+	allocated.Users += pending.Users
+	allocated.Traffic += pending.Traffic
+
+	if ocservUser.ID == 0 && allocated.Users+1 > int64(reseller.MaxUsers) {
+		return fmt.Errorf("reseller %s reached the maximum of %d users", owner, reseller.MaxUsers)
+	}
+
+	size := trafficAllocation(ocservUser.TrafficType, ocservUser.TrafficSize)
+	if allocated.Traffic+size <= int64(reseller.TrafficPool) {
+		return nil
+	}
+
+	if ocservUser.ID != 0 {
+		var previous commonModels.OcservUser
+		if err = tx.Select("traffic_type", "traffic_size").Where("id = ?", ocservUser.ID).First(&previous).Error; err != nil {
+			return err
+		}
+		if size <= trafficAllocation(previous.TrafficType, previous.TrafficSize) {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"reseller %s traffic pool exceeded: %d GiB of %d GiB allocated",
+		owner, allocated.Traffic+size, reseller.TrafficPool,
+	)
+}
+
+func (r *ResellerRepository) Usage(ctx context.Context, reseller *models.Reseller) (*ResellerUsage, error) {
+	owner := reseller.User.Username
+	usage := &ResellerUsage{Reseller: *reseller}
+
+	var totals struct {
+		Users   int64
+		Traffic int64
+		Rx      float64
+		Tx      float64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&commonModels.OcservUser{}).
+		Select(`
+		COUNT(*) AS users,
+		COALESCE(SUM(CASE WHEN traffic_type <> ? THEN traffic_size ELSE 0 END), 0) AS traffic,
+		COALESCE(SUM(rx), 0) / 1073741824.0 AS rx,
+		COALESCE(SUM(tx), 0) / 1073741824.0 AS tx`, commonModels.Free).
+		Where("owner = ?", owner).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Model(&commonModels.OcservUser{}).
+		Select("`group`, COUNT(*) AS users").
+		Where("owner = ?", owner).
+		Group("`group`").
+		Order("`group`").
+		Scan(&usage.Groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	usage.Users = totals.Users
+	usage.Traffic = totals.Traffic
+	usage.Rx = totals.Rx
+	usage.Tx = totals.Tx
+	usage.RemainingUsers = max(int64(reseller.MaxUsers)-totals.Users, 0)
+	usage.RemainingTraffic = max(int64(reseller.TrafficPool)-totals.Traffic, 0)
+	return usage, nil
+}
+
+// TopUp adds the users and traffic of the top-up to the reseller quotas and records it in the ledger.
+func (r *ResellerRepository) TopUp(ctx context.Context, reseller *models.Reseller, topUp *models.ResellerTopUp) (*models.Reseller, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		topUp.ResellerID = reseller.ID
+		if err := tx.Create(topUp).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Reseller{}).
+			Where("id = ?", reseller.ID).
+			Updates(map[string]interface{}{
+				"max_users":    gorm.Expr("max_users + ?", topUp.Users),
+				"traffic_pool": gorm.Expr("traffic_pool + ?", topUp.Traffic),
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Preload("User").Where("id = ?", reseller.ID).First(reseller).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reseller, nil
+}
+
+func (r *ResellerRepository) TopUps(ctx context.Context, resellerID uint, pagination *request.Pagination) ([]models.ResellerTopUp, int64, error) {
+	var totalRecords int64
+
+	if err := r.db.WithContext(ctx).Model(&models.ResellerTopUp{}).Where("reseller_id = ?", resellerID).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var topUps []models.ResellerTopUp
+	txPaginator := request.Paginator(ctx, r.db, pagination)
+	if err := txPaginator.Model(&topUps).Where("reseller_id = ?", resellerID).Find(&topUps).Error; err != nil {
+		return nil, 0, err
+	}
+	return topUps, totalRecords, nil
+}
+
+// trafficAllocation returns the GiB a user takes from a traffic pool. Free users take none.
+func trafficAllocation(trafficType string, trafficSize int) int64 {
+	if trafficType == commonModels.Free {
+		return 0
+	}
+	return int64(trafficSize)
+}
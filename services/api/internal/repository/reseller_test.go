@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"sync"
+	"testing"
+)
+
+func setupReseller(t *testing.T, reseller models.Reseller) *gorm.DB {
+	db := setupDB(t, &models.User{}, &models.Reseller{}, &commonModels.Node{}, &commonModels.OcservUser{})
+	db = db.Session(&gorm.Session{SkipHooks: true})
+
+	for i, username := range []string{"reseller", "staff"} {
+		user := models.User{UID: fmt.Sprintf("uid-%d", i), Username: username, Salt: "salt"}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		if username == "reseller" {
+			reseller.UserID = user.ID
+			if reseller.AllowedGroups == nil {
+				reseller.AllowedGroups = &commonModels.CSVStringList{}
+			}
+			reseller.AllowedTrafficTypes = &commonModels.CSVStringList{}
+			if err := db.Create(&reseller).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return db
+}
+
+func createOcservUser(db *gorm.DB, owner, username string, trafficSize int) error {
+	return db.Create(&commonModels.OcservUser{
+		UID:         username,
+		Owner:       owner,
+		Username:    username,
+		Password:    "secret",
+		Group:       "defaults",
+		TrafficType: commonModels.MonthlyTransmit,
+		TrafficSize: trafficSize,
+	}).Error
+}
+
+func TestCheckResellerQuota(t *testing.T) {
+	groups := commonModels.CSVStringList{"defaults"}
+	db := setupReseller(t, models.Reseller{MaxUsers: 2, TrafficPool: 30, AllowedGroups: &groups})
+	repo := repository.NewResellerRepository()
+	ctx := context.Background()
+
+	assert.NoError(t, createOcservUser(db, "reseller", "alice", 20))
+
+	tests := []struct {
+		name  string
+		owner string
+		user  commonModels.OcservUser
+		err   string
+	}{
+		{
+			name:  "not a reseller",
+			owner: "staff",
+			user:  commonModels.OcservUser{Group: "vip", TrafficType: commonModels.MonthlyTransmit, TrafficSize: 1000},
+		},
+		{
+			name:  "within quotas",
+			owner: "reseller",
+			user:  commonModels.OcservUser{Group: "defaults", TrafficType: commonModels.MonthlyTransmit, TrafficSize: 10},
+		},
+		{
+			name:  "group not allowed",
+			owner: "reseller",
+			user:  commonModels.OcservUser{Group: "vip", TrafficType: commonModels.Free},
+			err:   "group vip is not allowed for reseller reseller",
+		},
+		{
+			name:  "traffic pool exceeded",
+			owner: "reseller",
+			user:  commonModels.OcservUser{Group: "defaults", TrafficType: commonModels.MonthlyTransmit, TrafficSize: 11},
+			err:   "reseller reseller traffic pool exceeded: 31 GiB of 30 GiB allocated",
+		},
+		{
+			name:  "free users take no traffic",
+			owner: "reseller",
+			user:  commonModels.OcservUser{Group: "defaults", TrafficType: commonModels.Free, TrafficSize: 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.Check(ctx, tt.owner, &tt.user)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+
+	// the rows of an import preview are not saved but take quota
+	var pending repository.ResellerAllocation
+	pending.Add(&commonModels.OcservUser{TrafficType: commonModels.MonthlyTransmit, TrafficSize: 5})
+	err := repo.CheckPending(ctx, "reseller", &commonModels.OcservUser{Group: "defaults", TrafficType: commonModels.Free}, pending)
+	assert.EqualError(t, err, "reseller reseller reached the maximum of 2 users")
+	err = repo.CheckPending(ctx, "reseller", &commonModels.OcservUser{Group: "defaults", TrafficType: commonModels.Free}, repository.ResellerAllocation{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, createOcservUser(db, "reseller", "bob", 10))
+	err = repo.Check(ctx, "reseller", &commonModels.OcservUser{Group: "defaults", TrafficType: commonModels.Free})
+	assert.EqualError(t, err, "reseller reseller reached the maximum of 2 users")
+
+	// a lowered quota does not block edits that do not grow the allocation
+	assert.NoError(t, db.Model(&models.Reseller{}).Where("1 = 1").Update("traffic_pool", 5).Error)
+	var alice commonModels.OcservUser
+	assert.NoError(t, db.Where("username = ?", "alice").First(&alice).Error)
+	alice.TrafficSize = 15
+	assert.NoError(t, repo.Check(ctx, "reseller", &alice))
+	alice.TrafficSize = 21
+	assert.EqualError(t, repo.Check(ctx, "reseller", &alice), "reseller reseller traffic pool exceeded: 31 GiB of 5 GiB allocated")
+}
+
+func TestCheckResellerQuotaConcurrentCreates(t *testing.T) {
+	db := setupReseller(t, models.Reseller{MaxUsers: 3, TrafficPool: 100})
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = db.Transaction(func(tx *gorm.DB) error {
+				username := fmt.Sprintf("user%d", i)
+				ocservUser := &commonModels.OcservUser{Group: "defaults", TrafficType: commonModels.MonthlyTransmit, TrafficSize: 10}
+				if err := repository.CheckResellerQuota(tx, "reseller", ocservUser); err != nil {
+					return err
+				}
+				return createOcservUser(tx, "reseller", username, 10)
+			})
+		}()
+	}
+	wg.Wait()
+
+	var count int64
+	assert.NoError(t, db.Model(&commonModels.OcservUser{}).Where("owner = ?", "reseller").Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
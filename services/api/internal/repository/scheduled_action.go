@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"gorm.io/gorm"
+)
+
+// ScheduledActionRepository stores the actions scheduled on users. The user expiry service runs them.
+type ScheduledActionRepository struct {
+	db *gorm.DB
+}
+
+type ScheduledActionCRUD interface {
+	Actions(ctx context.Context, userID uint, status string, pagination *request.Pagination) ([]models.ScheduledAction, int64, error)
+	GetAction(ctx context.Context, userID, id uint) (*models.ScheduledAction, error)
+	CreateAction(ctx context.Context, action *models.ScheduledAction) (*models.ScheduledAction, error)
+	UpdateAction(ctx context.Context, action *models.ScheduledAction) (*models.ScheduledAction, error)
+	DeleteAction(ctx context.Context, userID, id uint) error
+}
+
+type ScheduledActionRepositoryInterface interface {
+	ScheduledActionCRUD
+}
+
+func NewScheduledActionRepository() *ScheduledActionRepository {
+	return &ScheduledActionRepository{
+		db: database.GetConnection(),
+	}
+}
+
+// Actions returns the actions scheduled on the user, limited to a status unless it is empty.
+func (r *ScheduledActionRepository) Actions(
+	ctx context.Context, userID uint, status string, pagination *request.Pagination,
+) ([]models.ScheduledAction, int64, error) {
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("oc_user_id = ?", userID)
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		return db
+	}
+
+	var totalRecords int64
+	if err := filter(r.db.WithContext(ctx).Model(&models.ScheduledAction{})).Count(&totalRecords).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var actions []models.ScheduledAction
+	txPaginator := filter(request.Paginator(ctx, r.db, pagination))
+	if err := txPaginator.Model(&actions).Find(&actions).Error; err != nil {
+		return nil, 0, err
+	}
+	return actions, totalRecords, nil
+}
+
+func (r *ScheduledActionRepository) GetAction(ctx context.Context, userID, id uint) (*models.ScheduledAction, error) {
+	var action models.ScheduledAction
+	err := r.db.WithContext(ctx).Where("id = ? AND oc_user_id = ?", id, userID).First(&action).Error
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+func (r *ScheduledActionRepository) CreateAction(ctx context.Context, action *models.ScheduledAction) (*models.ScheduledAction, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkScheduledAction(tx, action); err != nil {
+			return err
+		}
+		return tx.Create(action).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// UpdateAction saves the action, which must still be pending.
+func (r *ScheduledActionRepository) UpdateAction(ctx context.Context, action *models.ScheduledAction) (*models.ScheduledAction, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkScheduledAction(tx, action); err != nil {
+			return err
+		}
+		result := tx.Model(action).
+			Where("status = ?", models.ScheduledPending).
+			Select("action", "group", "plan_id", "run_at").
+			Updates(action)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("only pending actions can be updated")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// DeleteAction cancels a pending action or removes an executed one from the history of the user.
+// Running actions are left to record their result.
+func (r *ScheduledActionRepository) DeleteAction(ctx context.Context, userID, id uint) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND oc_user_id = ? AND status <> ?", id, userID, models.ScheduledRunning).
+		Delete(&models.ScheduledAction{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// checkScheduledAction checks the action and that the group or active plan it targets exists. They are
+// checked again when the action runs, since they may change in between.
+func checkScheduledAction(tx *gorm.DB, action *models.ScheduledAction) error {
+	if err := action.Validate(); err != nil {
+		return err
+	}
+
+	switch action.Action {
+	case models.ScheduledChangeGroup:
+		action.PlanID = nil
+		return groupExists(tx, action.Group)
+	case models.ScheduledChangePlan:
+		action.Group = ""
+		var plan models.Plan
+		if err := tx.Where("id = ?", *action.PlanID).First(&plan).Error; err != nil {
+			return fmt.Errorf("invalid plan: %w", err)
+		}
+		if !plan.IsActive {
+			return errors.New("plan is not active")
+		}
+		return groupExists(tx, plan.Group)
+	default:
+		action.Group = ""
+		action.PlanID = nil
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCreateAction(t *testing.T) {
+	db := setupDB(t, &commonModels.OcservGroup{}, &commonModels.Plan{}, &commonModels.ScheduledAction{})
+	assert.NoError(t, db.Create(&commonModels.OcservGroup{Name: "vip", Owner: "admin"}).Error)
+	assert.NoError(t, db.Create([]commonModels.Plan{
+		{ID: 1, Name: "gold", Group: "vip", TrafficType: commonModels.Free, DurationDays: 30, IsActive: true},
+		{ID: 2, Name: "retired", Group: "vip", TrafficType: commonModels.Free, DurationDays: 30},
+		{ID: 3, Name: "orphan", Group: "removed", TrafficType: commonModels.Free, DurationDays: 30, IsActive: true},
+	}).Error)
+	gold, retired, orphan, missing := uint(1), uint(2), uint(3), uint(9)
+
+	tests := []struct {
+		name   string
+		action commonModels.ScheduledAction
+		err    string
+	}{
+		{name: "change group", action: commonModels.ScheduledAction{Action: commonModels.ScheduledChangeGroup, Group: "vip"}},
+		{name: "change to defaults", action: commonModels.ScheduledAction{Action: commonModels.ScheduledChangeGroup, Group: "defaults"}},
+		{
+			name:   "change to a missing group",
+			action: commonModels.ScheduledAction{Action: commonModels.ScheduledChangeGroup, Group: "removed"},
+			err:    "group removed not found",
+		},
+		{name: "change plan", action: commonModels.ScheduledAction{Action: commonModels.ScheduledChangePlan, PlanID: &gold}},
+		{
+			name:   "change to an inactive plan",
+			action: commonModels.ScheduledAction{Action: commonModels.ScheduledChangePlan, PlanID: &retired},
+			err:    "plan is not active",
+		},
+		{
+			name:   "change to a plan of a missing group",
+			action: commonModels.ScheduledAction{Action: commonModels.ScheduledChangePlan, PlanID: &orphan},
+			err:    "group removed not found",
+		},
+		{
+			name:   "change to a missing plan",
+			action: commonModels.ScheduledAction{Action: commonModels.ScheduledChangePlan, PlanID: &missing},
+			err:    "invalid plan: record not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := tt.action
+			action.OcUserID, action.RunAt, action.CreatedBy = 1, time.Now().Add(time.Hour), "admin"
+			_, err := repository.NewScheduledActionRepository().CreateAction(context.Background(), &action)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// TrafficSeries selects the buckets of a traffic statistics series: the dates it covers, the
+// granularity of its buckets and the time zone they are cut in.
+type TrafficSeries struct {
+	DateStart   *time.Time
+	DateEnd     *time.Time
+	Granularity string
+	Location    *time.Location
+
+	// After and Limit page the buckets, see Page. NextCursor is set to the cursor of the next page of
+	// buckets when there is one.
+	After      *time.Time
+	Limit      int
+	NextCursor string
+}
+
+// NewTrafficSeries parses the YYYY-MM-DD dates of a series in the time zone tz, UTC when empty.
+// The end date is inclusive and the granularity defaults to days. The rollups are cut on whole UTC
+// hours, so in time zones off UTC by a fraction of an hour, such as Asia/Tehran, buckets start on the
+// first whole UTC hour after their label, up to 59 minutes late.
+func NewTrafficSeries(dateStart, dateEnd, granularity, tz string) (*TrafficSeries, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz: %w", err)
+	}
+
+	series := &TrafficSeries{Granularity: granularity, Location: loc}
+	switch granularity {
+	case "":
+		series.Granularity = models.GranularityDay
+	case models.GranularityHour, models.GranularityDay, models.GranularityWeek, models.GranularityMonth:
+	default:
+		return nil, fmt.Errorf("invalid granularity %q", granularity)
+	}
+
+	if dateStart != "" {
+		t, err := time.ParseInLocation("2006-01-02", dateStart, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date_start: %w", err)
+		}
+		series.DateStart = &t
+	}
+	if dateEnd != "" {
+		t, err := time.ParseInLocation("2006-01-02", dateEnd, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date_end: %w", err)
+		}
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		series.DateEnd = &t
+	}
+	if series.DateStart != nil && series.DateEnd != nil && series.DateStart.After(*series.DateEnd) {
+		return nil, errors.New("date start is after end")
+	}
+	return series, nil
+}
+
+// Page limits the series to size buckets, all of them when 0, starting at cursor, a NextCursor of the
+// series, or at the first bucket when cursor is empty.
+func (s *TrafficSeries) Page(cursor string, size int) error {
+	if size < 0 {
+		return errors.New("invalid size")
+	}
+	s.Limit = size
+	if cursor == "" {
+		return nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return errors.New("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339, string(b))
+	if err != nil {
+		return errors.New("invalid cursor")
+	}
+	s.After = &t
+	return nil
+}
+
+// rollup returns the table and granularity of the coarsest rollup the buckets can be summed from.
+// Rollups are cut in UTC, so other time zones are summed from hours, see NewTrafficSeries.
+func (s *TrafficSeries) rollup() (string, string) {
+	if s.Location != time.UTC || s.Granularity == models.GranularityHour {
+		return models.OcservUserTrafficHourly{}.TableName(), models.GranularityHour
+	}
+	if s.Granularity == models.GranularityMonth && monthAligned(s.DateStart, s.DateEnd) {
+		return models.OcservUserTrafficMonthly{}.TableName(), models.GranularityMonth
+	}
+	return models.OcservUserTrafficDaily{}.TableName(), models.GranularityDay
+}
+
+// monthAligned reports whether the range starts and ends on month boundaries.
+func monthAligned(dateStart, dateEnd *time.Time) bool {
+	if dateStart != nil && dateStart.Day() != 1 {
+		return false
+	}
+	return dateEnd == nil || dateEnd.Add(time.Nanosecond).Day() == 1
+}
+
+// trafficSeries sums the rollup rows matching filter into the buckets of the series. Rollup rows
+// are aliased as t.
+func trafficSeries(db *gorm.DB, series *TrafficSeries, filter func(*gorm.DB) *gorm.DB) ([]models.DailyTraffic, error) {
+	table, granularity := series.rollup()
+
+	query := db.Table(table + " AS t").Select("t.start AS start, SUM(t.rx) AS rx, SUM(t.tx) AS tx")
+	if series.DateStart != nil {
+		query = query.Where("t.start >= ?", models.BucketStart(series.DateStart.UTC(), granularity))
+	}
+	if series.DateEnd != nil {
+		query = query.Where("t.start <= ?", series.DateEnd.UTC())
+	}
+	if filter != nil {
+		query = filter(query)
+	}
+
+	if series.After != nil {
+		query = query.Where("t.start >= ?", series.After.UTC())
+	}
+
+	rows, err := query.Group("t.start").Order("t.start").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	results := []models.DailyTraffic{}
+	for rows.Next() {
+		var row struct {
+			Start time.Time
+			Rx    int64
+			Tx    int64
+		}
+		if err = query.ScanRows(rows, &row); err != nil {
+			return nil, err
+		}
+
+		start := models.BucketStart(row.Start.In(series.Location), series.Granularity)
+		label := models.BucketLabel(start, series.Granularity)
+		if n := len(results); n == 0 || results[n-1].Date != label {
+			if series.Limit > 0 && n == series.Limit {
+				series.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(start.UTC().Format(time.RFC3339)))
+				break
+			}
+			results = append(results, models.DailyTraffic{Date: label})
+		}
+		last := &results[len(results)-1]
+		last.Rx += float64(row.Rx) / (1 << 30)
+		last.Tx += float64(row.Tx) / (1 << 30)
+	}
+	return results, rows.Err()
+}
+
+// trafficRollup returns a query on the rollup covering the range: the hourly rollup for a date range,
+// the monthly rollup for all time. Rollup rows are aliased as t.
+func trafficRollup(db *gorm.DB, dateStart, dateEnd *time.Time) *gorm.DB {
+	if dateStart == nil && dateEnd == nil {
+		return db.Table(models.OcservUserTrafficMonthly{}.TableName() + " AS t")
+	}
+
+	query := db.Table(models.OcservUserTrafficHourly{}.TableName() + " AS t")
+	if dateStart != nil {
+		query = query.Where("t.start >= ?", models.BucketStart(dateStart.UTC(), models.GranularityHour))
+	}
+	if dateEnd != nil {
+		query = query.Where("t.start <= ?", dateEnd.UTC())
+	}
+	return query
+}
+
+// trafficTotals sums the rx and tx of the rollup query in GiB.
+func trafficTotals(query *gorm.DB) (TotalBandwidths, error) {
+	var total TotalBandwidths
+	err := query.Select(`
+		COALESCE(SUM(t.rx),0) / 1073741824.0 AS rx,
+		COALESCE(SUM(t.tx),0) / 1073741824.0 AS tx`).
+		Scan(&total).Error
+	return total, err
+}
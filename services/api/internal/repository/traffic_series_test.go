@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestNewTrafficSeries(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		dateStart   string
+		dateEnd     string
+		granularity string
+		tz          string
+		start       time.Time
+		end         time.Time
+		want        string
+		err         string
+	}{
+		{
+			name:      "days in utc by default",
+			dateStart: "2025-01-01",
+			dateEnd:   "2025-01-31",
+			start:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond),
+			want:      models.GranularityDay,
+		},
+		{
+			name:        "dates in the time zone",
+			dateStart:   "2025-01-01",
+			dateEnd:     "2025-01-01",
+			granularity: models.GranularityHour,
+			tz:          "Europe/Berlin",
+			start:       time.Date(2025, 1, 1, 0, 0, 0, 0, berlin),
+			end:         time.Date(2025, 1, 2, 0, 0, 0, 0, berlin).Add(-time.Nanosecond),
+			want:        models.GranularityHour,
+		},
+		{name: "open range", granularity: models.GranularityMonth, want: models.GranularityMonth},
+		{name: "invalid time zone", tz: "Mars/Olympus", err: "invalid tz"},
+		{name: "invalid granularity", granularity: "year", err: `invalid granularity "year"`},
+		{name: "invalid start", dateStart: "01/01/2025", err: "invalid date_start"},
+		{name: "invalid end", dateEnd: "2025-02-30", err: "invalid date_end"},
+		{name: "start after end", dateStart: "2025-02-01", dateEnd: "2025-01-31", err: "date start is after end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := repository.NewTrafficSeries(tt.dateStart, tt.dateEnd, tt.granularity, tt.tz)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, series.Granularity)
+			if tt.dateStart == "" {
+				assert.Nil(t, series.DateStart)
+			} else {
+				require.NotNil(t, series.DateStart)
+				assert.True(t, tt.start.Equal(*series.DateStart), series.DateStart)
+			}
+			if tt.dateEnd == "" {
+				assert.Nil(t, series.DateEnd)
+			} else {
+				require.NotNil(t, series.DateEnd)
+				assert.True(t, tt.end.Equal(*series.DateEnd), series.DateEnd)
+			}
+		})
+	}
+}
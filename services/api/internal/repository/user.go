@@ -85,18 +85,21 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) (*mo
 	return user, nil
 }
 
-func (r *UserRepository) Users(ctx context.Context, pagination *request.Pagination) ([]models.User, int64, error) {
-	var totalRecords int64
-
-	whereFilters := "is_admin = false"
-
-	if err := r.db.WithContext(ctx).Model(&models.User{}).Where(whereFilters).Count(&totalRecords).Error; err != nil {
-		return nil, 0, err
-	}
+// staffsKeyset lists the orders of the staff list supporting cursor pagination.
+var staffsKeyset = request.OrderBy{
+	"id":         "id",
+	"username":   "username",
+	"created_at": "created_at",
+}
 
+func (r *UserRepository) Users(ctx context.Context, pagination *request.Pagination) ([]models.User, int64, error) {
 	var staffs []models.User
-	txPaginator := request.Paginator(ctx, r.db, pagination)
-	err := txPaginator.Model(&staffs).Where(whereFilters).Find(&staffs).Error
+
+	totalRecords, err := request.Paginate(ctx, r.db, pagination, nil, staffsKeyset, &staffs,
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_admin = false")
+		},
+	)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -126,11 +129,25 @@ func (r *UserRepository) DeleteUser(ctx context.Context, uid string) error {
 		return err
 	}
 
-	err = r.db.WithContext(ctx).Delete(&user).Error
-	if err != nil {
-		return err
-	}
-	return nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err2 := tx.Where("user_id = ?", user.ID).Delete(&models.OcservUserOwner{}).Error; err2 != nil {
+			return err2
+		}
+
+		var resellerIDs []uint
+		if err2 := tx.Model(&models.Reseller{}).Where("user_id = ?", user.ID).Pluck("id", &resellerIDs).Error; err2 != nil {
+			return err2
+		}
+		if len(resellerIDs) > 0 {
+			if err2 := tx.Where("reseller_id IN ?", resellerIDs).Delete(&models.ResellerTopUp{}).Error; err2 != nil {
+				return err2
+			}
+			if err2 := tx.Where("id IN ?", resellerIDs).Delete(&models.Reseller{}).Error; err2 != nil {
+				return err2
+			}
+		}
+		return tx.Delete(&user).Error
+	})
 }
 
 func (r *UserRepository) GetByUID(ctx context.Context, uid string) (*models.User, error) {
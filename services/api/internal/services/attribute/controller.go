@@ -0,0 +1,99 @@
+package attribute
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"net/http"
+)
+
+type Controller struct {
+	request   request.CustomRequestInterface
+	labelRepo repository.LabelRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:   request.NewCustomRequest(),
+		labelRepo: repository.NewLabelRepository(),
+	}
+}
+
+// Attributes 	 List of custom attributes
+//
+// @Summary      List of custom attributes
+// @Description  List of the custom attributes users and groups can be given, with the type of their values
+// @Tags         Ocserv(Attributes)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  []models.AttributeDefinition
+// @Router       /ocserv/attributes [get]
+func (ctl *Controller) Attributes(c echo.Context) error {
+	definitions, err := ctl.labelRepo.Definitions(c.Request().Context())
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, definitions)
+}
+
+// CreateAttribute 	 Custom attribute creation
+//
+// @Summary      Custom attribute creation
+// @Description  Define a custom attribute of users and groups. Its values must be of the given type.
+// @Tags         Ocserv(Attributes)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  CreateAttributeData  true "attribute data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      201  {object} models.AttributeDefinition
+// @Router       /ocserv/attributes [post]
+func (ctl *Controller) CreateAttribute(c echo.Context) error {
+	var data CreateAttributeData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	definition, err := ctl.labelRepo.CreateDefinition(c.Request().Context(), &models.AttributeDefinition{
+		Key:         data.Key,
+		Type:        data.Type,
+		Description: data.Description,
+	})
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, definition)
+}
+
+// DeleteAttribute 	 Custom attribute delete
+//
+// @Summary      Custom attribute delete
+// @Description  Delete a custom attribute with its values on every user and group
+// @Tags         Ocserv(Attributes)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 key path string true "Attribute key"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      204  {object} nil
+// @Router       /ocserv/attributes/{key} [delete]
+func (ctl *Controller) DeleteAttribute(c echo.Context) error {
+	key := c.Param("key")
+	if key == "" {
+		return ctl.request.BadRequest(c, errors.New("attribute key is required"))
+	}
+
+	if err := ctl.labelRepo.DeleteDefinition(c.Request().Context(), key); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,14 @@
+package attribute
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/ocserv/attributes", middlewares.AuthMiddleware())
+	g.GET("", ctl.Attributes)
+	g.POST("", ctl.CreateAttribute, middlewares.AdminPermission())
+	g.DELETE("/:key", ctl.DeleteAttribute, middlewares.AdminPermission())
+}
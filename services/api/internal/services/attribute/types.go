@@ -0,0 +1,7 @@
+package attribute
+
+type CreateAttributeData struct {
+	Key         string `json:"key" validate:"required,min=1,max=32" example:"department"` // lowercase letters, digits and _
+	Type        string `json:"type" validate:"required,oneof=string number bool date" example:"string"`
+	Description string `json:"description" validate:"omitempty,max=1024" example:"Department of the user"`
+}
@@ -0,0 +1,247 @@
+package config_version
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/ocservconf"
+	"net/http"
+	"strconv"
+)
+
+type Controller struct {
+	request           request.CustomRequestInterface
+	configVersionRepo repository.ConfigVersionRepositoryInterface
+	ocservGroupRepo   repository.OcservGroupRepositoryInterface
+	ocservUserRepo    repository.OcservUserRepositoryInterface
+	ipamRepo          repository.IPAMRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:           request.NewCustomRequest(),
+		configVersionRepo: repository.NewConfigVersionRepository(),
+		ocservGroupRepo:   repository.NewOcservGroupRepository(),
+		ocservUserRepo:    repository.NewtOcservUserRepository(),
+		ipamRepo:          repository.NewIPAMRepository(),
+	}
+}
+
+// kindParam validates the kind path param.
+func kindParam(c echo.Context) (string, error) {
+	switch kind := c.Param("kind"); kind {
+	case models.ConfigKindGroup, models.ConfigKindDefaults, models.ConfigKindUser:
+		return kind, nil
+	default:
+		return "", errors.New("kind must be group, defaults or user")
+	}
+}
+
+// ConfigVersions 	 List of config versions
+//
+// @Summary      List of config versions
+// @Description  Versions of a group, defaults group or user config, newest first. The defaults group is named defaults.
+// @Tags         Config Versions
+// @Accept       json
+// @Produce      json
+// @Param 		 kind path string true "config kind" Enums(group, defaults, user)
+// @Param 		 name path string true "group name or username"
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  ConfigVersionsResponse
+// @Router       /config-versions/{kind}/{name} [get]
+func (ctl *Controller) ConfigVersions(c echo.Context) error {
+	kind, err := kindParam(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	pagination := ctl.request.Pagination(c)
+
+	versions, total, err := ctl.configVersionRepo.Versions(c.Request().Context(), kind, c.Param("name"), pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ConfigVersionsResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: versions,
+	})
+}
+
+// ConfigVersion 	 Config version detail
+//
+// @Summary      Config version detail
+// @Description  Config version detail with the rendered config file and its diff against the previous version
+// @Tags         Config Versions
+// @Accept       json
+// @Produce      json
+// @Param 		 kind path string true "config kind" Enums(group, defaults, user)
+// @Param 		 name path string true "group name or username"
+// @Param 		 version path int true "version number"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  models.ConfigVersion
+// @Router       /config-versions/{kind}/{name}/{version} [get]
+func (ctl *Controller) ConfigVersion(c echo.Context) error {
+	version, err := ctl.version(c, c.Param("version"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, version)
+}
+
+// DiffConfigVersions 	 Diff two config versions
+//
+// @Summary      Diff two config versions
+// @Description  Unified diff between the config files of two versions
+// @Tags         Config Versions
+// @Accept       json
+// @Produce      json
+// @Param 		 kind path string true "config kind" Enums(group, defaults, user)
+// @Param 		 name path string true "group name or username"
+// @Param 		 from query int true "from version"
+// @Param 		 to query int true "to version"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  ConfigVersionDiffResponse
+// @Router       /config-versions/{kind}/{name}/diff [get]
+func (ctl *Controller) DiffConfigVersions(c echo.Context) error {
+	from, err := ctl.version(c, c.QueryParam("from"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	to, err := ctl.version(c, c.QueryParam("to"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ConfigVersionDiffResponse{
+		From: from.Version,
+		To:   to.Version,
+		Diff: ocservconf.Diff(
+			[]byte(from.Content), []byte(to.Content),
+			fmt.Sprintf("v%d", from.Version), fmt.Sprintf("v%d", to.Version),
+		),
+	})
+}
+
+// RollbackConfigVersion 	 Roll back to a config version
+//
+// @Summary      Roll back to a config version
+// @Description  Rewrite the group, defaults group or user config with the given version and reload ocserv.
+// @Description  The rollback is recorded as a new version.
+// @Tags         Config Versions
+// @Accept       json
+// @Produce      json
+// @Param 		 kind path string true "config kind" Enums(group, defaults, user)
+// @Param 		 name path string true "group name or username"
+// @Param 		 version path int true "version number"
+// @Param        request    body  RollbackConfigVersionData  false "rollback note"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  models.ConfigVersion
+// @Router       /config-versions/{kind}/{name}/{version}/rollback [post]
+func (ctl *Controller) RollbackConfigVersion(c echo.Context) error {
+	var data RollbackConfigVersionData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	target, err := ctl.version(c, c.Param("version"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ctx := c.Request().Context()
+	name := c.Param("name")
+	note := data.Note
+	if note == "" {
+		note = fmt.Sprintf("rollback to v%d", target.Version)
+	}
+
+	var previous, current interface{}
+	switch target.Kind {
+	case models.ConfigKindGroup:
+		var config *models.OcservGroupConfig
+		if err = json.Unmarshal([]byte(target.Config), &config); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+		ocservGroup, err := ctl.ocservGroupRepo.GetByName(ctx, name)
+		if err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+		previous, current = ocservGroup.Config, config
+		ocservGroup.Config = config
+		if _, err = ctl.ocservGroupRepo.Update(ctx, ocservGroup); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+	case models.ConfigKindDefaults:
+		var config *models.OcservGroupConfig
+		if err = json.Unmarshal([]byte(target.Config), &config); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+		previous, _ = ctl.ocservGroupRepo.DefaultGroup()
+		current = config
+		if err = ctl.ocservGroupRepo.UpdateDefaultGroup(config); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+	case models.ConfigKindUser:
+		var config *models.OcservUserConfig
+		if err = json.Unmarshal([]byte(target.Config), &config); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+		ocservUser, err := ctl.ocservUserRepo.GetByUsername(ctx, name)
+		if err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+		previous, current = ocservUser.Config, config
+		ocservUser.Config = config
+		// the restored explicit addresses are checked against the pools and the other users
+		err = ctl.ipamRepo.SaveUser(ctx, ocservUser, func() error {
+			_, err2 := ctl.ocservUserRepo.Update(ctx, ocservUser, nil)
+			return err2
+		})
+		if err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+	}
+
+	version, err := ctl.configVersionRepo.Record(ctx, target.Kind, name, previous, current, c.Get("username").(string), note)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, version)
+}
+
+// version loads the version with the given number of the config in the path.
+func (ctl *Controller) version(c echo.Context, number string) (*models.ConfigVersion, error) {
+	kind, err := kindParam(c)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return nil, errors.New("invalid version")
+	}
+	return ctl.configVersionRepo.GetVersion(c.Request().Context(), kind, c.Param("name"), n)
+}
@@ -0,0 +1,16 @@
+package config_version
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/config-versions", middlewares.AuthMiddleware(), middlewares.AdminPermission())
+
+	g.GET("/:kind/:name", ctl.ConfigVersions)
+	g.GET("/:kind/:name/diff", ctl.DiffConfigVersions)
+	g.GET("/:kind/:name/:version", ctl.ConfigVersion)
+	g.POST("/:kind/:name/:version/rollback", ctl.RollbackConfigVersion)
+}
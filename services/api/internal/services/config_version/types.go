@@ -0,0 +1,21 @@
+package config_version
+
+import (
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+)
+
+type ConfigVersionsResponse struct {
+	Meta   request.Meta           `json:"meta" validate:"required"`
+	Result []models.ConfigVersion `json:"result" validate:"omitempty"`
+}
+
+type ConfigVersionDiffResponse struct {
+	From int    `json:"from" validate:"required"`
+	To   int    `json:"to" validate:"required"`
+	Diff string `json:"diff" validate:"required"`
+}
+
+type RollbackConfigVersionData struct {
+	Note string `json:"note" validate:"omitempty,max=1024"`
+}
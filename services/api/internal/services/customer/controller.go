@@ -50,9 +50,22 @@ func (ctl *Controller) Summary(c echo.Context) error {
 		return ctl.request.BadRequest(c, errors.New("invalid username or password"))
 	}
 
+	traffic, err := ctl.ocservUserRepo.TrafficUsage(c.Request().Context(), user)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	// the usage covers the current period of the traffic policy, or the current and previous
+	// months for free users
 	dateEnd := time.Now()
 	firstOfThisMonth := time.Date(dateEnd.Year(), dateEnd.Month(), 1, 0, 0, 0, 0, dateEnd.Location())
 	dateStart := firstOfThisMonth.AddDate(0, -1, 0)
+	if traffic != nil {
+		dateStart = user.CreatedAt
+		if traffic.PeriodStart != nil {
+			dateStart = *traffic.PeriodStart
+		}
+	}
 
 	usage, err := ctl.ocservUserRepo.TotalBandwidthUserDateRange(
 		c.Request().Context(),
@@ -73,13 +86,17 @@ func (ctl *Controller) Summary(c echo.Context) error {
 			DeactivatedAt: user.DeactivatedAt,
 			TrafficType:   user.TrafficType,
 			TrafficSize:   user.TrafficSize,
-			Rx:            user.Rx,
-			Tx:            user.Tx,
+
+			TrafficDirection: user.TrafficDirection,
+			TrafficPeriod:    user.TrafficPeriod,
+			Rx:               user.Rx,
+			Tx:               user.Tx,
 		},
 		Usage: UsageResponse{
 			DateStart:  dateStart,
 			DateEnd:    dateEnd,
 			Bandwidths: usage,
+			Traffic:    traffic,
 		},
 	})
 }
@@ -14,6 +14,7 @@ type Controller struct {
 	request        request.CustomRequestInterface
 	occtlRepo      repository.OcctlRepositoryInterface
 	ocservUserRepo repository.OcservUserRepositoryInterface
+	nodeRepo       repository.NodeRepositoryInterface
 }
 
 func New() *Controller {
@@ -21,6 +22,7 @@ func New() *Controller {
 		request:        request.NewCustomRequest(),
 		occtlRepo:      repository.NewOcctlRepository(),
 		ocservUserRepo: repository.NewtOcservUserRepository(),
+		nodeRepo:       repository.NewNodeRepository(),
 	}
 }
 
@@ -47,11 +49,12 @@ func (ctl *Controller) Home(c echo.Context) error {
 		ipBans           *[]models.IPBanPoints
 		topBandwidthUser repository.TopBandwidthUsers
 		totalBandwidth   repository.TotalBandwidths
-		errs             = make(chan error, 4)
+		nodes            []repository.NodeStatus
+		errs             = make(chan error, 8)
 		wg               sync.WaitGroup
 	)
 
-	wg.Add(7)
+	wg.Add(8)
 
 	go func() {
 		defer wg.Done()
@@ -125,6 +128,16 @@ func (ctl *Controller) Home(c echo.Context) error {
 		totalBandwidth = bandwidth
 	}()
 
+	go func() {
+		defer wg.Done()
+		status, err := ctl.nodeRepo.NodesStatus(ctx)
+		if err != nil {
+			errs <- err
+			return
+		}
+		nodes = status
+	}()
+
 	wg.Wait()
 	close(errs)
 
@@ -133,6 +146,17 @@ func (ctl *Controller) Home(c echo.Context) error {
 		return ctl.request.BadRequest(c, err)
 	}
 
+	// sessions of remote nodes are listed next to the local ones, tagged with the node name
+	for _, n := range nodes {
+		if n.Sessions == nil {
+			continue
+		}
+		if onlineUsers == nil {
+			onlineUsers = &[]models.OnlineUserSession{}
+		}
+		*onlineUsers = append(*onlineUsers, *n.Sessions...)
+	}
+
 	resp := GetHomeResponse{
 		ServerStatus: status,
 		Statistics:   statistics,
@@ -143,6 +167,7 @@ func (ctl *Controller) Home(c echo.Context) error {
 		},
 		TopBandwidthUser: topBandwidthUser,
 		TotalBandwidth:   totalBandwidth,
+		Nodes:            nodes,
 	}
 
 	return c.JSON(http.StatusOK, resp)
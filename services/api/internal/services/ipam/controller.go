@@ -0,0 +1,127 @@
+package ipam
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"net/http"
+)
+
+type Controller struct {
+	request           request.CustomRequestInterface
+	ipamRepo          repository.IPAMRepositoryInterface
+	ocservUserRepo    repository.OcservUserRepositoryInterface
+	configVersionRepo repository.ConfigVersionRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:           request.NewCustomRequest(),
+		ipamRepo:          repository.NewIPAMRepository(),
+		ocservUserRepo:    repository.NewtOcservUserRepository(),
+		configVersionRepo: repository.NewConfigVersionRepository(),
+	}
+}
+
+// Pools 		 IPv4 pools
+//
+// @Summary      IPv4 pools
+// @Description  IPv4 pool of every group with its utilization by explicit-ipv4 addresses
+// @Tags         IPAM
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  []repository.IPAMPool
+// @Router       /ipam/pools [get]
+func (ctl *Controller) Pools(c echo.Context) error {
+	pools, err := ctl.ipamRepo.Pools(c.Request().Context())
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, pools)
+}
+
+// Pool 		 IPv4 pool of a group
+//
+// @Summary      IPv4 pool of a group
+// @Description  IPv4 pool of a group with its explicit-ipv4 allocations and the next free address
+// @Tags         IPAM
+// @Accept       json
+// @Produce      json
+// @Param 		 group path string true "Group name, or defaults"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  repository.IPAMPoolDetail
+// @Router       /ipam/pools/{group} [get]
+func (ctl *Controller) Pool(c echo.Context) error {
+	pool, err := ctl.ipamRepo.Pool(c.Request().Context(), c.Param("group"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, pool)
+}
+
+// Conflicts 	 explicit-ipv4 conflicts
+//
+// @Summary      explicit-ipv4 conflicts
+// @Description  explicit-ipv4 addresses given to several users, outside the pool of their user or reserved in the pool
+// @Tags         IPAM
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  []repository.IPAMConflict
+// @Router       /ipam/conflicts [get]
+func (ctl *Controller) Conflicts(c echo.Context) error {
+	conflicts, err := ctl.ipamRepo.Conflicts(c.Request().Context())
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, conflicts)
+}
+
+// Allocate 	 Allocate explicit-ipv4
+//
+// @Summary      Allocate explicit-ipv4
+// @Description  Give the ocserv user the next free address of its pool as explicit-ipv4, unless it already has one
+// @Tags         IPAM
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  AllocateData   true "ocserv user to allocate an address to"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  models.OcservUser
+// @Router       /ipam/allocate [post]
+func (ctl *Controller) Allocate(c echo.Context) error {
+	var data AllocateData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ocservUserRepo.GetByUID(c.Request().Context(), data.UID)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	previousConfig := ocservUser.Config
+
+	ocservUser, err = ctl.ipamRepo.Allocate(c.Request().Context(), ocservUser)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ctl.configVersionRepo.RecordChange(
+		c.Request().Context(), models.ConfigKindUser, ocservUser.Username,
+		previousConfig, ocservUser.Config, c.Get("username").(string), "ipam allocation",
+	)
+	return c.JSON(http.StatusOK, ocservUser)
+}
@@ -0,0 +1,16 @@
+package ipam
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/ipam", middlewares.AuthMiddleware(), middlewares.AdminPermission())
+
+	g.GET("/pools", ctl.Pools)
+	g.GET("/pools/:group", ctl.Pool)
+	g.GET("/conflicts", ctl.Conflicts)
+	g.POST("/allocate", ctl.Allocate)
+}
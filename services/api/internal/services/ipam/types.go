@@ -0,0 +1,5 @@
+package ipam
+
+type AllocateData struct {
+	UID string `json:"uid" validate:"required"`
+}
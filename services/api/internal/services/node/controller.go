@@ -0,0 +1,291 @@
+package node
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"net/http"
+)
+
+type Controller struct {
+	request  request.CustomRequestInterface
+	nodeRepo repository.NodeRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:  request.NewCustomRequest(),
+		nodeRepo: repository.NewNodeRepository(),
+	}
+}
+
+// Nodes 	 	 List of ocserv nodes
+//
+// @Summary      List of ocserv nodes
+// @Description  List of ocserv nodes managed through their webhook agent
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  NodesResponse
+// @Router       /nodes [get]
+func (ctl *Controller) Nodes(c echo.Context) error {
+	pagination := ctl.request.Pagination(c)
+
+	nodes, total, err := ctl.nodeRepo.Nodes(c.Request().Context(), pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, NodesResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: nodes,
+	})
+}
+
+// NodesLookup 	 List of ocserv node names
+//
+// @Summary      List of ocserv node names
+// @Description  List of ocserv node names for assigning users and groups
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  []models.Node
+// @Router       /nodes/lookup [get]
+func (ctl *Controller) NodesLookup(c echo.Context) error {
+	nodes, err := ctl.nodeRepo.NodesLookup(c.Request().Context())
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, nodes)
+}
+
+// NodesStatus 	 Status of ocserv nodes
+//
+// @Summary      Status of ocserv nodes
+// @Description  Server status and online sessions of every active node
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  []repository.NodeStatus
+// @Router       /nodes/status [get]
+func (ctl *Controller) NodesStatus(c echo.Context) error {
+	status, err := ctl.nodeRepo.NodesStatus(c.Request().Context())
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// Node 	 	 Ocserv node detail
+//
+// @Summary      Ocserv node detail
+// @Description  Ocserv node detail
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param 		 id path int true "Node ID"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  models.Node
+// @Router       /nodes/{id} [get]
+func (ctl *Controller) Node(c echo.Context) error {
+	nodeID := c.Param("id")
+	if nodeID == "" {
+		return ctl.request.BadRequest(c, errors.New("invalid node id"))
+	}
+
+	n, err := ctl.nodeRepo.GetByID(c.Request().Context(), nodeID)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, n)
+}
+
+// CreateNode 	 Ocserv node creation
+//
+// @Summary      Ocserv node creation
+// @Description  Register an ocserv node by the URL of its webhook agent
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  CreateNodeData  true "node create data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      201  {object} models.Node
+// @Router       /nodes [post]
+func (ctl *Controller) CreateNode(c echo.Context) error {
+	var data CreateNodeData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	n := &models.Node{
+		Name:        data.Name,
+		AgentURL:    data.AgentURL,
+		Token:       data.Token,
+		Description: data.Description,
+		IsActive:    true,
+	}
+	if data.IsActive != nil {
+		n.IsActive = *data.IsActive
+	}
+
+	newNode, err := ctl.nodeRepo.Create(c.Request().Context(), n)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, newNode)
+}
+
+// UpdateNode 	 Ocserv node update
+//
+// @Summary      Ocserv node update
+// @Description  Ocserv node update
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Node ID"
+// @Param        request    body  UpdateNodeData  true "node update data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object} models.Node
+// @Router       /nodes/{id} [patch]
+func (ctl *Controller) UpdateNode(c echo.Context) error {
+	nodeID := c.Param("id")
+	if nodeID == "" {
+		return ctl.request.BadRequest(c, errors.New("invalid node id"))
+	}
+
+	var data UpdateNodeData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	n, err := ctl.nodeRepo.GetByID(c.Request().Context(), nodeID)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if data.AgentURL != nil {
+		n.AgentURL = *data.AgentURL
+	}
+	if data.Token != nil {
+		n.Token = *data.Token
+	}
+	if data.Description != nil {
+		n.Description = *data.Description
+	}
+	if data.IsActive != nil {
+		n.IsActive = *data.IsActive
+	}
+
+	updatedNode, err := ctl.nodeRepo.Update(c.Request().Context(), n)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, updatedNode)
+}
+
+// DeleteNode 	 Ocserv node delete
+//
+// @Summary      Ocserv node delete
+// @Description  Remove a node from the dashboard. Users and groups on the node itself are left untouched.
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Node ID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      204  {object} nil
+// @Router       /nodes/{id} [delete]
+func (ctl *Controller) DeleteNode(c echo.Context) error {
+	nodeID := c.Param("id")
+	if nodeID == "" {
+		return ctl.request.BadRequest(c, errors.New("invalid node id"))
+	}
+
+	if err := ctl.nodeRepo.Delete(c.Request().Context(), nodeID); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
+
+// Statistics 	 Ocserv node traffic statistics
+//
+// @Summary      Ocserv node traffic statistics
+// @Description  Traffic of all users recorded on the node, in buckets of the given granularity
+// @Tags         Nodes
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Node ID"
+// @Param 		 date_start query string false "date_start"
+// @Param 		 date_end query string false "date_end"
+// @Param 		 granularity query string false "granularity" Enums(hour, day, week, month)
+// @Param 		 tz query string false "IANA time zone of the buckets, UTC when empty. Buckets of time zones off UTC by a fraction of an hour start up to 59 minutes after their label"
+// @Param 		 cursor query string false "X-Next-Cursor header of the previous page of buckets"
+// @Param 		 size query int false "Number of buckets per page, all when empty" minimum(1)
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object} []models.DailyTraffic
+// @Header       200  {string} X-Next-Cursor "Cursor of the next page of buckets"
+// @Router       /nodes/{id}/statistics [get]
+func (ctl *Controller) Statistics(c echo.Context) error {
+	nodeID := c.Param("id")
+	if nodeID == "" {
+		return ctl.request.BadRequest(c, errors.New("invalid node id"))
+	}
+
+	var data StatisticsData
+	if err := c.Bind(&data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	series, err := repository.NewTrafficSeries(data.DateStart, data.DateEnd, data.Granularity, data.TZ)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if err = series.Page(data.Cursor, data.Size); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	stats, err := ctl.nodeRepo.NodeStatistics(c.Request().Context(), nodeID, series)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if series.NextCursor != "" {
+		c.Response().Header().Set(request.NextCursorHeader, series.NextCursor)
+	}
+	return c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,19 @@
+package node
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/nodes", middlewares.AuthMiddleware(), middlewares.AdminPermission())
+	g.GET("", ctl.Nodes)
+	g.GET("/lookup", ctl.NodesLookup)
+	g.GET("/status", ctl.NodesStatus)
+	g.GET("/:id", ctl.Node)
+	g.POST("", ctl.CreateNode)
+	g.PATCH("/:id", ctl.UpdateNode)
+	g.DELETE("/:id", ctl.DeleteNode)
+	g.GET("/:id/statistics", ctl.Statistics)
+}
@@ -0,0 +1,35 @@
+package node
+
+import (
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+)
+
+type CreateNodeData struct {
+	Name        string `json:"name" validate:"required,min=2,max=64" example:"de-1"`
+	AgentURL    string `json:"agent_url" validate:"required,url,startswith=https://" example:"https://10.0.0.2:8888"`
+	Token       string `json:"token" validate:"required,min=16,max=128" example:"agent-shared-secret"` // AGENT_TOKEN of the agent
+	Description string `json:"description" validate:"omitempty,max=1024" example:"Frankfurt node"`
+	IsActive    *bool  `json:"is_active" validate:"omitempty" example:"true"`
+}
+
+type UpdateNodeData struct {
+	AgentURL    *string `json:"agent_url" validate:"omitempty,url,startswith=https://" example:"https://10.0.0.2:8888"`
+	Token       *string `json:"token" validate:"omitempty,min=16,max=128" example:"agent-shared-secret"`
+	Description *string `json:"description" validate:"omitempty,max=1024" example:"Frankfurt node"`
+	IsActive    *bool   `json:"is_active" validate:"omitempty" example:"true"`
+}
+
+type NodesResponse struct {
+	Meta   request.Meta  `json:"meta" validate:"required"`
+	Result []models.Node `json:"result" validate:"omitempty"`
+}
+
+type StatisticsData struct {
+	DateStart   string `json:"date_start" query:"date_start" validate:"omitempty" example:"2025-1-31"`
+	DateEnd     string `json:"date_end" query:"date_end" validate:"omitempty" example:"2025-12-31"`
+	Granularity string `json:"granularity" query:"granularity" validate:"omitempty,oneof=hour day week month" example:"day"`
+	TZ          string `json:"tz" query:"tz" validate:"omitempty" example:"Europe/Berlin"` // IANA name, UTC when empty
+	Cursor      string `json:"cursor" query:"cursor" validate:"omitempty"`                 // X-Next-Cursor of the previous page
+	Size        int    `json:"size" query:"size" validate:"omitempty,min=1"`               // buckets per page, all when 0
+}
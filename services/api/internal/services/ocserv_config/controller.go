@@ -0,0 +1,99 @@
+package ocserv_config
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"net/http"
+)
+
+type Controller struct {
+	request          request.CustomRequestInterface
+	ocservServerRepo repository.OcservServerRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:          request.NewCustomRequest(),
+		ocservServerRepo: repository.NewOcservServerRepository(),
+	}
+}
+
+// OcservConfig 	 Main ocserv config
+//
+// @Summary      Main ocserv config
+// @Description  Directives of the main ocserv.conf managed by the dashboard
+// @Tags         Ocserv(Config)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  models.OcservServerConfig
+// @Router       /ocserv/config [get]
+func (ctl *Controller) OcservConfig(c echo.Context) error {
+	config, err := ctl.ocservServerRepo.Config()
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, config)
+}
+
+// PreviewOcservConfig 	 Preview main ocserv config update
+//
+// @Summary      Preview main ocserv config update
+// @Description  Validate the update and return the diff it would apply to ocserv.conf, without writing it.
+// @Description  Omitted fields are left untouched, empty strings and lists remove the directive.
+// @Tags         Ocserv(Config)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  models.OcservServerConfig  true "ocserv config update"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  OcservConfigDiffResponse
+// @Router       /ocserv/config/preview [post]
+func (ctl *Controller) PreviewOcservConfig(c echo.Context) error {
+	var data models.OcservServerConfig
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	diff, err := ctl.ocservServerRepo.Preview(&data)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, OcservConfigDiffResponse{Diff: diff, Changed: diff != ""})
+}
+
+// UpdateOcservConfig 	 Update main ocserv config
+//
+// @Summary      Update main ocserv config
+// @Description  Update ocserv.conf. The new version is checked with ocserv --test-config before it replaces the file,
+// @Description  the previous version is backed up and ocserv is reloaded. Omitted fields are left untouched,
+// @Description  empty strings and lists remove the directive.
+// @Tags         Ocserv(Config)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  models.OcservServerConfig  true "ocserv config update"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  OcservConfigDiffResponse
+// @Router       /ocserv/config [patch]
+func (ctl *Controller) UpdateOcservConfig(c echo.Context) error {
+	var data models.OcservServerConfig
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	diff, err := ctl.ocservServerRepo.Update(&data)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, OcservConfigDiffResponse{Diff: diff, Changed: diff != ""})
+}
@@ -0,0 +1,15 @@
+package ocserv_config
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/ocserv/config", middlewares.AuthMiddleware(), middlewares.AdminPermission())
+
+	g.GET("", ctl.OcservConfig)
+	g.POST("/preview", ctl.PreviewOcservConfig)
+	g.PATCH("", ctl.UpdateOcservConfig)
+}
@@ -0,0 +1,6 @@
+package ocserv_config
+
+type OcservConfigDiffResponse struct {
+	Diff    string `json:"diff" validate:"required"`
+	Changed bool   `json:"changed" validate:"required"`
+}
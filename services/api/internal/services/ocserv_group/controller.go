@@ -1,33 +1,53 @@
 package ocserv_group
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
 	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
 	"github.com/mmtaee/ocserv-users-management/common/models"
-	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
 )
 
 type Controller struct {
-	request         request.CustomRequestInterface
-	ocservGroupRepo repository.OcservGroupRepositoryInterface
-	ocservUserRepo  repository.OcservUserRepositoryInterface
+	request           request.CustomRequestInterface
+	ocservGroupRepo   repository.OcservGroupRepositoryInterface
+	ocservUserRepo    repository.OcservUserRepositoryInterface
+	ocservOcctlRepo   repository.OcctlRepositoryInterface
+	nodeRepo          repository.NodeRepositoryInterface
+	configVersionRepo repository.ConfigVersionRepositoryInterface
+	labelRepo         repository.LabelRepositoryInterface
+	ipamRepo          repository.IPAMRepositoryInterface
 }
 
 func New() *Controller {
 	return &Controller{
-		request:         request.NewCustomRequest(),
-		ocservGroupRepo: repository.NewOcservGroupRepository(),
-		ocservUserRepo:  repository.NewtOcservUserRepository(),
+		request:           request.NewCustomRequest(),
+		ocservGroupRepo:   repository.NewOcservGroupRepository(),
+		ocservUserRepo:    repository.NewtOcservUserRepository(),
+		ocservOcctlRepo:   repository.NewOcctlRepository(),
+		nodeRepo:          repository.NewNodeRepository(),
+		configVersionRepo: repository.NewConfigVersionRepository(),
+		labelRepo:         repository.NewLabelRepository(),
+		ipamRepo:          repository.NewIPAMRepository(),
 	}
 }
 
+// saveLabels applies the tags and attributes given on create or update and returns the group with its labels.
+func (ctl *Controller) saveLabels(c echo.Context, ocservGroup *models.OcservGroup, labels *repository.LabelEdit) (*models.OcservGroup, error) {
+	ctx := c.Request().Context()
+	if labels.Tags != nil || len(labels.AddTags) > 0 || len(labels.Attributes) > 0 {
+		if _, err := ctl.labelRepo.EditGroups(ctx, []uint{ocservGroup.ID}, "", labels); err != nil {
+			return nil, err
+		}
+	}
+	return ctl.ocservGroupRepo.GetByID(ctx, strconv.Itoa(int(ocservGroup.ID)))
+}
+
 // OcservGroupsLookup 	 List of Ocserv group names
 //
 // @Summary      List of Ocserv group names
@@ -69,12 +89,29 @@ func (ctl *Controller) OcservGroupsLookup(c echo.Context) error {
 // @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
 // @Param 		 order query string false "Field to order by"
 // @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param 		 cursor query string false "Cursor of the page for cursor pagination ordered by id or name, empty for the first page"
+// @Param 		 fields query string false "Comma separated fields of the items to return"
+// @Param 		 total query bool false "Count the total records, true by default without a cursor"
+// @Param 		 tag query []string false "tags the groups all have, repeated or comma separated" collectionFormat(multi)
+// @Param 		 attr query []string false "comparisons of defined custom attributes such as department=sales or seats>=10, with = != > >= < <=, only = and != for bool attributes" collectionFormat(multi)
 // @Param        Authorization header string true "Bearer TOKEN"
 // @Failure      400 {object} request.ErrorResponse
 // @Failure      401 {object} middlewares.Unauthorized
 // @Success      200  {object}  OcservGroupsResponse
 // @Router       /ocserv/groups [get]
 func (ctl *Controller) OcservGroups(c echo.Context) error {
+	var data OcservGroupsFilterData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	labels, err := repository.ParseLabelFilter(data.Tags, data.Attributes)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if err = ctl.labelRepo.CheckFilter(c.Request().Context(), labels); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
 	pagination := ctl.request.Pagination(c)
 
 	owner := ""
@@ -86,19 +123,12 @@ func (ctl *Controller) OcservGroups(c echo.Context) error {
 		owner = username
 	}
 
-	ocservGroup, total, err := ctl.ocservGroupRepo.Groups(c.Request().Context(), pagination, owner)
+	ocservGroup, total, err := ctl.ocservGroupRepo.Groups(c.Request().Context(), pagination, owner, labels)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
 
-	return c.JSON(http.StatusOK, OcservGroupsResponse{
-		Meta: request.Meta{
-			Page:         pagination.Page,
-			PageSize:     pagination.PageSize,
-			TotalRecords: total,
-		},
-		Result: ocservGroup,
-	})
+	return ctl.request.Response(c, pagination, total, ocservGroup)
 }
 
 // OcservGroup 	 Ocserv group detail
@@ -145,22 +175,50 @@ func (ctl *Controller) CreateOcservGroup(c echo.Context) error {
 	if err := ctl.request.DoValidate(c, &data); err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	if err := utils.ValidateName(data.Name); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 
 	owner := c.Get("username").(string)
 	if owner == "" {
 		return ctl.request.BadRequest(c, errors.New("admin or staff username not found"))
 	}
 
+	nodes, err := ctl.nodeRepo.GetByIDs(c.Request().Context(), data.Nodes)
+	if err != nil {
+		return ctl.request.BadRequest(c, fmt.Errorf("invalid nodes: %w", err))
+	}
+
 	ocservGroup := models.OcservGroup{
 		Name:   data.Name,
 		Owner:  owner,
 		Config: data.Config,
+		Nodes:  nodes,
+	}
+	if data.Schedule != nil && len(data.Schedule.Windows) > 0 {
+		if err = data.Schedule.Validate(); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+		ocservGroup.Schedule = data.Schedule
+	}
+
+	labels := &repository.LabelEdit{AddTags: data.Tags, Attributes: data.Attributes}
+	if err = ctl.labelRepo.CheckEdit(c.Request().Context(), labels); err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
 	newOcservGroup, err := ctl.ocservGroupRepo.Create(c.Request().Context(), &ocservGroup)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	ctl.configVersionRepo.RecordChange(
+		c.Request().Context(), models.ConfigKindGroup, newOcservGroup.Name,
+		nil, newOcservGroup.Config, c.Get("username").(string), "",
+	)
+
+	if newOcservGroup, err = ctl.saveLabels(c, newOcservGroup, labels); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 	return c.JSON(http.StatusCreated, newOcservGroup)
 }
 
@@ -193,23 +251,54 @@ func (ctl *Controller) UpdateOcservGroup(c echo.Context) error {
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	previousConfig := ocservGroup.Config
 	ocservGroup.Config = data.Config
+	if data.Nodes != nil {
+		nodes, err := ctl.nodeRepo.GetByIDs(c.Request().Context(), *data.Nodes)
+		if err != nil {
+			return ctl.request.BadRequest(c, fmt.Errorf("invalid nodes: %w", err))
+		}
+		ocservGroup.Nodes = nodes
+	}
+	if data.Schedule != nil {
+		ocservGroup.Schedule = nil
+		if len(data.Schedule.Windows) > 0 {
+			if err = data.Schedule.Validate(); err != nil {
+				return ctl.request.BadRequest(c, err)
+			}
+			ocservGroup.Schedule = data.Schedule
+		}
+	}
+	labels := &repository.LabelEdit{Tags: data.Tags, Attributes: data.Attributes}
+	if err = ctl.labelRepo.CheckEdit(c.Request().Context(), labels); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
 	updatedOcservGroup, err := ctl.ocservGroupRepo.Update(c.Request().Context(), ocservGroup)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	ctl.configVersionRepo.RecordChange(
+		c.Request().Context(), models.ConfigKindGroup, updatedOcservGroup.Name,
+		previousConfig, updatedOcservGroup.Config, c.Get("username").(string), "",
+	)
+
+	if updatedOcservGroup, err = ctl.saveLabels(c, updatedOcservGroup, labels); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 	return c.JSON(http.StatusOK, updatedOcservGroup)
 }
 
 // DeleteOcservGroup 	     Ocserv Group delete
 //
 // @Summary      Ocserv Group delete
-// @Description  Ocserv Group delete
+// @Description  Ocserv Group delete. Members are first moved to the reassign_to group (defaults when empty); the group is removed only once they are.
 // @Tags         Ocserv(Groups)
 // @Accept       json
 // @Produce      json
 // @Param        Authorization header string true "Bearer TOKEN"
 // @Param 		 id path int true "Ocserv Group ID"
+// @Param 		 reassign_to query string false "Group receiving the members" default(defaults)
 // @Failure      400 {object} request.ErrorResponse
 // @Failure      401 {object} middlewares.Unauthorized
 // @Success      204  {object} nil
@@ -220,41 +309,214 @@ func (ctl *Controller) DeleteOcservGroup(c echo.Context) error {
 		return ctl.request.BadRequest(c, errors.New("group id is empty"))
 	}
 
-	group, err := ctl.ocservGroupRepo.Delete(c.Request().Context(), groupID)
+	reassignTo := c.QueryParam("reassign_to")
+	if reassignTo == "" {
+		reassignTo = "defaults"
+	}
+
+	err := ctl.ipamRepo.MoveUsers(c.Request().Context(), reassignTo, func(check repository.MoveCheck) error {
+		_, err2 := ctl.ocservGroupRepo.Delete(c.Request().Context(), groupID, reassignTo, check)
+		return err2
+	})
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
+
+// OcservGroupMembers 	 List of Ocserv group members
+//
+// @Summary      List of Ocserv group members
+// @Description  List of Ocserv group members. Non-admin users only see the ocserv users shared with them.
+// @Tags         Ocserv(Groups)
+// @Accept       json
+// @Produce      json
+// @Param 		 id path int true "Ocserv Group ID"
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  OcservGroupMembersResponse
+// @Router       /ocserv/groups/{id}/members [get]
+func (ctl *Controller) OcservGroupMembers(c echo.Context) error {
+	groupID := c.Param("id")
+	if groupID == "" {
+		return ctl.request.BadRequest(c, errors.New("invalid group id"))
+	}
+
+	owner := ""
+	if isAdmin := c.Get("isAdmin").(bool); !isAdmin {
+		username := c.Get("username").(string)
+		if username == "" {
+			return ctl.request.BadRequest(c, errors.New("invalid username context"))
+		}
+		owner = username
+	}
+
+	ctx := c.Request().Context()
+	group, err := ctl.ocservGroupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	pagination := ctl.request.Pagination(c)
+	members, total, err := ctl.ocservGroupRepo.Members(ctx, group.Name, pagination, owner)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
 
-	go func(groupName string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	if len(members) > 0 {
+		onlineUsers, err := ctl.ocservOcctlRepo.OnlineUsers()
+		if err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+
+		onlineMap := make(map[string]struct{}, len(onlineUsers))
+		for _, u := range onlineUsers {
+			onlineMap[u] = struct{}{}
+		}
+		for i := range members {
+			if _, ok := onlineMap[members[i].Username]; ok {
+				members[i].IsOnline = true
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, OcservGroupMembersResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: members,
+	})
+}
+
+// EditOcservGroupsLabels 	 Edit the labels of Ocserv groups
+//
+// @Summary      Edit the labels of Ocserv groups
+// @Description  Edit the tags and custom attributes of several groups at once. tags replaces the tags, then add_tags and remove_tags apply.
+// @Description  attributes sets the given attributes and removes those set to null. Non-admin users only edit their own groups.
+// @Tags         Ocserv(Groups)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  EditOcservGroupsLabelsData  true "labels edit"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} EditLabelsResponse
+// @Router       /ocserv/groups/labels [post]
+func (ctl *Controller) EditOcservGroupsLabels(c echo.Context) error {
+	var data EditOcservGroupsLabelsData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	owner := ""
+	if isAdmin := c.Get("isAdmin").(bool); !isAdmin {
+		owner = c.Get("username").(string)
+		if owner == "" {
+			return ctl.request.BadRequest(c, errors.New("invalid username context"))
+		}
+	}
 
-		var wg sync.WaitGroup
+	edited, err := ctl.labelRepo.EditGroups(c.Request().Context(), data.IDs, owner, &repository.LabelEdit{
+		Tags:       data.Tags,
+		AddTags:    data.AddTags,
+		RemoveTags: data.RemoveTags,
+		Attributes: data.Attributes,
+	})
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, EditLabelsResponse{Edited: edited})
+}
 
-		users, err := ctl.ocservUserRepo.UpdateUsersByDeleteGroup(ctx, groupName)
+// MoveOcservGroupMembers 	 Move Ocserv users to a group
+//
+// @Summary      Move Ocserv users to a group
+// @Description  Move Ocserv users to a group in the database, ocpasswd and the nodes. Either all users are moved or none.
+// @Tags         Ocserv(Groups)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request body MoveMembersData true "users and target group"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  []models.OcservUser
+// @Router       /ocserv/groups/members/move [post]
+func (ctl *Controller) MoveOcservGroupMembers(c echo.Context) error {
+	var data MoveMembersData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var users []models.OcservUser
+	err := ctl.ipamRepo.MoveUsers(c.Request().Context(), data.Group, func(check repository.MoveCheck) error {
+		var err2 error
+		users, err2 = ctl.ocservUserRepo.MoveUsers(c.Request().Context(), data.UIDs, data.Group, check)
+		return err2
+	})
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+// OcservGroupsStatistics 	 Ocserv Groups Statistics
+//
+// @Summary      Ocserv Groups Statistics
+// @Description  Members, online members and traffic (GiB) per group. Traffic covers the optional date range.
+// @Tags         Ocserv(Groups)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 date_start query string false "date_start"
+// @Param 		 date_end query string false "date_end"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  []repository.GroupStatistics
+// @Router       /ocserv/groups/statistics [get]
+func (ctl *Controller) OcservGroupsStatistics(c echo.Context) error {
+	var data StatisticsData
+	if err := c.Bind(&data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var startDate, endDate *time.Time
+
+	if data.DateStart != "" {
+		t, err := time.Parse("2006-01-02", data.DateStart)
 		if err != nil {
-			logger.Error("Failed to load users for removed group %s: %v", groupName, err)
-			return
+			return ctl.request.BadRequest(c, fmt.Errorf("invalid date_start: %w", err))
 		}
+		startDate = &t
+	}
 
-		for _, u := range users {
-			// create local copy for goroutine
-			ocservUser := u
-			ocservUser.Group = "defaults"
-
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				if _, err2 := ctl.ocservUserRepo.Update(ctx, &ocservUser); err2 != nil {
-					logger.Warn("DeleteGroup: failed to update user %s: %v", ocservUser.Username, err2)
-				}
-			}()
+	if data.DateEnd != "" {
+		t, err := time.Parse("2006-01-02", data.DateEnd)
+		if err != nil {
+			return ctl.request.BadRequest(c, fmt.Errorf("invalid date_end: %w", err))
 		}
+		t = t.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+		endDate = &t
+	}
 
-		wg.Wait()
-	}(group.Name)
+	onlineUsers, err := ctl.ocservOcctlRepo.OnlineUsers()
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 
-	return c.JSON(http.StatusNoContent, nil)
+	stats, err := ctl.ocservGroupRepo.Statistics(c.Request().Context(), startDate, endDate, onlineUsers)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, stats)
 }
 
 // GetDefaultsGroup 	     Ocserv Defaults Group config
@@ -296,10 +558,16 @@ func (ctl *Controller) UpdateDefaultsGroup(c echo.Context) error {
 		return ctl.request.BadRequest(c, err)
 	}
 
+	previousConfig, _ := ctl.ocservGroupRepo.DefaultGroup()
+
 	err := ctl.ocservGroupRepo.UpdateDefaultGroup(data.Config)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	ctl.configVersionRepo.RecordChange(
+		c.Request().Context(), models.ConfigKindDefaults, models.ConfigKindDefaults,
+		previousConfig, data.Config, c.Get("username").(string), "",
+	)
 	return c.JSON(http.StatusOK, nil)
 }
 
@@ -10,7 +10,11 @@ func Routes(e *echo.Group) {
 	g := e.Group("/ocserv/groups", middlewares.AuthMiddleware())
 	g.GET("", ctl.OcservGroups)
 	g.GET("/lookup", ctl.OcservGroupsLookup)
+	g.GET("/statistics", ctl.OcservGroupsStatistics, middlewares.AdminPermission())
+	g.POST("/members/move", ctl.MoveOcservGroupMembers, middlewares.AdminPermission())
+	g.POST("/labels", ctl.EditOcservGroupsLabels)
 	g.GET("/:id", ctl.OcservGroup)
+	g.GET("/:id/members", ctl.OcservGroupMembers)
 	g.POST("", ctl.CreateOcservGroup)
 	g.PATCH("/:id", ctl.UpdateOcservGroup)
 	g.DELETE("/:id", ctl.DeleteOcservGroup)
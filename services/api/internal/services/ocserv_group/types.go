@@ -7,12 +7,26 @@ import (
 )
 
 type CreateOcservGroupData struct {
-	Name   string                    `json:"name" validate:"required"`
-	Config *models.OcservGroupConfig `json:"config" validate:"required"`
+	Name       string                    `json:"name" validate:"required"`
+	Config     *models.OcservGroupConfig `json:"config" validate:"required"`
+	Nodes      []uint                    `json:"nodes" validate:"omitempty" example:"1,2"`
+	Schedule   *models.AccessSchedule    `json:"schedule" validate:"omitempty"`
+	Tags       []string                  `json:"tags" validate:"omitempty" example:"eu"`
+	Attributes models.Attributes         `json:"attributes" validate:"omitempty"` // values of defined custom attributes
 }
 
 type UpdateOcservGroupData struct {
-	Config *models.OcservGroupConfig `json:"config" validate:"required"`
+	Config     *models.OcservGroupConfig `json:"config" validate:"required"`
+	Nodes      *[]uint                   `json:"nodes" validate:"omitempty" example:"1,2"`
+	Schedule   *models.AccessSchedule    `json:"schedule" validate:"omitempty"`          // a schedule without windows clears it
+	Tags       *[]string                 `json:"tags" validate:"omitempty" example:"eu"` // replaces the tags
+	Attributes models.Attributes         `json:"attributes" validate:"omitempty"`        // sets the given attributes, null removes one
+}
+
+// OcservGroupsFilterData filters the group list by labels, see repository.ParseLabelFilter.
+type OcservGroupsFilterData struct {
+	Tags       []string `query:"tag" validate:"omitempty"`
+	Attributes []string `query:"attr" validate:"omitempty"`
 }
 
 type OcservGroupsResponse struct {
@@ -23,3 +37,31 @@ type OcservGroupsResponse struct {
 type SyncGroupRequest struct {
 	Groups []group.UnsyncedGroup `json:"groups" validate:"required,dive"`
 }
+
+type OcservGroupMembersResponse struct {
+	Meta   request.Meta        `json:"meta" validate:"required"`
+	Result []models.OcservUser `json:"result" validate:"omitempty"`
+}
+
+type MoveMembersData struct {
+	UIDs  []string `json:"uids" validate:"required,min=1,unique,dive,required"`
+	Group string   `json:"group" validate:"required" example:"defaults"`
+}
+
+type StatisticsData struct {
+	DateStart string `json:"date_start" query:"date_start" validate:"omitempty" example:"2025-1-31"`
+	DateEnd   string `json:"date_end" query:"date_end" validate:"omitempty" example:"2025-12-31"`
+}
+
+// EditOcservGroupsLabelsData edits the tags and custom attributes of several groups at once.
+type EditOcservGroupsLabelsData struct {
+	IDs        []uint            `json:"ids" validate:"required,min=1,max=1000,unique"`
+	Tags       *[]string         `json:"tags" validate:"omitempty" example:"eu"` // replaces the tags
+	AddTags    []string          `json:"add_tags" validate:"omitempty" example:"eu"`
+	RemoveTags []string          `json:"remove_tags" validate:"omitempty" example:"legacy"`
+	Attributes models.Attributes `json:"attributes" validate:"omitempty"` // sets the given attributes, null removes one
+}
+
+type EditLabelsResponse struct {
+	Edited int `json:"edited" validate:"required"`
+}
@@ -1,6 +1,7 @@
 package ocserv_user
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
@@ -8,25 +9,44 @@ import (
 	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
 	"github.com/mmtaee/ocserv-users-management/common/models"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
 	"golang.org/x/sync/errgroup"
 	"net/http"
 	"slices"
+	"strconv"
 	"sync"
 	"time"
 )
 
 type Controller struct {
-	request         request.CustomRequestInterface
-	userRepo        repository.UserRepositoryInterface
-	ocservUserRepo  repository.OcservUserRepositoryInterface
-	ocservOcctlRepo repository.OcctlRepositoryInterface
+	request           request.CustomRequestInterface
+	userRepo          repository.UserRepositoryInterface
+	ocservUserRepo    repository.OcservUserRepositoryInterface
+	ocservOcctlRepo   repository.OcctlRepositoryInterface
+	nodeRepo          repository.NodeRepositoryInterface
+	resellerRepo      repository.ResellerRepositoryInterface
+	planRepo          repository.PlanRepositoryInterface
+	ocservGroupRepo   repository.OcservGroupRepositoryInterface
+	configVersionRepo repository.ConfigVersionRepositoryInterface
+	ipamRepo          repository.IPAMRepositoryInterface
+	labelRepo         repository.LabelRepositoryInterface
+	scheduledRepo     repository.ScheduledActionRepositoryInterface
 }
 
 func New() *Controller {
 	return &Controller{
-		request:         request.NewCustomRequest(),
-		ocservUserRepo:  repository.NewtOcservUserRepository(),
-		ocservOcctlRepo: repository.NewOcctlRepository(),
+		request:           request.NewCustomRequest(),
+		userRepo:          repository.NewUserRepository(),
+		ocservUserRepo:    repository.NewtOcservUserRepository(),
+		ocservOcctlRepo:   repository.NewOcctlRepository(),
+		nodeRepo:          repository.NewNodeRepository(),
+		resellerRepo:      repository.NewResellerRepository(),
+		planRepo:          repository.NewPlanRepository(),
+		ocservGroupRepo:   repository.NewOcservGroupRepository(),
+		configVersionRepo: repository.NewConfigVersionRepository(),
+		ipamRepo:          repository.NewIPAMRepository(),
+		labelRepo:         repository.NewLabelRepository(),
+		scheduledRepo:     repository.NewScheduledActionRepository(),
 	}
 }
 
@@ -39,29 +59,47 @@ func New() *Controller {
 // @Produce      json
 // @Param 		 page query int false "Page number, starting from 1" minimum(1)
 // @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
-// @Param 		 order query string false "Field to order by"
+// @Param 		 order query string false "Field to order by, id by default. usage orders by the percent of the quota used in the current period, top-ups included, as usage_above filters" Enums(id, username, group, created_at, expire_at, traffic_size, rx, tx, usage)
 // @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param 		 cursor query string false "Cursor of the page for cursor pagination ordered by id, username, created_at, traffic_size, rx or tx, empty for the first page"
+// @Param 		 fields query string false "Comma separated fields of the items to return"
+// @Param 		 total query bool false "Count the total records, true by default without a cursor"
 // @Param 		 q query string false "ocserv username q search" minLength(2)
+// @Param 		 group query string false "group name"
+// @Param 		 traffic_type query string false "traffic type" Enums(Free, MonthlyTransmit, MonthlyReceive, TotallyTransmit, TotallyReceive, Custom)
+// @Param 		 locked query bool false "locked users"
+// @Param 		 expired query bool false "users expired before today"
+// @Param 		 deactivated query bool false "deactivated users"
+// @Param 		 online query bool false "users connected now"
+// @Param 		 expire_before query string false "expire date on or before, YYYY-MM-DD"
+// @Param 		 expire_after query string false "expire date on or after, YYYY-MM-DD"
+// @Param 		 usage_above query number false "percent of the quota used in the current period, active top-ups included"
+// @Param 		 created_after query string false "created on or after, YYYY-MM-DD"
+// @Param 		 created_before query string false "created on or before, YYYY-MM-DD"
+// @Param 		 description query string false "description search"
+// @Param 		 tag query []string false "tags the users all have, repeated or comma separated" collectionFormat(multi)
+// @Param 		 attr query []string false "comparisons of defined custom attributes such as department=sales or seats>=10, with = != > >= < <=, only = and != for bool attributes" collectionFormat(multi)
 // @Param        Authorization header string true "Bearer TOKEN"
 // @Failure      400 {object} request.ErrorResponse
 // @Failure      401 {object} middlewares.Unauthorized
 // @Success      200  {object}  OcservUsersResponse
 // @Router       /ocserv/users [get]
 func (ctl *Controller) OcservUsers(c echo.Context) error {
-	owner := ""
-	if isAdmin := c.Get("isAdmin").(bool); !isAdmin {
-		username := c.Get("username").(string)
-		if username == "" {
-			return ctl.request.BadRequest(c, errors.New("invalid user uid"))
-		}
-		owner = username
+	var data OcservUsersFilterData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
-	pagination := ctl.request.Pagination(c)
+	filter, err := ctl.usersFilter(c, &data)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 
-	q := c.QueryParam("q")
+	pagination := ctl.request.Pagination(c)
+	// is_online is set from the username
+	pagination.Required = []string{"username"}
 
-	ocservUsers, total, err := ctl.ocservUserRepo.Users(c.Request().Context(), pagination, owner, q)
+	ocservUsers, total, err := ctl.ocservUserRepo.Users(c.Request().Context(), pagination, filter)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
@@ -86,14 +124,7 @@ func (ctl *Controller) OcservUsers(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusOK, OcservUsersResponse{
-		Meta: request.Meta{
-			Page:         pagination.Page,
-			TotalRecords: total,
-			PageSize:     pagination.PageSize,
-		},
-		Result: ocservUsers,
-	})
+	return ctl.request.Response(c, pagination, total, ocservUsers)
 }
 
 // OcservUser 	 Ocserv user detail
@@ -107,7 +138,7 @@ func (ctl *Controller) OcservUsers(c echo.Context) error {
 // @Param        Authorization header string true "Bearer TOKEN"
 // @Failure      400 {object} request.ErrorResponse
 // @Failure      401 {object} middlewares.Unauthorized
-// @Success      200  {object}  models.OcservUser
+// @Success      200  {object}  OcservUserResponse
 // @Router       /ocserv/users/{uid} [get]
 func (ctl *Controller) OcservUser(c echo.Context) error {
 	// TODO: add staff filter to get ocserv user for same owner
@@ -120,7 +151,41 @@ func (ctl *Controller) OcservUser(c echo.Context) error {
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
-	return c.JSON(http.StatusOK, u)
+
+	state, err := ctl.ocservUserRepo.ScheduleState(c.Request().Context(), u)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, OcservUserResponse{
+		OcservUser:    *u,
+		ScheduleState: state,
+	})
+}
+
+// EffectiveConfigOcservUser 	 Ocserv user effective config
+//
+// @Summary      Ocserv user effective config
+// @Description  Settings ocserv applies to the user, merged from ocserv.conf, the group file (or defaults/group.conf when the user has no group file) and the user file, with the layer each value comes from
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  user.EffectiveConfig
+// @Router       /ocserv/users/{uid}/effective-config [get]
+func (ctl *Controller) EffectiveConfigOcservUser(c echo.Context) error {
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	config, err := ctl.ocservUserRepo.EffectiveConfig(c.Request().Context(), ocservUser)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, config)
 }
 
 // CreateOcservUser 	     Ocserv User creation
@@ -147,6 +212,41 @@ func (ctl *Controller) CreateOcservUser(c echo.Context) error {
 	if err := ctl.request.DoValidate(c, &data); err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	if err := utils.ValidateName(data.Username); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var planID *uint
+	if data.PlanID != nil {
+		plan, err := ctl.planRepo.GetByID(c.Request().Context(), *data.PlanID)
+		if err != nil {
+			return ctl.request.BadRequest(c, fmt.Errorf("invalid plan: %w", err))
+		}
+		if !plan.IsActive {
+			return ctl.request.BadRequest(c, errors.New("plan is not active"))
+		}
+		planID = &plan.ID
+
+		if data.Group == "" {
+			data.Group = plan.Group
+		}
+		if data.TrafficType == "" {
+			data.TrafficType = plan.TrafficType
+			data.TrafficSize = plan.TrafficSize
+			data.TrafficDirection = plan.TrafficDirection
+			data.TrafficPeriod = plan.TrafficPeriod
+		}
+		if data.OverQuotaAction == "" {
+			data.OverQuotaAction = plan.OverQuotaAction
+			data.ThrottleRate = plan.ThrottleRate
+		}
+		if data.Config == nil {
+			data.Config = plan.Config
+		}
+		if data.ExpireAt == "" {
+			data.ExpireAt = plan.ExpireFrom(time.Now()).Format("2006-01-02")
+		}
+	}
 
 	expireAt, err := time.Parse("2006-01-02", data.ExpireAt)
 	if err != nil {
@@ -157,6 +257,11 @@ func (ctl *Controller) CreateOcservUser(c echo.Context) error {
 		data.TrafficSize = 0
 	}
 
+	nodes, err := ctl.nodeRepo.GetByIDs(c.Request().Context(), data.Nodes)
+	if err != nil {
+		return ctl.request.BadRequest(c, fmt.Errorf("invalid nodes: %w", err))
+	}
+
 	ocUser := &models.OcservUser{
 		Owner:       owner,
 		Username:    data.Username,
@@ -165,13 +270,47 @@ func (ctl *Controller) CreateOcservUser(c echo.Context) error {
 		ExpireAt:    &expireAt,
 		TrafficSize: data.TrafficSize,
 		TrafficType: data.TrafficType,
+		Description: data.Description,
 		Config:      data.Config,
+		Nodes:       nodes,
+		PlanID:      planID,
+
+		TrafficDirection: data.TrafficDirection,
+		TrafficPeriod:    data.TrafficPeriod,
+		TrafficResetDay:  data.TrafficResetDay,
+		OverQuotaAction:  data.OverQuotaAction,
+		ThrottleRate:     data.ThrottleRate,
+	}
+	if data.Schedule != nil && len(data.Schedule.Windows) > 0 {
+		if err = data.Schedule.Validate(); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+		ocUser.Schedule = data.Schedule
+	}
+
+	labels := &repository.LabelEdit{AddTags: data.Tags, Attributes: data.Attributes}
+	if err = ctl.labelRepo.CheckEdit(c.Request().Context(), labels); err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
-	u, err := ctl.ocservUserRepo.Create(c.Request().Context(), ocUser)
+	var u *models.OcservUser
+	err = ctl.ipamRepo.SaveUser(c.Request().Context(), ocUser, func() error {
+		var err2 error
+		u, err2 = ctl.ocservUserRepo.Create(c.Request().Context(), ocUser, labels)
+		return err2
+	})
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	ctl.configVersionRepo.RecordChange(
+		c.Request().Context(), models.ConfigKindUser, u.Username,
+		nil, u.Config, c.Get("username").(string), "",
+	)
+
+	// reloaded with its labels
+	if u, err = ctl.ocservUserRepo.GetByUID(c.Request().Context(), u.UID); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 
 	return c.JSON(http.StatusCreated, u)
 }
@@ -191,17 +330,12 @@ func (ctl *Controller) CreateOcservUser(c echo.Context) error {
 // @Success      201  {object} models.OcservUser
 // @Router       /ocserv/users/{uid} [patch]
 func (ctl *Controller) UpdateOcservUser(c echo.Context) error {
-	userID := c.Param("uid")
-	if userID == "" {
-		return ctl.request.BadRequest(c, errors.New("user id is required"))
-	}
-
 	var data UpdateOcservUserData
 	if err := ctl.request.DoValidate(c, &data); err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
 
-	ocservUser, err := ctl.ocservUserRepo.GetByUID(c.Request().Context(), userID)
+	ocservUser, err := ctl.ownedOcservUser(c)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
@@ -218,9 +352,37 @@ func (ctl *Controller) UpdateOcservUser(c echo.Context) error {
 	if data.TrafficSize != nil {
 		ocservUser.TrafficSize = *data.TrafficSize
 	}
-	if data.TrafficType != nil && slices.Contains([]string{"Free", "MonthlyTransmit", "MonthlyReceive", "TotallyTransmit", "TotallyReceive"}, *data.TrafficType) {
+	if data.TrafficType != nil && slices.Contains(trafficTypes, *data.TrafficType) {
 		ocservUser.TrafficType = *data.TrafficType
 	}
+	if data.TrafficDirection != nil {
+		ocservUser.TrafficDirection = *data.TrafficDirection
+	}
+	if data.TrafficPeriod != nil {
+		ocservUser.TrafficPeriod = *data.TrafficPeriod
+	}
+	if data.TrafficResetDay != nil {
+		ocservUser.TrafficResetDay = *data.TrafficResetDay
+	}
+	if data.OverQuotaAction != nil {
+		ocservUser.OverQuotaAction = *data.OverQuotaAction
+		if ocservUser.OverQuotaAction != models.OverQuotaThrottle {
+			ocservUser.ThrottledAt = nil
+		}
+	}
+	if data.ThrottleRate != nil {
+		ocservUser.ThrottleRate = *data.ThrottleRate
+	}
+	if data.Schedule != nil {
+		ocservUser.Schedule = nil
+		if len(data.Schedule.Windows) > 0 {
+			if err = data.Schedule.Validate(); err != nil {
+				return ctl.request.BadRequest(c, err)
+			}
+			ocservUser.Schedule = data.Schedule
+		}
+	}
+	previousConfig := ocservUser.Config
 	if data.Config != nil {
 		ocservUser.Config = data.Config
 	}
@@ -230,11 +392,37 @@ func (ctl *Controller) UpdateOcservUser(c echo.Context) error {
 			ocservUser.ExpireAt = &expire
 		}
 	}
+	if data.Nodes != nil {
+		nodes, err := ctl.nodeRepo.GetByIDs(c.Request().Context(), *data.Nodes)
+		if err != nil {
+			return ctl.request.BadRequest(c, fmt.Errorf("invalid nodes: %w", err))
+		}
+		ocservUser.Nodes = nodes
+	}
+
+	labels := &repository.LabelEdit{Tags: data.Tags, Attributes: data.Attributes}
+	if err = ctl.labelRepo.CheckEdit(c.Request().Context(), labels); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 
-	updatedOcservUser, err := ctl.ocservUserRepo.Update(c.Request().Context(), ocservUser)
+	var updatedOcservUser *models.OcservUser
+	err = ctl.ipamRepo.SaveUser(c.Request().Context(), ocservUser, func() error {
+		var err2 error
+		updatedOcservUser, err2 = ctl.ocservUserRepo.Update(c.Request().Context(), ocservUser, labels)
+		return err2
+	})
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	ctl.configVersionRepo.RecordChange(
+		c.Request().Context(), models.ConfigKindUser, updatedOcservUser.Username,
+		previousConfig, updatedOcservUser.Config, c.Get("username").(string), "",
+	)
+
+	// reloaded with its labels
+	if updatedOcservUser, err = ctl.ocservUserRepo.GetByUID(c.Request().Context(), updatedOcservUser.UID); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
 	return c.JSON(http.StatusOK, updatedOcservUser)
 }
 
@@ -252,12 +440,12 @@ func (ctl *Controller) UpdateOcservUser(c echo.Context) error {
 // @Success      204  {object} nil
 // @Router       /ocserv/users/{uid} [delete]
 func (ctl *Controller) DeleteOcservUser(c echo.Context) error {
-	userID := c.Param("uid")
-	if userID == "" {
-		return ctl.request.BadRequest(c, errors.New("user id is required"))
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
-	username, err := ctl.ocservUserRepo.Delete(c.Request().Context(), userID)
+	username, err := ctl.ocservUserRepo.Delete(c.Request().Context(), ocservUser.UID)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
@@ -283,12 +471,12 @@ func (ctl *Controller) DeleteOcservUser(c echo.Context) error {
 // @Success      200  {object} nil
 // @Router       /ocserv/users/{uid}/lock [post]
 func (ctl *Controller) LockOcservUser(c echo.Context) error {
-	userID := c.Param("uid")
-	if userID == "" {
-		return ctl.request.BadRequest(c, errors.New("user id is required"))
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
-	err := ctl.ocservUserRepo.Lock(c.Request().Context(), userID)
+	err = ctl.ocservUserRepo.Lock(c.Request().Context(), ocservUser.UID)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
@@ -309,12 +497,12 @@ func (ctl *Controller) LockOcservUser(c echo.Context) error {
 // @Success      200  {object} nil
 // @Router       /ocserv/users/{uid}/unlock [post]
 func (ctl *Controller) UnLockOcservUser(c echo.Context) error {
-	userID := c.Param("uid")
-	if userID == "" {
-		return ctl.request.BadRequest(c, errors.New("user id is required"))
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
-	err := ctl.ocservUserRepo.UnLock(c.Request().Context(), userID)
+	err = ctl.ocservUserRepo.UnLock(c.Request().Context(), ocservUser.UID)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
@@ -357,9 +545,14 @@ func (ctl *Controller) DisconnectOcservUser(c echo.Context) error {
 // @Param 		 uid path string true "Ocserv User UID"
 // @Param 		 date_start query string false "date_start"
 // @Param 		 date_end query string false "date_end"
+// @Param 		 granularity query string false "granularity" Enums(hour, day, week, month)
+// @Param 		 tz query string false "IANA time zone of the buckets, UTC when empty. Buckets of time zones off UTC by a fraction of an hour start up to 59 minutes after their label"
+// @Param 		 cursor query string false "X-Next-Cursor header of the previous page of buckets"
+// @Param 		 size query int false "Number of buckets per page, all when empty" minimum(1)
 // @Failure      400 {object} request.ErrorResponse
 // @Failure      401 {object} middlewares.Unauthorized
 // @Success      200  {object} StatisticsResponse
+// @Header       200  {string} X-Next-Cursor "Cursor of the next page of buckets"
 // @Router       /ocserv/users/{uid}/statistics [get]
 func (ctl *Controller) StatisticsOcservUser(c echo.Context) error {
 	userID := c.Param("uid")
@@ -372,35 +565,25 @@ func (ctl *Controller) StatisticsOcservUser(c echo.Context) error {
 		return ctl.request.BadRequest(c, err)
 	}
 
-	var startDate, endDate *time.Time
-
-	if data.DateStart != "" {
-		t, err := time.Parse("2006-01-02", data.DateStart)
-		if err != nil {
-			return ctl.request.BadRequest(c, fmt.Errorf("invalid date_start: %w", err))
-		}
-		startDate = &t
+	series, err := repository.NewTrafficSeries(data.DateStart, data.DateEnd, data.Granularity, data.TZ)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
-
-	if data.DateEnd != "" {
-		t, err := time.Parse("2006-01-02", data.DateEnd)
-		if err != nil {
-			return ctl.request.BadRequest(c, fmt.Errorf("invalid date_end: %w", err))
-		}
-		t = t.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-		endDate = &t
+	if err = series.Page(data.Cursor, data.Size); err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
 	ctx := c.Request().Context()
 	var (
 		stats []models.DailyTraffic
 		total repository.TotalBandwidths
+		nodes []models.NodeBandwidths
 	)
 
 	g, ctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
-		s, err := ctl.ocservUserRepo.UserStatistics(ctx, userID, startDate, endDate)
+		s, err := ctl.ocservUserRepo.UserStatistics(ctx, userID, series)
 		if err != nil {
 			return err
 		}
@@ -417,13 +600,26 @@ func (ctl *Controller) StatisticsOcservUser(c echo.Context) error {
 		return nil
 	})
 
+	g.Go(func() error {
+		n, err := ctl.ocservUserRepo.NodeBandwidthsUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		nodes = n
+		return nil
+	})
+
 	if err := g.Wait(); err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	if series.NextCursor != "" {
+		c.Response().Header().Set(request.NextCursorHeader, series.NextCursor)
+	}
 
 	return c.JSON(http.StatusOK, StatisticsResponse{
 		Statistics:      stats,
 		TotalBandwidths: total,
+		NodeBandwidths:  nodes,
 	})
 }
 
@@ -437,9 +633,14 @@ func (ctl *Controller) StatisticsOcservUser(c echo.Context) error {
 // @Param        Authorization header string true "Bearer TOKEN"
 // @Param 		 date_start query string true "date_start"
 // @Param 		 date_end query string true "date_end"
+// @Param 		 granularity query string false "granularity" Enums(hour, day, week, month)
+// @Param 		 tz query string false "IANA time zone of the buckets, UTC when empty. Buckets of time zones off UTC by a fraction of an hour start up to 59 minutes after their label"
+// @Param 		 cursor query string false "X-Next-Cursor header of the previous page of buckets"
+// @Param 		 size query int false "Number of buckets per page, all when empty" minimum(1)
 // @Failure      400 {object} request.ErrorResponse
 // @Failure      401 {object} middlewares.Unauthorized
 // @Success      200 {object} []models.DailyTraffic
+// @Header       200 {string} X-Next-Cursor "Cursor of the next page of buckets"
 // @Router       /ocserv/users/statistics [get]
 func (ctl *Controller) Statistics(c echo.Context) error {
 	var data StatisticsData
@@ -451,29 +652,21 @@ func (ctl *Controller) Statistics(c echo.Context) error {
 		return ctl.request.BadRequest(c, errors.New("statistics date start and end are required"))
 	}
 
-	var startDate, endDate *time.Time
-
-	tStart, err := time.Parse("2006-01-02", data.DateStart)
-	if err != nil {
-		return ctl.request.BadRequest(c, fmt.Errorf("invalid date_start: %w", err))
-	}
-	startDate = &tStart
-
-	tEnd, err := time.Parse("2006-01-02", data.DateEnd)
+	series, err := repository.NewTrafficSeries(data.DateStart, data.DateEnd, data.Granularity, data.TZ)
 	if err != nil {
-		return ctl.request.BadRequest(c, fmt.Errorf("invalid date_end: %w", err))
+		return ctl.request.BadRequest(c, err)
 	}
-	tEnd = tEnd.Add(23*time.Hour + 59*time.Minute + 59*time.Second + 999999999*time.Nanosecond)
-	endDate = &tEnd
-
-	if tStart.After(*endDate) {
-		return ctl.request.BadRequest(c, errors.New("date start is after end"))
+	if err = series.Page(data.Cursor, data.Size); err != nil {
+		return ctl.request.BadRequest(c, err)
 	}
 
-	stats, err := ctl.ocservUserRepo.Statistics(c.Request().Context(), startDate, endDate)
+	stats, err := ctl.ocservUserRepo.Statistics(c.Request().Context(), series)
 	if err != nil {
 		return ctl.request.BadRequest(c, err)
 	}
+	if series.NextCursor != "" {
+		c.Response().Header().Set(request.NextCursorHeader, series.NextCursor)
+	}
 	return c.JSON(http.StatusOK, stats)
 }
 
@@ -590,6 +783,14 @@ func (ctl *Controller) SyncToDB(c echo.Context) error {
 		expireAt, _ = time.Parse("2006-01-02", time.Now().AddDate(0, 0, 30).Format("2006-01-02"))
 	}
 
+	var trafficDirection, trafficPeriod string
+	if data.TrafficDirection != nil {
+		trafficDirection = *data.TrafficDirection
+	}
+	if data.TrafficPeriod != nil {
+		trafficPeriod = *data.TrafficPeriod
+	}
+
 	var users []models.OcservUser
 	var wg sync.WaitGroup
 	var mux sync.Mutex
@@ -602,13 +803,16 @@ func (ctl *Controller) SyncToDB(c echo.Context) error {
 
 			newUser := models.OcservUser{
 				Username:    u.Username,
-				Password:    "Secret-Ocpasswd",
+				Password:    models.OcpasswdPassword,
 				Group:       u.Group,
 				Owner:       owner,
 				ExpireAt:    &expireAt,
 				TrafficSize: *data.TrafficSize,
 				TrafficType: *data.TrafficType,
 				Config:      data.Config,
+
+				TrafficDirection: trafficDirection,
+				TrafficPeriod:    trafficPeriod,
 			}
 
 			mux.Lock()
@@ -679,3 +883,881 @@ func (ctl *Controller) ActivateExpiredOcservUsers(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, nil)
 }
+
+// usersFilter builds the filter of the user list, limited to the users shared with staff.
+func (ctl *Controller) usersFilter(c echo.Context, data *OcservUsersFilterData) (*repository.OcservUsersFilter, error) {
+	filter := &repository.OcservUsersFilter{
+		Q:           data.Q,
+		Group:       data.Group,
+		TrafficType: data.TrafficType,
+		Locked:      data.Locked,
+		Expired:     data.Expired,
+		Deactivated: data.Deactivated,
+		Online:      data.Online,
+		UsageAbove:  data.UsageAbove,
+		Description: data.Description,
+	}
+
+	if isAdmin := c.Get("isAdmin").(bool); !isAdmin {
+		username := c.Get("username").(string)
+		if username == "" {
+			return nil, errors.New("invalid user uid")
+		}
+		filter.Owner = username
+	}
+
+	for _, date := range []struct {
+		name  string
+		value string
+		dest  **time.Time
+	}{
+		{"expire_before", data.ExpireBefore, &filter.ExpireBefore},
+		{"expire_after", data.ExpireAfter, &filter.ExpireAfter},
+		{"created_after", data.CreatedAfter, &filter.CreatedAfter},
+		{"created_before", data.CreatedBefore, &filter.CreatedBefore},
+	} {
+		if date.value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", date.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", date.name, err)
+		}
+		*date.dest = &t
+	}
+
+	labels, err := repository.ParseLabelFilter(data.Tags, data.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	if err = ctl.labelRepo.CheckFilter(c.Request().Context(), labels); err != nil {
+		return nil, err
+	}
+	filter.Labels = labels
+
+	if filter.Online != nil {
+		connected, err := ctl.ocservOcctlRepo.OnlineUsers()
+		if err != nil {
+			return nil, err
+		}
+		filter.Connected = connected
+	}
+	return filter, nil
+}
+
+// ownedOcservUser loads the ocserv user of the uid path param. Staff must be one of its owners.
+func (ctl *Controller) ownedOcservUser(c echo.Context) (*models.OcservUser, error) {
+	userID := c.Param("uid")
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	ocservUser, err := ctl.ocservUserRepo.GetByUID(c.Request().Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isAdmin := c.Get("isAdmin").(bool); !isAdmin {
+		ok, err := ctl.ocservUserRepo.IsOwner(c.Request().Context(), ocservUser, c.Get("username").(string))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("ocserv user is not owned by you")
+		}
+	}
+	return ocservUser, nil
+}
+
+// OcservUserOwners 	     Ocserv User owners
+//
+// @Summary      Ocserv User owners
+// @Description  Dashboard users sharing the ownership of the Ocserv User
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} []models.UsersLookup
+// @Router       /ocserv/users/{uid}/owners [get]
+func (ctl *Controller) OcservUserOwners(c echo.Context) error {
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	owners, err := ctl.ocservUserRepo.Owners(c.Request().Context(), ocservUser)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, owners)
+}
+
+// ShareOcservUser 	     Share Ocserv User
+//
+// @Summary      Share Ocserv User
+// @Description  Add a dashboard user to the owners of the Ocserv User
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param        request    body  OwnerData  true "dashboard user to share with"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} []models.UsersLookup
+// @Router       /ocserv/users/{uid}/owners [post]
+func (ctl *Controller) ShareOcservUser(c echo.Context) error {
+	var data OwnerData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	owner, err := ctl.userRepo.GetByUID(c.Request().Context(), data.UserUID)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.ocservUserRepo.AddOwner(c.Request().Context(), ocservUser, owner); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	owners, err := ctl.ocservUserRepo.Owners(c.Request().Context(), ocservUser)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, owners)
+}
+
+// UnshareOcservUser 	     Unshare Ocserv User
+//
+// @Summary      Unshare Ocserv User
+// @Description  Remove a dashboard user from the owners of the Ocserv User. The primary owner cannot be removed.
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param 		 owner_uid path string true "Owner User UID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      204 {object} nil
+// @Router       /ocserv/users/{uid}/owners/{owner_uid} [delete]
+func (ctl *Controller) UnshareOcservUser(c echo.Context) error {
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	owner, err := ctl.userRepo.GetByUID(c.Request().Context(), c.Param("owner_uid"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.ocservUserRepo.RemoveOwner(c.Request().Context(), ocservUser, owner); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
+
+// TransferOcservUser 	     Transfer Ocserv User
+//
+// @Summary      Transfer Ocserv User
+// @Description  Make a dashboard user the primary and only owner of the Ocserv User. Only admins and the primary owner can transfer it. Reseller quotas of the new owner apply.
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param        request    body  OwnerData  true "new owner"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} models.OcservUser
+// @Router       /ocserv/users/{uid}/owners/transfer [post]
+func (ctl *Controller) TransferOcservUser(c echo.Context) error {
+	var data OwnerData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	// co-owners share the user, they cannot take it from its primary owner
+	if isAdmin := c.Get("isAdmin").(bool); !isAdmin && ocservUser.Owner != c.Get("username").(string) {
+		return ctl.request.BadRequest(c, errors.New("only the primary owner or an admin can transfer the ocserv user"))
+	}
+
+	owner, err := ctl.userRepo.GetByUID(c.Request().Context(), data.UserUID)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.ocservUserRepo.TransferOwner(c.Request().Context(), ocservUser, owner); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, ocservUser)
+}
+
+// RenewOcservUser 	     Renew Ocserv User with a plan
+//
+// @Summary      Renew Ocserv User with a plan
+// @Description  Apply the group and traffic of the plan, extend the expiry by the plan duration, reset the counters and unlock the user
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param        request    body  RenewOcservUserData  true "plan to renew with"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} models.OcservUser
+// @Router       /ocserv/users/{uid}/renew [post]
+func (ctl *Controller) RenewOcservUser(c echo.Context) error {
+	var data RenewOcservUserData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	planID := ocservUser.PlanID
+	if data.PlanID != nil {
+		planID = data.PlanID
+	}
+	if planID == nil {
+		return ctl.request.BadRequest(c, errors.New("plan_id is required for users without a plan"))
+	}
+
+	plan, err := ctl.planRepo.GetByID(c.Request().Context(), *planID)
+	if err != nil {
+		return ctl.request.BadRequest(c, fmt.Errorf("invalid plan: %w", err))
+	}
+	if !plan.IsActive {
+		return ctl.request.BadRequest(c, errors.New("plan is not active"))
+	}
+
+	previousConfig := ocservUser.Config
+	u, err := ctl.ocservUserRepo.Renew(c.Request().Context(), ocservUser, plan)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	ctl.configVersionRepo.RecordChange(
+		c.Request().Context(), models.ConfigKindUser, u.Username,
+		previousConfig, u.Config, c.Get("username").(string), "",
+	)
+	return c.JSON(http.StatusOK, u)
+}
+
+// TopUpOcservUser 	     Top up Ocserv User traffic
+//
+// @Summary      Top up Ocserv User traffic
+// @Description  Add extra traffic on top of the traffic size of the user. Users locked or throttled for exceeding their quota are restored once back under it
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param        request    body  TopUpData  true "top-up data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      201 {object} TopUpResponse
+// @Router       /ocserv/users/{uid}/top-ups [post]
+func (ctl *Controller) TopUpOcservUser(c echo.Context) error {
+	var data TopUpData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if ocservUser.TrafficPolicy() == nil {
+		return ctl.request.BadRequest(c, errors.New("users with free traffic cannot be topped up"))
+	}
+
+	topUp := &models.TrafficTopUp{
+		Amount: data.Amount,
+		Author: c.Get("username").(string),
+		Note:   data.Note,
+	}
+	if data.ExpireAt != "" {
+		expireAt, err := time.Parse("2006-01-02", data.ExpireAt)
+		if err != nil {
+			return ctl.request.BadRequest(c, fmt.Errorf("invalid expire_at: %w", err))
+		}
+		if !expireAt.After(time.Now()) {
+			return ctl.request.BadRequest(c, errors.New("expire_at must be in the future"))
+		}
+		topUp.ExpireAt = &expireAt
+	}
+
+	traffic, err := ctl.ocservUserRepo.TopUp(c.Request().Context(), ocservUser, topUp)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, TopUpResponse{
+		TopUp:   *topUp,
+		Traffic: traffic,
+	})
+}
+
+// OcservUserTopUps 	     List of Ocserv User top-ups
+//
+// @Summary      List of Ocserv User top-ups
+// @Description  Ledger of the traffic top-ups of the user
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} TopUpsResponse
+// @Router       /ocserv/users/{uid}/top-ups [get]
+func (ctl *Controller) OcservUserTopUps(c echo.Context) error {
+	pagination := ctl.request.Pagination(c)
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	topUps, total, err := ctl.ocservUserRepo.TopUps(c.Request().Context(), ocservUser, pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, TopUpsResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: topUps,
+	})
+}
+
+// EditOcservUsersLabels 	     Edit the labels of Ocserv Users
+//
+// @Summary      Edit the labels of Ocserv Users
+// @Description  Edit the tags and custom attributes of several users at once. tags replaces the tags, then add_tags and remove_tags apply.
+// @Description  attributes sets the given attributes and removes those set to null. Non-admin users only edit the users shared with them.
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  EditOcservUsersLabelsData  true "labels edit"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} EditLabelsResponse
+// @Router       /ocserv/users/labels [post]
+func (ctl *Controller) EditOcservUsersLabels(c echo.Context) error {
+	var data EditOcservUsersLabelsData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	owner := ""
+	if isAdmin := c.Get("isAdmin").(bool); !isAdmin {
+		owner = c.Get("username").(string)
+		if owner == "" {
+			return ctl.request.BadRequest(c, errors.New("invalid username context"))
+		}
+	}
+
+	edited, err := ctl.labelRepo.EditUsers(c.Request().Context(), data.UIDs, owner, &repository.LabelEdit{
+		Tags:       data.Tags,
+		AddTags:    data.AddTags,
+		RemoveTags: data.RemoveTags,
+		Attributes: data.Attributes,
+	})
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, EditLabelsResponse{Edited: edited})
+}
+
+// ImportOcservUsers 	     Import Ocserv Users from CSV
+//
+// @Summary      Import Ocserv Users from CSV
+// @Description  Create Ocserv Users from a CSV file with the columns username, password, group, traffic_type, traffic_size (GiB), traffic_direction, traffic_period, expire_at (YYYY-MM-DD), description,
+// @Description  plan (name of an active plan filling the group, traffic, expiry and config left empty), tags (comma separated) and attributes (JSON object of defined custom attributes).
+// @Description  Only username is required; an empty password is generated. Rows are validated one by one and invalid rows are skipped.
+// @Description  With dry_run=true nothing is created and the validated rows are returned as a preview. The file is limited to 8 MiB and 5000 rows.
+// @Tags         Ocserv(Users)
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 file formData file true "CSV file"
+// @Param 		 dry_run query bool false "validate only"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} ImportOcservUsersResponse
+// @Router       /ocserv/users/import [post]
+func (ctl *Controller) ImportOcservUsers(c echo.Context) error {
+	owner := c.Get("username").(string)
+	if owner == "" {
+		return ctl.request.BadRequest(c, errors.New("admin or staff username not found"))
+	}
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxImportSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return ctl.request.BadRequest(c, fmt.Errorf("csv file is required: %w", err))
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	defer file.Close()
+
+	rows, err := parseImportCSV(file)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ctx := c.Request().Context()
+
+	groups, err := ctl.ocservGroupRepo.GroupsLookup(ctx, "")
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	activePlans, err := ctl.planRepo.PlansLookup(ctx)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	plans := make(map[string]*models.Plan, len(activePlans))
+	for i := range activePlans {
+		plans[activePlans[i].Name] = &activePlans[i]
+	}
+
+	resp := ImportOcservUsersResponse{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   rows,
+	}
+
+	seen := make(map[string]struct{}, len(rows))
+	// the users of the previous valid rows, not created in a preview, still take reseller quota
+	var pending repository.ResellerAllocation
+	for i := range rows {
+		row := &rows[i]
+		plan := validateImportRow(row, groups, plans, seen)
+		if _, err = ctl.ocservUserRepo.GetByUsername(ctx, row.Username); err == nil {
+			row.Errors = append(row.Errors, "username already exists")
+		}
+		labels := &repository.LabelEdit{AddTags: row.Tags, Attributes: row.Attributes}
+		if err = ctl.labelRepo.CheckEdit(ctx, labels); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		expireAt, _ := time.Parse("2006-01-02", row.ExpireAt)
+		ocUser := &models.OcservUser{
+			Owner:       owner,
+			Username:    row.Username,
+			Password:    row.Password,
+			Group:       row.Group,
+			ExpireAt:    &expireAt,
+			TrafficType: row.TrafficType,
+			TrafficSize: row.TrafficSize,
+			Description: row.Description,
+			// an empty config leaves every setting to the group
+			Config: &models.OcservUserConfig{},
+
+			TrafficDirection: row.TrafficDirection,
+			TrafficPeriod:    row.TrafficPeriod,
+		}
+		if plan != nil {
+			ocUser.PlanID = &plan.ID
+			ocUser.OverQuotaAction = plan.OverQuotaAction
+			ocUser.ThrottleRate = plan.ThrottleRate
+			if plan.Config != nil {
+				ocUser.Config = plan.Config
+			}
+		}
+		if err = ctl.resellerRepo.CheckPending(ctx, owner, ocUser, pending); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+		resp.Valid++
+
+		if dryRun {
+			pending.Add(ocUser)
+			continue
+		}
+		err = ctl.ipamRepo.SaveUser(ctx, ocUser, func() error {
+			_, err2 := ctl.ocservUserRepo.Create(ctx, ocUser, labels)
+			return err2
+		})
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+		ctl.configVersionRepo.RecordChange(
+			ctx, models.ConfigKindUser, ocUser.Username, nil, ocUser.Config, c.Get("username").(string), "",
+		)
+		row.Created = true
+		resp.Created++
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ExportOcservUsers 	     Export Ocserv Users
+//
+// @Summary      Export Ocserv Users
+// @Description  Export Ocserv Users matching the filters of the list as CSV or XLSX. The CSV can be imported again.
+// @Description  Passwords are left empty, and generated again on import, unless an admin sets with_passwords.
+// @Description  Cells starting with = + - or @ are prefixed with a quote so that spreadsheets do not run them as formulas.
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 format query string false "file format" Enums(csv, xlsx)
+// @Param 		 with_passwords query bool false "export the passwords in plain text, admins only"
+// @Param 		 q query string false "ocserv username q search" minLength(2)
+// @Param 		 group query string false "group name"
+// @Param 		 traffic_type query string false "traffic type" Enums(Free, MonthlyTransmit, MonthlyReceive, TotallyTransmit, TotallyReceive, Custom)
+// @Param 		 locked query bool false "locked users"
+// @Param 		 expired query bool false "users expired before today"
+// @Param 		 deactivated query bool false "deactivated users"
+// @Param 		 online query bool false "users connected now"
+// @Param 		 expire_before query string false "expire date on or before, YYYY-MM-DD"
+// @Param 		 expire_after query string false "expire date on or after, YYYY-MM-DD"
+// @Param 		 usage_above query number false "percent of the quota used in the current period, active top-ups included"
+// @Param 		 created_after query string false "created on or after, YYYY-MM-DD"
+// @Param 		 created_before query string false "created on or before, YYYY-MM-DD"
+// @Param 		 description query string false "description search"
+// @Param 		 tag query []string false "tags the users all have, repeated or comma separated" collectionFormat(multi)
+// @Param 		 attr query []string false "comparisons of defined custom attributes such as department=sales or seats>=10, with = != > >= < <=, only = and != for bool attributes" collectionFormat(multi)
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {file} file
+// @Router       /ocserv/users/export [get]
+func (ctl *Controller) ExportOcservUsers(c echo.Context) error {
+	var data OcservUsersFilterData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	filter, err := ctl.usersFilter(c, &data)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		return ctl.request.BadRequest(c, errors.New("format must be csv or xlsx"))
+	}
+	withPasswords, _ := strconv.ParseBool(c.QueryParam("with_passwords"))
+	if isAdmin := c.Get("isAdmin").(bool); withPasswords && !isAdmin {
+		return ctl.request.BadRequest(c, errors.New("only admins can export passwords"))
+	}
+
+	users, err := ctl.ocservUserRepo.UsersExport(c.Request().Context(), filter)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var buf bytes.Buffer
+	contentType := "text/csv"
+	if format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = writeUsersXLSX(&buf, users, withPasswords)
+	} else {
+		err = writeUsersCSV(&buf, users, withPasswords)
+	}
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	filename := fmt.Sprintf("ocserv-users-%s.%s", time.Now().Format("20060102"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
+
+// scheduledActionID parses the id path param.
+func scheduledActionID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid scheduled action id")
+	}
+	return uint(id), nil
+}
+
+// checkScheduledAction checks the action runs in the future and, for group and plan changes, that the
+// group exists or the plan is active and that the changed user fits the reseller quotas of its owner.
+func (ctl *Controller) checkScheduledAction(c echo.Context, ocservUser *models.OcservUser, action *models.ScheduledAction) error {
+	if err := action.Validate(); err != nil {
+		return err
+	}
+	if !action.RunAt.After(time.Now()) {
+		return errors.New("run_at must be in the future")
+	}
+
+	changed := *ocservUser
+	switch action.Action {
+	case models.ScheduledChangeGroup:
+		if action.Group != "defaults" {
+			if _, err := ctl.ocservGroupRepo.GetByName(c.Request().Context(), action.Group); err != nil {
+				return fmt.Errorf("group %s not found", action.Group)
+			}
+		}
+		changed.Group = action.Group
+	case models.ScheduledChangePlan:
+		plan, err := ctl.planRepo.GetByID(c.Request().Context(), *action.PlanID)
+		if err != nil {
+			return fmt.Errorf("invalid plan: %w", err)
+		}
+		if !plan.IsActive {
+			return errors.New("plan is not active")
+		}
+		changed.ApplyPlan(plan, action.RunAt)
+	default:
+		return nil
+	}
+	return ctl.resellerRepo.Check(c.Request().Context(), ocservUser.Owner, &changed)
+}
+
+// OcservUserScheduledActions 	     List of Ocserv User scheduled actions
+//
+// @Summary      List of Ocserv User scheduled actions
+// @Description  Actions scheduled on the user, pending ones and the history of executed ones with their result
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param 		 status query string false "action status" Enums(pending, done, failed)
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} ScheduledActionsResponse
+// @Router       /ocserv/users/{uid}/scheduled-actions [get]
+func (ctl *Controller) OcservUserScheduledActions(c echo.Context) error {
+	pagination := ctl.request.Pagination(c)
+
+	var data ScheduledActionsData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	actions, total, err := ctl.scheduledRepo.Actions(c.Request().Context(), ocservUser.ID, data.Status, pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ScheduledActionsResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: actions,
+	})
+}
+
+// OcservUserScheduledAction 	     Ocserv User scheduled action
+//
+// @Summary      Ocserv User scheduled action
+// @Description  Ocserv User scheduled action
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param 		 id path int true "Scheduled action ID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} models.ScheduledAction
+// @Router       /ocserv/users/{uid}/scheduled-actions/{id} [get]
+func (ctl *Controller) OcservUserScheduledAction(c echo.Context) error {
+	id, err := scheduledActionID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	action, err := ctl.scheduledRepo.GetAction(c.Request().Context(), ocservUser.ID, id)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, action)
+}
+
+// CreateOcservUserScheduledAction 	     Schedule an action on Ocserv User
+//
+// @Summary      Schedule an action on Ocserv User
+// @Description  Schedule a lock, unlock, delete, group change, plan change or traffic reset of the user. The user expiry service runs it once due, or as soon as it starts again if it was down, and records the result.
+// @Description  change_plan renews the user on the plan as the renew endpoint does.
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param        request    body  ScheduledActionData  true "scheduled action"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      201 {object} models.ScheduledAction
+// @Router       /ocserv/users/{uid}/scheduled-actions [post]
+func (ctl *Controller) CreateOcservUserScheduledAction(c echo.Context) error {
+	var data ScheduledActionData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	action := &models.ScheduledAction{
+		OcUserID:  ocservUser.ID,
+		Action:    data.Action,
+		Group:     data.Group,
+		PlanID:    data.PlanID,
+		RunAt:     data.RunAt.UTC(),
+		CreatedBy: c.Get("username").(string),
+		Status:    models.ScheduledPending,
+	}
+	if err = ctl.checkScheduledAction(c, ocservUser, action); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	action, err = ctl.scheduledRepo.CreateAction(c.Request().Context(), action)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, action)
+}
+
+// UpdateOcservUserScheduledAction 	     Update Ocserv User scheduled action
+//
+// @Summary      Update Ocserv User scheduled action
+// @Description  Change the action, target or run time of a pending action
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param 		 id path int true "Scheduled action ID"
+// @Param        request    body  UpdateScheduledActionData  true "scheduled action update"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {object} models.ScheduledAction
+// @Router       /ocserv/users/{uid}/scheduled-actions/{id} [patch]
+func (ctl *Controller) UpdateOcservUserScheduledAction(c echo.Context) error {
+	var data UpdateScheduledActionData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	id, err := scheduledActionID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	action, err := ctl.scheduledRepo.GetAction(c.Request().Context(), ocservUser.ID, id)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if action.Status != models.ScheduledPending {
+		return ctl.request.BadRequest(c, errors.New("only pending actions can be updated"))
+	}
+
+	if data.Action != nil {
+		action.Action = *data.Action
+	}
+	if data.Group != nil {
+		action.Group = *data.Group
+	}
+	if data.PlanID != nil {
+		action.PlanID = data.PlanID
+	}
+	if data.RunAt != nil {
+		action.RunAt = data.RunAt.UTC()
+	}
+	if err = ctl.checkScheduledAction(c, ocservUser, action); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	action, err = ctl.scheduledRepo.UpdateAction(c.Request().Context(), action)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, action)
+}
+
+// DeleteOcservUserScheduledAction 	     Delete Ocserv User scheduled action
+//
+// @Summary      Delete Ocserv User scheduled action
+// @Description  Cancel a pending action, or remove an executed one from the history
+// @Tags         Ocserv(Users)
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 uid path string true "Ocserv User UID"
+// @Param 		 id path int true "Scheduled action ID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      204 {object} nil
+// @Router       /ocserv/users/{uid}/scheduled-actions/{id} [delete]
+func (ctl *Controller) DeleteOcservUserScheduledAction(c echo.Context) error {
+	id, err := scheduledActionID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	ocservUser, err := ctl.ownedOcservUser(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.scheduledRepo.DeleteAction(c.Request().Context(), ocservUser.ID, id); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
@@ -10,15 +10,31 @@ func Routes(e *echo.Group) {
 	g := e.Group("/ocserv/users", middlewares.AuthMiddleware())
 
 	g.GET("", ctl.OcservUsers)
+	g.GET("/export", ctl.ExportOcservUsers)
+	g.POST("/import", ctl.ImportOcservUsers)
+	g.POST("/labels", ctl.EditOcservUsersLabels)
 	g.GET("/:uid", ctl.OcservUser)
+	g.GET("/:uid/effective-config", ctl.EffectiveConfigOcservUser)
 	g.POST("", ctl.CreateOcservUser)
 	g.PATCH("/:uid", ctl.UpdateOcservUser)
 	g.DELETE("/:uid", ctl.DeleteOcservUser)
 	g.POST("/:uid/lock", ctl.LockOcservUser)
 	g.POST("/:uid/unlock", ctl.UnLockOcservUser)
 	g.POST("/:uid/activate", ctl.ActivateExpiredOcservUsers)
+	g.POST("/:uid/renew", ctl.RenewOcservUser)
+	g.POST("/:uid/top-ups", ctl.TopUpOcservUser, middlewares.AdminPermission())
+	g.GET("/:uid/top-ups", ctl.OcservUserTopUps)
+	g.GET("/:uid/scheduled-actions", ctl.OcservUserScheduledActions)
+	g.POST("/:uid/scheduled-actions", ctl.CreateOcservUserScheduledAction)
+	g.GET("/:uid/scheduled-actions/:id", ctl.OcservUserScheduledAction)
+	g.PATCH("/:uid/scheduled-actions/:id", ctl.UpdateOcservUserScheduledAction)
+	g.DELETE("/:uid/scheduled-actions/:id", ctl.DeleteOcservUserScheduledAction)
 	g.POST("/:username/disconnect", ctl.DisconnectOcservUser)
 	g.GET("/:uid/statistics", ctl.StatisticsOcservUser)
+	g.GET("/:uid/owners", ctl.OcservUserOwners)
+	g.POST("/:uid/owners", ctl.ShareOcservUser)
+	g.DELETE("/:uid/owners/:owner_uid", ctl.UnshareOcservUser)
+	g.POST("/:uid/owners/transfer", ctl.TransferOcservUser)
 	g.GET("/statistics", ctl.Statistics, middlewares.AdminPermission())
 	g.GET("/total-bandwidth", ctl.TotalBandwidth, middlewares.AdminPermission())
 	g.GET("/ocpasswd", ctl.OcpasswdUsers, middlewares.AdminPermission())
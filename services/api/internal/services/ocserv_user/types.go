@@ -5,27 +5,71 @@ import (
 	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
 	"github.com/mmtaee/ocserv-users-management/common/models"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
+	"time"
 )
 
 type CreateOcservUserData struct {
-	Group       string                   `json:"group" validate:"required"`
-	Username    string                   `json:"username" validate:"required,min=2,max=32"`
-	Password    string                   `json:"password" validate:"required,min=2,max=32"`
-	ExpireAt    string                   `json:"expire_at" validate:"omitempty" example:"2025-12-31"`
-	TrafficType string                   `json:"traffic_type" validate:"required,oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive" example:"MonthlyTransmit"`
-	TrafficSize int                      `json:"traffic_size" validate:"omitempty,gte=0" example:"10737418240"` // 10 GiB
-	Description string                   `json:"description" validate:"omitempty,max=1024" example:"User for testing VPN access"`
-	Config      *models.OcservUserConfig `json:"config" validate:"required"`
+	Group            string                   `json:"group" validate:"required_without=PlanID"`
+	Username         string                   `json:"username" validate:"required,min=2,max=32"`
+	Password         string                   `json:"password" validate:"required,min=2,max=32"`
+	ExpireAt         string                   `json:"expire_at" validate:"omitempty" example:"2025-12-31"`
+	TrafficType      string                   `json:"traffic_type" validate:"required_without=PlanID,omitempty,oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive Custom" example:"MonthlyTransmit"`
+	TrafficSize      int                      `json:"traffic_size" validate:"omitempty,gte=0" example:"10737418240"` // 10 GiB
+	TrafficDirection string                   `json:"traffic_direction" validate:"required_if=TrafficType Custom,omitempty,oneof=rx tx both" example:"both"`
+	TrafficPeriod    string                   `json:"traffic_period" validate:"required_if=TrafficType Custom,omitempty,oneof=daily weekly monthly lifetime" example:"monthly"`
+	TrafficResetDay  int                      `json:"traffic_reset_day" validate:"omitempty,min=0,max=31" example:"15"` // day of month monthly periods start on, 0 for the signup day
+	OverQuotaAction  string                   `json:"over_quota_action" validate:"omitempty,oneof=lock throttle" example:"throttle"`
+	ThrottleRate     int                      `json:"throttle_rate" validate:"required_if=OverQuotaAction throttle,omitempty,gte=0" example:"65536"` // rx/tx bytes per second once over quota
+	Description      string                   `json:"description" validate:"omitempty,max=1024" example:"User for testing VPN access"`
+	Config           *models.OcservUserConfig `json:"config" validate:"required_without=PlanID"`
+	Nodes            []uint                   `json:"nodes" validate:"omitempty" example:"1,2"`
+	PlanID           *uint                    `json:"plan_id" validate:"omitempty" example:"1"` // fills group, traffic, expiry and config left empty
+	Schedule         *models.AccessSchedule   `json:"schedule" validate:"omitempty"`            // defaults to the schedule of the group
+	Tags             []string                 `json:"tags" validate:"omitempty" example:"vip,eu"`
+	Attributes       models.Attributes        `json:"attributes" validate:"omitempty"` // values of defined custom attributes
 }
 
 type UpdateOcservUserData struct {
-	Group       *string                  `json:"group" example:"default"`
-	Password    *string                  `json:"password" validate:"min=2,max=32"`
-	ExpireAt    *string                  `json:"expire_at"  validate:"omitempty" example:"2025-12-31"`
-	TrafficType *string                  `json:"traffic_type" validate:"oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive" example:"MonthlyTransmit"`
-	TrafficSize *int                     `json:"traffic_size" validate:"gte=0" example:"10737418240"` // 10 GiB
-	Description *string                  `json:"description" validate:"omitempty,max=1024" example:"User for testing VPN access"`
-	Config      *models.OcservUserConfig `json:"config" validate:"omitempty"`
+	Group            *string                  `json:"group" example:"default"`
+	Password         *string                  `json:"password" validate:"min=2,max=32"`
+	ExpireAt         *string                  `json:"expire_at"  validate:"omitempty" example:"2025-12-31"`
+	TrafficType      *string                  `json:"traffic_type" validate:"oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive Custom" example:"MonthlyTransmit"`
+	TrafficSize      *int                     `json:"traffic_size" validate:"gte=0" example:"10737418240"` // 10 GiB
+	TrafficDirection *string                  `json:"traffic_direction" validate:"omitempty,oneof=rx tx both" example:"both"`
+	TrafficPeriod    *string                  `json:"traffic_period" validate:"omitempty,oneof=daily weekly monthly lifetime" example:"monthly"`
+	TrafficResetDay  *int                     `json:"traffic_reset_day" validate:"omitempty,min=0,max=31" example:"15"`
+	OverQuotaAction  *string                  `json:"over_quota_action" validate:"omitempty,oneof=lock throttle" example:"throttle"`
+	ThrottleRate     *int                     `json:"throttle_rate" validate:"omitempty,gte=0" example:"65536"`
+	Description      *string                  `json:"description" validate:"omitempty,max=1024" example:"User for testing VPN access"`
+	Config           *models.OcservUserConfig `json:"config" validate:"omitempty"`
+	Nodes            *[]uint                  `json:"nodes" validate:"omitempty" example:"1,2"`
+	Schedule         *models.AccessSchedule   `json:"schedule" validate:"omitempty"`              // a schedule without windows falls back to the group schedule
+	Tags             *[]string                `json:"tags" validate:"omitempty" example:"vip,eu"` // replaces the tags
+	Attributes       models.Attributes        `json:"attributes" validate:"omitempty"`            // sets the given attributes, null removes one
+}
+
+type OcservUserResponse struct {
+	models.OcservUser
+	ScheduleState *models.ScheduleState `json:"schedule_state" validate:"omitempty"` // nil without a user or group schedule
+}
+
+// OcservUsersFilterData filters the user list and export. Dates are YYYY-MM-DD and their bounds inclusive.
+type OcservUsersFilterData struct {
+	Q             string   `query:"q" validate:"omitempty"`
+	Group         string   `query:"group" validate:"omitempty"`
+	TrafficType   string   `query:"traffic_type" validate:"omitempty,oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive Custom"`
+	Locked        *bool    `query:"locked" validate:"omitempty"`
+	Expired       *bool    `query:"expired" validate:"omitempty"`
+	Deactivated   *bool    `query:"deactivated" validate:"omitempty"`
+	Online        *bool    `query:"online" validate:"omitempty"`
+	ExpireBefore  string   `query:"expire_before" validate:"omitempty"`
+	ExpireAfter   string   `query:"expire_after" validate:"omitempty"`
+	UsageAbove    *float64 `query:"usage_above" validate:"omitempty,gte=0"`
+	CreatedAfter  string   `query:"created_after" validate:"omitempty"`
+	CreatedBefore string   `query:"created_before" validate:"omitempty"`
+	Description   string   `query:"description" validate:"omitempty"`
+	Tags          []string `query:"tag" validate:"omitempty"`
+	Attributes    []string `query:"attr" validate:"omitempty"` // comparisons such as department=sales, see repository.ParseLabelFilter
 }
 
 type OcservUsersResponse struct {
@@ -34,13 +78,18 @@ type OcservUsersResponse struct {
 }
 
 type StatisticsData struct {
-	DateStart string `json:"date_start" query:"date_start" validate:"omitempty" example:"2025-1-31"`
-	DateEnd   string `json:"date_end" query:"date_end" validate:"omitempty" example:"2025-12-31"`
+	DateStart   string `json:"date_start" query:"date_start" validate:"omitempty" example:"2025-1-31"`
+	DateEnd     string `json:"date_end" query:"date_end" validate:"omitempty" example:"2025-12-31"`
+	Granularity string `json:"granularity" query:"granularity" validate:"omitempty,oneof=hour day week month" example:"day"`
+	TZ          string `json:"tz" query:"tz" validate:"omitempty" example:"Europe/Berlin"` // IANA name, UTC when empty
+	Cursor      string `json:"cursor" query:"cursor" validate:"omitempty"`                 // X-Next-Cursor of the previous page
+	Size        int    `json:"size" query:"size" validate:"omitempty,min=1"`               // buckets per page, all when 0
 }
 
 type StatisticsResponse struct {
 	Statistics      []models.DailyTraffic      `json:"statistics" validate:"required"`
 	TotalBandwidths repository.TotalBandwidths `json:"total_bandwidths" validate:"required"`
+	NodeBandwidths  []models.NodeBandwidths    `json:"node_bandwidths" validate:"omitempty"`
 }
 
 type TotalBandwidthData struct {
@@ -49,12 +98,14 @@ type TotalBandwidthData struct {
 }
 
 type SyncOcpasswdRequest struct {
-	Users       []user.Ocpasswd          `json:"users" validate:"required"`
-	ExpireAt    *string                  `json:"expire_at" validate:"omitempty" example:"2025-12-31"`
-	TrafficType *string                  `json:"traffic_type" validate:"oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive" example:"MonthlyTransmit"`
-	TrafficSize *int                     `json:"traffic_size" validate:"gte=0" example:"10737418240"` // 10 GiB
-	Description *string                  `json:"description" validate:"omitempty,max=1024" example:"User for testing VPN access"`
-	Config      *models.OcservUserConfig `json:"config" validate:"omitempty"`
+	Users            []user.Ocpasswd          `json:"users" validate:"required"`
+	ExpireAt         *string                  `json:"expire_at" validate:"omitempty" example:"2025-12-31"`
+	TrafficType      *string                  `json:"traffic_type" validate:"oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive Custom" example:"MonthlyTransmit"`
+	TrafficSize      *int                     `json:"traffic_size" validate:"gte=0" example:"10737418240"` // 10 GiB
+	TrafficDirection *string                  `json:"traffic_direction" validate:"omitempty,oneof=rx tx both" example:"both"`
+	TrafficPeriod    *string                  `json:"traffic_period" validate:"omitempty,oneof=daily weekly monthly lifetime" example:"monthly"`
+	Description      *string                  `json:"description" validate:"omitempty,max=1024" example:"User for testing VPN access"`
+	Config           *models.OcservUserConfig `json:"config" validate:"omitempty"`
 }
 
 type OcservUsersSyncResponse struct {
@@ -65,3 +116,91 @@ type OcservUsersSyncResponse struct {
 type ActivateUserData struct {
 	ExpireAt *string `json:"expire_at" validate:"omitempty" example:"2025-12-31"`
 }
+
+type OwnerData struct {
+	UserUID string `json:"user_uid" validate:"required" example:"01K8Z4F2V9XQ3M6P7R0S1T2U3W"`
+}
+
+type RenewOcservUserData struct {
+	PlanID *uint `json:"plan_id" validate:"omitempty" example:"1"` // defaults to the current plan of the user
+}
+
+type TopUpData struct {
+	Amount   int    `json:"amount" validate:"required,gte=1" example:"10"`       // in GiB
+	ExpireAt string `json:"expire_at" validate:"omitempty" example:"2025-12-31"` // defaults to the end of the current traffic period
+	Note     string `json:"note" validate:"omitempty,max=1024" example:"Paid invoice 1024"`
+}
+
+type TopUpResponse struct {
+	TopUp   models.TrafficTopUp  `json:"top_up" validate:"required"`
+	Traffic *models.TrafficUsage `json:"traffic" validate:"omitempty"`
+}
+
+type TopUpsResponse struct {
+	Meta   request.Meta          `json:"meta" validate:"required"`
+	Result []models.TrafficTopUp `json:"result" validate:"omitempty"`
+}
+
+// ScheduledActionData schedules an action on the user. RunAt is RFC 3339 and must be in the future.
+type ScheduledActionData struct {
+	Action string    `json:"action" validate:"required,oneof=lock unlock delete change_group change_plan reset_traffic" example:"change_group"`
+	Group  string    `json:"group" validate:"required_if=Action change_group,omitempty" example:"staff"` // group of change_group
+	PlanID *uint     `json:"plan_id" validate:"required_if=Action change_plan,omitempty" example:"1"`    // plan of change_plan
+	RunAt  time.Time `json:"run_at" validate:"required" example:"2026-01-01T00:00:00Z"`
+}
+
+type UpdateScheduledActionData struct {
+	Action *string    `json:"action" validate:"omitempty,oneof=lock unlock delete change_group change_plan reset_traffic" example:"change_group"`
+	Group  *string    `json:"group" validate:"omitempty" example:"staff"`
+	PlanID *uint      `json:"plan_id" validate:"omitempty" example:"1"`
+	RunAt  *time.Time `json:"run_at" validate:"omitempty" example:"2026-01-01T00:00:00Z"`
+}
+
+type ScheduledActionsData struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending done failed"`
+}
+
+type ScheduledActionsResponse struct {
+	Meta   request.Meta             `json:"meta" validate:"required"`
+	Result []models.ScheduledAction `json:"result" validate:"omitempty"`
+}
+
+type ImportOcservUserRow struct {
+	Line              int               `json:"line" validate:"required"`
+	Username          string            `json:"username" validate:"required"`
+	Password          string            `json:"password" validate:"required"`
+	PasswordGenerated bool              `json:"password_generated" validate:"required"`
+	Group             string            `json:"group" validate:"required"`
+	TrafficType       string            `json:"traffic_type" validate:"required"`
+	TrafficSize       int               `json:"traffic_size" validate:"required"` // in GiB
+	TrafficDirection  string            `json:"traffic_direction" validate:"omitempty"`
+	TrafficPeriod     string            `json:"traffic_period" validate:"omitempty"`
+	ExpireAt          string            `json:"expire_at" validate:"required"`
+	Description       string            `json:"description" validate:"omitempty"`
+	Plan              string            `json:"plan" validate:"omitempty"` // name of an active plan
+	Tags              []string          `json:"tags" validate:"omitempty"`
+	Attributes        models.Attributes `json:"attributes" validate:"omitempty"`
+	Created           bool              `json:"created" validate:"required"`
+	Errors            []string          `json:"errors,omitempty" validate:"omitempty"`
+}
+
+type ImportOcservUsersResponse struct {
+	DryRun  bool                  `json:"dry_run" validate:"required"`
+	Total   int                   `json:"total" validate:"required"`
+	Valid   int                   `json:"valid" validate:"required"`
+	Created int                   `json:"created" validate:"required"`
+	Rows    []ImportOcservUserRow `json:"rows" validate:"required"`
+}
+
+// EditOcservUsersLabelsData edits the tags and custom attributes of several users at once.
+type EditOcservUsersLabelsData struct {
+	UIDs       []string          `json:"uids" validate:"required,min=1,max=1000,unique,dive,required"`
+	Tags       *[]string         `json:"tags" validate:"omitempty" example:"vip,eu"` // replaces the tags
+	AddTags    []string          `json:"add_tags" validate:"omitempty" example:"vip"`
+	RemoveTags []string          `json:"remove_tags" validate:"omitempty" example:"trial"`
+	Attributes models.Attributes `json:"attributes" validate:"omitempty"` // sets the given attributes, null removes one
+}
+
+type EditLabelsResponse struct {
+	Edited int `json:"edited" validate:"required"`
+}
@@ -0,0 +1,299 @@
+package ocserv_user
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/crypto"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxImportRows bounds the size of a single import file.
+const maxImportRows = 5000
+
+// maxImportSize bounds the size of the import upload.
+const maxImportSize = 8 << 20
+
+var trafficTypes = []string{
+	models.Free,
+	models.MonthlyTransmit,
+	models.MonthlyReceive,
+	models.TotallyTransmit,
+	models.TotallyReceive,
+	models.Custom,
+}
+
+var trafficDirections = []string{models.TrafficRX, models.TrafficTX, models.TrafficBoth}
+
+var trafficPeriods = []string{models.TrafficDaily, models.TrafficWeekly, models.TrafficMonthly, models.TrafficLifetime}
+
+// exportHeader is the column order of exported files. Its first columns match the import format,
+// so an export can be imported again. The password column is empty unless passwords are exported.
+var exportHeader = []string{
+	"username", "password", "group", "traffic_type", "traffic_size", "traffic_direction", "traffic_period",
+	"expire_at", "description", "owner", "is_locked", "deactivated_at", "rx", "tx", "created_at", "tags", "attributes",
+}
+
+// parseImportCSV reads the import rows. The header row names the columns in any order; username is
+// required, the other columns are optional and unknown columns are ignored. Cells escaped by the
+// export against formulas are unescaped.
+func parseImportCSV(r io.Reader) ([]ImportOcservUserRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, errors.New("csv header must contain a username column")
+	}
+
+	var rows []ImportOcservUserRow
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("read csv line %d: %w", line, err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("csv has more than %d rows", maxImportRows)
+		}
+
+		value := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return utils.UnescapeFormula(strings.TrimSpace(record[i]))
+		}
+
+		row := ImportOcservUserRow{
+			Line:        line,
+			Username:    value("username"),
+			Password:    value("password"),
+			Group:       value("group"),
+			TrafficType: value("traffic_type"),
+			ExpireAt:    value("expire_at"),
+			Description: value("description"),
+			Plan:        value("plan"),
+
+			TrafficDirection: value("traffic_direction"),
+			TrafficPeriod:    value("traffic_period"),
+		}
+		if size := value("traffic_size"); size != "" {
+			row.TrafficSize, err = strconv.Atoi(size)
+			if err != nil || row.TrafficSize < 0 {
+				row.Errors = append(row.Errors, "traffic_size must be a number of GiB, 0 or more")
+			}
+		}
+		if tags := value("tags"); tags != "" {
+			row.Tags = strings.Split(tags, ",")
+		}
+		if attributes := value("attributes"); attributes != "" {
+			if err = json.Unmarshal([]byte(attributes), &row.Attributes); err != nil {
+				row.Errors = append(row.Errors, "attributes must be a JSON object")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// validateImportRow fills the defaults of the row and records its validation errors. Like the create
+// endpoint, a plan fills the group, traffic and expiry left empty; the plan is returned.
+// plans holds the active plans by name and seen the usernames of the previous rows to report
+// duplicates within the file.
+func validateImportRow(
+	row *ImportOcservUserRow, groups []string, plans map[string]*models.Plan, seen map[string]struct{},
+) *models.Plan {
+	if l := len(row.Username); l < 2 || l > 32 {
+		row.Errors = append(row.Errors, "username must be between 2 and 32 characters")
+	} else if err := utils.ValidateName(row.Username); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+	if _, ok := seen[row.Username]; ok {
+		row.Errors = append(row.Errors, "username is repeated in the file")
+	}
+	seen[row.Username] = struct{}{}
+
+	if row.Password == "" {
+		password, err := crypto.RandomPassword(10)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("password could not be generated: %v", err))
+		}
+		row.Password, row.PasswordGenerated = password, err == nil
+	} else if l := len(row.Password); l < 2 || l > 32 {
+		row.Errors = append(row.Errors, "password must be between 2 and 32 characters")
+	}
+
+	plan := plans[row.Plan]
+	if row.Plan != "" && plan == nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("plan %s does not exist or is not active", row.Plan))
+	}
+	if plan != nil {
+		if row.Group == "" {
+			row.Group = plan.Group
+		}
+		if row.TrafficType == "" {
+			row.TrafficType = plan.TrafficType
+			row.TrafficSize = plan.TrafficSize
+			row.TrafficDirection = plan.TrafficDirection
+			row.TrafficPeriod = plan.TrafficPeriod
+		}
+		if row.ExpireAt == "" {
+			row.ExpireAt = plan.ExpireFrom(time.Now()).Format("2006-01-02")
+		}
+	}
+
+	if row.Group == "" {
+		row.Group = "defaults"
+	}
+	if row.Group != "defaults" && !slices.Contains(groups, row.Group) {
+		row.Errors = append(row.Errors, fmt.Sprintf("group %s does not exist", row.Group))
+	}
+
+	if row.TrafficType == "" {
+		row.TrafficType = models.Free
+	}
+	if !slices.Contains(trafficTypes, row.TrafficType) {
+		row.Errors = append(row.Errors, "traffic_type must be one of "+strings.Join(trafficTypes, ", "))
+	}
+	if row.TrafficType == models.Free {
+		row.TrafficSize = 0
+	}
+	if row.TrafficType == models.Custom {
+		if !slices.Contains(trafficDirections, row.TrafficDirection) {
+			row.Errors = append(row.Errors, "traffic_direction must be one of "+strings.Join(trafficDirections, ", "))
+		}
+		if !slices.Contains(trafficPeriods, row.TrafficPeriod) {
+			row.Errors = append(row.Errors, "traffic_period must be one of "+strings.Join(trafficPeriods, ", "))
+		}
+	}
+
+	if row.ExpireAt == "" {
+		row.ExpireAt = time.Now().AddDate(0, 0, 30).Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", row.ExpireAt); err != nil {
+		row.Errors = append(row.Errors, "expire_at must be formatted as YYYY-MM-DD")
+	}
+
+	if len(row.Description) > 1024 {
+		row.Errors = append(row.Errors, "description must be at most 1024 characters")
+	}
+	return plan
+}
+
+// exportRecord returns the columns of exportHeader for the user, escaped against formulas. The
+// password is left empty unless withPassword is set.
+func exportRecord(u models.OcservUser, withPassword bool) []string {
+	date := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	attributes := ""
+	if len(u.Attributes) > 0 {
+		b, _ := json.Marshal(u.Attributes)
+		attributes = string(b)
+	}
+	password := ""
+	if withPassword {
+		password = u.Password
+	}
+	record := []string{
+		u.Username,
+		password,
+		u.Group,
+		u.TrafficType,
+		strconv.Itoa(u.TrafficSize),
+		u.TrafficDirection,
+		u.TrafficPeriod,
+		date(u.ExpireAt),
+		u.Description,
+		u.Owner,
+		strconv.FormatBool(u.IsLocked),
+		date(u.DeactivatedAt),
+		strconv.Itoa(u.Rx),
+		strconv.Itoa(u.Tx),
+		u.CreatedAt.Format(time.RFC3339),
+		strings.Join(u.Tags, ","),
+		attributes,
+	}
+	for i, value := range record {
+		record[i] = utils.EscapeFormula(value)
+	}
+	return record
+}
+
+func writeUsersCSV(w io.Writer, users []models.OcservUser, withPasswords bool) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := writer.Write(exportRecord(u, withPasswords)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeUsersXLSX(w io.Writer, users []models.OcservUser, withPasswords bool) error {
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+
+	const sheet = "Users"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	toRow := func(values []string) []interface{} {
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+		return row
+	}
+
+	if err = sw.SetRow("A1", toRow(exportHeader)); err != nil {
+		return err
+	}
+	for i, u := range users {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err = sw.SetRow(cell, toRow(exportRecord(u, withPasswords))); err != nil {
+			return err
+		}
+	}
+	if err = sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
@@ -0,0 +1,82 @@
+package ocserv_user
+
+import (
+	"bytes"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	expireAt := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	users := []models.OcservUser{{
+		Username:    "alice",
+		Password:    "secret",
+		Group:       "defaults",
+		TrafficType: models.MonthlyTransmit,
+		TrafficSize: 10,
+		ExpireAt:    &expireAt,
+		Description: "=HYPERLINK(\"http://evil\")",
+		Tags:        []string{"vip"},
+	}}
+
+	var withoutPasswords bytes.Buffer
+	assert.NoError(t, writeUsersCSV(&withoutPasswords, users, false))
+	assert.NotContains(t, withoutPasswords.String(), "secret")
+	assert.Contains(t, withoutPasswords.String(), `"'=HYPERLINK(""http://evil"")"`)
+
+	var withPasswords bytes.Buffer
+	assert.NoError(t, writeUsersCSV(&withPasswords, users, true))
+
+	rows, err := parseImportCSV(&withPasswords)
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, "alice", rows[0].Username)
+		assert.Equal(t, "secret", rows[0].Password)
+		assert.Equal(t, 10, rows[0].TrafficSize)
+		assert.Equal(t, "2025-12-31", rows[0].ExpireAt)
+		assert.Equal(t, users[0].Description, rows[0].Description)
+		assert.Equal(t, []string{"vip"}, rows[0].Tags)
+	}
+}
+
+func TestValidateImportRow(t *testing.T) {
+	plans := map[string]*models.Plan{
+		"gold": {ID: 3, Name: "gold", Group: "vip", TrafficType: models.MonthlyTransmit, TrafficSize: 50, DurationDays: 30},
+	}
+	groups := []string{"vip"}
+
+	rows, err := parseImportCSV(strings.NewReader(
+		"username,password,plan,traffic_size\n" +
+			"alice,secret,gold,\n" +
+			"bob,secret,silver,\n" +
+			"alice,secret,,\n" +
+			"carol,secret,,-1\n" +
+			"dave,secret,,0\n",
+	))
+	assert.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	var got []*models.Plan
+	for i := range rows {
+		got = append(got, validateImportRow(&rows[i], groups, plans, seen))
+	}
+
+	assert.Same(t, plans["gold"], got[0])
+	assert.Empty(t, rows[0].Errors)
+	assert.Equal(t, "vip", rows[0].Group)
+	assert.Equal(t, models.MonthlyTransmit, rows[0].TrafficType)
+	assert.Equal(t, 50, rows[0].TrafficSize)
+	assert.Equal(t, plans["gold"].ExpireFrom(time.Now()).Format("2006-01-02"), rows[0].ExpireAt)
+
+	assert.Nil(t, got[1])
+	assert.Equal(t, []string{"plan silver does not exist or is not active"}, rows[1].Errors)
+
+	assert.Equal(t, []string{"username is repeated in the file"}, rows[2].Errors)
+	assert.Equal(t, "defaults", rows[2].Group)
+
+	assert.Equal(t, []string{"traffic_size must be a number of GiB, 0 or more"}, rows[3].Errors)
+	assert.Empty(t, rows[4].Errors)
+}
@@ -0,0 +1,274 @@
+package plan
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"net/http"
+	"strconv"
+)
+
+type Controller struct {
+	request  request.CustomRequestInterface
+	planRepo repository.PlanRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:  request.NewCustomRequest(),
+		planRepo: repository.NewPlanRepository(),
+	}
+}
+
+// planID parses the id path param.
+func planID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid plan id")
+	}
+	return uint(id), nil
+}
+
+// Plans 	 	 List of plans
+//
+// @Summary      List of plans
+// @Description  List of plans users can be provisioned or renewed with
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  PlansResponse
+// @Router       /plans [get]
+func (ctl *Controller) Plans(c echo.Context) error {
+	pagination := ctl.request.Pagination(c)
+
+	plans, total, err := ctl.planRepo.Plans(c.Request().Context(), pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, PlansResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: plans,
+	})
+}
+
+// PlansLookup 	 List of active plans
+//
+// @Summary      List of active plans
+// @Description  List of active plans for user creation and renewal
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  []models.Plan
+// @Router       /plans/lookup [get]
+func (ctl *Controller) PlansLookup(c echo.Context) error {
+	plans, err := ctl.planRepo.PlansLookup(c.Request().Context())
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, plans)
+}
+
+// Plan 	 	 Plan detail
+//
+// @Summary      Plan detail
+// @Description  Plan detail
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Param 		 id path int true "Plan ID"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  models.Plan
+// @Router       /plans/{id} [get]
+func (ctl *Controller) Plan(c echo.Context) error {
+	id, err := planID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	plan, err := ctl.planRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+// CreatePlan 	 Plan creation
+//
+// @Summary      Plan creation
+// @Description  Plan creation
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  CreatePlanData  true "plan create data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      201  {object} models.Plan
+// @Router       /plans [post]
+func (ctl *Controller) CreatePlan(c echo.Context) error {
+	var data CreatePlanData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if data.TrafficType == models.Free {
+		data.TrafficSize = 0
+	}
+
+	plan := &models.Plan{
+		Name:         data.Name,
+		Group:        data.Group,
+		TrafficType:  data.TrafficType,
+		TrafficSize:  data.TrafficSize,
+		DurationDays: data.DurationDays,
+
+		TrafficDirection: data.TrafficDirection,
+		TrafficPeriod:    data.TrafficPeriod,
+		OverQuotaAction:  data.OverQuotaAction,
+		ThrottleRate:     data.ThrottleRate,
+		Config:           data.Config,
+		Price:            data.Price,
+		Currency:         data.Currency,
+		Description:      data.Description,
+		IsActive:         true,
+	}
+	if data.IsActive != nil {
+		plan.IsActive = *data.IsActive
+	}
+
+	newPlan, err := ctl.planRepo.Create(c.Request().Context(), plan)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, newPlan)
+}
+
+// UpdatePlan 	 Plan update
+//
+// @Summary      Plan update
+// @Description  Plan update. Users already provisioned with the plan are not changed until renewed.
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Plan ID"
+// @Param        request    body  UpdatePlanData  true "plan update data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object} models.Plan
+// @Router       /plans/{id} [patch]
+func (ctl *Controller) UpdatePlan(c echo.Context) error {
+	id, err := planID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var data UpdatePlanData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	plan, err := ctl.planRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if data.Name != nil {
+		plan.Name = *data.Name
+	}
+	if data.Group != nil {
+		plan.Group = *data.Group
+	}
+	if data.TrafficType != nil {
+		plan.TrafficType = *data.TrafficType
+	}
+	if data.TrafficSize != nil {
+		plan.TrafficSize = *data.TrafficSize
+	}
+	if data.TrafficDirection != nil {
+		plan.TrafficDirection = *data.TrafficDirection
+	}
+	if data.TrafficPeriod != nil {
+		plan.TrafficPeriod = *data.TrafficPeriod
+	}
+	if data.OverQuotaAction != nil {
+		plan.OverQuotaAction = *data.OverQuotaAction
+	}
+	if data.ThrottleRate != nil {
+		plan.ThrottleRate = *data.ThrottleRate
+	}
+	if plan.TrafficType == models.Free {
+		plan.TrafficSize = 0
+	}
+	if data.DurationDays != nil {
+		plan.DurationDays = *data.DurationDays
+	}
+	if data.Config != nil {
+		plan.Config = data.Config
+	}
+	if data.Price != nil {
+		plan.Price = *data.Price
+	}
+	if data.Currency != nil {
+		plan.Currency = *data.Currency
+	}
+	if data.Description != nil {
+		plan.Description = *data.Description
+	}
+	if data.IsActive != nil {
+		plan.IsActive = *data.IsActive
+	}
+
+	updatedPlan, err := ctl.planRepo.Update(c.Request().Context(), plan)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, updatedPlan)
+}
+
+// DeletePlan 	 Plan delete
+//
+// @Summary      Plan delete
+// @Description  Plan delete. Users provisioned with the plan keep their settings.
+// @Tags         Plans
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Plan ID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      204  {object} nil
+// @Router       /plans/{id} [delete]
+func (ctl *Controller) DeletePlan(c echo.Context) error {
+	id, err := planID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.planRepo.Delete(c.Request().Context(), id); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,17 @@
+package plan
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/plans", middlewares.AuthMiddleware())
+	g.GET("", ctl.Plans)
+	g.GET("/lookup", ctl.PlansLookup)
+	g.GET("/:id", ctl.Plan)
+	g.POST("", ctl.CreatePlan, middlewares.AdminPermission())
+	g.PATCH("/:id", ctl.UpdatePlan, middlewares.AdminPermission())
+	g.DELETE("/:id", ctl.DeletePlan, middlewares.AdminPermission())
+}
@@ -0,0 +1,45 @@
+package plan
+
+import (
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+)
+
+type CreatePlanData struct {
+	Name             string                   `json:"name" validate:"required,min=2,max=64" example:"Monthly 50GiB"`
+	Group            string                   `json:"group" validate:"required" example:"defaults"`
+	TrafficType      string                   `json:"traffic_type" validate:"required,oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive Custom" example:"MonthlyTransmit"`
+	TrafficSize      int                      `json:"traffic_size" validate:"omitempty,gte=0" example:"50"` // in GiB
+	TrafficDirection string                   `json:"traffic_direction" validate:"required_if=TrafficType Custom,omitempty,oneof=rx tx both" example:"both"`
+	TrafficPeriod    string                   `json:"traffic_period" validate:"required_if=TrafficType Custom,omitempty,oneof=daily weekly monthly lifetime" example:"monthly"`
+	OverQuotaAction  string                   `json:"over_quota_action" validate:"omitempty,oneof=lock throttle" example:"throttle"`
+	ThrottleRate     int                      `json:"throttle_rate" validate:"required_if=OverQuotaAction throttle,omitempty,gte=0" example:"65536"` // rx/tx bytes per second once over quota
+	DurationDays     int                      `json:"duration_days" validate:"required,gte=1" example:"30"`
+	Config           *models.OcservUserConfig `json:"config" validate:"omitempty"`
+	Price            float64                  `json:"price" validate:"omitempty,gte=0" example:"5.5"`
+	Currency         string                   `json:"currency" validate:"omitempty,max=8" example:"USD"`
+	Description      string                   `json:"description" validate:"omitempty,max=1024" example:"Monthly plan with 50 GiB"`
+	IsActive         *bool                    `json:"is_active" validate:"omitempty" example:"true"`
+}
+
+type UpdatePlanData struct {
+	Name             *string                  `json:"name" validate:"omitempty,min=2,max=64" example:"Monthly 50GiB"`
+	Group            *string                  `json:"group" validate:"omitempty" example:"defaults"`
+	TrafficType      *string                  `json:"traffic_type" validate:"omitempty,oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive Custom" example:"MonthlyTransmit"`
+	TrafficSize      *int                     `json:"traffic_size" validate:"omitempty,gte=0" example:"50"` // in GiB
+	TrafficDirection *string                  `json:"traffic_direction" validate:"omitempty,oneof=rx tx both" example:"both"`
+	TrafficPeriod    *string                  `json:"traffic_period" validate:"omitempty,oneof=daily weekly monthly lifetime" example:"monthly"`
+	OverQuotaAction  *string                  `json:"over_quota_action" validate:"omitempty,oneof=lock throttle" example:"throttle"`
+	ThrottleRate     *int                     `json:"throttle_rate" validate:"omitempty,gte=0" example:"65536"`
+	DurationDays     *int                     `json:"duration_days" validate:"omitempty,gte=1" example:"30"`
+	Config           *models.OcservUserConfig `json:"config" validate:"omitempty"`
+	Price            *float64                 `json:"price" validate:"omitempty,gte=0" example:"5.5"`
+	Currency         *string                  `json:"currency" validate:"omitempty,max=8" example:"USD"`
+	Description      *string                  `json:"description" validate:"omitempty,max=1024" example:"Monthly plan with 50 GiB"`
+	IsActive         *bool                    `json:"is_active" validate:"omitempty" example:"true"`
+}
+
+type PlansResponse struct {
+	Meta   request.Meta  `json:"meta" validate:"required"`
+	Result []models.Plan `json:"result" validate:"omitempty"`
+}
@@ -0,0 +1,459 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	reports "github.com/mmtaee/ocserv-users-management/common/pkg/report"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type Controller struct {
+	request    request.CustomRequestInterface
+	reportRepo repository.ReportRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:    request.NewCustomRequest(),
+		reportRepo: repository.NewReportRepository(),
+	}
+}
+
+// reportID parses the id path param.
+func reportID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid report id")
+	}
+	return uint(id), nil
+}
+
+// owner returns the owner the caller is limited to: its username for staff, empty for admins.
+func owner(c echo.Context) (string, error) {
+	if isAdmin := c.Get("isAdmin").(bool); isAdmin {
+		return "", nil
+	}
+	username := c.Get("username").(string)
+	if username == "" {
+		return "", errors.New("invalid user uid")
+	}
+	return username, nil
+}
+
+// validateDelivery checks that the delivery is enabled and that the target is valid for it.
+func validateDelivery(delivery, target string) error {
+	if names := reports.Deliveries(); !slices.Contains(names, delivery) {
+		return fmt.Errorf("delivery must be one of %v", names)
+	}
+	return reports.ValidateTarget(delivery, target)
+}
+
+// ExportStatistics 	 Export traffic statistics
+//
+// @Summary      Export traffic statistics
+// @Description  Export the traffic of every user and the daily traffic of all of them over a date range as CSV, XLSX or PDF. Staff export the users they own.
+// @Tags         Reports
+// @Accept       json
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce      application/pdf
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 date_start query string true "date_start"
+// @Param 		 date_end query string true "date_end"
+// @Param 		 format query string false "file format" Enums(csv, xlsx, pdf)
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {file} file
+// @Router       /reports/export [get]
+func (ctl *Controller) ExportStatistics(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var data ExportData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if data.Format == "" {
+		data.Format = models.ReportFormatCSV
+	}
+
+	dateStart, err := time.Parse("2006-01-02", data.DateStart)
+	if err != nil {
+		return ctl.request.BadRequest(c, fmt.Errorf("invalid date_start: %w", err))
+	}
+	dateEnd, err := time.Parse("2006-01-02", data.DateEnd)
+	if err != nil {
+		return ctl.request.BadRequest(c, fmt.Errorf("invalid date_end: %w", err))
+	}
+	if dateStart.After(dateEnd) {
+		return ctl.request.BadRequest(c, errors.New("date start is after end"))
+	}
+
+	usageReport, err := ctl.reportRepo.Export(c.Request().Context(), username, data.Format, dateStart, dateEnd.AddDate(0, 0, 1))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", usageReport.Filename))
+	return c.Blob(http.StatusOK, reports.ContentType(usageReport.Format), usageReport.Content)
+}
+
+// Deliveries 	 Report deliveries
+//
+// @Summary      Report deliveries
+// @Description  Names of the deliveries scheduled reports can be sent with. The webhook delivery is enabled by REPORT_WEBHOOK_HOSTS, the hosts webhook targets may post to.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  []string
+// @Router       /reports/deliveries [get]
+func (ctl *Controller) Deliveries(c echo.Context) error {
+	return c.JSON(http.StatusOK, reports.Deliveries())
+}
+
+// ReportSchedules 	 List of report schedules
+//
+// @Summary      List of report schedules
+// @Description  List of report schedules. Staff see the schedules of their own reports.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  ReportSchedulesResponse
+// @Router       /reports/schedules [get]
+func (ctl *Controller) ReportSchedules(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	pagination := ctl.request.Pagination(c)
+
+	schedules, total, err := ctl.reportRepo.Schedules(c.Request().Context(), username, pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ReportSchedulesResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: schedules,
+	})
+}
+
+// ReportSchedule 	 Report schedule detail
+//
+// @Summary      Report schedule detail
+// @Description  Report schedule detail
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param 		 id path int true "Report schedule ID"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  models.ReportSchedule
+// @Router       /reports/schedules/{id} [get]
+func (ctl *Controller) ReportSchedule(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	id, err := reportID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	schedule, err := ctl.reportRepo.GetSchedule(c.Request().Context(), id, username)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, schedule)
+}
+
+// CreateReportSchedule 	 Report schedule creation
+//
+// @Summary      Report schedule creation
+// @Description  Schedule a usage report of the previous period, generated at the start of every period by the user expiry service. Reports of staff cover the users they own; admins may set the owner or leave it empty for every user.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  CreateReportScheduleData  true "report schedule create data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      201  {object} models.ReportSchedule
+// @Router       /reports/schedules [post]
+func (ctl *Controller) CreateReportSchedule(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var data CreateReportScheduleData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if data.Delivery == "" {
+		data.Delivery = reports.DeliveryStore
+	}
+	if err = validateDelivery(data.Delivery, data.Target); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if username == "" {
+		username = data.Owner
+	}
+
+	schedule := &models.ReportSchedule{
+		Name:     data.Name,
+		Owner:    username,
+		Period:   data.Period,
+		Format:   data.Format,
+		Delivery: data.Delivery,
+		Target:   data.Target,
+		IsActive: true,
+	}
+	if data.IsActive != nil {
+		schedule.IsActive = *data.IsActive
+	}
+	schedule.NextRunAt = schedule.NextRun(time.Now())
+
+	newSchedule, err := ctl.reportRepo.CreateSchedule(c.Request().Context(), schedule)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, newSchedule)
+}
+
+// UpdateReportSchedule 	 Report schedule update
+//
+// @Summary      Report schedule update
+// @Description  Report schedule update. Changing the period or reactivating the schedule moves its next run to the start of the next period.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Report schedule ID"
+// @Param        request    body  UpdateReportScheduleData  true "report schedule update data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object} models.ReportSchedule
+// @Router       /reports/schedules/{id} [patch]
+func (ctl *Controller) UpdateReportSchedule(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	id, err := reportID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var data UpdateReportScheduleData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	schedule, err := ctl.reportRepo.GetSchedule(c.Request().Context(), id, username)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	reschedule := false
+	if data.Name != nil {
+		schedule.Name = *data.Name
+	}
+	if data.Period != nil && *data.Period != schedule.Period {
+		schedule.Period = *data.Period
+		reschedule = true
+	}
+	if data.Format != nil {
+		schedule.Format = *data.Format
+	}
+	if data.Delivery != nil {
+		schedule.Delivery = *data.Delivery
+	}
+	if data.Target != nil {
+		schedule.Target = *data.Target
+	}
+	if data.Delivery != nil || data.Target != nil {
+		if err = validateDelivery(schedule.Delivery, schedule.Target); err != nil {
+			return ctl.request.BadRequest(c, err)
+		}
+	}
+	if data.IsActive != nil {
+		reschedule = reschedule || (*data.IsActive && !schedule.IsActive)
+		schedule.IsActive = *data.IsActive
+	}
+	if reschedule {
+		schedule.NextRunAt = schedule.NextRun(time.Now())
+	}
+
+	updatedSchedule, err := ctl.reportRepo.UpdateSchedule(c.Request().Context(), schedule)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, updatedSchedule)
+}
+
+// DeleteReportSchedule 	 Report schedule delete
+//
+// @Summary      Report schedule delete
+// @Description  Report schedule delete. The reports it generated are kept.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Report schedule ID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      204  {object} nil
+// @Router       /reports/schedules/{id} [delete]
+func (ctl *Controller) DeleteReportSchedule(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	id, err := reportID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.reportRepo.DeleteSchedule(c.Request().Context(), id, username); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
+
+// UsageReports 	 List of usage reports
+//
+// @Summary      List of usage reports
+// @Description  List of the usage reports generated by report schedules. Staff see their own reports.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param 		 schedule_id query int false "Report schedule ID"
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  UsageReportsResponse
+// @Router       /reports [get]
+func (ctl *Controller) UsageReports(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	var scheduleID *uint
+	if value := c.QueryParam("schedule_id"); value != "" {
+		id, err2 := strconv.ParseUint(value, 10, 64)
+		if err2 != nil {
+			return ctl.request.BadRequest(c, errors.New("invalid schedule_id"))
+		}
+		v := uint(id)
+		scheduleID = &v
+	}
+
+	pagination := ctl.request.Pagination(c)
+
+	usageReports, total, err := ctl.reportRepo.Reports(c.Request().Context(), username, scheduleID, pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, UsageReportsResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: usageReports,
+	})
+}
+
+// DownloadUsageReport 	 Usage report download
+//
+// @Summary      Usage report download
+// @Description  Download the file of a generated usage report
+// @Tags         Reports
+// @Accept       json
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce      application/pdf
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Usage report ID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200 {file} file
+// @Router       /reports/{id}/download [get]
+func (ctl *Controller) DownloadUsageReport(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	id, err := reportID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	usageReport, err := ctl.reportRepo.GetReport(c.Request().Context(), id, username)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", usageReport.Filename))
+	return c.Blob(http.StatusOK, reports.ContentType(usageReport.Format), usageReport.Content)
+}
+
+// DeleteUsageReport 	 Usage report delete
+//
+// @Summary      Usage report delete
+// @Description  Usage report delete
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param 		 id path int true "Usage report ID"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      204  {object} nil
+// @Router       /reports/{id} [delete]
+func (ctl *Controller) DeleteUsageReport(c echo.Context) error {
+	username, err := owner(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	id, err := reportID(c)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.reportRepo.DeleteReport(c.Request().Context(), id, username); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
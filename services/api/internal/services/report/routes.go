@@ -0,0 +1,21 @@
+package report
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/reports", middlewares.AuthMiddleware())
+	g.GET("/export", ctl.ExportStatistics)
+	g.GET("/deliveries", ctl.Deliveries)
+	g.GET("/schedules", ctl.ReportSchedules)
+	g.GET("/schedules/:id", ctl.ReportSchedule)
+	g.POST("/schedules", ctl.CreateReportSchedule)
+	g.PATCH("/schedules/:id", ctl.UpdateReportSchedule)
+	g.DELETE("/schedules/:id", ctl.DeleteReportSchedule)
+	g.GET("", ctl.UsageReports)
+	g.GET("/:id/download", ctl.DownloadUsageReport)
+	g.DELETE("/:id", ctl.DeleteUsageReport)
+}
@@ -0,0 +1,41 @@
+package report
+
+import (
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+)
+
+type ExportData struct {
+	DateStart string `json:"date_start" query:"date_start" validate:"required" example:"2025-01-01"`
+	DateEnd   string `json:"date_end" query:"date_end" validate:"required" example:"2025-01-31"`
+	Format    string `json:"format" query:"format" validate:"omitempty,oneof=csv xlsx pdf" example:"xlsx"`
+}
+
+type CreateReportScheduleData struct {
+	Name     string `json:"name" validate:"required,min=2,max=64" example:"Monthly usage"`
+	Owner    string `json:"owner" validate:"omitempty,max=16" example:"reseller1"` // admins only, empty for a report of every user
+	Period   string `json:"period" validate:"required,oneof=daily weekly monthly" example:"monthly"`
+	Format   string `json:"format" validate:"required,oneof=csv xlsx pdf" example:"pdf"`
+	Delivery string `json:"delivery" validate:"omitempty" example:"store"` // store when empty
+	Target   string `json:"target" validate:"required_if=Delivery webhook,omitempty,url,max=255" example:"https://example.com/reports"`
+	IsActive *bool  `json:"is_active" validate:"omitempty" example:"true"`
+}
+
+type UpdateReportScheduleData struct {
+	Name     *string `json:"name" validate:"omitempty,min=2,max=64" example:"Monthly usage"`
+	Period   *string `json:"period" validate:"omitempty,oneof=daily weekly monthly" example:"monthly"`
+	Format   *string `json:"format" validate:"omitempty,oneof=csv xlsx pdf" example:"pdf"`
+	Delivery *string `json:"delivery" validate:"omitempty" example:"webhook"`
+	Target   *string `json:"target" validate:"omitempty,url,max=255" example:"https://example.com/reports"`
+	IsActive *bool   `json:"is_active" validate:"omitempty" example:"true"`
+}
+
+type ReportSchedulesResponse struct {
+	Meta   request.Meta            `json:"meta" validate:"required"`
+	Result []models.ReportSchedule `json:"result" validate:"omitempty"`
+}
+
+type UsageReportsResponse struct {
+	Meta   request.Meta         `json:"meta" validate:"required"`
+	Result []models.UsageReport `json:"result" validate:"omitempty"`
+}
@@ -0,0 +1,283 @@
+package reseller
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
+	"net/http"
+)
+
+type Controller struct {
+	request      request.CustomRequestInterface
+	userRepo     repository.UserRepositoryInterface
+	resellerRepo repository.ResellerRepositoryInterface
+}
+
+func New() *Controller {
+	return &Controller{
+		request:      request.NewCustomRequest(),
+		userRepo:     repository.NewUserRepository(),
+		resellerRepo: repository.NewResellerRepository(),
+	}
+}
+
+// reseller loads the reseller quotas of the staff user with the given UID.
+func (ctl *Controller) reseller(c echo.Context, uid string) (*models.Reseller, error) {
+	user, err := ctl.userRepo.GetByUID(c.Request().Context(), uid)
+	if err != nil {
+		return nil, err
+	}
+	return ctl.resellerRepo.GetByUserID(c.Request().Context(), user.ID)
+}
+
+// Resellers 	 List of resellers
+//
+// @Summary      List of resellers
+// @Description  List of staff users with reseller quotas
+// @Tags         Resellers
+// @Accept       json
+// @Produce      json
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  ResellersResponse
+// @Router       /resellers [get]
+func (ctl *Controller) Resellers(c echo.Context) error {
+	pagination := ctl.request.Pagination(c)
+
+	resellers, total, err := ctl.resellerRepo.Resellers(c.Request().Context(), pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResellersResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: resellers,
+	})
+}
+
+// Usage 	 	 Reseller usage of the current user
+//
+// @Summary      Reseller usage of the current user
+// @Description  Quotas, allocated users and traffic of the logged-in reseller
+// @Tags         Resellers
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Success      200  {object}  repository.ResellerUsage
+// @Router       /resellers/usage [get]
+func (ctl *Controller) Usage(c echo.Context) error {
+	userUID := c.Get("userUID").(string)
+
+	reseller, err := ctl.reseller(c, userUID)
+	if err != nil {
+		return ctl.request.BadRequest(c, err, "user is not a reseller")
+	}
+
+	usage, err := ctl.resellerRepo.Usage(c.Request().Context(), reseller)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, usage)
+}
+
+// ResellerUsage 	 Reseller usage
+//
+// @Summary      Reseller usage
+// @Description  Quotas, allocated users and traffic of a reseller
+// @Tags         Resellers
+// @Accept       json
+// @Produce      json
+// @Param 		 uid path string true "User UID"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  repository.ResellerUsage
+// @Router       /resellers/{uid}/usage [get]
+func (ctl *Controller) ResellerUsage(c echo.Context) error {
+	reseller, err := ctl.reseller(c, c.Param("uid"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	usage, err := ctl.resellerRepo.Usage(c.Request().Context(), reseller)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, usage)
+}
+
+// SaveReseller 	 Set reseller quotas
+//
+// @Summary      Set reseller quotas
+// @Description  Turn a staff user into a reseller or replace the quotas of an existing reseller
+// @Tags         Resellers
+// @Accept       json
+// @Produce      json
+// @Param 		 uid path string true "User UID"
+// @Param        request    body  SaveResellerData  true "reseller quotas"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  models.Reseller
+// @Router       /resellers/{uid} [put]
+func (ctl *Controller) SaveReseller(c echo.Context) error {
+	var data SaveResellerData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	user, err := ctl.userRepo.GetByUID(c.Request().Context(), c.Param("uid"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if user.IsAdmin {
+		return ctl.request.BadRequest(c, errors.New("admin users cannot be resellers"))
+	}
+
+	reseller, err := ctl.resellerRepo.GetByUserID(c.Request().Context(), user.ID)
+	if err != nil {
+		reseller = &models.Reseller{UserID: user.ID}
+	}
+
+	groups := commonModels.CSVStringList(data.AllowedGroups)
+	trafficTypes := commonModels.CSVStringList(data.AllowedTrafficTypes)
+
+	reseller.MaxUsers = data.MaxUsers
+	reseller.TrafficPool = data.TrafficPool
+	reseller.AllowedGroups = &groups
+	reseller.AllowedTrafficTypes = &trafficTypes
+	reseller.User = *user
+
+	saved, err := ctl.resellerRepo.Save(c.Request().Context(), reseller)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, saved)
+}
+
+// DeleteReseller 	 Remove reseller quotas
+//
+// @Summary      Remove reseller quotas
+// @Description  Remove the quotas and top-up history of a reseller. The staff user and its ocserv users are kept.
+// @Tags         Resellers
+// @Accept       json
+// @Produce      json
+// @Param 		 uid path string true "User UID"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      204  {object}  nil
+// @Router       /resellers/{uid} [delete]
+func (ctl *Controller) DeleteReseller(c echo.Context) error {
+	user, err := ctl.userRepo.GetByUID(c.Request().Context(), c.Param("uid"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	if err = ctl.resellerRepo.Delete(c.Request().Context(), user.ID); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusNoContent, nil)
+}
+
+// TopUp 	 	 Top up reseller quotas
+//
+// @Summary      Top up reseller quotas
+// @Description  Add users and traffic to the quotas of a reseller
+// @Tags         Resellers
+// @Accept       json
+// @Produce      json
+// @Param 		 uid path string true "User UID"
+// @Param        request    body  TopUpData  true "top-up data"
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      201  {object}  models.Reseller
+// @Router       /resellers/{uid}/top-ups [post]
+func (ctl *Controller) TopUp(c echo.Context) error {
+	var data TopUpData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	if data.Users == 0 && data.Traffic == 0 {
+		return ctl.request.BadRequest(c, errors.New("users or traffic is required"))
+	}
+
+	reseller, err := ctl.reseller(c, c.Param("uid"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	topUp := &models.ResellerTopUp{
+		Users:   data.Users,
+		Traffic: data.Traffic,
+		Author:  c.Get("username").(string),
+		Note:    data.Note,
+	}
+
+	updated, err := ctl.resellerRepo.TopUp(c.Request().Context(), reseller, topUp)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusCreated, updated)
+}
+
+// TopUps 	 	 List of reseller top-ups
+//
+// @Summary      List of reseller top-ups
+// @Description  Ledger of the quota top-ups of a reseller
+// @Tags         Resellers
+// @Accept       json
+// @Produce      json
+// @Param 		 uid path string true "User UID"
+// @Param 		 page query int false "Page number, starting from 1" minimum(1)
+// @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
+// @Param 		 order query string false "Field to order by"
+// @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  TopUpsResponse
+// @Router       /resellers/{uid}/top-ups [get]
+func (ctl *Controller) TopUps(c echo.Context) error {
+	pagination := ctl.request.Pagination(c)
+
+	reseller, err := ctl.reseller(c, c.Param("uid"))
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	topUps, total, err := ctl.resellerRepo.TopUps(c.Request().Context(), reseller.ID, pagination)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	return c.JSON(http.StatusOK, TopUpsResponse{
+		Meta: request.Meta{
+			Page:         pagination.Page,
+			PageSize:     pagination.PageSize,
+			TotalRecords: total,
+		},
+		Result: topUps,
+	})
+}
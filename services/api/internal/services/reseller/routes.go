@@ -0,0 +1,19 @@
+package reseller
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/routing/middlewares"
+)
+
+func Routes(e *echo.Group) {
+	ctl := New()
+	g := e.Group("/resellers", middlewares.AuthMiddleware())
+	g.GET("/usage", ctl.Usage)
+
+	g.GET("", ctl.Resellers, middlewares.AdminPermission())
+	g.GET("/:uid/usage", ctl.ResellerUsage, middlewares.AdminPermission())
+	g.PUT("/:uid", ctl.SaveReseller, middlewares.AdminPermission())
+	g.DELETE("/:uid", ctl.DeleteReseller, middlewares.AdminPermission())
+	g.POST("/:uid/top-ups", ctl.TopUp, middlewares.AdminPermission())
+	g.GET("/:uid/top-ups", ctl.TopUps, middlewares.AdminPermission())
+}
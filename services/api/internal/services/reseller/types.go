@@ -0,0 +1,29 @@
+package reseller
+
+import (
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+)
+
+type SaveResellerData struct {
+	MaxUsers            int      `json:"max_users" validate:"gte=0" example:"50"`
+	TrafficPool         int      `json:"traffic_pool" validate:"gte=0" example:"500"` // in GiB
+	AllowedGroups       []string `json:"allowed_groups" validate:"omitempty" example:"defaults"`
+	AllowedTrafficTypes []string `json:"allowed_traffic_types" validate:"omitempty,dive,oneof=Free MonthlyTransmit MonthlyReceive TotallyTransmit TotallyReceive Custom" example:"MonthlyTransmit"`
+}
+
+type TopUpData struct {
+	Users   int    `json:"users" validate:"gte=0" example:"10"`
+	Traffic int    `json:"traffic" validate:"gte=0" example:"100"` // in GiB
+	Note    string `json:"note" validate:"omitempty,max=1024" example:"Paid invoice 1024"`
+}
+
+type ResellersResponse struct {
+	Meta   request.Meta      `json:"meta" validate:"required"`
+	Result []models.Reseller `json:"result" validate:"omitempty"`
+}
+
+type TopUpsResponse struct {
+	Meta   request.Meta           `json:"meta" validate:"required"`
+	Result []models.ResellerTopUp `json:"result" validate:"omitempty"`
+}
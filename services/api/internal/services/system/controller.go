@@ -22,6 +22,7 @@ type Controller struct {
 	userRepo        repository.UserRepositoryInterface
 	captchaVerifier captcha.GoogleCaptchaInterface
 	cryptoRepo      crypto.CustomPasswordInterface
+	driftRepo       repository.DriftRepositoryInterface
 }
 
 func New() *Controller {
@@ -31,6 +32,7 @@ func New() *Controller {
 		userRepo:        repository.NewUserRepository(),
 		captchaVerifier: captcha.NewGoogleVerifier(),
 		cryptoRepo:      crypto.NewCustomPassword(),
+		driftRepo:       repository.NewDriftRepository(),
 	}
 }
 
@@ -284,6 +286,9 @@ func (ctl *Controller) CreateUser(c echo.Context) error {
 // @Param 		 size query int false "Number of items per page" minimum(1) maximum(100) name(size)
 // @Param 		 order query string false "Field to order by"
 // @Param 		 sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param 		 cursor query string false "Cursor of the page for cursor pagination ordered by id, username or created_at, empty for the first page"
+// @Param 		 fields query string false "Comma separated fields of the items to return"
+// @Param 		 total query bool false "Count the total records, true by default without a cursor"
 // @Param        Authorization header string true "Bearer TOKEN"
 // @Failure      400 {object} request.ErrorResponse
 // @Failure      401 {object} middlewares.Unauthorized
@@ -298,14 +303,7 @@ func (ctl *Controller) Users(c echo.Context) error {
 		return ctl.request.BadRequest(c, err)
 	}
 
-	return c.JSON(http.StatusOK, UsersResponse{
-		Meta: request.Meta{
-			Page:         pagination.Page,
-			PageSize:     pagination.PageSize,
-			TotalRecords: total,
-		},
-		Result: users,
-	})
+	return ctl.request.Response(c, pagination, total, users)
 }
 
 // ChangeUserPasswordByAdmin 		 Change user password by admin
@@ -443,3 +441,56 @@ func (ctl *Controller) UsersLookup(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, users)
 }
+
+// Drift 		 Drift between the database and ocserv files
+//
+// @Summary      Check drift between the database and ocserv files
+// @Description  Compare ocserv users and groups in the database with ocpasswd and the user and group config files
+// @Tags         System
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  repository.DriftReport
+// @Router       /system/drift [get]
+func (ctl *Controller) Drift(c echo.Context) error {
+	report, err := ctl.driftRepo.Check(c.Request().Context())
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// RepairDrift 	 Repair drift between the database and ocserv files
+//
+// @Summary      Repair drift between the database and ocserv files
+// @Description  Repair drift taking the database (db) or the ocserv files (files) as the truth, optionally limited to some kinds or names. With source db, users missing in the database are only deleted from ocpasswd with delete_missing_in_db
+// @Tags         System
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer TOKEN"
+// @Param        request    body  RepairDriftData   true "repair drift data"
+// @Failure      400 {object} request.ErrorResponse
+// @Failure      401 {object} middlewares.Unauthorized
+// @Failure      403 {object} middlewares.PermissionDenied
+// @Success      200  {object}  repository.DriftRepairReport
+// @Router       /system/drift/repair [post]
+func (ctl *Controller) RepairDrift(c echo.Context) error {
+	var data RepairDriftData
+	if err := ctl.request.DoValidate(c, &data); err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+
+	report, err := ctl.driftRepo.Repair(
+		c.Request().Context(),
+		data.Source,
+		repository.DriftFilter{Kinds: data.Kinds, Names: data.Names, DeleteMissingInDB: data.DeleteMissingInDB},
+		c.Get("username").(string),
+	)
+	if err != nil {
+		return ctl.request.BadRequest(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
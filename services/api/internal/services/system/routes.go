@@ -22,4 +22,6 @@ func Routes(e *echo.Group) {
 	g.DELETE("/users/:uid", ctl.DeleteUser, middlewares.AdminPermission())
 	g.GET("/users", ctl.Users, middlewares.AdminPermission())
 	g.GET("/users/lookup", ctl.UsersLookup, middlewares.AdminPermission())
+	g.GET("/drift", ctl.Drift, middlewares.AdminPermission())
+	g.POST("/drift/repair", ctl.RepairDrift, middlewares.AdminPermission())
 }
@@ -63,3 +63,12 @@ type SetupSystemResponse struct {
 	System models.System `json:"system" validate:"required"`
 	Token  string        `json:"token" validate:"required"`
 }
+
+type RepairDriftData struct {
+	Source string   `json:"source" validate:"required,oneof=db files" enums:"db,files"`
+	Kinds  []string `json:"kinds" validate:"omitempty,dive,oneof=missing_in_ocpasswd missing_in_db lock_mismatch group_mismatch user_config_mismatch group_config_mismatch orphan_user_config orphan_group_config"`
+	Names  []string `json:"names" validate:"omitempty"`
+
+	// with source db, delete the ocpasswd entries of users missing in the database
+	DeleteMissingInDB bool `json:"delete_missing_in_db" validate:"omitempty"`
+}
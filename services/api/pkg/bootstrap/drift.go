@@ -0,0 +1,82 @@
+package bootstrap
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"os"
+	"time"
+)
+
+// ScheduleDriftRepair repairs drift between the database and ocserv files every
+// DRIFT_REPAIR_INTERVAL (e.g. 1h), taking DRIFT_REPAIR_SOURCE (db or files, default db)
+// as the truth. Scheduled repair is disabled when the interval is not set. Users missing
+// in the database are only deleted from ocpasswd when DRIFT_REPAIR_DELETE_MISSING is true.
+func ScheduleDriftRepair(ctx context.Context) {
+	value := os.Getenv("DRIFT_REPAIR_INTERVAL")
+	if value == "" {
+		return
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		logger.Warn("Warning: invalid DRIFT_REPAIR_INTERVAL %q, scheduled drift repair disabled", value)
+		return
+	}
+
+	source := os.Getenv("DRIFT_REPAIR_SOURCE")
+	if source == "" {
+		source = repository.DriftSourceDB
+	}
+	if source != repository.DriftSourceDB && source != repository.DriftSourceFiles {
+		logger.Warn("Warning: invalid DRIFT_REPAIR_SOURCE %q, scheduled drift repair disabled", source)
+		return
+	}
+
+	filter := repository.DriftFilter{DeleteMissingInDB: os.Getenv("DRIFT_REPAIR_DELETE_MISSING") == "true"}
+
+	logger.Info("Scheduled drift repair every %s from %s", interval, source)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		driftRepo := repository.NewDriftRepository()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				owner, err := driftRepairOwner()
+				if err != nil {
+					logger.Error("Failed to get the owner of repaired users: %v", err)
+					continue
+				}
+				report, err := driftRepo.Repair(ctx, source, filter, owner)
+				if err != nil {
+					logger.Error("Failed to repair drift: %v", err)
+					continue
+				}
+				if report.Repaired > 0 || report.Failed > 0 {
+					logger.Info("Drift repair from %s: %d repaired, %d failed", source, report.Repaired, report.Failed)
+				}
+				for _, result := range report.Results {
+					if result.Error != "" {
+						logger.Error("Failed to repair %s of %s: %s", result.Drift.Kind, result.Drift.Name, result.Error)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// driftRepairOwner returns the first admin, who owns users created from ocpasswd.
+func driftRepairOwner() (string, error) {
+	var admin models.User
+	err := database.GetConnection().Where("is_admin = ?", true).Order("id").First(&admin).Error
+	if err != nil {
+		return "", err
+	}
+	return admin.Username, nil
+}
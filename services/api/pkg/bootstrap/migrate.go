@@ -2,6 +2,7 @@ package bootstrap
 
 import (
 	"github.com/mmtaee/ocserv-users-management/api/internal/models"
+	"github.com/mmtaee/ocserv-users-management/api/internal/repository"
 	commonModels "github.com/mmtaee/ocserv-users-management/common/models"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
@@ -11,9 +12,25 @@ var tables = []interface{}{
 	&models.System{},
 	&models.User{},
 	&models.UserToken{},
+	&models.Reseller{},
+	&models.ResellerTopUp{},
+	&commonModels.Node{},
+	&commonModels.Plan{},
 	&commonModels.OcservGroup{},
 	&commonModels.OcservUser{},
 	&commonModels.OcservUserTrafficStatistics{},
+	&commonModels.OcservUserTrafficHourly{},
+	&commonModels.OcservUserTrafficDaily{},
+	&commonModels.OcservUserTrafficMonthly{},
+	&commonModels.TrafficTopUp{},
+	&commonModels.ReportSchedule{},
+	&commonModels.UsageReport{},
+	&commonModels.AttributeDefinition{},
+	&commonModels.LabelTag{},
+	&commonModels.LabelAttribute{},
+	&commonModels.ScheduledAction{},
+	&models.OcservUserOwner{},
+	&commonModels.ConfigVersion{},
 }
 
 func Migrate() {
@@ -23,5 +40,14 @@ func Migrate() {
 	if err != nil {
 		logger.Fatal("error in AutoMigrate: %v", err)
 	}
+	if err = repository.SyncPrimaryOwners(engine); err != nil {
+		logger.Fatal("error in migrating ocserv user owners: %v", err)
+	}
+	if err = commonModels.BackfillDeactivatedReasons(engine); err != nil {
+		logger.Fatal("error in migrating ocserv user deactivation reasons: %v", err)
+	}
+	if err = commonModels.BuildTrafficRollups(engine); err != nil {
+		logger.Fatal("error in building traffic rollups: %v", err)
+	}
 	logger.Info("migration complete")
 }
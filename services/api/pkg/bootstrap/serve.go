@@ -34,6 +34,10 @@ func Serve(debug bool, host string, port int) {
 
 	go routing.Serve(cfg)
 
+	driftCtx, driftCancel := context.WithCancel(context.Background())
+	defer driftCancel()
+	ScheduleDriftRepair(driftCtx)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
@@ -2,9 +2,11 @@ package crypto
 
 import (
 	"crypto/md5"
+	cryptoRand "crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/config"
+	"math/big"
 	"math/rand"
 )
 
@@ -53,6 +55,22 @@ func salt(length int) string {
 	return string(result)
 }
 
+// RandomPassword returns a random alphanumeric password generated with crypto/rand, each character
+// drawn uniformly from the charset.
+func RandomPassword(length int) (string, error) {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	size := big.NewInt(int64(len(charset)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := cryptoRand.Int(cryptoRand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
+	}
+	return string(b), nil
+}
+
 func create(passwd, salt string) string {
 	secretKey := config.Get().SecretKey
 	passwordHash := fmt.Sprintf("%s%s%s", salt, passwd, secretKey)
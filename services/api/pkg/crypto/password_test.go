@@ -3,6 +3,7 @@ package crypto_test
 import (
 	"github.com/mmtaee/ocserv-users-management/api/pkg/crypto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
 )
@@ -54,3 +55,14 @@ func TestCheckPasswordWrongSalt(t *testing.T) {
 	match := cp.CheckPassword("securepass", data.Hash, "badSalt")
 	assert.False(t, match)
 }
+
+func TestRandomPassword(t *testing.T) {
+	p1, err := crypto.RandomPassword(12)
+	require.NoError(t, err)
+	p2, err := crypto.RandomPassword(12)
+	require.NoError(t, err)
+
+	assert.Equal(t, 12, len(p1))
+	assert.Regexp(t, "^[a-zA-Z0-9]+$", p1)
+	assert.NotEqual(t, p1, p2)
+}
@@ -0,0 +1,202 @@
+package request
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor param Paginate did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position of the last row of a page in cursor mode: the value of the column the page
+// is ordered by, kept with its type so it compares the same way in SQL, and the id breaking ties.
+type cursor struct {
+	ID     uint64     `json:"id"`
+	String *string    `json:"s,omitempty"`
+	Time   *time.Time `json:"t,omitempty"`
+	Number *float64   `json:"n,omitempty"`
+}
+
+func (c *cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err = json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// value returns the order column value of the cursor, nil when it was ordered by id.
+func (c *cursor) value() interface{} {
+	switch {
+	case c.String != nil:
+		return *c.String
+	case c.Time != nil:
+		return *c.Time
+	case c.Number != nil:
+		return *c.Number
+	}
+	return nil
+}
+
+func (c *cursor) setValue(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		c.String = &v
+	case time.Time:
+		c.Time = &v
+	default:
+		rv := reflect.ValueOf(v)
+		switch {
+		case rv.CanInt():
+			n := float64(rv.Int())
+			c.Number = &n
+		case rv.CanUint():
+			n := float64(rv.Uint())
+			c.Number = &n
+		case rv.CanFloat():
+			n := rv.Float()
+			c.Number = &n
+		default:
+			return fmt.Errorf("cursor pagination does not support %T columns", v)
+		}
+	}
+	return nil
+}
+
+// Paginate finds the page of the rows filter selects into dest, a pointer to a slice of models, and
+// counts them when pagination.CountTotal, returning -1 otherwise. When pagination.Fields is set, only the
+// columns of the listed fields are read, see selectFields.
+//
+// Offset mode pages by pagination.Page ordered as PaginatorOrderBy with orderBy does. Cursor mode pages
+// after pagination.Cursor, ordered by the column keyset maps pagination.Order to and then by id, and
+// sets pagination.NextCursor. The keyset columns must be plain NOT NULL columns of the model, so a page
+// is found by an indexed range instead of skipping the rows before it.
+func Paginate(ctx context.Context, db *gorm.DB, pagination *Pagination, orderBy, keyset OrderBy,
+	dest interface{}, filter func(*gorm.DB) *gorm.DB) (int64, error) {
+	var totalRecords int64 = -1
+	if pagination.CountTotal() {
+		if err := filter(db.WithContext(ctx).Model(dest)).Count(&totalRecords).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if !pagination.Keyset {
+		txPaginator, err := pagination.selectFields(filter(PaginatorOrderBy(ctx, db, pagination, orderBy)), dest)
+		if err != nil {
+			return 0, err
+		}
+		return totalRecords, txPaginator.Model(dest).Find(dest).Error
+	}
+
+	if pagination.Order == "" {
+		pagination.Order = "id"
+	}
+	column, ok := keyset[pagination.Order]
+	if !ok {
+		return 0, fmt.Errorf("order %s does not support cursor pagination", pagination.Order)
+	}
+	if pagination.Sort != "DESC" {
+		pagination.Sort = "ASC"
+	}
+	op := ">"
+	if pagination.Sort == "DESC" {
+		op = "<"
+	}
+
+	query, err := pagination.selectFields(filter(db.WithContext(ctx).Model(dest)), dest, strings.Trim(column, "`\""))
+	if err != nil {
+		return 0, err
+	}
+	if pagination.Cursor != "" {
+		after, err := decodeCursor(pagination.Cursor)
+		if err != nil {
+			return 0, err
+		}
+		if column == "id" {
+			query = query.Where("id "+op+" ?", after.ID)
+		} else {
+			value := after.value()
+			if value == nil {
+				return 0, ErrInvalidCursor
+			}
+			query = query.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, op),
+				value, value, after.ID)
+		}
+	}
+
+	order := column + " " + pagination.Sort
+	if column != "id" {
+		order += ", id " + pagination.Sort
+	}
+	query = query.Order(order).Limit(pagination.PageSize + 1).Find(dest)
+	if query.Error != nil {
+		return 0, query.Error
+	}
+
+	pagination.NextCursor = ""
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() <= pagination.PageSize {
+		return totalRecords, nil
+	}
+	rows.SetLen(pagination.PageSize)
+
+	last := reflect.Indirect(rows.Index(pagination.PageSize - 1))
+	next, err := lastCursor(ctx, query, last, strings.Trim(column, "`\""))
+	if err != nil {
+		return 0, err
+	}
+	pagination.NextCursor = next.encode()
+	return totalRecords, nil
+}
+
+// lastCursor reads the cursor after the row from its model fields.
+func lastCursor(ctx context.Context, query *gorm.DB, row reflect.Value, column string) (*cursor, error) {
+	schema := query.Statement.Schema
+	if schema == nil {
+		return nil, errors.New("cursor pagination needs a model")
+	}
+	idField := schema.LookUpField("id")
+	if idField == nil {
+		return nil, errors.New("cursor pagination needs an id column")
+	}
+
+	var next cursor
+	id, _ := idField.ValueOf(ctx, row)
+	rv := reflect.ValueOf(id)
+	switch {
+	case rv.CanUint():
+		next.ID = rv.Uint()
+	case rv.CanInt():
+		next.ID = uint64(rv.Int())
+	default:
+		return nil, fmt.Errorf("cursor pagination does not support %T ids", id)
+	}
+
+	if column != "id" {
+		field := schema.LookUpField(column)
+		if field == nil {
+			return nil, fmt.Errorf("cursor pagination column %s not found", column)
+		}
+		value, _ := field.ValueOf(ctx, row)
+		if err := next.setValue(value); err != nil {
+			return nil, err
+		}
+	}
+	return &next, nil
+}
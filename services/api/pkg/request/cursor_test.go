@@ -0,0 +1,188 @@
+package request_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+var itemsKeyset = request.OrderBy{
+	"id":         "id",
+	"name":       "name",
+	"score":      "score",
+	"created_at": "created_at",
+}
+
+func noFilter(db *gorm.DB) *gorm.DB {
+	return db
+}
+
+// walk pages through the items in cursor mode, returning the ids of every page.
+func walk(t *testing.T, db *gorm.DB, order, sort string, size int) [][]uint {
+	t.Helper()
+
+	var pages [][]uint
+	pagination := &request.Pagination{PageSize: size, Order: order, Sort: sort, Keyset: true}
+	for {
+		var result []item
+		total, err := request.Paginate(context.Background(), db, pagination, nil, itemsKeyset, &result, noFilter)
+		require.NoError(t, err)
+		assert.EqualValues(t, -1, total, "cursor mode does not count by default")
+
+		pages = append(pages, ids(result))
+		if pagination.NextCursor == "" {
+			return pages
+		}
+		require.Len(t, result, size, "pages before the last one are full")
+		pagination.Cursor = pagination.NextCursor
+	}
+}
+
+func TestPaginateCursor(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := setupItems(t,
+		item{Name: "carol", Score: 2.5, CreatedAt: day.Add(2 * time.Hour)},
+		item{Name: "alice", Score: 1, CreatedAt: day},
+		item{Name: "bob", Score: 2.5, CreatedAt: day.Add(time.Hour)},
+		item{Name: "dave", Score: 3, CreatedAt: day.Add(time.Hour)},
+		item{Name: "erin", Score: 2.5, CreatedAt: day.Add(3 * time.Hour)},
+	)
+
+	tests := []struct {
+		name  string
+		order string
+		sort  string
+		size  int
+		want  [][]uint
+	}{
+		{name: "id", order: "id", sort: "ASC", size: 2, want: [][]uint{{1, 2}, {3, 4}, {5}}},
+		{name: "id descending", order: "id", sort: "DESC", size: 2, want: [][]uint{{5, 4}, {3, 2}, {1}}},
+		{name: "default order", size: 3, want: [][]uint{{1, 2, 3}, {4, 5}}},
+		{name: "string", order: "name", sort: "ASC", size: 2, want: [][]uint{{2, 3}, {1, 4}, {5}}},
+		{name: "string descending", order: "name", sort: "DESC", size: 2, want: [][]uint{{5, 4}, {1, 3}, {2}}},
+		{name: "number ties", order: "score", sort: "ASC", size: 2, want: [][]uint{{2, 1}, {3, 5}, {4}}},
+		{name: "number ties descending", order: "score", sort: "DESC", size: 2, want: [][]uint{{4, 5}, {3, 1}, {2}}},
+		{name: "time ties", order: "created_at", sort: "ASC", size: 2, want: [][]uint{{2, 3}, {4, 1}, {5}}},
+		{name: "time ties descending", order: "created_at", sort: "DESC", size: 3, want: [][]uint{{5, 1, 4}, {3, 2}}},
+		{name: "last page full", order: "id", sort: "ASC", size: 5, want: [][]uint{{1, 2, 3, 4, 5}}},
+		{name: "page larger than the rows", order: "name", sort: "ASC", size: 10, want: [][]uint{{2, 3, 1, 4, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, walk(t, db, tt.order, tt.sort, tt.size))
+		})
+	}
+}
+
+func TestPaginateCursorErrors(t *testing.T) {
+	db := setupItems(t, item{Name: "alice"}, item{Name: "bob"})
+
+	byName := &request.Pagination{PageSize: 1, Order: "name", Keyset: true}
+	var result []item
+	_, err := request.Paginate(context.Background(), db, byName, nil, itemsKeyset, &result, noFilter)
+	require.NoError(t, err)
+	require.NotEmpty(t, byName.NextCursor)
+
+	byID := &request.Pagination{PageSize: 1, Order: "id", Keyset: true}
+	_, err = request.Paginate(context.Background(), db, byID, nil, itemsKeyset, &result, noFilter)
+	require.NoError(t, err)
+	require.NotEmpty(t, byID.NextCursor)
+
+	tests := []struct {
+		name   string
+		order  string
+		cursor string
+		err    string
+	}{
+		{name: "not base64", order: "name", cursor: "not a cursor!", err: request.ErrInvalidCursor.Error()},
+		{name: "not json", order: "name", cursor: "bm90IGpzb24", err: request.ErrInvalidCursor.Error()},
+		{name: "id cursor of a column order", order: "name", cursor: byID.NextCursor, err: request.ErrInvalidCursor.Error()},
+		{name: "unsupported order", order: "secret", err: "order secret does not support cursor pagination"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pagination := &request.Pagination{PageSize: 1, Order: tt.order, Cursor: tt.cursor, Keyset: true}
+			var result []item
+			_, err := request.Paginate(context.Background(), db, pagination, nil, itemsKeyset, &result, noFilter)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestPaginateFields(t *testing.T) {
+	db := setupItems(t,
+		item{Name: "alice", Score: 1, Secret: "a"},
+		item{Name: "bob", Score: 2, Secret: "b"},
+		item{Name: "carol", Score: 3, Secret: "c"},
+	)
+
+	t.Run("offset", func(t *testing.T) {
+		pagination := &request.Pagination{Page: 1, PageSize: 10, Order: "id", Sort: "ASC", Fields: "name, online"}
+		var result []item
+		total, err := request.Paginate(context.Background(), db, pagination, nil, itemsKeyset, &result, noFilter)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, total)
+		assert.Equal(t, []item{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}, {ID: 3, Name: "carol"}}, result)
+	})
+
+	t.Run("required", func(t *testing.T) {
+		pagination := &request.Pagination{
+			Page: 1, PageSize: 1, Order: "id", Sort: "ASC", Fields: "name", Required: []string{"score"},
+		}
+		var result []item
+		_, err := request.Paginate(context.Background(), db, pagination, nil, itemsKeyset, &result, noFilter)
+		require.NoError(t, err)
+		assert.Equal(t, []item{{ID: 1, Name: "alice", Score: 1}}, result)
+	})
+
+	t.Run("cursor reads the order column", func(t *testing.T) {
+		pagination := &request.Pagination{PageSize: 2, Order: "score", Sort: "DESC", Fields: "name", Keyset: true}
+		var result []item
+		_, err := request.Paginate(context.Background(), db, pagination, nil, itemsKeyset, &result, noFilter)
+		require.NoError(t, err)
+		assert.Equal(t, []item{{ID: 3, Name: "carol", Score: 3}, {ID: 2, Name: "bob", Score: 2}}, result)
+
+		pagination.Cursor = pagination.NextCursor
+		result = nil
+		_, err = request.Paginate(context.Background(), db, pagination, nil, itemsKeyset, &result, noFilter)
+		require.NoError(t, err)
+		assert.Equal(t, []item{{ID: 1, Name: "alice", Score: 1}}, result)
+		assert.Empty(t, pagination.NextCursor)
+	})
+
+	for _, fields := range []string{"name,unknown", "Secret", "secret"} {
+		t.Run("unknown "+fields, func(t *testing.T) {
+			empty := func(db *gorm.DB) *gorm.DB { return db.Where("1 = 0") }
+			pagination := &request.Pagination{Page: 1, PageSize: 10, Fields: fields}
+			var result []item
+			_, err := request.Paginate(context.Background(), db, pagination, nil, itemsKeyset, &result, empty)
+			assert.ErrorContains(t, err, "unknown field")
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	items := []item{{ID: 1, Name: "alice", Score: 1, Secret: "a"}}
+
+	sparse, err := request.Fields(items, " name,,score ")
+	require.NoError(t, err)
+	require.Len(t, sparse, 1)
+	assert.Equal(t, map[string]string{"name": `"alice"`, "score": "1"}, map[string]string{
+		"name": string(sparse[0]["name"]), "score": string(sparse[0]["score"]),
+	})
+	assert.Len(t, sparse[0], 2)
+
+	sparse, err = request.Fields([]item{}, "name")
+	require.NoError(t, err)
+	assert.Empty(t, sparse)
+
+	_, err = request.Fields([]item{}, "name,secret")
+	assert.EqualError(t, err, "unknown field secret")
+
+	_, err = request.Fields([]*item{}, "unknown")
+	assert.EqualError(t, err, "unknown field unknown")
+}
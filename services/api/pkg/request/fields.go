@@ -0,0 +1,165 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ListResponse is the response of a list page, with the items limited to the fields query param when given.
+type ListResponse struct {
+	Meta   Meta        `json:"meta" validate:"required"`
+	Result interface{} `json:"result" validate:"omitempty"`
+}
+
+// Response writes the page of the list, the total records counted by Paginate and result, a slice.
+func (r *Request) Response(c echo.Context, p *Pagination, total int64, result interface{}) error {
+	if p.Fields != "" {
+		sparse, err := Fields(result, p.Fields)
+		if err != nil {
+			return r.BadRequest(c, err)
+		}
+		result = sparse
+	}
+	return c.JSON(http.StatusOK, ListResponse{
+		Meta:   p.Meta(total),
+		Result: result,
+	})
+}
+
+// Fields returns the items of result, a slice of structs, as JSON objects holding only the fields listed
+// in fields, a comma separated list of their JSON names. Fields the struct does not have are an error,
+// even when result is empty; fields omitted as empty are left out.
+func Fields(result interface{}, fields string) ([]map[string]json.RawMessage, error) {
+	names, err := fieldNames(reflect.TypeOf(result), fields)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]json.RawMessage
+	if err = json.Unmarshal(b, &items); err != nil {
+		return nil, err
+	}
+
+	sparse := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		sparse[i] = make(map[string]json.RawMessage, len(names))
+		for _, name := range names {
+			if value, ok := item[name]; ok {
+				sparse[i][name] = value
+			}
+		}
+	}
+	return sparse, nil
+}
+
+// fieldNames returns the names listed in fields, checked against the JSON fields of the items of t, a
+// slice or pointer to a slice of structs.
+func fieldNames(t reflect.Type, fields string) ([]string, error) {
+	known := jsonFields(itemType(t))
+
+	var names []string
+	for _, name := range strings.Split(fields, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// itemType returns the struct type of the items of t, a slice of structs or of pointers to structs,
+// possibly behind a pointer.
+func itemType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonFields maps the JSON names of the fields of the struct t, including those of embedded structs,
+// to the names of their Go fields.
+func jsonFields(t reflect.Type) map[string]string {
+	fields := make(map[string]string)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			for k, v := range jsonFields(itemType(f.Type)) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Name
+	}
+	return fields
+}
+
+// selectFields limits the columns the query reads into dest, a pointer to a slice of models, to those of
+// the fields listed in p.Fields and p.Required, the primary key and extra. Fields without a column, such
+// as computed ones, are left to be set on the models. Unknown fields are an error.
+func (p *Pagination) selectFields(query *gorm.DB, dest interface{}, extra ...string) (*gorm.DB, error) {
+	if p.Fields == "" {
+		return query, nil
+	}
+	names, err := fieldNames(reflect.TypeOf(dest), strings.Join(append([]string{p.Fields}, p.Required...), ","))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &gorm.Statement{DB: query}
+	if err = stmt.Parse(dest); err != nil {
+		return nil, err
+	}
+	known := jsonFields(itemType(reflect.TypeOf(dest)))
+
+	var columns []string
+	add := func(name string) {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil || field.DBName == "" || !field.Readable || field.IgnoreMigration {
+			return
+		}
+		for _, column := range columns {
+			if column == field.DBName {
+				return
+			}
+		}
+		columns = append(columns, field.DBName)
+	}
+	for _, field := range stmt.Schema.PrimaryFields {
+		add(field.Name)
+	}
+	for _, name := range extra {
+		add(name)
+	}
+	for _, name := range names {
+		add(known[name])
+	}
+	return query.Select(columns), nil
+}
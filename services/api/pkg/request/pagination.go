@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
+	"regexp"
 )
 
 // Pagination defines pagination query parameters for the API.
@@ -12,12 +13,25 @@ import (
 // @Param size query int false "Number of items per page" minimum(1) maximum(100) name(size)
 // @Param order query string false "Field to order by"
 // @Param sort query string false "Sort order, either ASC or DESC" Enums(ASC, DESC)
+// @Param cursor query string false "Cursor of the page in cursor mode, empty for the first page"
+// @Param fields query string false "Comma separated fields of the items to return"
+// @Param total query bool false "Count the total records, true by default in offset mode only"
 // @Description Pagination parameters
 type Pagination struct {
 	Page     int    `json:"page" query:"page" validate:"omitempty,min=1"`
 	PageSize int    `json:"size" query:"size" validate:"omitempty,min=1,max=100"`
 	Order    string `json:"order" query:"order" validate:"omitempty"`
 	Sort     string `json:"sort" query:"sort" validate:"omitempty,oneof=DESC ASC"`
+	Cursor   string `json:"cursor" query:"cursor" validate:"omitempty"`
+	Fields   string `json:"fields" query:"fields" validate:"omitempty"`
+	Total    *bool  `json:"total" query:"total" validate:"omitempty"`
+
+	// Keyset is set when the cursor param is given, even empty, and pages after Cursor instead of by Page.
+	Keyset bool `json:"-" query:"-"`
+	// NextCursor is set by Paginate in cursor mode to the cursor of the next page, empty on the last page.
+	NextCursor string `json:"-" query:"-"`
+	// Required lists the JSON fields Paginate reads along with Fields, as the handler needs them.
+	Required []string `json:"-" query:"-"`
 }
 
 func (r *Request) Pagination(c echo.Context) *Pagination {
@@ -44,25 +58,81 @@ func (r *Request) Pagination(c echo.Context) *Pagination {
 	if pagination.Sort == "" {
 		pagination.Sort = "ASC"
 	}
+	pagination.Keyset = c.QueryParams().Has("cursor")
 
 	return &pagination
 }
 
 type Meta struct {
-	Page         int   `json:"page" validate:"required"`
-	PageSize     int   `json:"size" validate:"required"`
-	TotalRecords int64 `json:"total_records" validate:"required"`
+	Page         int    `json:"page" validate:"required"`
+	PageSize     int    `json:"size" validate:"required"`
+	TotalRecords int64  `json:"total_records" validate:"required"`          // -1 when the total is not counted
+	NextCursor   string `json:"next_cursor,omitempty" validate:"omitempty"` // cursor mode only, empty on the last page
+}
+
+// CountTotal reports whether the total records are counted: when asked, by default in offset mode only.
+func (p *Pagination) CountTotal() bool {
+	if p.Total != nil {
+		return *p.Total
+	}
+	return !p.Keyset
+}
+
+// Meta returns the meta of the page with the total records counted by Paginate.
+func (p *Pagination) Meta(total int64) Meta {
+	return Meta{
+		Page:         p.Page,
+		PageSize:     p.PageSize,
+		TotalRecords: total,
+		NextCursor:   p.NextCursor,
+	}
 }
 
+// NextCursorHeader carries the cursor of the next page of responses paged without a Meta, such as
+// statistics series.
+const NextCursorHeader = "X-Next-Cursor"
+
+// OrderBy maps the values of the order query param a list accepts to the SQL they order by.
+type OrderBy map[string]string
+
+// identifier matches the plain column names Paginator orders by when no OrderBy is given.
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Paginator pages the query ordered by pagination.Order, which must be a plain column name.
 func Paginator(ctx context.Context, db *gorm.DB, pagination *Pagination) *gorm.DB {
+	return PaginatorOrderBy(ctx, db, pagination, nil)
+}
+
+// PaginatorOrderBy pages the query ordered by the SQL orderBy maps pagination.Order to, or by the
+// plain column name in pagination.Order when orderBy is nil. Other orders fall back to id, so query
+// strings are never interpolated into ORDER BY. Ties are broken by id to keep pages stable.
+func PaginatorOrderBy(ctx context.Context, db *gorm.DB, pagination *Pagination, orderBy OrderBy) *gorm.DB {
 	if pagination.Order == "" {
 		pagination.Order = "id"
 		pagination.Sort = "DESC"
 	}
+	if pagination.Sort != "DESC" {
+		pagination.Sort = "ASC"
+	}
 
-	offset := (pagination.Page - 1) * pagination.PageSize
+	column := "id"
+	if orderBy != nil {
+		if expr, ok := orderBy[pagination.Order]; ok {
+			column = expr
+		} else {
+			pagination.Order = "id"
+		}
+	} else if identifier.MatchString(pagination.Order) {
+		column = db.Statement.Quote(pagination.Order)
+	} else {
+		pagination.Order = "id"
+	}
 
-	order := fmt.Sprintf("%s %s", pagination.Order, pagination.Sort)
+	offset := (pagination.Page - 1) * pagination.PageSize
 
-	return db.WithContext(ctx).Order(order).Limit(pagination.PageSize).Offset(offset)
+	query := db.WithContext(ctx).Order(fmt.Sprintf("%s %s", column, pagination.Sort))
+	if pagination.Order != "id" {
+		query = query.Order("id " + pagination.Sort)
+	}
+	return query.Limit(pagination.PageSize).Offset(offset)
 }
@@ -0,0 +1,97 @@
+package request_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/api/pkg/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+	Secret    string    `json:"-"`
+	Online    bool      `json:"online" gorm:"-:migration;->"`
+}
+
+// setupItems returns an in-memory database holding the items.
+func setupItems(t *testing.T, items ...item) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// every connection to :memory: opens its own database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&item{}))
+	if len(items) > 0 {
+		require.NoError(t, db.Create(&items).Error)
+	}
+	return db
+}
+
+func ids(items []item) []uint {
+	result := []uint{}
+	for _, i := range items {
+		result = append(result, i.ID)
+	}
+	return result
+}
+
+func TestPaginatorOrderBy(t *testing.T) {
+	db := setupItems(t,
+		item{Name: "carol", Score: 2},
+		item{Name: "alice", Score: 1},
+		item{Name: "bob", Score: 2},
+		item{Name: "dave", Score: 3},
+	)
+	orderBy := request.OrderBy{"name": "name", "score": "score", "rank": "score * -1"}
+
+	tests := []struct {
+		name      string
+		orderBy   request.OrderBy
+		page      int
+		size      int
+		order     string
+		sort      string
+		want      []uint
+		wantOrder string
+		wantSort  string
+	}{
+		{name: "default", orderBy: orderBy, size: 10, want: []uint{4, 3, 2, 1}, wantOrder: "id", wantSort: "DESC"},
+		{name: "mapped", orderBy: orderBy, size: 10, order: "name", sort: "ASC", want: []uint{2, 3, 1, 4}, wantOrder: "name", wantSort: "ASC"},
+		{name: "expression", orderBy: orderBy, size: 10, order: "rank", sort: "ASC", want: []uint{4, 1, 3, 2}, wantOrder: "rank", wantSort: "ASC"},
+		{name: "ties by id", orderBy: orderBy, size: 10, order: "score", sort: "ASC", want: []uint{2, 1, 3, 4}, wantOrder: "score", wantSort: "ASC"},
+		{name: "ties by id descending", orderBy: orderBy, size: 10, order: "score", sort: "DESC", want: []uint{4, 3, 1, 2}, wantOrder: "score", wantSort: "DESC"},
+		{name: "invalid sort", orderBy: orderBy, size: 10, order: "name", sort: "name", want: []uint{2, 3, 1, 4}, wantOrder: "name", wantSort: "ASC"},
+		{name: "unknown order", orderBy: orderBy, size: 10, order: "created_at", sort: "DESC", want: []uint{4, 3, 2, 1}, wantOrder: "id", wantSort: "DESC"},
+		{name: "second page", orderBy: orderBy, page: 2, size: 3, order: "name", sort: "ASC", want: []uint{4}, wantOrder: "name", wantSort: "ASC"},
+		{name: "column", size: 10, order: "name", sort: "DESC", want: []uint{4, 1, 3, 2}, wantOrder: "name", wantSort: "DESC"},
+		{name: "injection", size: 10, order: "name; DROP TABLE items", sort: "ASC", want: []uint{1, 2, 3, 4}, wantOrder: "id", wantSort: "ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := tt.page
+			if page == 0 {
+				page = 1
+			}
+			pagination := &request.Pagination{Page: page, PageSize: tt.size, Order: tt.order, Sort: tt.sort}
+
+			var result []item
+			err := request.PaginatorOrderBy(context.Background(), db, pagination, tt.orderBy).Find(&result).Error
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(result))
+			assert.Equal(t, tt.wantOrder, pagination.Order)
+			assert.Equal(t, tt.wantSort, pagination.Sort)
+		})
+	}
+}
@@ -15,6 +15,7 @@ const (
 	MonthlyReceive  = "MonthlyReceive"
 	TotallyTransmit = "TotallyTransmit"
 	TotallyReceive  = "TotallyReceive"
+	Custom          = "Custom" // direction and period set by TrafficDirection and TrafficPeriod
 )
 
 func (s *CSVStringList) Value() (driver.Value, error) {
@@ -38,7 +39,20 @@ func (s *CSVStringList) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]string(*s))
 }
 
+// UnmarshalJSON accepts a list, or a comma-separated string as stored for keys that used to be single-valued.
 func (s *CSVStringList) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		list := []string{}
+		for _, item := range strings.Split(str, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*s = list
+		return nil
+	}
+
 	var arr []string
 	if err := json.Unmarshal(b, &arr); err != nil {
 		return err
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Kinds of config files tracked by ConfigVersion.
+const (
+	ConfigKindGroup    = "group"
+	ConfigKindDefaults = "defaults"
+	ConfigKindUser     = "user"
+)
+
+// ConfigVersion is a snapshot of a group, defaults group or user config taken on every change.
+// Name is the group name or username; the defaults group is named "defaults". The throttling of a
+// user over its quota and its end are recorded too, with the throttled config as Config.
+type ConfigVersion struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Kind      string    `json:"kind" gorm:"type:varchar(16);not null;uniqueIndex:idx_config_versions_kind_name_version" enums:"group,defaults,user" validate:"required"`
+	Name      string    `json:"name" gorm:"type:varchar(255);not null;uniqueIndex:idx_config_versions_kind_name_version" validate:"required"`
+	Version   int       `json:"version" gorm:"not null;uniqueIndex:idx_config_versions_kind_name_version" validate:"required"`
+	Config    string    `json:"-" gorm:"type:text"`                                  // JSON of the config model
+	Content   string    `json:"content" gorm:"type:text" validate:"omitempty"`       // rendered config file
+	Diff      string    `json:"diff" gorm:"type:text" validate:"omitempty"`          // unified diff against the previous version
+	Author    string    `json:"author" gorm:"type:varchar(16)" validate:"omitempty"` // empty for the baseline and for the changes of the services
+	Note      string    `json:"note" gorm:"type:text" validate:"omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime" validate:"required"`
+}
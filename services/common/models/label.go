@@ -0,0 +1,162 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of the targets of tags and custom attributes.
+const (
+	LabelKindUser  = "user"
+	LabelKindGroup = "group"
+)
+
+// Types of custom attribute values.
+const (
+	AttributeString = "string"
+	AttributeNumber = "number"
+	AttributeBool   = "bool"
+	AttributeDate   = "date"
+)
+
+var (
+	tagPattern          = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,31}$`)
+	attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// AttributeDefinition declares a custom attribute of users and groups and the type of its values.
+type AttributeDefinition struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Key         string    `json:"key" gorm:"type:varchar(32);not null;uniqueIndex" validate:"required" example:"department"`
+	Type        string    `json:"type" gorm:"type:varchar(8);not null" enums:"string,number,bool,date" validate:"required"`
+	Description string    `json:"description" gorm:"type:text" validate:"omitempty"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime" validate:"required"`
+}
+
+// LabelTag is a tag on a user or group.
+type LabelTag struct {
+	Kind     string `gorm:"type:varchar(8);primaryKey"`
+	TargetID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name     string `gorm:"type:varchar(32);primaryKey;index"`
+}
+
+// LabelAttribute is the value of a custom attribute of a user or group. Value holds the canonical
+// text of the value and Number the value of number attributes, so they compare as numbers.
+type LabelAttribute struct {
+	Kind     string   `gorm:"type:varchar(8);primaryKey"`
+	TargetID uint     `gorm:"primaryKey;autoIncrement:false"`
+	Key      string   `gorm:"type:varchar(32);primaryKey;index"`
+	Value    string   `gorm:"type:varchar(255);not null"`
+	Number   *float64 `gorm:"index"`
+}
+
+// Attributes maps custom attribute keys to their values: a string for string and date attributes,
+// a float64 for number attributes and a bool for bool attributes.
+type Attributes map[string]interface{}
+
+// Validate checks the key and type of the definition.
+func (d *AttributeDefinition) Validate() error {
+	if !attributeKeyPattern.MatchString(d.Key) {
+		return fmt.Errorf("attribute key %q must be lowercase letters, digits and _, up to 32 characters", d.Key)
+	}
+	if !slices.Contains([]string{AttributeString, AttributeNumber, AttributeBool, AttributeDate}, d.Type) {
+		return fmt.Errorf("invalid attribute type %q", d.Type)
+	}
+	return nil
+}
+
+// Normalize converts the value to the Go type of the attribute. Number, bool and date values are also
+// accepted as strings, as they come in filters and imported files.
+func (d *AttributeDefinition) Normalize(value interface{}) (interface{}, error) {
+	invalid := fmt.Errorf("attribute %s must be a %s", d.Key, d.Type)
+
+	switch d.Type {
+	case AttributeString:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		if len(s) > 255 {
+			return nil, fmt.Errorf("attribute %s must be at most 255 characters", d.Key)
+		}
+		return s, nil
+	case AttributeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			return n, nil
+		}
+	case AttributeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, invalid
+			}
+			return b, nil
+		}
+	case AttributeDate:
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse("2006-01-02", s); err == nil {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("attribute %s must be a date formatted as YYYY-MM-DD", d.Key)
+	}
+	return nil, invalid
+}
+
+// NewLabelAttribute returns the stored form of a value normalized by AttributeDefinition.Normalize.
+func NewLabelAttribute(kind string, targetID uint, key string, value interface{}) LabelAttribute {
+	attribute := LabelAttribute{Kind: kind, TargetID: targetID, Key: key}
+	switch v := value.(type) {
+	case float64:
+		attribute.Value = strconv.FormatFloat(v, 'f', -1, 64)
+		attribute.Number = &v
+	case bool:
+		attribute.Value = strconv.FormatBool(v)
+	default:
+		attribute.Value = fmt.Sprint(v)
+	}
+	return attribute
+}
+
+// Typed returns the value of the attribute as the Go type of attributeType.
+func (a *LabelAttribute) Typed(attributeType string) interface{} {
+	switch attributeType {
+	case AttributeNumber:
+		if a.Number != nil {
+			return *a.Number
+		}
+	case AttributeBool:
+		return a.Value == "true"
+	}
+	return a.Value
+}
+
+// NormalizeTags lowercases, deduplicates, sorts and validates tags.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag %q must be lowercase letters, digits and _.:-, up to 32 characters", tag)
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
@@ -0,0 +1,94 @@
+package models_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+		err  string
+	}{
+		{name: "none", tags: nil, want: []string{}},
+		{name: "lowercased and trimmed", tags: []string{" VIP ", "team:sales"}, want: []string{"team:sales", "vip"}},
+		{name: "deduplicated and sorted", tags: []string{"b", "a", "B", "a"}, want: []string{"a", "b"}},
+		{name: "punctuation", tags: []string{"v1.2_beta-3"}, want: []string{"v1.2_beta-3"}},
+		{name: "longest", tags: []string{strings.Repeat("a", 32)}, want: []string{strings.Repeat("a", 32)}},
+		{
+			name: "too long",
+			tags: []string{strings.Repeat("a", 33)},
+			err:  `tag "` + strings.Repeat("a", 33) + `" must be lowercase letters, digits and _.:-, up to 32 characters`,
+		},
+		{name: "empty", tags: []string{" "}, err: `tag "" must be lowercase letters, digits and _.:-, up to 32 characters`},
+		{name: "leading punctuation", tags: []string{"-vip"}, err: `tag "-vip" must be lowercase letters, digits and _.:-, up to 32 characters`},
+		{name: "space", tags: []string{"gold member"}, err: `tag "gold member" must be lowercase letters, digits and _.:-, up to 32 characters`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := models.NormalizeTags(tt.tags)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, tags)
+		})
+	}
+}
+
+func TestAttributeDefinitionNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition models.AttributeDefinition
+		value      interface{}
+		want       interface{}
+		err        string
+	}{
+		{name: "string", definition: models.AttributeDefinition{Key: "team", Type: models.AttributeString}, value: "sales", want: "sales"},
+		{
+			name:       "long string",
+			definition: models.AttributeDefinition{Key: "team", Type: models.AttributeString},
+			value:      strings.Repeat("a", 256),
+			err:        "attribute team must be at most 255 characters",
+		},
+		{name: "string from number", definition: models.AttributeDefinition{Key: "team", Type: models.AttributeString}, value: 1.0, err: "attribute team must be a string"},
+		{name: "number", definition: models.AttributeDefinition{Key: "seats", Type: models.AttributeNumber}, value: 2.5, want: 2.5},
+		{name: "number from int", definition: models.AttributeDefinition{Key: "seats", Type: models.AttributeNumber}, value: 3, want: 3.0},
+		{name: "number from string", definition: models.AttributeDefinition{Key: "seats", Type: models.AttributeNumber}, value: "10", want: 10.0},
+		{name: "invalid number", definition: models.AttributeDefinition{Key: "seats", Type: models.AttributeNumber}, value: "ten", err: "attribute seats must be a number"},
+		{name: "number from bool", definition: models.AttributeDefinition{Key: "seats", Type: models.AttributeNumber}, value: true, err: "attribute seats must be a number"},
+		{name: "bool", definition: models.AttributeDefinition{Key: "trial", Type: models.AttributeBool}, value: true, want: true},
+		{name: "bool from string", definition: models.AttributeDefinition{Key: "trial", Type: models.AttributeBool}, value: "FALSE", want: false},
+		{name: "invalid bool", definition: models.AttributeDefinition{Key: "trial", Type: models.AttributeBool}, value: "yes", err: "attribute trial must be a bool"},
+		{name: "date", definition: models.AttributeDefinition{Key: "joined", Type: models.AttributeDate}, value: "2026-02-28", want: "2026-02-28"},
+		{
+			name:       "invalid date",
+			definition: models.AttributeDefinition{Key: "joined", Type: models.AttributeDate},
+			value:      "2026-02-30",
+			err:        "attribute joined must be a date formatted as YYYY-MM-DD",
+		},
+		{
+			name:       "date time",
+			definition: models.AttributeDefinition{Key: "joined", Type: models.AttributeDate},
+			value:      "2026-02-28T10:00:00Z",
+			err:        "attribute joined must be a date formatted as YYYY-MM-DD",
+		},
+		{name: "unknown type", definition: models.AttributeDefinition{Key: "x", Type: "list"}, value: "a", err: "attribute x must be a list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.definition.Normalize(tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Node struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"type:varchar(64);not null;uniqueIndex" validate:"required"`
+	AgentURL    string    `json:"agent_url" gorm:"type:varchar(255);not null" validate:"required"` // Example: 'https://10.0.0.2:8888'
+	Token       string    `json:"-" gorm:"type:varchar(128);default:''"`
+	Description string    `json:"description" gorm:"type:text" validate:"omitempty"`
+	IsActive    bool      `json:"is_active" validate:"omitempty"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type NodeBandwidths struct {
+	NodeID *uint   `json:"node_id"`
+	Node   string  `json:"node"` // empty for the local ocserv
+	RX     float64 `json:"rx" validate:"required"`
+	TX     float64 `json:"tx" validate:"required"`
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 type IPBan struct {
 	IP       string `json:"IP"`
 	Since    string `json:"Since"`
@@ -17,11 +22,16 @@ type Iroute struct {
 }
 
 type OnlineUserSession struct {
-	Username    string `json:"Username"`
-	Group       string `json:"Groupname"`
-	AverageRX   string `json:"Average RX"`
-	AverageTX   string `json:"Average TX"`
-	ConnectedAt string `json:"_Connected at"`
+	Username     string `json:"Username"`
+	Group        string `json:"Groupname"`
+	RemoteIP     string `json:"Remote IP"`
+	RemoteFamily string `json:"remote_family"` // ipv4 or ipv6
+	IPv4         string `json:"IPv4,omitempty"`
+	IPv6         string `json:"IPv6,omitempty"`
+	AverageRX    string `json:"Average RX"`
+	AverageTX    string `json:"Average TX"`
+	ConnectedAt  string `json:"_Connected at"`
+	Node         string `json:"node,omitempty"` // empty for the local ocserv
 }
 
 type ServerVersion struct {
@@ -30,17 +40,53 @@ type ServerVersion struct {
 }
 
 type IPBanPoints struct {
-	IP    string `json:"IP"`
-	Since string `json:"Since"`
-	Until string `json:"_Since"`
-	Score int    `json:"Score"`
+	IP     string `json:"IP"`
+	Family string `json:"family"` // ipv4 or ipv6
+	Since  string `json:"Since"`
+	Until  string `json:"_Since"`
+	Score  int    `json:"Score"`
 }
 
+// IRoute is an entry of occtl show iroutes. IRoute keeps the iroutes as occtl prints them, a
+// comma-separated string, for the clients reading the original response; Networks holds them as a
+// list in canonical CIDR notation.
 type IRoute struct {
-	ID       string `json:"ID"`
-	Username string `json:"Username"`
-	Vhost    string `json:"vhost"`
-	Device   string `json:"Device"`
-	IP       string `json:"IP"`
-	IRoute   string `json:"iRoutes"`
+	ID       string   `json:"ID"`
+	Username string   `json:"Username"`
+	Vhost    string   `json:"vhost"`
+	Device   string   `json:"Device"`
+	IP       string   `json:"IP"`
+	Family   string   `json:"family"` // ipv4 or ipv6
+	IRoute   string   `json:"iRoutes"`
+	Networks []string `json:"networks"`
+}
+
+// UnmarshalJSON reads the ID as occtl prints it, a number or a string, and the iroutes
+// as a list or a single comma-separated string.
+func (r *IRoute) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		ID       json.RawMessage `json:"ID"`
+		Username string          `json:"Username"`
+		Vhost    string          `json:"vhost"`
+		Device   string          `json:"Device"`
+		IP       string          `json:"IP"`
+		IRoutes  CSVStringList   `json:"iRoutes"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	*r = IRoute{
+		ID:       strings.Trim(string(raw.ID), `"`),
+		Username: raw.Username,
+		Vhost:    raw.Vhost,
+		Device:   raw.Device,
+		IP:       raw.IP,
+		IRoute:   strings.Join(raw.IRoutes, ", "),
+		Networks: raw.IRoutes,
+	}
+	if r.Networks == nil {
+		r.Networks = []string{}
+	}
+	return nil
 }
@@ -0,0 +1,55 @@
+package models_test
+
+import (
+	"encoding/json"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIRouteJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		id       string
+		iroute   string
+		networks []string
+	}{
+		{
+			name:     "string",
+			data:     `{"ID": 12, "Username": "alice", "iRoutes": "10.0.0.0/255.0.0.0, 192.168.1.0/24"}`,
+			id:       "12",
+			iroute:   "10.0.0.0/255.0.0.0, 192.168.1.0/24",
+			networks: []string{"10.0.0.0/255.0.0.0", "192.168.1.0/24"},
+		},
+		{
+			name:     "list",
+			data:     `{"ID": "13", "Username": "bob", "iRoutes": ["fd00:24::/48"]}`,
+			id:       "13",
+			iroute:   "fd00:24::/48",
+			networks: []string{"fd00:24::/48"},
+		},
+		{
+			name:     "none",
+			data:     `{"ID": 14, "Username": "carol"}`,
+			id:       "14",
+			networks: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var route models.IRoute
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &route))
+			assert.Equal(t, tt.id, route.ID)
+			assert.Equal(t, tt.iroute, route.IRoute)
+			assert.Equal(t, tt.networks, route.Networks)
+
+			b, err := json.Marshal(route)
+			require.NoError(t, err)
+			var out map[string]interface{}
+			require.NoError(t, json.Unmarshal(b, &out))
+			assert.IsType(t, "", out["iRoutes"], "iRoutes keeps its string type")
+		})
+	}
+}
@@ -4,39 +4,58 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"gorm.io/gorm"
 )
 
 type OcservGroupConfig struct {
-	// Comma-separated list of DNS servers to assign to the client. Example: '8.8.8.8,1.1.1.1'
-	DNS *CSVStringList `json:"dns" gorm:"type:text"`
+	// Static IPv4 address to assign to the client. Example: '192.168.100.10'
+	ExplicitIPv4 *string `json:"explicit-ipv4"`
 
-	// NetBIOS Name Servers (WINS) for Windows clients. Example: '192.168.1.1'
-	NBNS *string `json:"nbns"`
+	// Static IPv6 address to assign to the client. Example: 'fd00:24::10'
+	ExplicitIPv6 *string `json:"explicit-ipv6"`
 
-	// The pool of addresses that leases will be given from. Example: '192.168.1.0/24'
+	// The pool of addresses from which to assign to the client. Example: '192.168.1.0/24'
 	IPv4Network *string `json:"ipv4-network"`
 
+	// The pool of IPv6 addresses from which to assign to the client. Example: 'fd00:24::/48'
+	IPv6Network *string `json:"ipv6-network"`
+
+	// Prefix length of the IPv6 subnet given to each client. Example: 128
+	IPv6SubnetPrefix *int `json:"ipv6-subnet-prefix"`
+
+	// DNS servers to assign to the client, IPv4 or IPv6. Example: ['8.8.8.8', '2001:4860:4860::8888']
+	DNS *CSVStringList `json:"dns" gorm:"type:text"`
+
+	// NetBIOS Name Servers (WINS) for Windows clients. Example: ['192.168.1.1']
+	NBNS *CSVStringList `json:"nbns" gorm:"type:text"`
+
+	// Routes pushed to the client for routing traffic, IPv4 or IPv6. Example: ['0.0.0.0/0', '10.10.0.0/16', 'fd00::/8']
+	Route *CSVStringList `json:"route" gorm:"type:text"`
+
+	// List of networks to exclude from VPN routing. Example: ['192.168.0.0/16', '10.0.0.0/8']
+	NoRoute *CSVStringList `json:"no-route" gorm:"type:text"`
+
+	// Networks behind the client, routed to it by the server. Example: ['10.0.0.0/8']
+	IRoute *CSVStringList `json:"iroute" gorm:"type:text"`
+
+	// List of domains over which the provided DNS servers should be used. Example: ['example.com', 'internal.company.com']
+	SplitDNS *CSVStringList `json:"split-dns" gorm:"type:text"`
+
+	// Hostname assigned to the client. Example: 'alice-laptop'
+	Hostname *string `json:"hostname"`
+
+	// XML profile file sent to AnyConnect clients. Example: '/etc/ocserv/profile.xml'
+	UserProfile *string `json:"user-profile"`
+
 	// Maximum receive bandwidth in bytes per second. Example: '100000' for 100 KB/s
 	RxDataPerSec *int `json:"rx-data-per-sec"`
 
 	// Maximum transmit bandwidth in bytes per second. Example: '200000' for 200 KB/s
 	TxDataPerSec *int `json:"tx-data-per-sec"`
 
-	// Static IPv4 address to assign to client. Example: '192.168.100.10'
-	ExplicitIPv4 *string `json:"explicit-ipv4"`
-
 	// Linux control group to assign the VPN worker process to. Format: 'controller,subsystem:name'. Example: 'cpuset,cpu:test'
 	CGroup *string `json:"cgroup"`
 
-	// Internal route available only via VPN. Format: 'IP/prefix'. Example: '10.0.0.0/8'
-	IRoute *string `json:"iroute"`
-
-	// Routes pushed to the client for routing traffic. Example: ['0.0.0.0/0', '10.10.0.0/16']
-	Route *CSVStringList `json:"route" gorm:"type:text"`
-
-	// List of networks to exclude from VPN routing. Each entry should be in 'IP/prefix' format. Example: ['192.168.0.0/16', '10.0.0.0/8']
-	NoRoute *CSVStringList `json:"no-route" gorm:"type:text"`
-
 	// Priority for routes; lower is higher priority. Example: 1
 	NetPriority *int `json:"net-priority"`
 
@@ -64,26 +83,29 @@ type OcservGroupConfig struct {
 	// Interval in seconds for stats reporting. Example: 300
 	StatsReportTime *int `json:"stats-report-time"`
 
+	// Interval in seconds between interim accounting updates. Example: 300
+	InterimUpdate *int `json:"interim-update"`
+
 	// Tunnel interface MTU to avoid fragmentation. Example: 1400
 	MTU *int `json:"mtu"`
 
+	// Maximum session time in seconds before forced disconnect. Example: 3600
+	SessionTimeout *int `json:"session-timeout"`
+
 	// Time in seconds before disconnecting idle clients. Example: 600
 	IdleTimeout *int `json:"idle-timeout"`
 
-	// Idle timeout for mobile clients. Example: 900
+	// Idle timeout in seconds for mobile clients. Example: 900
 	MobileIdleTimeout *int `json:"mobile-idle-timeout"`
 
+	// Rekey time in seconds; triggers key renegotiation. Example: 86400 for 24 hours
+	RekeyTime *int `json:"rekey-time"`
+
 	// Allow client access only to defined routes. Example: true
 	RestrictUserToRoutes *bool `json:"restrict-user-to-routes"`
 
-	// Comma-separated list of allowed (or blocked, if negated) protocols and ports. Supports 'tcp(port)', 'udp(port)', 'icmp()', 'icmpv6()', and negation with '!()'. Example: 'tcp(443), tcp(80), udp(53)', or '!(tcp(22), udp(1194))'
+	// Comma-separated list of allowed or blocked ports/protocols. Supports 'tcp(port)', 'udp(port)', 'icmp()', 'icmpv6()', and negation with '!()'. Example: 'tcp(443), udp(53)' or '!(tcp(22), udp(1194))'
 	RestrictUserToPorts *string `json:"restrict-user-to-ports"`
-
-	// List of domains over which the provided DNS servers should be used. Example: ['example.com', 'internal.company.com']
-	SplitDNS *CSVStringList `json:"split-dns" gorm:"type:text"`
-
-	// Max session time in seconds before forced disconnect. Example: 3600
-	SessionTimeout *int `json:"session-timeout"`
 }
 
 type OcservGroup struct {
@@ -91,6 +113,20 @@ type OcservGroup struct {
 	Name   string             `json:"name" gorm:"type:varchar(255);not null;uniqueIndex" validate:"required"`
 	Owner  string             `json:"owner" gorm:"type:varchar(32);default:''" validate:"required"`
 	Config *OcservGroupConfig `json:"config" gorm:"type:json"`
+	Nodes  []Node             `json:"nodes,omitempty" gorm:"many2many:ocserv_group_nodes;"`
+
+	// Schedule applies to the members of the group without a schedule of their own.
+	Schedule *AccessSchedule `json:"schedule" gorm:"type:text" validate:"omitempty"`
+
+	Tags       []string   `json:"tags" gorm:"-" validate:"omitempty"`       // see LabelTag
+	Attributes Attributes `json:"attributes" gorm:"-" validate:"omitempty"` // see LabelAttribute
+}
+
+func (o *OcservGroup) BeforeSave(tx *gorm.DB) error {
+	if o.Schedule != nil {
+		return o.Schedule.Validate()
+	}
+	return nil
 }
 
 func (c *OcservGroupConfig) Value() (driver.Value, error) {
@@ -0,0 +1,65 @@
+package models
+
+// OcservServerConfig holds the directives of the main ocserv.conf managed by the dashboard.
+// Nil fields are left untouched on update; empty strings and lists remove the directive.
+type OcservServerConfig struct {
+	// TCP port to listen on. Example: 443
+	TCPPort *int `json:"tcp-port"`
+
+	// UDP port to listen on for DTLS. Example: 443
+	UDPPort *int `json:"udp-port"`
+
+	// Authentication methods, one per entry. Example: ['plain[passwd=/etc/ocserv/ocpasswd]']
+	Auth *CSVStringList `json:"auth"`
+
+	// Maximum number of connected clients; 0 is unlimited. Example: 1024
+	MaxClients *int `json:"max-clients"`
+
+	// Default maximum simultaneous logins per user. Example: 2
+	MaxSameClients *int `json:"max-same-clients"`
+
+	// Hide the VPN behind a web server unless the URL carries the camouflage secret. Example: true
+	Camouflage *bool `json:"camouflage"`
+
+	// Secret expected in the URL when camouflage is enabled. Example: 'mysecretkey'
+	CamouflageSecret *string `json:"camouflage_secret"`
+
+	// Realm shown by browsers when camouflage is enabled. Example: 'Restricted Content'
+	CamouflageRealm *string `json:"camouflage_realm"`
+
+	// DNS servers pushed to clients, IPv4 or IPv6. Example: ['8.8.8.8', '2001:4860:4860::8888']
+	DNS *CSVStringList `json:"dns"`
+
+	// Force all DNS traffic through the VPN tunnel. Example: true
+	TunnelAllDNS *bool `json:"tunnel-all-dns"`
+
+	// The pool of addresses that leases will be given from. Example: '172.16.24.0/24'
+	IPv4Network *string `json:"ipv4-network"`
+
+	// The pool of IPv6 addresses that leases will be given from. Example: 'fd00:24::/48'
+	IPv6Network *string `json:"ipv6-network"`
+
+	// Prefix length of the IPv6 subnet given to each client. Example: 128
+	IPv6SubnetPrefix *int `json:"ipv6-subnet-prefix"`
+
+	// Routes pushed to clients, IPv4 or IPv6. Example: ['default'] or ['10.10.0.0/16', 'fd00::/8']
+	Route *CSVStringList `json:"route"`
+
+	// Networks excluded from VPN routing. Example: ['192.168.0.0/16']
+	NoRoute *CSVStringList `json:"no-route"`
+
+	// Server certificate file. Example: '/etc/ocserv/certs/cert.pem'
+	ServerCert *string `json:"server-cert"`
+
+	// Server private key file. Example: '/etc/ocserv/certs/cert.key'
+	ServerKey *string `json:"server-key"`
+
+	// CA certificate used for certificate authentication. Example: '/etc/ocserv/certs/ca-cert.pem'
+	CACert *string `json:"ca-cert"`
+
+	// Banner shown to clients after login. Example: 'Welcome'
+	Banner *string `json:"banner"`
+
+	// Banner shown to clients before login. Example: 'Authorized users only'
+	PreLoginBanner *string `json:"pre-login-banner"`
+}
@@ -13,27 +13,84 @@ type OcservUserConfig struct {
 	// Static IPv4 address to assign to the user. Example: '192.168.100.10'
 	ExplicitIPv4 *string `json:"explicit-ipv4"`
 
+	// Static IPv6 address to assign to the user. Example: 'fd00:24::10'
+	ExplicitIPv6 *string `json:"explicit-ipv6"`
+
 	// The pool of addresses from which to assign to the user. Example: '192.168.1.0/24'
 	IPv4Network *string `json:"ipv4-network"`
 
-	// Comma-separated list of DNS servers to assign to the user. Example: '8.8.8.8,1.1.1.1'
+	// The pool of IPv6 addresses from which to assign to the user. Example: 'fd00:24::/48'
+	IPv6Network *string `json:"ipv6-network"`
+
+	// Prefix length of the IPv6 subnet given to each client. Example: 128
+	IPv6SubnetPrefix *int `json:"ipv6-subnet-prefix"`
+
+	// DNS servers to assign to the user, IPv4 or IPv6. Example: ['8.8.8.8', '2001:4860:4860::8888']
 	DNS *CSVStringList `json:"dns" gorm:"type:text"`
 
-	// NetBIOS Name Servers (WINS) for Windows clients. Example: '192.168.1.1'
-	NBNS *string `json:"nbns"`
+	// NetBIOS Name Servers (WINS) for Windows clients. Example: ['192.168.1.1']
+	NBNS *CSVStringList `json:"nbns" gorm:"type:text"`
 
-	// Routes pushed to the user for routing traffic. Example: ['0.0.0.0/0', '10.10.0.0/16']
+	// Routes pushed to the user for routing traffic, IPv4 or IPv6. Example: ['0.0.0.0/0', '10.10.0.0/16', 'fd00::/8']
 	Route *CSVStringList `json:"route" gorm:"type:text"`
 
 	// List of networks to exclude from VPN routing. Example: ['192.168.0.0/16', '10.0.0.0/8']
 	NoRoute *CSVStringList `json:"no-route" gorm:"type:text"`
 
-	// Internal route available only via VPN. Example: '10.0.0.0/8'
-	IRoute *string `json:"iroute"`
+	// Networks behind the user, routed to it by the server. Example: ['10.0.0.0/8']
+	IRoute *CSVStringList `json:"iroute" gorm:"type:text"`
 
 	// List of domains over which the provided DNS servers should be used. Example: ['example.com', 'internal.company.com']
 	SplitDNS *CSVStringList `json:"split-dns" gorm:"type:text"`
 
+	// Hostname assigned to the user. Example: 'alice-laptop'
+	Hostname *string `json:"hostname"`
+
+	// XML profile file sent to AnyConnect clients. Example: '/etc/ocserv/profile.xml'
+	UserProfile *string `json:"user-profile"`
+
+	// Maximum receive bandwidth in bytes per second. Example: '100000' for 100 KB/s
+	RxDataPerSec *int `json:"rx-data-per-sec"`
+
+	// Maximum transmit bandwidth in bytes per second. Example: '200000' for 200 KB/s
+	TxDataPerSec *int `json:"tx-data-per-sec"`
+
+	// Linux control group to assign the VPN worker process to. Format: 'controller,subsystem:name'. Example: 'cpuset,cpu:test'
+	CGroup *string `json:"cgroup"`
+
+	// Priority for routes; lower is higher priority. Example: 1
+	NetPriority *int `json:"net-priority"`
+
+	// Disconnect user if its IP changes (e.g., due to network switch). Example: true
+	DenyRoaming *bool `json:"deny-roaming"`
+
+	// Disables UDP, enforcing TCP-only VPN connection. Example: true
+	NoUDP *bool `json:"no-udp"`
+
+	// Interval in seconds to send keep-alive pings. Example: 60
+	KeepAlive *int `json:"keepalive"`
+
+	// Dead Peer Detection timeout in seconds. Example: 90
+	DPD *int `json:"dpd"`
+
+	// DPD timeout specifically for mobile clients. Example: 300
+	MobileDPD *int `json:"mobile-dpd"`
+
+	// Maximum simultaneous logins of the user. Example: 2
+	MaxSameClients *int `json:"max-same-clients"`
+
+	// Force all DNS traffic through the VPN tunnel. Example: true
+	TunnelAllDNS *bool `json:"tunnel-all-dns"`
+
+	// Interval in seconds for stats reporting. Example: 300
+	StatsReportTime *int `json:"stats-report-time"`
+
+	// Interval in seconds between interim accounting updates. Example: 300
+	InterimUpdate *int `json:"interim-update"`
+
+	// Tunnel interface MTU to avoid fragmentation. Example: 1400
+	MTU *int `json:"mtu"`
+
 	// Maximum session time in seconds before forced disconnect. Example: 3600
 	SessionTimeout *int `json:"session-timeout"`
 
@@ -47,43 +104,68 @@ type OcservUserConfig struct {
 	RekeyTime *int `json:"rekey-time"`
 
 	// Allow user access only to defined routes. Example: true
-	RestrictToRoutes *bool `json:"restrict-to-routes"`
+	RestrictUserToRoutes *bool `json:"restrict-user-to-routes"`
 
 	// Comma-separated list of allowed or blocked ports/protocols. Supports 'tcp(port)', 'udp(port)', 'icmp()', 'icmpv6()', and negation with '!()'. Example: 'tcp(443), udp(53)' or '!(tcp(22), udp(1194))'
-	RestrictToPorts *string `json:"restrict-to-ports"`
+	RestrictUserToPorts *string `json:"restrict-user-to-ports"`
 }
 
+// OcpasswdPassword is the password stored for users adopted from ocpasswd, whose real password is
+// only known to ocpasswd as a hash. It must never be written back to ocpasswd.
+const OcpasswdPassword = "Secret-Ocpasswd"
+
+// Reasons a user is deactivated for, see OcservUser.DeactivatedReason.
+const (
+	DeactivatedExpired = "expired"
+	DeactivatedQuota   = "quota"
+)
+
 type OcservUser struct {
-	ID            uint              `json:"-" gorm:"primaryKey;autoIncrement" `
-	UID           string            `json:"uid" gorm:"gorm:type:char(26);not null;uniqueIndex" validate:"required"`
-	Owner         string            `json:"owner" gorm:"type:varchar(16);default:''" validate:"required"`
-	Group         string            `json:"group" gorm:"type:varchar(16);default:'defaults'" validate:"required"`
-	Username      string            `json:"username" gorm:"type:varchar(16);not null;uniqueIndex" validate:"required"`
-	Password      string            `json:"password" gorm:"type:varchar(16);not null" validate:"required"`
-	IsLocked      bool              `json:"is_locked" gorm:"default(false)" validate:"required"`
-	CreatedAt     time.Time         `json:"created_at" gorm:"autoCreateTime" validate:"required"`
-	UpdatedAt     time.Time         `json:"updated_at" gorm:"autoUpdateTime" validate:"omitempty"`
-	ExpireAt      *time.Time        `json:"expire_at" gorm:"type:date" validate:"omitempty"`
-	DeactivatedAt *time.Time        `json:"deactivated_at" gorm:"type:date" validate:"omitempty"`
-	TrafficType   string            `json:"traffic_type" gorm:"type:varchar(32);not null;default:1" enums:"Free,MonthlyTransmit,MonthlyReceive,TotallyTransmit,TotallyReceive" validate:"required"`
-	TrafficSize   int               `json:"traffic_size" gorm:"not null" validate:"required"` // in GiB  >> x * 1024 ** 3
-	Rx            int               `json:"rx" gorm:"not null;default:0" validate:"required"` // Receive in bytes
-	Tx            int               `json:"tx" gorm:"not null;default:0" validate:"required"` // Transmit in bytes
-	Description   string            `json:"description" gorm:"type:text" validate:"omitempty"`
-	IsOnline      bool              `json:"is_online" gorm:"-:migration;->" validate:"required"`
-	Config        *OcservUserConfig `json:"config" gorm:"type:text"`
+	ID                uint              `json:"-" gorm:"primaryKey;autoIncrement" `
+	UID               string            `json:"uid" gorm:"gorm:type:char(26);not null;uniqueIndex" validate:"required"`
+	Owner             string            `json:"owner" gorm:"type:varchar(16);default:''" validate:"required"`
+	Group             string            `json:"group" gorm:"type:varchar(16);default:'defaults'" validate:"required"`
+	Username          string            `json:"username" gorm:"type:varchar(16);not null;uniqueIndex" validate:"required"`
+	Password          string            `json:"password" gorm:"type:varchar(16);not null" validate:"required"`
+	IsLocked          bool              `json:"is_locked" gorm:"default(false)" validate:"required"`
+	CreatedAt         time.Time         `json:"created_at" gorm:"autoCreateTime" validate:"required"`
+	UpdatedAt         time.Time         `json:"updated_at" gorm:"autoUpdateTime" validate:"omitempty"`
+	ExpireAt          *time.Time        `json:"expire_at" gorm:"type:date" validate:"omitempty"`
+	DeactivatedAt     *time.Time        `json:"deactivated_at" gorm:"type:date" validate:"omitempty"`
+	DeactivatedReason string            `json:"deactivated_reason" gorm:"type:varchar(16);not null;default:''" enums:"expired,quota" validate:"omitempty"` // empty while active
+	TrafficType       string            `json:"traffic_type" gorm:"type:varchar(32);not null;default:1" enums:"Free,MonthlyTransmit,MonthlyReceive,TotallyTransmit,TotallyReceive,Custom" validate:"required"`
+	TrafficSize       int               `json:"traffic_size" gorm:"not null" validate:"required"` // in GiB  >> x * 1024 ** 3
+	TrafficDirection  string            `json:"traffic_direction" gorm:"type:varchar(8);not null;default:''" enums:"rx,tx,both" validate:"omitempty"`
+	TrafficPeriod     string            `json:"traffic_period" gorm:"type:varchar(16);not null;default:''" enums:"daily,weekly,monthly,lifetime" validate:"omitempty"`
+	TrafficResetDay   int               `json:"traffic_reset_day" gorm:"not null;default:0" validate:"omitempty"` // day of month monthly periods start on, 0 for the default
+	OverQuotaAction   string            `json:"over_quota_action" gorm:"type:varchar(16);not null;default:'lock'" enums:"lock,throttle" validate:"omitempty"`
+	ThrottleRate      int               `json:"throttle_rate" gorm:"not null;default:0" validate:"omitempty"` // rx/tx bytes per second while throttled
+	ThrottledAt       *time.Time        `json:"throttled_at" validate:"omitempty"`
+	Schedule          *AccessSchedule   `json:"schedule" gorm:"type:text" validate:"omitempty"` // falls back to the group schedule when nil
+	ScheduleLockedAt  *time.Time        `json:"schedule_locked_at" validate:"omitempty"`
+	Rx                int               `json:"rx" gorm:"not null;default:0" validate:"required"` // Receive in bytes
+	Tx                int               `json:"tx" gorm:"not null;default:0" validate:"required"` // Transmit in bytes
+	TrafficResetAt    *time.Time        `json:"traffic_reset_at" validate:"omitempty"`            // last reset of the counters, the usage of the period counts from it
+	Description       string            `json:"description" gorm:"type:text" validate:"omitempty"`
+	IsOnline          bool              `json:"is_online" gorm:"-:migration;->" validate:"required"`
+	Config            *OcservUserConfig `json:"config" gorm:"type:text"`
+	Nodes             []Node            `json:"nodes,omitempty" gorm:"many2many:ocserv_user_nodes;"`
+	PlanID            *uint             `json:"plan_id" gorm:"index" validate:"omitempty"`
+	Tags              []string          `json:"tags" gorm:"-" validate:"omitempty"`       // see LabelTag
+	Attributes        Attributes        `json:"attributes" gorm:"-" validate:"omitempty"` // see LabelAttribute
 }
 
 type OcservUserTrafficStatistics struct {
 	ID        uint      `json:"-" gorm:"primaryKey;autoIncrement"`
 	OcUserID  uint      `json:"-" gorm:"index;constraint:OnDelete:CASCADE"`
+	NodeID    *uint     `json:"node_id" gorm:"index"` // nil for the local ocserv
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	Rx        int       `json:"rx" gorm:"default:0"` // in bytes
 	Tx        int       `json:"tx" gorm:"default:0"` // in bytes
 }
 
 type DailyTraffic struct {
-	Date string  `json:"date"` // Format: YYYY-MM-DD
+	Date string  `json:"date"` // start of the bucket: YYYY-MM-DD, YYYY-MM-DD HH:00 for hours, YYYY-MM for months
 	Rx   float64 `json:"rx"`   // in GiB
 	Tx   float64 `json:"tx"`   // in GiB
 }
@@ -100,24 +182,96 @@ func (c *OcservUserConfig) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, c)
 }
 
+// UnmarshalJSON also reads restrict-to-routes and restrict-to-ports, the keys stored before the
+// user config used the ocserv directive names restrict-user-to-routes and restrict-user-to-ports.
+func (c *OcservUserConfig) UnmarshalJSON(b []byte) error {
+	type config OcservUserConfig
+	var legacy struct {
+		config
+		RestrictToRoutes *bool   `json:"restrict-to-routes"`
+		RestrictToPorts  *string `json:"restrict-to-ports"`
+	}
+	if err := json.Unmarshal(b, &legacy); err != nil {
+		return err
+	}
+
+	*c = OcservUserConfig(legacy.config)
+	if c.RestrictUserToRoutes == nil {
+		c.RestrictUserToRoutes = legacy.RestrictToRoutes
+	}
+	if c.RestrictUserToPorts == nil {
+		c.RestrictUserToPorts = legacy.RestrictToPorts
+	}
+	return nil
+}
+
+// BackfillDeactivatedReasons sets the reason of the users deactivated before it was recorded: users
+// deactivated after their expiry date had expired, the others were over their quota.
+func BackfillDeactivatedReasons(db *gorm.DB) error {
+	legacy := func() *gorm.DB {
+		return db.Model(&OcservUser{}).Where("deactivated_at IS NOT NULL AND deactivated_reason = ''")
+	}
+	err := legacy().
+		Where("expire_at IS NOT NULL AND expire_at < deactivated_at").
+		UpdateColumn("deactivated_reason", DeactivatedExpired).Error
+	if err != nil {
+		return err
+	}
+	return legacy().UpdateColumn("deactivated_reason", DeactivatedQuota).Error
+}
+
+// DeleteOcservUser deletes the user with its node assignments, owners, top-ups, scheduled actions and
+// labels, within the transaction tx. Running scheduled actions are kept, so that an action deleting its own user records its
+// result. The owners are deleted by table name, as their model belongs to the api.
+func DeleteOcservUser(tx *gorm.DB, u *OcservUser) error {
+	if err := tx.Model(u).Association("Nodes").Clear(); err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM ocserv_user_owners WHERE ocserv_user_id = ?", u.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("oc_user_id = ?", u.ID).Delete(&TrafficTopUp{}).Error; err != nil {
+		return err
+	}
+	err := tx.Where("oc_user_id = ? AND status <> ?", u.ID, ScheduledRunning).Delete(&ScheduledAction{}).Error
+	if err != nil {
+		return err
+	}
+	if err = tx.Where("kind = ? AND target_id = ?", LabelKindUser, u.ID).Delete(&LabelTag{}).Error; err != nil {
+		return err
+	}
+	if err = tx.Where("kind = ? AND target_id = ?", LabelKindUser, u.ID).Delete(&LabelAttribute{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(u).Error
+}
+
 func (o *OcservUser) BeforeUpdate(tx *gorm.DB) (err error) {
-	if o.TrafficType != "" {
-		if !validateTrafficType(o.TrafficType) {
-			return fmt.Errorf("invalid TrafficType: %s", o.TrafficType)
+	if o.Schedule != nil {
+		if err = o.Schedule.Validate(); err != nil {
+			return err
 		}
 	}
-	if o.TrafficType == Free {
-		o.TrafficSize = 0
+	if o.TrafficType != "" {
+		if err = normalizeTraffic(o.TrafficType, &o.TrafficDirection, &o.TrafficPeriod, &o.TrafficSize); err != nil {
+			return err
+		}
+		return normalizeOverQuota(&o.OverQuotaAction, o.ThrottleRate)
 	}
 	return nil
 }
 
 func (o *OcservUser) BeforeCreate(tx *gorm.DB) (err error) {
-	if !validateTrafficType(o.TrafficType) {
-		return fmt.Errorf("invalid TrafficType: %s", o.TrafficType)
+	if err = normalizeTraffic(o.TrafficType, &o.TrafficDirection, &o.TrafficPeriod, &o.TrafficSize); err != nil {
+		return err
+	}
+	if err = normalizeOverQuota(&o.OverQuotaAction, o.ThrottleRate); err != nil {
+		return err
 	}
-	if o.TrafficType == Free {
-		o.TrafficSize = 0
+	if o.Schedule != nil {
+		if err = o.Schedule.Validate(); err != nil {
+			return err
+		}
 	}
 
 	if o.UID == "" {
@@ -125,12 +279,3 @@ func (o *OcservUser) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return
 }
-
-func validateTrafficType(trafficType string) bool {
-	switch trafficType {
-	case Free, MonthlyTransmit, MonthlyReceive, TotallyTransmit, TotallyReceive:
-		return true
-	default:
-		return false
-	}
-}
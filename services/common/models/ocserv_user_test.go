@@ -0,0 +1,124 @@
+package models_test
+
+import (
+	"encoding/json"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+// setupDB opens an in-memory database with the tables.
+func setupDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(tables...))
+	return db
+}
+
+func TestOcservUserConfigUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		routes *bool
+		ports  *string
+	}{
+		{
+			name:   "legacy keys",
+			data:   `{"restrict-to-routes": true, "restrict-to-ports": "tcp(443)", "rx-data-per-sec": 1024}`,
+			routes: ptr(true),
+			ports:  ptr("tcp(443)"),
+		},
+		{
+			name:   "directive names",
+			data:   `{"restrict-user-to-routes": false, "restrict-user-to-ports": "udp(53)"}`,
+			routes: ptr(false),
+			ports:  ptr("udp(53)"),
+		},
+		{
+			name:   "directive names win over legacy keys",
+			data:   `{"restrict-user-to-routes": false, "restrict-to-routes": true, "restrict-user-to-ports": "udp(53)", "restrict-to-ports": "tcp(443)"}`,
+			routes: ptr(false),
+			ports:  ptr("udp(53)"),
+		},
+		{
+			name: "neither",
+			data: `{"rx-data-per-sec": 1024}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config models.OcservUserConfig
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &config))
+			assert.Equal(t, tt.routes, config.RestrictUserToRoutes)
+			assert.Equal(t, tt.ports, config.RestrictUserToPorts)
+		})
+	}
+
+	var config models.OcservUserConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"restrict-to-routes": true, "rx-data-per-sec": 1024}`), &config))
+	require.NotNil(t, config.RxDataPerSec)
+	assert.Equal(t, 1024, *config.RxDataPerSec, "other keys are kept")
+
+	b, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"restrict-user-to-routes":true`)
+	assert.NotContains(t, string(b), `"restrict-to-routes"`, "legacy keys are not written back")
+
+	var scanned models.OcservUserConfig
+	require.NoError(t, scanned.Scan([]byte(`{"restrict-to-ports": "tcp(22)"}`)))
+	assert.Equal(t, ptr("tcp(22)"), scanned.RestrictUserToPorts, "configs stored with legacy keys are read")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestBackfillDeactivatedReasons(t *testing.T) {
+	db := setupDB(t, &models.OcservUser{})
+
+	day := func(d int) *time.Time {
+		date := time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	users := []struct {
+		username      string
+		expireAt      *time.Time
+		deactivatedAt *time.Time
+		reason        string
+		want          string
+	}{
+		{username: "active", expireAt: day(20)},
+		{username: "expired", expireAt: day(10), deactivatedAt: day(12), want: models.DeactivatedExpired},
+		{username: "quota", expireAt: day(20), deactivatedAt: day(12), want: models.DeactivatedQuota},
+		{username: "unlimited", deactivatedAt: day(12), want: models.DeactivatedQuota},
+		{username: "recorded", expireAt: day(20), deactivatedAt: day(12), reason: models.DeactivatedExpired, want: models.DeactivatedExpired},
+	}
+	for _, u := range users {
+		require.NoError(t, db.Create(&models.OcservUser{
+			UID:               u.username,
+			Username:          u.username,
+			Password:          "secret",
+			TrafficType:       models.Free,
+			ExpireAt:          u.expireAt,
+			DeactivatedAt:     u.deactivatedAt,
+			DeactivatedReason: u.reason,
+		}).Error)
+	}
+
+	require.NoError(t, models.BackfillDeactivatedReasons(db))
+
+	for _, u := range users {
+		var saved models.OcservUser
+		require.NoError(t, db.Where("username = ?", u.username).First(&saved).Error)
+		assert.Equal(t, u.want, saved.DeactivatedReason, u.username)
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Plan is a template of the settings a user is provisioned or renewed with.
+type Plan struct {
+	ID               uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name             string            `json:"name" gorm:"type:varchar(64);not null;uniqueIndex" validate:"required"`
+	Group            string            `json:"group" gorm:"type:varchar(16);default:'defaults'" validate:"required"`
+	TrafficType      string            `json:"traffic_type" gorm:"type:varchar(32);not null" enums:"Free,MonthlyTransmit,MonthlyReceive,TotallyTransmit,TotallyReceive,Custom" validate:"required"`
+	TrafficSize      int               `json:"traffic_size" gorm:"not null;default:0" validate:"required"` // in GiB
+	TrafficDirection string            `json:"traffic_direction" gorm:"type:varchar(8);not null;default:''" enums:"rx,tx,both" validate:"omitempty"`
+	TrafficPeriod    string            `json:"traffic_period" gorm:"type:varchar(16);not null;default:''" enums:"daily,weekly,monthly,lifetime" validate:"omitempty"`
+	OverQuotaAction  string            `json:"over_quota_action" gorm:"type:varchar(16);not null;default:'lock'" enums:"lock,throttle" validate:"omitempty"`
+	ThrottleRate     int               `json:"throttle_rate" gorm:"not null;default:0" validate:"omitempty"` // rx/tx bytes per second while throttled
+	DurationDays     int               `json:"duration_days" gorm:"not null" validate:"required"`
+	Config           *OcservUserConfig `json:"config" gorm:"type:text"`
+	Price            float64           `json:"price" gorm:"not null;default:0" validate:"omitempty"`
+	Currency         string            `json:"currency" gorm:"type:varchar(8);default:''" validate:"omitempty"` // Example: 'USD'
+	Description      string            `json:"description" gorm:"type:text" validate:"omitempty"`
+	IsActive         bool              `json:"is_active" validate:"required"`
+	CreatedAt        time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (p *Plan) BeforeSave(tx *gorm.DB) error {
+	if err := normalizeTraffic(p.TrafficType, &p.TrafficDirection, &p.TrafficPeriod, &p.TrafficSize); err != nil {
+		return err
+	}
+	return normalizeOverQuota(&p.OverQuotaAction, p.ThrottleRate)
+}
+
+// ExpireFrom returns the expiry date after renewing the plan at from. Expiry dates are calendar days,
+// stored as UTC midnight like the YYYY-MM-DD dates of the API: the day is taken in the location of
+// from, so a renewal at 01:00 in Tehran counts from that day and not from the previous day in UTC.
+func (p *Plan) ExpireFrom(from time.Time) time.Time {
+	y, m, d := from.AddDate(0, 0, p.DurationDays).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ApplyPlan renews the user on the plan at now: group and traffic come from the plan, the expiry is
+// extended by the plan duration from the current expiry (or now if already expired), the counters are
+// reset and the user is unlocked and unthrottled. The user config is kept, unless the user has none.
+func (o *OcservUser) ApplyPlan(plan *Plan, now time.Time) {
+	from := now
+	if o.ExpireAt != nil && o.ExpireAt.After(from) {
+		from = *o.ExpireAt
+	}
+	expireAt := plan.ExpireFrom(from)
+
+	o.PlanID = &plan.ID
+	o.Group = plan.Group
+	o.TrafficType = plan.TrafficType
+	o.TrafficSize = plan.TrafficSize
+	o.TrafficDirection = plan.TrafficDirection
+	o.TrafficPeriod = plan.TrafficPeriod
+	o.OverQuotaAction = plan.OverQuotaAction
+	o.ThrottleRate = plan.ThrottleRate
+	o.ExpireAt = &expireAt
+	o.DeactivatedAt = nil
+	o.DeactivatedReason = ""
+	o.ThrottledAt = nil
+	o.IsLocked = false
+	o.Rx = 0
+	o.Tx = 0
+	o.TrafficResetAt = &now
+	if o.Config == nil {
+		o.Config = plan.Config
+	}
+}
@@ -0,0 +1,108 @@
+package models_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPlanExpireFrom(t *testing.T) {
+	tehran := time.FixedZone("Asia/Tehran", 3*3600+1800)
+
+	tests := []struct {
+		name string
+		days int
+		from time.Time
+		want string
+	}{
+		{"same month", 10, time.Date(2025, 3, 5, 15, 30, 0, 0, time.UTC), "2025-03-15"},
+		{"month end", 30, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), "2025-03-02"},
+		{"year end", 1, time.Date(2025, 12, 31, 23, 59, 0, 0, time.UTC), "2026-01-01"},
+		{"day of the location of from", 1, time.Date(2025, 3, 5, 1, 0, 0, 0, tehran), "2025-03-06"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := models.Plan{DurationDays: tt.days}
+			got := plan.ExpireFrom(tt.from)
+			assert.Equal(t, tt.want, got.Format("2006-01-02"))
+			assert.Equal(t, time.UTC, got.Location())
+			assert.True(t, got.Equal(got.Truncate(24*time.Hour)))
+		})
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	planConfig := &models.OcservUserConfig{}
+	plan := &models.Plan{
+		ID:              7,
+		Group:           "vip",
+		TrafficType:     models.MonthlyTransmit,
+		TrafficSize:     50,
+		OverQuotaAction: models.OverQuotaThrottle,
+		ThrottleRate:    1024,
+		DurationDays:    30,
+		Config:          planConfig,
+	}
+
+	t.Run("expired user renews from now", func(t *testing.T) {
+		expired := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		u := models.OcservUser{Group: "defaults", ExpireAt: &expired, DeactivatedAt: &expired, IsLocked: true, Rx: 10, Tx: 20}
+		u.ApplyPlan(plan, now)
+
+		assert.Equal(t, "2025-04-04", u.ExpireAt.Format("2006-01-02"))
+		assert.Equal(t, uint(7), *u.PlanID)
+		assert.Equal(t, "vip", u.Group)
+		assert.Equal(t, models.MonthlyTransmit, u.TrafficType)
+		assert.Equal(t, 50, u.TrafficSize)
+		assert.Equal(t, models.OverQuotaThrottle, u.OverQuotaAction)
+		assert.Equal(t, 1024, u.ThrottleRate)
+		assert.Nil(t, u.DeactivatedAt)
+		assert.False(t, u.IsLocked)
+		assert.Zero(t, u.Rx)
+		assert.Zero(t, u.Tx)
+		assert.Equal(t, now, *u.TrafficResetAt)
+		assert.Same(t, planConfig, u.Config)
+	})
+
+	t.Run("active user is extended from its expiry", func(t *testing.T) {
+		expireAt := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+		userConfig := &models.OcservUserConfig{}
+		u := models.OcservUser{ExpireAt: &expireAt, Config: userConfig}
+		u.ApplyPlan(plan, now)
+
+		assert.Equal(t, "2025-04-19", u.ExpireAt.Format("2006-01-02"))
+		assert.Same(t, userConfig, u.Config)
+	})
+
+	t.Run("deactivation and throttle are lifted", func(t *testing.T) {
+		deactivatedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		u := models.OcservUser{
+			DeactivatedAt: &deactivatedAt, DeactivatedReason: models.DeactivatedQuota, ThrottledAt: &deactivatedAt,
+			IsLocked: true,
+		}
+		u.ApplyPlan(plan, now)
+
+		assert.Nil(t, u.DeactivatedAt)
+		assert.Empty(t, u.DeactivatedReason)
+		assert.Nil(t, u.ThrottledAt)
+		assert.False(t, u.IsLocked)
+	})
+
+	t.Run("custom policy", func(t *testing.T) {
+		custom := &models.Plan{
+			ID: 8, Group: "defaults", TrafficType: models.Custom, TrafficSize: 5, TrafficDirection: models.TrafficBoth,
+			TrafficPeriod: models.TrafficDaily, DurationDays: 1,
+		}
+		u := models.OcservUser{TrafficType: models.MonthlyReceive, TrafficDirection: models.TrafficRX, TrafficPeriod: models.TrafficMonthly}
+		u.ApplyPlan(custom, now)
+
+		assert.Equal(t, models.Custom, u.TrafficType)
+		assert.Equal(t, models.TrafficBoth, u.TrafficDirection)
+		assert.Equal(t, models.TrafficDaily, u.TrafficPeriod)
+		assert.Equal(t, 5, u.TrafficSize)
+		assert.Equal(t, "2025-03-06", u.ExpireAt.Format("2006-01-02"))
+		assert.Nil(t, u.Config)
+	})
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+// Formats of exported statistics and usage reports.
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatXLSX = "xlsx"
+	ReportFormatPDF  = "pdf"
+)
+
+// Periods of scheduled usage reports.
+const (
+	ReportDaily   = "daily"
+	ReportWeekly  = "weekly"
+	ReportMonthly = "monthly"
+)
+
+// ReportSchedule generates a usage report of the previous period at the start of every period. Reports of
+// an owner cover the users it owns, reports without an owner cover every user.
+type ReportSchedule struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string     `json:"name" gorm:"type:varchar(64);not null" validate:"required"`
+	Owner     string     `json:"owner" gorm:"type:varchar(16);index;default:''" validate:"omitempty"` // empty for global reports
+	Period    string     `json:"period" gorm:"type:varchar(16);not null" enums:"daily,weekly,monthly" validate:"required"`
+	Format    string     `json:"format" gorm:"type:varchar(8);not null" enums:"csv,xlsx,pdf" validate:"required"`
+	Delivery  string     `json:"delivery" gorm:"type:varchar(32);not null;default:'store'" example:"store" validate:"required"`
+	Target    string     `json:"target" gorm:"type:varchar(255);default:''" validate:"omitempty"` // destination of the delivery, such as a webhook URL on a host of REPORT_WEBHOOK_HOSTS
+	IsActive  bool       `json:"is_active" validate:"required"`
+	LastRunAt *time.Time `json:"last_run_at" validate:"omitempty"`
+	NextRunAt time.Time  `json:"next_run_at" gorm:"index" validate:"required"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// UsageReport is a generated usage report, stored for download whatever its delivery.
+type UsageReport struct {
+	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ScheduleID    *uint     `json:"schedule_id" gorm:"index" validate:"omitempty"` // nil for reports generated on demand
+	Owner         string    `json:"owner" gorm:"type:varchar(16);index;default:''" validate:"omitempty"`
+	Format        string    `json:"format" gorm:"type:varchar(8);not null" enums:"csv,xlsx,pdf" validate:"required"`
+	DateStart     time.Time `json:"date_start" validate:"required"`
+	DateEnd       time.Time `json:"date_end" validate:"required"`
+	Filename      string    `json:"filename" gorm:"type:varchar(128);not null" validate:"required"`
+	Size          int       `json:"size" validate:"required"` // in bytes
+	Content       []byte    `json:"-"`
+	DeliveryError string    `json:"delivery_error" gorm:"type:text" validate:"omitempty"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Range returns the previous complete period before now, in UTC. The end is exclusive.
+func (s *ReportSchedule) Range(now time.Time) (time.Time, time.Time) {
+	end := s.periodStart(now.UTC())
+	switch s.Period {
+	case ReportWeekly:
+		return end.AddDate(0, 0, -7), end
+	case ReportMonthly:
+		return end.AddDate(0, -1, 0), end
+	default:
+		return end.AddDate(0, 0, -1), end
+	}
+}
+
+// NextRun returns the start of the period after the one containing now, in UTC.
+func (s *ReportSchedule) NextRun(now time.Time) time.Time {
+	start := s.periodStart(now.UTC())
+	switch s.Period {
+	case ReportWeekly:
+		return start.AddDate(0, 0, 7)
+	case ReportMonthly:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func (s *ReportSchedule) periodStart(t time.Time) time.Time {
+	switch s.Period {
+	case ReportWeekly:
+		return BucketStart(t, GranularityWeek)
+	case ReportMonthly:
+		return BucketStart(t, GranularityMonth)
+	default:
+		return BucketStart(t, GranularityDay)
+	}
+}
@@ -0,0 +1,90 @@
+package models_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReportScheduleRange(t *testing.T) {
+	tehran := time.FixedZone("Asia/Tehran", 3*3600+1800)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		period string
+		now    time.Time
+		start  time.Time
+		end    time.Time
+		next   time.Time
+	}{
+		{
+			name:   "daily",
+			period: models.ReportDaily,
+			now:    time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC),
+			start:  day(2025, 3, 4),
+			end:    day(2025, 3, 5),
+			next:   day(2025, 3, 6),
+		},
+		{
+			name:   "daily at the year start",
+			period: models.ReportDaily,
+			now:    day(2025, 1, 1),
+			start:  day(2024, 12, 31),
+			end:    day(2025, 1, 1),
+			next:   day(2025, 1, 2),
+		},
+		{
+			name:   "daily in utc",
+			period: models.ReportDaily,
+			now:    time.Date(2025, 3, 6, 2, 0, 0, 0, tehran), // March 5th in UTC
+			start:  day(2025, 3, 4),
+			end:    day(2025, 3, 5),
+			next:   day(2025, 3, 6),
+		},
+		{
+			name:   "weekly from monday",
+			period: models.ReportWeekly,
+			now:    time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC), // wednesday
+			start:  day(2024, 12, 23),
+			end:    day(2024, 12, 30),
+			next:   day(2025, 1, 6),
+		},
+		{
+			name:   "weekly on monday",
+			period: models.ReportWeekly,
+			now:    day(2025, 1, 6),
+			start:  day(2024, 12, 30),
+			end:    day(2025, 1, 6),
+			next:   day(2025, 1, 13),
+		},
+		{
+			name:   "monthly",
+			period: models.ReportMonthly,
+			now:    time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC),
+			start:  day(2025, 2, 1),
+			end:    day(2025, 3, 1),
+			next:   day(2025, 4, 1),
+		},
+		{
+			name:   "monthly at the year start",
+			period: models.ReportMonthly,
+			now:    day(2025, 1, 1),
+			start:  day(2024, 12, 1),
+			end:    day(2025, 1, 1),
+			next:   day(2025, 2, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := models.ReportSchedule{Period: tt.period}
+			start, end := schedule.Range(tt.now)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+			assert.Equal(t, tt.next, schedule.NextRun(tt.now))
+		})
+	}
+}
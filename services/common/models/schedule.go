@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+)
+
+// Sources of the schedule applied to a user.
+const (
+	ScheduleSourceUser  = "user"
+	ScheduleSourceGroup = "group"
+)
+
+var scheduleDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// AccessSchedule restricts the connections of a user to weekday and time windows in a timezone.
+// Outside of every window the user is locked and disconnected.
+type AccessSchedule struct {
+	Timezone string           `json:"timezone" validate:"omitempty" example:"Europe/Berlin"` // IANA name, UTC when empty
+	Windows  []ScheduleWindow `json:"windows" validate:"required"`
+}
+
+// ScheduleWindow allows connections on the given weekdays from Start until End. A window whose End is
+// not after its Start spans midnight and ends on the next day.
+type ScheduleWindow struct {
+	Days  []string `json:"days" validate:"required" enums:"mon,tue,wed,thu,fri,sat,sun" example:"mon,tue,wed,thu,fri"`
+	Start string   `json:"start" validate:"required" example:"09:00"` // HH:MM
+	End   string   `json:"end" validate:"required" example:"17:00"`   // HH:MM
+}
+
+// ScheduleState is the schedule applied to a user and whether it currently allows connections.
+type ScheduleState struct {
+	Source     string          `json:"source" enums:"user,group" validate:"required"`
+	Schedule   *AccessSchedule `json:"schedule" validate:"required"`
+	Allowed    bool            `json:"allowed" validate:"required"`
+	NextChange *time.Time      `json:"next_change" validate:"omitempty"` // nil when the schedule never changes state
+	LockedAt   *time.Time      `json:"locked_at" validate:"omitempty"`   // set while the user is locked by the schedule
+}
+
+// Value stores a nil schedule as NULL, so users and groups without one can be told apart in queries.
+func (s *AccessSchedule) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *AccessSchedule) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("AccessSchedule: failed to scan type %T", value)
+	}
+}
+
+// Validate checks the timezone, weekdays and times of the schedule.
+func (s *AccessSchedule) Validate() error {
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("invalid schedule timezone %q: %w", s.Timezone, err)
+	}
+	if len(s.Windows) == 0 {
+		return errors.New("schedule requires at least one window")
+	}
+	for _, w := range s.Windows {
+		if len(w.Days) == 0 {
+			return errors.New("schedule window requires at least one day")
+		}
+		for _, day := range w.Days {
+			if !slices.Contains(scheduleDays, day) {
+				return fmt.Errorf("invalid schedule day %q", day)
+			}
+		}
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("schedule window %s-%s is empty", w.Start, w.End)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the schedule allows connections at now.
+func (s *AccessSchedule) Allows(now time.Time) bool {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return true
+	}
+	return s.allows(now.In(loc))
+}
+
+// NextChange returns the first minute within a week after now at which Allows flips, or nil.
+func (s *AccessSchedule) NextChange(now time.Time) *time.Time {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil
+	}
+	t := now.In(loc).Truncate(time.Minute)
+	allowed := s.allows(t)
+	for i := 0; i < 7*24*60; i++ {
+		t = t.Add(time.Minute)
+		if s.allows(t) != allowed {
+			return &t
+		}
+	}
+	return nil
+}
+
+// allows reports whether the schedule allows connections at now, given in the schedule timezone.
+func (s *AccessSchedule) allows(now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+	today := scheduleDays[now.Weekday()]
+	yesterday := scheduleDays[(now.Weekday()+6)%7]
+
+	for _, w := range s.Windows {
+		start, err1 := parseClock(w.Start)
+		end, err2 := parseClock(w.End)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if start < end {
+			if slices.Contains(w.Days, today) && minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+		if (slices.Contains(w.Days, today) && minute >= start) || (slices.Contains(w.Days, yesterday) && minute < end) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScheduleState returns the state of the schedule of the user, falling back to the schedule of its
+// group, or nil when neither has one.
+func (o *OcservUser) ScheduleState(db *gorm.DB, now time.Time) (*ScheduleState, error) {
+	state := &ScheduleState{
+		Source:   ScheduleSourceUser,
+		Schedule: o.Schedule,
+		LockedAt: o.ScheduleLockedAt,
+	}
+
+	if state.Schedule == nil {
+		var group OcservGroup
+		err := db.Where("name = ?", o.Group).Limit(1).Find(&group).Error
+		if err != nil {
+			return nil, err
+		}
+		if group.Schedule == nil {
+			return nil, nil
+		}
+		state.Source, state.Schedule = ScheduleSourceGroup, group.Schedule
+	}
+
+	state.Allowed = state.Schedule.Allows(now)
+	state.NextChange = state.Schedule.NextChange(now)
+	return state, nil
+}
+
+// parseClock returns the minute of the day of an HH:MM time.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
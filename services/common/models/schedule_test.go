@@ -0,0 +1,186 @@
+package models_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func officeSchedule() *models.AccessSchedule {
+	return &models.AccessSchedule{
+		Timezone: "Europe/Berlin",
+		Windows: []models.ScheduleWindow{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00"},
+			// spans midnight into saturday
+			{Days: []string{"fri"}, Start: "22:00", End: "02:00"},
+		},
+	}
+}
+
+func TestAccessScheduleValidate(t *testing.T) {
+	window := func(days []string, start, end string) []models.ScheduleWindow {
+		return []models.ScheduleWindow{{Days: days, Start: start, End: end}}
+	}
+	mon := []string{"mon"}
+
+	tests := []struct {
+		name     string
+		schedule models.AccessSchedule
+		err      string
+	}{
+		{name: "valid", schedule: *officeSchedule()},
+		{name: "utc by default", schedule: models.AccessSchedule{Windows: window(mon, "09:00", "17:00")}},
+		{
+			name:     "invalid timezone",
+			schedule: models.AccessSchedule{Timezone: "Mars/Olympus", Windows: window(mon, "09:00", "17:00")},
+			err:      `invalid schedule timezone "Mars/Olympus"`,
+		},
+		{name: "no windows", schedule: models.AccessSchedule{}, err: "schedule requires at least one window"},
+		{
+			name:     "no days",
+			schedule: models.AccessSchedule{Windows: window(nil, "09:00", "17:00")},
+			err:      "schedule window requires at least one day",
+		},
+		{
+			name:     "invalid day",
+			schedule: models.AccessSchedule{Windows: window([]string{"monday"}, "09:00", "17:00")},
+			err:      `invalid schedule day "monday"`,
+		},
+		{
+			name:     "invalid start",
+			schedule: models.AccessSchedule{Windows: window(mon, "24:00", "17:00")},
+			err:      `invalid schedule time "24:00", expected HH:MM`,
+		},
+		{
+			name:     "invalid end",
+			schedule: models.AccessSchedule{Windows: window(mon, "09:00", "5pm")},
+			err:      `invalid schedule time "5pm", expected HH:MM`,
+		},
+		{
+			name:     "empty window",
+			schedule: models.AccessSchedule{Windows: window(mon, "09:00", "09:00")},
+			err:      "schedule window 09:00-09:00 is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestAccessScheduleAllows(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		allowed bool
+	}{
+		{name: "within the window", now: time.Date(2025, 1, 6, 10, 0, 0, 0, berlin), allowed: true},
+		{name: "at the start", now: time.Date(2025, 1, 6, 9, 0, 0, 0, berlin), allowed: true},
+		{name: "before the start", now: time.Date(2025, 1, 6, 8, 59, 0, 0, berlin)},
+		{name: "at the end", now: time.Date(2025, 1, 6, 17, 0, 0, 0, berlin)},
+		{name: "on another day", now: time.Date(2025, 1, 12, 10, 0, 0, 0, berlin)},
+		{name: "before midnight", now: time.Date(2025, 1, 10, 23, 0, 0, 0, berlin), allowed: true},
+		{name: "after midnight", now: time.Date(2025, 1, 11, 1, 59, 0, 0, berlin), allowed: true},
+		{name: "at the end after midnight", now: time.Date(2025, 1, 11, 2, 0, 0, 0, berlin)},
+		{name: "after midnight of another day", now: time.Date(2025, 1, 12, 1, 0, 0, 0, berlin)},
+		{name: "in the schedule timezone", now: time.Date(2025, 1, 6, 8, 30, 0, 0, time.UTC), allowed: true},
+		{name: "outside in the schedule timezone", now: time.Date(2025, 1, 6, 16, 30, 0, 0, time.UTC)},
+		{name: "in summer time", now: time.Date(2025, 7, 7, 7, 30, 0, 0, time.UTC), allowed: true},
+		{name: "before the start in winter time", now: time.Date(2025, 1, 6, 7, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, officeSchedule().Allows(tt.now))
+		})
+	}
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		schedule := officeSchedule()
+		schedule.Timezone = "Mars/Olympus"
+		assert.True(t, schedule.Allows(time.Date(2025, 1, 12, 10, 0, 0, 0, time.UTC)))
+	})
+}
+
+func TestAccessScheduleNextChange(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		schedule *models.AccessSchedule
+		now      time.Time
+		next     time.Time
+	}{
+		{
+			name:     "window closes",
+			schedule: officeSchedule(),
+			now:      time.Date(2025, 1, 6, 10, 0, 30, 0, berlin),
+			next:     time.Date(2025, 1, 6, 17, 0, 0, 0, berlin),
+		},
+		{
+			name:     "window opens the next day",
+			schedule: officeSchedule(),
+			now:      time.Date(2025, 1, 6, 17, 0, 0, 0, berlin),
+			next:     time.Date(2025, 1, 7, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:     "window spanning midnight opens",
+			schedule: officeSchedule(),
+			now:      time.Date(2025, 1, 10, 20, 0, 0, 0, berlin),
+			next:     time.Date(2025, 1, 10, 22, 0, 0, 0, berlin),
+		},
+		{
+			name:     "window spanning midnight closes",
+			schedule: officeSchedule(),
+			now:      time.Date(2025, 1, 10, 23, 0, 0, 0, berlin),
+			next:     time.Date(2025, 1, 11, 2, 0, 0, 0, berlin),
+		},
+		{
+			name:     "over the weekend",
+			schedule: officeSchedule(),
+			now:      time.Date(2025, 1, 11, 3, 0, 0, 0, berlin),
+			next:     time.Date(2025, 1, 13, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:     "in the schedule timezone",
+			schedule: officeSchedule(),
+			now:      time.Date(2025, 1, 6, 6, 0, 0, 0, time.UTC),
+			next:     time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "across the switch to summer time",
+			schedule: officeSchedule(),
+			now:      time.Date(2025, 3, 29, 12, 0, 0, 0, berlin),
+			next:     time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.schedule.NextChange(tt.now)
+			require.NotNil(t, next)
+			assert.Equal(t, tt.next.UTC(), next.UTC())
+		})
+	}
+
+	t.Run("never changes", func(t *testing.T) {
+		everyDay := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+		schedule := &models.AccessSchedule{Windows: []models.ScheduleWindow{
+			{Days: everyDay, Start: "00:00", End: "12:00"},
+			{Days: everyDay, Start: "12:00", End: "00:00"},
+		}}
+		assert.Nil(t, schedule.NextChange(time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)))
+	})
+}
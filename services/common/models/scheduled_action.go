@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Actions a ScheduledAction runs on a user.
+const (
+	ScheduledLock         = "lock"
+	ScheduledUnlock       = "unlock"
+	ScheduledDelete       = "delete"
+	ScheduledChangeGroup  = "change_group"
+	ScheduledChangePlan   = "change_plan"
+	ScheduledResetTraffic = "reset_traffic"
+)
+
+// Statuses of a ScheduledAction.
+const (
+	ScheduledPending = "pending"
+	ScheduledRunning = "running" // claimed by a run of the user expiry service
+	ScheduledDone    = "done"
+	ScheduledFailed  = "failed"
+)
+
+// ScheduledAction runs an action on a user at RunAt. The user expiry service runs the pending actions
+// once due, including those missed while it was down, and records their result.
+type ScheduledAction struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	OcUserID   uint       `json:"-" gorm:"not null;index;constraint:OnDelete:CASCADE"`
+	Action     string     `json:"action" gorm:"type:varchar(16);not null" enums:"lock,unlock,delete,change_group,change_plan,reset_traffic" validate:"required"`
+	Group      string     `json:"group" gorm:"type:varchar(16);default:''" validate:"omitempty"` // group of change_group
+	PlanID     *uint      `json:"plan_id" validate:"omitempty"`                                  // plan of change_plan
+	RunAt      time.Time  `json:"run_at" gorm:"not null;index" validate:"required"`
+	CreatedBy  string     `json:"created_by" gorm:"type:varchar(16);not null" validate:"required"`
+	Status     string     `json:"status" gorm:"type:varchar(8);not null;default:'pending';index" enums:"pending,running,done,failed" validate:"required"`
+	Result     string     `json:"result" gorm:"type:text" validate:"omitempty"` // error of failed actions
+	ExecutedAt *time.Time `json:"executed_at" validate:"omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Validate checks the action has the target it needs.
+func (a *ScheduledAction) Validate() error {
+	switch a.Action {
+	case ScheduledChangeGroup:
+		if a.Group == "" {
+			return errors.New("group is required to change the group")
+		}
+	case ScheduledChangePlan:
+		if a.PlanID == nil {
+			return errors.New("plan_id is required to change the plan")
+		}
+	case ScheduledLock, ScheduledUnlock, ScheduledDelete, ScheduledResetTraffic:
+	default:
+		return errors.New("invalid action")
+	}
+	return nil
+}
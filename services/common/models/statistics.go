@@ -0,0 +1,230 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// Granularities of the traffic statistics series.
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// TrafficBucket is the traffic of a user on a node over the hour, day or month starting at Start, in UTC.
+// Sessions count in the bucket of their disconnect, as ocserv only logs the totals of a session then.
+type TrafficBucket struct {
+	OcUserID uint      `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	NodeID   uint      `json:"node_id" gorm:"primaryKey;autoIncrement:false"` // 0 for the local ocserv
+	Start    time.Time `json:"start" gorm:"primaryKey;index"`
+	Rx       int       `json:"rx" gorm:"not null;default:0"` // in bytes
+	Tx       int       `json:"tx" gorm:"not null;default:0"` // in bytes
+}
+
+// OcservUserTrafficHourly, OcservUserTrafficDaily and OcservUserTrafficMonthly are the rollups of
+// OcservUserTrafficStatistics maintained by log_stream. They outlive the raw rows, which are pruned
+// after the configured retention.
+type OcservUserTrafficHourly struct {
+	TrafficBucket `gorm:"embedded"`
+}
+
+type OcservUserTrafficDaily struct {
+	TrafficBucket `gorm:"embedded"`
+}
+
+type OcservUserTrafficMonthly struct {
+	TrafficBucket `gorm:"embedded"`
+}
+
+func (OcservUserTrafficHourly) TableName() string {
+	return "ocserv_user_traffic_hourly"
+}
+
+func (OcservUserTrafficDaily) TableName() string {
+	return "ocserv_user_traffic_daily"
+}
+
+func (OcservUserTrafficMonthly) TableName() string {
+	return "ocserv_user_traffic_monthly"
+}
+
+// BucketStart returns the start of the hour, day, week or month containing t, in the location of t.
+// Weeks start on Monday.
+func BucketStart(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case GranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// BucketLabel formats the start of a bucket as reported in DailyTraffic.Date.
+func BucketLabel(start time.Time, granularity string) string {
+	switch granularity {
+	case GranularityHour:
+		return start.Format("2006-01-02 15:00")
+	case GranularityMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// rollupBatchSize is the number of raw statistics rows read at once when rebuilding the rollups.
+const rollupBatchSize = 1000
+
+type bucketKey struct {
+	userID uint
+	nodeID uint
+	start  time.Time
+}
+
+// AddTrafficRollups adds the traffic of a saved session to the hourly, daily and monthly rollups
+// containing its disconnect.
+func AddTrafficRollups(db *gorm.DB, traffic OcservUserTrafficStatistics) error {
+	var nodeID uint
+	if traffic.NodeID != nil {
+		nodeID = *traffic.NodeID
+	}
+	at := traffic.CreatedAt.UTC()
+
+	for _, bucket := range []interface{}{
+		&OcservUserTrafficHourly{TrafficBucket: newBucket(traffic, nodeID, BucketStart(at, GranularityHour))},
+		&OcservUserTrafficDaily{TrafficBucket: newBucket(traffic, nodeID, BucketStart(at, GranularityDay))},
+		&OcservUserTrafficMonthly{TrafficBucket: newBucket(traffic, nodeID, BucketStart(at, GranularityMonth))},
+	} {
+		if err := db.Clauses(addToBucket).Create(bucket).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addToBucket adds the traffic of the inserted buckets to the existing ones.
+var addToBucket = clause.OnConflict{
+	Columns: []clause.Column{{Name: "oc_user_id"}, {Name: "node_id"}, {Name: "start"}},
+	DoUpdates: clause.Assignments(map[string]interface{}{
+		"rx": gorm.Expr("rx + excluded.rx"),
+		"tx": gorm.Expr("tx + excluded.tx"),
+	}),
+}
+
+func newBucket(traffic OcservUserTrafficStatistics, nodeID uint, start time.Time) TrafficBucket {
+	return TrafficBucket{
+		OcUserID: traffic.OcUserID,
+		NodeID:   nodeID,
+		Start:    start,
+		Rx:       traffic.Rx,
+		Tx:       traffic.Tx,
+	}
+}
+
+// BuildTrafficRollups fills the rollups from the raw statistics saved before them, as after upgrading
+// from a version without rollups. For each user and node, only the raw rows of the hours before its
+// first hourly rollup are summed, since the later ones were added to the rollups when saved. Running
+// it again adds nothing.
+func BuildTrafficRollups(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// the start of the first hourly rollup of each user and node, nil without one
+		firsts := make(map[bucketKey]*time.Time)
+		first := func(userID, nodeID uint) (*time.Time, error) {
+			key := bucketKey{userID: userID, nodeID: nodeID}
+			if start, ok := firsts[key]; ok {
+				return start, nil
+			}
+			var buckets []OcservUserTrafficHourly
+			err := tx.Where("oc_user_id = ? AND node_id = ?", userID, nodeID).Order("start").Limit(1).Find(&buckets).Error
+			if err != nil {
+				return nil, err
+			}
+			if len(buckets) > 0 {
+				firsts[key] = &buckets[0].Start
+			} else {
+				firsts[key] = nil
+			}
+			return firsts[key], nil
+		}
+
+		hourly := make(map[bucketKey]*TrafficBucket)
+		var rows []OcservUserTrafficStatistics
+		err := tx.Model(&OcservUserTrafficStatistics{}).FindInBatches(&rows, rollupBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, row := range rows {
+				var nodeID uint
+				if row.NodeID != nil {
+					nodeID = *row.NodeID
+				}
+				start, err := first(row.OcUserID, nodeID)
+				if err != nil {
+					return err
+				}
+				key := bucketKey{row.OcUserID, nodeID, BucketStart(row.CreatedAt.UTC(), GranularityHour)}
+				if start != nil && !key.start.Before(*start) {
+					continue
+				}
+				bucket, ok := hourly[key]
+				if !ok {
+					bucket = &TrafficBucket{OcUserID: key.userID, NodeID: key.nodeID, Start: key.start}
+					hourly[key] = bucket
+				}
+				bucket.Rx += row.Rx
+				bucket.Tx += row.Tx
+			}
+			return nil
+		}).Error
+		if err != nil || len(hourly) == 0 {
+			return err
+		}
+
+		daily := rollUp(hourly, GranularityDay)
+		monthly := rollUp(daily, GranularityMonth)
+
+		// the days and months of the first hourly rollups already hold the traffic of their later hours
+		if err = tx.Clauses(addToBucket).CreateInBatches(rollupRows(hourly, func(b TrafficBucket) OcservUserTrafficHourly {
+			return OcservUserTrafficHourly{TrafficBucket: b}
+		}), rollupBatchSize).Error; err != nil {
+			return err
+		}
+		if err = tx.Clauses(addToBucket).CreateInBatches(rollupRows(daily, func(b TrafficBucket) OcservUserTrafficDaily {
+			return OcservUserTrafficDaily{TrafficBucket: b}
+		}), rollupBatchSize).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(addToBucket).CreateInBatches(rollupRows(monthly, func(b TrafficBucket) OcservUserTrafficMonthly {
+			return OcservUserTrafficMonthly{TrafficBucket: b}
+		}), rollupBatchSize).Error
+	})
+}
+
+// rollUp sums the buckets into buckets of the coarser granularity.
+func rollUp(buckets map[bucketKey]*TrafficBucket, granularity string) map[bucketKey]*TrafficBucket {
+	result := make(map[bucketKey]*TrafficBucket)
+	for key, bucket := range buckets {
+		key.start = BucketStart(key.start, granularity)
+		sum, ok := result[key]
+		if !ok {
+			sum = &TrafficBucket{OcUserID: key.userID, NodeID: key.nodeID, Start: key.start}
+			result[key] = sum
+		}
+		sum.Rx += bucket.Rx
+		sum.Tx += bucket.Tx
+	}
+	return result
+}
+
+// rollupRows converts the buckets to rows of a rollup table.
+func rollupRows[T any](buckets map[bucketKey]*TrafficBucket, row func(TrafficBucket) T) []T {
+	rows := make([]T, 0, len(buckets))
+	for _, bucket := range buckets {
+		rows = append(rows, row(*bucket))
+	}
+	return rows
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+func TestBucketStart(t *testing.T) {
+	tehran := time.FixedZone("Asia/Tehran", 3*3600+1800)
+	// Wednesday
+	at := time.Date(2025, 1, 1, 13, 45, 30, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		t           time.Time
+		granularity string
+		start       time.Time
+	}{
+		{name: "hour", t: at, granularity: GranularityHour, start: time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{name: "day", t: at, granularity: GranularityDay, start: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "day by default", t: at, granularity: "", start: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "week across the year end", t: at, granularity: GranularityWeek, start: time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)},
+		{
+			name:        "week on sunday",
+			t:           time.Date(2025, 1, 5, 23, 0, 0, 0, time.UTC),
+			granularity: GranularityWeek,
+			start:       time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "week on monday",
+			t:           time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+			granularity: GranularityWeek,
+			start:       time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "month", t: at, granularity: GranularityMonth, start: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{
+			name:        "day in the location of t",
+			t:           at.In(tehran).Add(10 * time.Hour),
+			granularity: GranularityDay,
+			start:       time.Date(2025, 1, 2, 0, 0, 0, 0, tehran),
+		},
+		{
+			name:        "hour in the location of t",
+			t:           at.In(tehran),
+			granularity: GranularityHour,
+			start:       time.Date(2025, 1, 1, 17, 0, 0, 0, tehran),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.start, BucketStart(tt.t, tt.granularity))
+		})
+	}
+}
+
+func TestRollUp(t *testing.T) {
+	hour := func(day, h int) time.Time {
+		return time.Date(2025, 1, day, h, 0, 0, 0, time.UTC)
+	}
+	hourly := map[bucketKey]*TrafficBucket{}
+	for _, b := range []TrafficBucket{
+		{OcUserID: 1, Start: hour(31, 1), Rx: 1, Tx: 10},
+		{OcUserID: 1, Start: hour(31, 23), Rx: 2, Tx: 20},
+		{OcUserID: 1, NodeID: 2, Start: hour(31, 5), Rx: 4, Tx: 40},
+		{OcUserID: 2, Start: hour(31, 5), Rx: 8, Tx: 80},
+		{OcUserID: 1, Start: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Rx: 16, Tx: 160},
+	} {
+		hourly[bucketKey{b.OcUserID, b.NodeID, b.Start}] = &b
+	}
+
+	daily := rollUp(hourly, GranularityDay)
+	assert.Equal(t, map[bucketKey]*TrafficBucket{
+		{1, 0, hour(31, 0)}: {OcUserID: 1, Start: hour(31, 0), Rx: 3, Tx: 30},
+		{1, 2, hour(31, 0)}: {OcUserID: 1, NodeID: 2, Start: hour(31, 0), Rx: 4, Tx: 40},
+		{2, 0, hour(31, 0)}: {OcUserID: 2, Start: hour(31, 0), Rx: 8, Tx: 80},
+		{1, 0, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)}: {
+			OcUserID: 1, Start: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Rx: 16, Tx: 160,
+		},
+	}, daily)
+
+	monthly := rollUp(daily, GranularityMonth)
+	assert.Len(t, monthly, 4)
+	assert.Equal(t, TrafficBucket{OcUserID: 1, Start: hour(1, 0), Rx: 3, Tx: 30}, *monthly[bucketKey{1, 0, hour(1, 0)}])
+}
+
+func TestBuildTrafficRollups(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(
+		&OcservUserTrafficStatistics{}, &OcservUserTrafficHourly{}, &OcservUserTrafficDaily{}, &OcservUserTrafficMonthly{},
+	))
+
+	at := func(day, h, m int) time.Time {
+		return time.Date(2025, 1, day, h, m, 0, 0, time.UTC)
+	}
+	// saved before the upgrade
+	for _, row := range []OcservUserTrafficStatistics{
+		{OcUserID: 1, CreatedAt: at(1, 10, 5), Rx: 1, Tx: 1},
+		{OcUserID: 1, CreatedAt: at(1, 10, 50), Rx: 2, Tx: 2},
+		{OcUserID: 1, CreatedAt: at(2, 9, 0), Rx: 4, Tx: 4},
+		{OcUserID: 2, CreatedAt: at(2, 9, 0), Rx: 8, Tx: 8},
+	} {
+		require.NoError(t, db.Create(&row).Error)
+	}
+	// saved by an upgraded log stream before the migration, already in the rollups
+	later := OcservUserTrafficStatistics{OcUserID: 1, CreatedAt: at(2, 12, 0), Rx: 16, Tx: 16}
+	require.NoError(t, db.Create(&later).Error)
+	require.NoError(t, AddTrafficRollups(db, later))
+
+	require.NoError(t, BuildTrafficRollups(db))
+	// running it again adds nothing
+	require.NoError(t, BuildTrafficRollups(db))
+
+	sums := func(model interface{}, userID uint) map[time.Time]int {
+		var buckets []TrafficBucket
+		require.NoError(t, db.Model(model).Where("oc_user_id = ?", userID).Find(&buckets).Error)
+		result := make(map[time.Time]int)
+		for _, b := range buckets {
+			assert.Equal(t, b.Rx, b.Tx)
+			result[b.Start.UTC()] = b.Rx
+		}
+		return result
+	}
+
+	assert.Equal(t, map[time.Time]int{at(1, 10, 0): 3, at(2, 9, 0): 4, at(2, 12, 0): 16}, sums(&OcservUserTrafficHourly{}, 1))
+	assert.Equal(t, map[time.Time]int{at(1, 0, 0): 3, at(2, 0, 0): 20}, sums(&OcservUserTrafficDaily{}, 1))
+	assert.Equal(t, map[time.Time]int{at(1, 0, 0): 23}, sums(&OcservUserTrafficMonthly{}, 1))
+	assert.Equal(t, map[time.Time]int{at(2, 9, 0): 8}, sums(&OcservUserTrafficHourly{}, 2))
+	assert.Equal(t, map[time.Time]int{at(1, 0, 0): 8}, sums(&OcservUserTrafficMonthly{}, 2))
+}
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Directions and periods of a traffic policy. Custom traffic types set them explicitly,
+// the other traffic types imply them.
+const (
+	TrafficRX   = "rx"
+	TrafficTX   = "tx"
+	TrafficBoth = "both"
+
+	TrafficDaily    = "daily"
+	TrafficWeekly   = "weekly"
+	TrafficMonthly  = "monthly"
+	TrafficLifetime = "lifetime"
+)
+
+// Actions taken when a user exhausts its quota: lock the account, or throttle it to ThrottleRate
+// until the next period reset.
+const (
+	OverQuotaLock     = "lock"
+	OverQuotaThrottle = "throttle"
+)
+
+// legacyTrafficPolicies are the direction and period implied by the traffic types predating Custom.
+var legacyTrafficPolicies = map[string][2]string{
+	MonthlyTransmit: {TrafficTX, TrafficMonthly},
+	MonthlyReceive:  {TrafficRX, TrafficMonthly},
+	TotallyTransmit: {TrafficTX, TrafficLifetime},
+	TotallyReceive:  {TrafficRX, TrafficLifetime},
+}
+
+// TrafficPolicy is the quota of a user: Limit bytes of Direction traffic per Period.
+type TrafficPolicy struct {
+	Direction string
+	Period    string
+	Limit     int // in bytes
+	// ResetDay is the day of month monthly periods start on, clamped to the length of short months.
+	ResetDay int
+	// Anchor is the signup time; weekly periods start on its weekday.
+	Anchor time.Time
+}
+
+// TrafficTopUp is a ledger row of add-on traffic granted to a user on top of its TrafficSize.
+// A top-up counts until ExpireAt, or until the end of the period it was added in when ExpireAt is nil.
+type TrafficTopUp struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	OcUserID  uint       `json:"-" gorm:"not null;index;constraint:OnDelete:CASCADE"`
+	Amount    int        `json:"amount" gorm:"not null" validate:"required"` // in GiB
+	ExpireAt  *time.Time `json:"expire_at" validate:"omitempty"`
+	Author    string     `json:"author" gorm:"type:varchar(16);not null" validate:"required"`
+	Note      string     `json:"note" gorm:"type:text" validate:"omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TrafficUsage is the traffic a user consumed in the current period of its policy.
+type TrafficUsage struct {
+	Direction   string     `json:"direction" validate:"required"`
+	Period      string     `json:"period" validate:"required"`
+	PeriodStart *time.Time `json:"period_start" validate:"omitempty"` // nil for lifetime policies
+	ResetAt     *time.Time `json:"reset_at" validate:"omitempty"`     // nil for lifetime policies
+	Rx          int        `json:"rx" validate:"required"`            // in bytes
+	Tx          int        `json:"tx" validate:"required"`            // in bytes
+	Used        int        `json:"used" validate:"required"`          // bytes counted against the limit
+	TopUp       int        `json:"top_up" validate:"required"`        // bytes of the active top-ups, included in Limit
+	Limit       int        `json:"limit" validate:"required"`         // in bytes
+	Exceeded    bool       `json:"exceeded" validate:"required"`
+}
+
+// normalizeTraffic validates the traffic settings, fills the direction and period implied by the
+// traffic types predating Custom, and clears the quota of Free.
+func normalizeTraffic(trafficType string, direction, period *string, size *int) error {
+	switch trafficType {
+	case Free:
+		*direction, *period, *size = "", "", 0
+		return nil
+	case Custom:
+		switch *direction {
+		case TrafficRX, TrafficTX, TrafficBoth:
+		default:
+			return fmt.Errorf("invalid traffic direction: %q", *direction)
+		}
+		switch *period {
+		case TrafficDaily, TrafficWeekly, TrafficMonthly, TrafficLifetime:
+		default:
+			return fmt.Errorf("invalid traffic period: %q", *period)
+		}
+		return nil
+	}
+
+	legacy, ok := legacyTrafficPolicies[trafficType]
+	if !ok {
+		return fmt.Errorf("invalid TrafficType: %s", trafficType)
+	}
+	*direction, *period = legacy[0], legacy[1]
+	return nil
+}
+
+// normalizeOverQuota validates the over-quota action, defaulting to OverQuotaLock.
+func normalizeOverQuota(action *string, rate int) error {
+	switch *action {
+	case "":
+		*action = OverQuotaLock
+	case OverQuotaLock:
+	case OverQuotaThrottle:
+		if rate <= 0 {
+			return fmt.Errorf("throttle rate is required for the %s over-quota action", OverQuotaThrottle)
+		}
+	default:
+		return fmt.Errorf("invalid over-quota action: %q", *action)
+	}
+	return nil
+}
+
+// AppliedConfig returns the config written to the per-user config file: Config, with the receive and
+// transmit rates capped to ThrottleRate while the user is throttled.
+func (o *OcservUser) AppliedConfig() *OcservUserConfig {
+	if o.ThrottledAt == nil || o.ThrottleRate <= 0 {
+		return o.Config
+	}
+
+	var config OcservUserConfig
+	if o.Config != nil {
+		config = *o.Config
+	}
+	rate := o.ThrottleRate
+	if config.RxDataPerSec == nil || *config.RxDataPerSec == 0 || *config.RxDataPerSec > rate {
+		config.RxDataPerSec = &rate
+	}
+	if config.TxDataPerSec == nil || *config.TxDataPerSec == 0 || *config.TxDataPerSec > rate {
+		config.TxDataPerSec = &rate
+	}
+	return &config
+}
+
+// TrafficPolicy returns the quota of the user, or nil for Free users. Monthly periods of the
+// traffic types predating Custom keep starting on the first of the month unless a reset day is
+// set; Custom monthly periods start on the signup day by default.
+func (o *OcservUser) TrafficPolicy() *TrafficPolicy {
+	direction, period, size := o.TrafficDirection, o.TrafficPeriod, o.TrafficSize
+	if err := normalizeTraffic(o.TrafficType, &direction, &period, &size); err != nil || o.TrafficType == Free {
+		return nil
+	}
+
+	resetDay := o.TrafficResetDay
+	if resetDay == 0 {
+		resetDay = 1
+		if o.TrafficType == Custom {
+			resetDay = o.CreatedAt.Day()
+		}
+	}
+
+	return &TrafficPolicy{
+		Direction: direction,
+		Period:    period,
+		Limit:     size * (1 << 30),
+		ResetDay:  resetDay,
+		Anchor:    o.CreatedAt,
+	}
+}
+
+// TrafficUsage returns the usage of the current period of the user policy, or nil for Free users.
+// Lifetime usage is read from the Rx and Tx counters of the user, periodic usage is summed from
+// the hourly traffic of the period. The active top-ups of the user extend the limit. A reset of the
+// counters, by a renewal or an admin, starts the usage of the period again.
+func (o *OcservUser) TrafficUsage(db *gorm.DB, now time.Time) (*TrafficUsage, error) {
+	policy := o.TrafficPolicy()
+	if policy == nil {
+		return nil, nil
+	}
+
+	usage := &TrafficUsage{
+		Direction: policy.Direction,
+		Period:    policy.Period,
+		Limit:     policy.Limit,
+	}
+
+	from := policy.PeriodStart(now)
+	if o.TrafficResetAt != nil && o.TrafficResetAt.After(from) {
+		from = *o.TrafficResetAt
+	}
+
+	if policy.Period == TrafficLifetime {
+		usage.Rx, usage.Tx = o.Rx, o.Tx
+	} else {
+		start, resetAt := policy.PeriodStart(now), policy.NextReset(now)
+		usage.PeriodStart, usage.ResetAt = &start, &resetAt
+
+		rx, tx, err := o.trafficSince(db, from)
+		if err != nil {
+			return nil, err
+		}
+		usage.Rx, usage.Tx = rx, tx
+	}
+
+	topUp, err := o.activeTopUp(db, from, now)
+	if err != nil {
+		return nil, err
+	}
+	usage.TopUp = topUp * (1 << 30)
+	usage.Limit += usage.TopUp
+
+	usage.Used = policy.Used(usage.Rx, usage.Tx)
+	usage.Exceeded = usage.Used >= usage.Limit
+	return usage, nil
+}
+
+// trafficSince sums the traffic of the user since from, from the hourly traffic. When from falls
+// within an hour, as after a reset, that hour is summed from the raw statistics saved since from;
+// it counts nothing once they are pruned.
+func (o *OcservUser) trafficSince(db *gorm.DB, from time.Time) (int, int, error) {
+	var hour, raw struct {
+		Rx int
+		Tx int
+	}
+
+	start := BucketStart(from.UTC(), GranularityHour)
+	if start.Before(from) {
+		start = start.Add(time.Hour)
+		err := db.Model(&OcservUserTrafficStatistics{}).
+			Select("COALESCE(SUM(rx), 0) AS rx, COALESCE(SUM(tx), 0) AS tx").
+			// saved with the local time of the log stream, like the bucket starts in UTC
+			Where("oc_user_id = ? AND created_at >= ? AND created_at < ?", o.ID, from.Local(), start.Local()).
+			Scan(&raw).Error
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	err := db.Model(&OcservUserTrafficHourly{}).
+		Select("COALESCE(SUM(rx), 0) AS rx, COALESCE(SUM(tx), 0) AS tx").
+		Where("oc_user_id = ? AND start >= ?", o.ID, start).
+		Scan(&hour).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return hour.Rx + raw.Rx, hour.Tx + raw.Tx, nil
+}
+
+// activeTopUp returns the GiB of the top-ups of the user counting at now: the unexpired ones, and the
+// ones without expiry added since from, the start of the current period or the last reset.
+func (o *OcservUser) activeTopUp(db *gorm.DB, from, now time.Time) (int, error) {
+	var amount int
+	err := db.Model(&TrafficTopUp{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("oc_user_id = ?", o.ID).
+		Where("(expire_at > ? OR (expire_at IS NULL AND created_at >= ?))", now, from).
+		Scan(&amount).Error
+	return amount, err
+}
+
+// Used returns the bytes of rx and tx counted against the limit.
+func (p *TrafficPolicy) Used(rx, tx int) int {
+	switch p.Direction {
+	case TrafficRX:
+		return rx
+	case TrafficTX:
+		return tx
+	default:
+		return rx + tx
+	}
+}
+
+// PeriodStart returns the start of the period containing now, in the location of now.
+// Lifetime policies have a single period starting at the zero time.
+func (p *TrafficPolicy) PeriodStart(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch p.Period {
+	case TrafficDaily:
+		return today
+	case TrafficWeekly:
+		days := (int(today.Weekday()) - int(p.Anchor.In(now.Location()).Weekday()) + 7) % 7
+		return today.AddDate(0, 0, -days)
+	case TrafficMonthly:
+		start := p.monthStart(today.Year(), today.Month(), now.Location())
+		if start.After(today) {
+			start = p.monthStart(today.Year(), today.Month()-1, now.Location())
+		}
+		return start
+	default:
+		return time.Time{}
+	}
+}
+
+// NextReset returns the start of the period following the one containing now, or the zero time
+// for lifetime policies.
+func (p *TrafficPolicy) NextReset(now time.Time) time.Time {
+	start := p.PeriodStart(now)
+
+	switch p.Period {
+	case TrafficDaily:
+		return start.AddDate(0, 0, 1)
+	case TrafficWeekly:
+		return start.AddDate(0, 0, 7)
+	case TrafficMonthly:
+		return p.monthStart(start.Year(), start.Month()+1, now.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// monthStart returns the reset day of the month, clamped to the last day of the month.
+func (p *TrafficPolicy) monthStart(year int, month time.Month, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(p.ResetDay, lastDay)-1)
+}
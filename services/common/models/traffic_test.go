@@ -0,0 +1,189 @@
+package models_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTrafficPolicyPeriods(t *testing.T) {
+	tehran := time.FixedZone("Asia/Tehran", 3*3600+1800)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	// Wednesday
+	anchor := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		policy models.TrafficPolicy
+		now    time.Time
+		start  time.Time
+		next   time.Time
+	}{
+		{
+			name:   "daily",
+			policy: models.TrafficPolicy{Period: models.TrafficDaily},
+			now:    time.Date(2025, 3, 5, 23, 59, 0, 0, time.UTC),
+			start:  day(2025, 3, 5),
+			next:   day(2025, 3, 6),
+		},
+		{
+			name:   "daily at the year end",
+			policy: models.TrafficPolicy{Period: models.TrafficDaily},
+			now:    time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC),
+			start:  day(2025, 12, 31),
+			next:   day(2026, 1, 1),
+		},
+		{
+			name:   "weekly on the anchor weekday",
+			policy: models.TrafficPolicy{Period: models.TrafficWeekly, Anchor: anchor},
+			now:    time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC),
+			start:  day(2025, 1, 15),
+			next:   day(2025, 1, 22),
+		},
+		{
+			name:   "weekly the day before the anchor weekday",
+			policy: models.TrafficPolicy{Period: models.TrafficWeekly, Anchor: anchor},
+			now:    time.Date(2025, 1, 14, 23, 0, 0, 0, time.UTC),
+			start:  day(2025, 1, 8),
+			next:   day(2025, 1, 15),
+		},
+		{
+			name:   "weekly across the year end",
+			policy: models.TrafficPolicy{Period: models.TrafficWeekly, Anchor: anchor},
+			now:    time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			start:  day(2025, 12, 31),
+			next:   day(2026, 1, 7),
+		},
+		{
+			name:   "weekly anchor weekday in the location of now",
+			policy: models.TrafficPolicy{Period: models.TrafficWeekly, Anchor: time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)},
+			now:    time.Date(2025, 1, 9, 1, 0, 0, 0, tehran),
+			start:  time.Date(2025, 1, 9, 0, 0, 0, 0, tehran),
+			next:   time.Date(2025, 1, 16, 0, 0, 0, 0, tehran),
+		},
+		{
+			name:   "monthly on the first",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 1},
+			now:    time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC),
+			start:  day(2025, 12, 1),
+			next:   day(2026, 1, 1),
+		},
+		{
+			name:   "monthly wraps back to december",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 15},
+			now:    time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC),
+			start:  day(2024, 12, 15),
+			next:   day(2025, 1, 15),
+		},
+		{
+			name:   "monthly on the reset day",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 15},
+			now:    time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+			start:  day(2025, 1, 15),
+			next:   day(2025, 2, 15),
+		},
+		{
+			name:   "day 31 wraps back to december",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 31},
+			now:    time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC),
+			start:  day(2024, 12, 31),
+			next:   day(2025, 1, 31),
+		},
+		{
+			name:   "day 31 clamped to february",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 31},
+			now:    time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC),
+			start:  day(2025, 1, 31),
+			next:   day(2025, 2, 28),
+		},
+		{
+			name:   "day 31 on the clamped day",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 31},
+			now:    time.Date(2025, 2, 28, 12, 0, 0, 0, time.UTC),
+			start:  day(2025, 2, 28),
+			next:   day(2025, 3, 31),
+		},
+		{
+			name:   "day 31 in a leap year",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 31},
+			now:    time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			start:  day(2024, 2, 29),
+			next:   day(2024, 3, 31),
+		},
+		{
+			name:   "day 31 clamped to a 30 day month",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 31},
+			now:    time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+			start:  day(2025, 4, 30),
+			next:   day(2025, 5, 31),
+		},
+		{
+			name:   "monthly in the location of now",
+			policy: models.TrafficPolicy{Period: models.TrafficMonthly, ResetDay: 1},
+			now:    time.Date(2025, 3, 1, 0, 30, 0, 0, tehran),
+			start:  time.Date(2025, 3, 1, 0, 0, 0, 0, tehran),
+			next:   time.Date(2025, 4, 1, 0, 0, 0, 0, tehran),
+		},
+		{
+			name:   "lifetime",
+			policy: models.TrafficPolicy{Period: models.TrafficLifetime},
+			now:    time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.start, tt.policy.PeriodStart(tt.now))
+			assert.Equal(t, tt.next, tt.policy.NextReset(tt.now))
+		})
+	}
+}
+
+func TestTrafficUsageAfterRenew(t *testing.T) {
+	db := setupDB(t, &models.OcservUser{}, &models.TrafficTopUp{}, &models.OcservUserTrafficStatistics{},
+		&models.OcservUserTrafficHourly{}, &models.OcservUserTrafficDaily{}, &models.OcservUserTrafficMonthly{})
+
+	const mib = 1 << 20
+	at := func(day, h, m int) time.Time {
+		// saved with the local time of the log stream
+		return time.Date(2025, 3, day, h, m, 0, 0, time.UTC).Local()
+	}
+	u := models.OcservUser{
+		UID: "alice", Username: "alice", Password: "secret", Group: "defaults",
+		TrafficType: models.MonthlyTransmit, TrafficSize: 1, CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, db.Create(&u).Error)
+	addTraffic := func(createdAt time.Time, tx int) {
+		traffic := models.OcservUserTrafficStatistics{OcUserID: u.ID, CreatedAt: createdAt, Tx: tx}
+		require.NoError(t, db.Create(&traffic).Error)
+		require.NoError(t, models.AddTrafficRollups(db, traffic))
+	}
+
+	addTraffic(at(10, 9, 0), 2048*mib)
+	// a top-up without expiry added before the renewal
+	require.NoError(t, db.Create(&models.TrafficTopUp{OcUserID: u.ID, Amount: 1, Author: "admin", CreatedAt: at(11, 0, 0)}).Error)
+	usage, err := u.TrafficUsage(db, at(15, 12, 0))
+	require.NoError(t, err)
+	assert.True(t, usage.Exceeded)
+	assert.Equal(t, 2048*mib, usage.Tx)
+
+	renewedAt := at(15, 12, 30)
+	u.ApplyPlan(&models.Plan{ID: 1, Group: "defaults", TrafficType: models.MonthlyTransmit, TrafficSize: 1, DurationDays: 30}, renewedAt)
+	require.NoError(t, db.Save(&u).Error)
+
+	// the hour of the renewal counts from the renewal
+	addTraffic(at(15, 12, 10), 100*mib)
+	addTraffic(at(15, 12, 45), 200*mib)
+	addTraffic(at(15, 14, 0), 300*mib)
+
+	require.NoError(t, db.First(&u, u.ID).Error)
+	usage, err = u.TrafficUsage(db, at(15, 15, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 500*mib, usage.Tx)
+	assert.Equal(t, 1024*mib, usage.Limit, "top-ups added before the renewal no longer count")
+	assert.False(t, usage.Exceeded)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local).UTC(), usage.PeriodStart.UTC())
+}
@@ -4,36 +4,47 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
 	"net/http"
+	"os"
 	"time"
 )
 
+// TokenHeader carries the shared secret the webhook agent requires, AGENT_TOKEN.
+const TokenHeader = "X-Agent-Token"
+
 type WebhookPayload struct {
-	Username string `json:"username"`
+	Username    string                    `json:"username"`
+	Password    string                    `json:"password,omitempty"`
+	Group       string                    `json:"group,omitempty"`
+	UserConfig  *models.OcservUserConfig  `json:"user_config,omitempty"`
+	GroupConfig *models.OcservGroupConfig `json:"group_config,omitempty"`
 }
 
 type OcservOcctlDocker struct {
 	apiURL string
+	token  string
 }
 
 type OcservOcctlUsersDocker interface {
 	DisconnectUser(username string) (string, error)
 	Lock(username string) (string, error)
 	Unlock(username string) (string, error)
+	UpdateConfig(username string, config *models.OcservUserConfig) (string, error)
+	Create(group, username, password string, config *models.OcservUserConfig) (string, error)
+	Delete(username string) (string, error)
 }
 
 func NewOcservOcctlDocker() *OcservOcctlDocker {
-	return &OcservOcctlDocker{apiURL: "http://ocserv:8888"}
+	return &OcservOcctlDocker{apiURL: "http://ocserv:8888", token: os.Getenv("AGENT_TOKEN")}
 }
 
 // call webhook endpoint api
-func (d *OcservOcctlDocker) call(name string, username string) error {
+func (d *OcservOcctlDocker) call(name string, payload WebhookPayload) error {
 	endpoint := fmt.Sprintf("%s/webhook/%s", d.apiURL, name)
 
-	logger.Info("Docker webhook call for %s %s", name, username)
-
-	payload := WebhookPayload{Username: username}
+	logger.Info("Docker webhook call for %s %s", name, payload.Username)
 
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -46,6 +57,7 @@ func (d *OcservOcctlDocker) call(name string, username string) error {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TokenHeader, d.token)
 
 	client := &http.Client{Timeout: 10 * time.Second}
 
@@ -65,13 +77,28 @@ func (d *OcservOcctlDocker) call(name string, username string) error {
 }
 
 func (d *OcservOcctlDocker) DisconnectUser(username string) (string, error) {
-	return "", d.call("disconnect", username)
+	return "", d.call("disconnect", WebhookPayload{Username: username})
 }
 
 func (d *OcservOcctlDocker) Lock(username string) (string, error) {
-	return "", d.call("lock", username)
+	return "", d.call("lock", WebhookPayload{Username: username})
 }
 
 func (d *OcservOcctlDocker) Unlock(username string) (string, error) {
-	return "", d.call("unlock", username)
+	return "", d.call("unlock", WebhookPayload{Username: username})
+}
+
+// UpdateConfig rewrites the per-user config file and reloads ocserv.
+func (d *OcservOcctlDocker) UpdateConfig(username string, config *models.OcservUserConfig) (string, error) {
+	return "", d.call("config", WebhookPayload{Username: username, UserConfig: config})
+}
+
+// Create creates or rewrites the user in ocpasswd with its group and config, and reloads ocserv.
+func (d *OcservOcctlDocker) Create(group, username, password string, config *models.OcservUserConfig) (string, error) {
+	return "", d.call("create", WebhookPayload{Username: username, Password: password, Group: group, UserConfig: config})
+}
+
+// Delete removes the user from ocpasswd and reloads ocserv.
+func (d *OcservOcctlDocker) Delete(username string) (string, error) {
+	return "", d.call("delete", WebhookPayload{Username: username})
 }
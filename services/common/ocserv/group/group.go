@@ -7,9 +7,7 @@ import (
 	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"sync"
 )
 
 type OcservGroup struct{}
@@ -28,51 +26,19 @@ func NewOcservGroup() *OcservGroup {
 
 // Create creates a new group configuration file for the given group name.
 // The file is written to ocserv.ConfigGroupBaseDir/<name> with permission 0640.
-// It serializes the provided OcservGroupConfig into the file using pkg.ConfigWriter.
+// The provided OcservGroupConfig is validated and written atomically using utils.WriteConfigFile.
 func (g *OcservGroup) Create(name string, config *models.OcservGroupConfig) error {
-	filename := filepath.Join(utils.ConfigGroupBaseDir, name)
-
-	// Open file with create, truncate, write-only flags and permission 0640
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	err = utils.ConfigWriter(file, utils.ToMap(config))
-	if err != nil {
-		return err
-	}
-	return nil
+	return utils.WriteConfigFile(utils.GroupConfigFilePathCreator(name), utils.ToMap(config), utils.GroupConfigKeys)
 }
 
-// Delete removes the configuration file for the given group name
-// from ocserv.ConfigGroupBaseDir. It also resets the group assignment
-// for all users belonging to this group back to the default (empty).
-// User group resets are done concurrently, but errors from resetting
-// are ignored.
+// Delete removes the configuration file for the given group name from
+// ocserv.ConfigGroupBaseDir. A missing file is not an error. Members of the
+// group are left untouched; callers move them first with the user SetGroup.
 func (g *OcservGroup) Delete(name string) error {
 	filename := filepath.Join(utils.ConfigGroupBaseDir, name)
-	if err := os.Remove(filename); err != nil {
-		return err
-	}
-	users, err := utils.GetUsersByGroup(name)
-	if err != nil {
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
 		return err
 	}
-
-	var wg sync.WaitGroup
-
-	// Reset group assignment for each user to default (empty)
-	for _, user := range users {
-		wg.Add(1)
-		go func(u string) {
-			defer wg.Done()
-			_, _ = exec.Command(utils.OcpasswdExec, "-g", "", "-c", utils.OcpasswdPath, u).CombinedOutput()
-		}(user)
-	}
-	wg.Wait()
-
 	return nil
 }
 
@@ -93,20 +59,10 @@ func (g *OcservGroup) DefaultsGroup() (*models.OcservGroupConfig, error) {
 }
 
 // UpdateDefaultsGroup overwrites the ocserv.DefaultGroupFile with
-// the provided OcservGroupConfig. The file is opened with create,
-// truncate, and write-only flags and written with permission 0640.
+// the provided OcservGroupConfig. The config is validated and the file
+// is replaced atomically with permission 0640.
 func (g *OcservGroup) UpdateDefaultsGroup(config *models.OcservGroupConfig) error {
-	file, err := os.OpenFile(utils.DefaultGroupFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	err = utils.ConfigWriter(file, utils.ToMap(config))
-	if err != nil {
-		return err
-	}
-	return nil
+	return utils.WriteConfigFile(utils.DefaultGroupFile, utils.ToMap(config), utils.GroupConfigKeys)
 }
 
 // GroupList scans the ConfigGroupBaseDir for directories and returns their configurations.
@@ -0,0 +1,256 @@
+package node
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	occtlDocker "github.com/mmtaee/ocserv-users-management/common/occtl_docker"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenHeader carries the shared secret of a node agent.
+const TokenHeader = occtlDocker.TokenHeader
+
+type Agent struct {
+	name   string
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+type AgentUsers interface {
+	Create(group, username, password string, config *models.OcservUserConfig) error
+	Lock(username string) (string, error)
+	UnLock(username string) (string, error)
+	Delete(username string) (string, error)
+	DisconnectUser(username string) (string, error)
+	UpdateConfig(username string, config *models.OcservUserConfig) error
+}
+
+type AgentGroups interface {
+	CreateGroup(name string, config *models.OcservGroupConfig) error
+	DeleteGroup(name string) error
+	UpdateDefaultsGroup(config *models.OcservGroupConfig) error
+}
+
+type AgentServer interface {
+	OnlineSessions() (*[]models.OnlineUserSession, error)
+	ShowStatus() (map[string]interface{}, error)
+}
+
+type AgentInterface interface {
+	Name() string
+	AgentUsers
+	AgentGroups
+	AgentServer
+}
+
+// NewAgent returns a client for the webhook agent running next to the ocserv of the given node.
+var NewAgent = func(n models.Node) AgentInterface {
+	return &Agent{
+		name:   n.Name,
+		apiURL: strings.TrimRight(n.AgentURL, "/"),
+		token:  n.Token,
+		client: agentClient(),
+	}
+}
+
+// agentClient is the HTTP client of the agents. AGENT_CA_FILE adds the CA of agents serving
+// self-signed certificates to the system roots.
+var agentClient = sync.OnceValue(func() *http.Client {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	caFile := os.Getenv("AGENT_CA_FILE")
+	if caFile == "" {
+		return client
+	}
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		logger.Error("Failed to read agent CA file %s: %v", caFile, err)
+		return client
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(ca) {
+		logger.Error("No certificate found in agent CA file %s", caFile)
+	}
+	client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	return client
+})
+
+func (a *Agent) Name() string {
+	return a.name
+}
+
+// call posts the payload to /webhook/<action> of the agent and returns the response body. Agents
+// receive passwords, so they are only called over https.
+func (a *Agent) call(action string, payload occtlDocker.WebhookPayload) ([]byte, error) {
+	if !strings.HasPrefix(a.apiURL, "https://") {
+		return nil, fmt.Errorf("agent %s: agent url must use https", a.name)
+	}
+	endpoint := fmt.Sprintf("%s/webhook/%s", a.apiURL, action)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TokenHeader, a.token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		logger.Error("Failed to call node %s agent: %v", a.name, err)
+		return nil, fmt.Errorf("call agent %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read agent %s response: %w", action, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Error("Node %s agent %s failed with status: %d", a.name, action, resp.StatusCode)
+		return nil, fmt.Errorf("agent %s failed: status %d: %s", action, resp.StatusCode, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+func (a *Agent) Create(group, username, password string, config *models.OcservUserConfig) error {
+	_, err := a.call("create", occtlDocker.WebhookPayload{
+		Username:   username,
+		Password:   password,
+		Group:      group,
+		UserConfig: config,
+	})
+	return err
+}
+
+func (a *Agent) Lock(username string) (string, error) {
+	out, err := a.call("lock", occtlDocker.WebhookPayload{Username: username})
+	return string(out), err
+}
+
+func (a *Agent) UnLock(username string) (string, error) {
+	out, err := a.call("unlock", occtlDocker.WebhookPayload{Username: username})
+	return string(out), err
+}
+
+func (a *Agent) Delete(username string) (string, error) {
+	out, err := a.call("delete", occtlDocker.WebhookPayload{Username: username})
+	return string(out), err
+}
+
+func (a *Agent) DisconnectUser(username string) (string, error) {
+	out, err := a.call("disconnect", occtlDocker.WebhookPayload{Username: username})
+	return string(out), err
+}
+
+// UpdateConfig rewrites the per-user config file on the node and reloads its ocserv.
+func (a *Agent) UpdateConfig(username string, config *models.OcservUserConfig) error {
+	_, err := a.call("config", occtlDocker.WebhookPayload{Username: username, UserConfig: config})
+	return err
+}
+
+func (a *Agent) CreateGroup(name string, config *models.OcservGroupConfig) error {
+	_, err := a.call("group-create", occtlDocker.WebhookPayload{Group: name, GroupConfig: config})
+	return err
+}
+
+func (a *Agent) DeleteGroup(name string) error {
+	_, err := a.call("group-delete", occtlDocker.WebhookPayload{Group: name})
+	return err
+}
+
+func (a *Agent) UpdateDefaultsGroup(config *models.OcservGroupConfig) error {
+	_, err := a.call("group-defaults", occtlDocker.WebhookPayload{GroupConfig: config})
+	return err
+}
+
+// OnlineSessions returns the sessions connected to the node, tagged with the node name.
+func (a *Agent) OnlineSessions() (*[]models.OnlineUserSession, error) {
+	out, err := a.call("sessions", occtlDocker.WebhookPayload{})
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []models.OnlineUserSession
+	if err = json.Unmarshal(out, &sessions); err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Node = a.name
+	}
+	return &sessions, nil
+}
+
+func (a *Agent) ShowStatus() (map[string]interface{}, error) {
+	out, err := a.call("status", occtlDocker.WebhookPayload{})
+	if err != nil {
+		return nil, err
+	}
+
+	var status map[string]interface{}
+	if err = json.Unmarshal(out, &status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// FanOut runs fn concurrently against the agent of every active node.
+// Errors are joined and prefixed with the name of the failing node.
+func FanOut(nodes []models.Node, fn func(agent AgentInterface) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, n := range nodes {
+		if !n.IsActive {
+			continue
+		}
+
+		wg.Add(1)
+		go func(agent AgentInterface) {
+			defer wg.Done()
+			if err := fn(agent); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("node %s: %w", agent.Name(), err))
+				mu.Unlock()
+			}
+		}(NewAgent(n))
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// UserNodes returns the nodes the given ocserv user is assigned to.
+func UserNodes(db *gorm.DB, userID uint) ([]models.Node, error) {
+	var nodes []models.Node
+	err := db.Model(&models.OcservUser{ID: userID}).Association("Nodes").Find(&nodes)
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
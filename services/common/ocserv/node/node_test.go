@@ -0,0 +1,113 @@
+package node_test
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/occtl_docker"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+type fakeAgent struct {
+	node.AgentInterface
+	name string
+}
+
+func (a *fakeAgent) Name() string {
+	return a.name
+}
+
+func TestFanOut(t *testing.T) {
+	newAgent := node.NewAgent
+	defer func() { node.NewAgent = newAgent }()
+	node.NewAgent = func(n models.Node) node.AgentInterface {
+		return &fakeAgent{name: n.Name}
+	}
+
+	nodes := []models.Node{
+		{Name: "a", IsActive: true},
+		{Name: "b", IsActive: false},
+		{Name: "c", IsActive: true},
+		{Name: "d", IsActive: true},
+	}
+
+	var (
+		mu     sync.Mutex
+		called []string
+	)
+	err := node.FanOut(nodes, func(agent node.AgentInterface) error {
+		mu.Lock()
+		called = append(called, agent.Name())
+		mu.Unlock()
+		if agent.Name() == "c" {
+			return errors.New("unreachable")
+		}
+		return nil
+	})
+
+	sort.Strings(called)
+	assert.Equal(t, []string{"a", "c", "d"}, called)
+	assert.EqualError(t, err, "node c: unreachable")
+
+	assert.NoError(t, node.FanOut(nodes[1:2], func(node.AgentInterface) error {
+		return errors.New("inactive nodes are skipped")
+	}))
+}
+
+func TestAgent(t *testing.T) {
+	var payload occtl_docker.WebhookPayload
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(node.TokenHeader) != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		switch r.URL.Path {
+		case "/webhook/lock":
+			_, _ = w.Write([]byte("locked"))
+		case "/webhook/sessions":
+			_, _ = w.Write([]byte(`[{"username":"alice"}]`))
+		default:
+			http.Error(w, "unknown action", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	// the agents trust the CA of AGENT_CA_FILE
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caFile, ca, 0600))
+	t.Setenv("AGENT_CA_FILE", caFile)
+
+	agent := node.NewAgent(models.Node{Name: "edge", AgentURL: srv.URL + "/", Token: "secret"})
+
+	out, err := agent.Lock("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "locked", out)
+	assert.Equal(t, "alice", payload.Username)
+
+	sessions, err := agent.OnlineSessions()
+	assert.NoError(t, err)
+	if assert.Len(t, *sessions, 1) {
+		assert.Equal(t, "alice", (*sessions)[0].Username)
+		assert.Equal(t, "edge", (*sessions)[0].Node)
+	}
+
+	_, err = agent.Delete("alice")
+	assert.EqualError(t, err, "agent delete failed: status 404: unknown action")
+
+	_, err = node.NewAgent(models.Node{Name: "edge", AgentURL: srv.URL, Token: "wrong"}).Lock("alice")
+	assert.ErrorContains(t, err, "status 401")
+
+	_, err = node.NewAgent(models.Node{Name: "edge", AgentURL: "http://127.0.0.1:8888", Token: "secret"}).Lock("alice")
+	assert.EqualError(t, err, "agent edge: agent url must use https")
+}
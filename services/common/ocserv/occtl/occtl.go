@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/mmtaee/ocserv-users-management/common/models"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
-	"net"
 	"os/exec"
 	"strings"
 )
@@ -88,6 +87,9 @@ func (o *OcservOcctl) OnlineSessions() (*[]models.OnlineUserSession, error) {
 	if err = json.Unmarshal(result, &sessions); err != nil {
 		return nil, err
 	}
+	for i := range sessions {
+		normalizeSession(&sessions[i])
+	}
 	return &sessions, nil
 }
 
@@ -128,20 +130,23 @@ func (o *OcservOcctl) ShowIPBans() (*[]models.IPBanPoints, error) {
 			return nil, err
 		}
 	}
+	for i := range ipBans {
+		ipBans[i].Family = utils.AddressFamily(ipBans[i].IP)
+	}
 
 	return &ipBans, nil
 
 }
 
-// UnbanIP removes an IP ban from the given IP address.
+// UnbanIP removes an IP ban from the given IPv4 or IPv6 address.
 // Executes: occtl unban ip <ip>
 func (o *OcservOcctl) UnbanIP(ip string) (string, error) {
-	parsedIP := net.ParseIP(ip)
+	parsedIP := utils.ParseAddress(ip)
 	if parsedIP == nil {
 		return "", fmt.Errorf("invalid IP: %s", ip)
 	}
 
-	cmd := exec.Command(occtlExec, "unban", "ip", ip)
+	cmd := exec.Command(occtlExec, "unban", "ip", parsedIP.String())
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", err
@@ -198,6 +203,15 @@ func (o *OcservOcctl) ShowIRoutes() (*[]models.IRoute, error) {
 	if err = json.Unmarshal(out, &routes); err != nil {
 		return nil, err
 	}
+	for i := range routes {
+		route := &routes[i]
+		route.Family = utils.AddressFamily(route.IP)
+		for j, network := range route.Networks {
+			if canonical, err := utils.CanonicalNetwork(network); err == nil {
+				route.Networks[j] = canonical
+			}
+		}
+	}
 	return &routes, nil
 }
 
@@ -214,6 +228,7 @@ func (o *OcservOcctl) ShowUser(username string) (models.OnlineUserSession, error
 	if err = json.Unmarshal(out, &session); err != nil {
 		return session, err
 	}
+	normalizeSession(&session)
 	return session, nil
 }
 
@@ -240,6 +255,7 @@ func (o *OcservOcctl) ShowUserByID(id string) (models.OnlineUserSession, error)
 	if err = json.Unmarshal(out, &session); err != nil {
 		return session, err
 	}
+	normalizeSession(&session)
 	return session, nil
 }
 
@@ -316,3 +332,12 @@ func (o *OcservOcctl) ShowEvent() string {
 	cleaned = strings.TrimSpace(cleaned)
 	return cleaned
 }
+
+// normalizeSession sets the family of the remote address of a session and prints its
+// addresses canonically, so IPv6 and IPv4-mapped addresses display consistently.
+func normalizeSession(session *models.OnlineUserSession) {
+	session.RemoteFamily = utils.AddressFamily(session.RemoteIP)
+	session.RemoteIP = utils.CanonicalAddress(session.RemoteIP)
+	session.IPv4 = utils.CanonicalAddress(session.IPv4)
+	session.IPv6 = utils.CanonicalAddress(session.IPv6)
+}
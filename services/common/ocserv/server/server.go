@@ -0,0 +1,256 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/ocservconf"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBackups is the number of previous ocserv.conf versions kept in utils.ConfigBackupDir.
+const maxBackups = 20
+
+// quotedKeys are written as quoted strings, since their values may contain spaces or separators.
+var quotedKeys = map[string]bool{
+	"auth":              true,
+	"banner":            true,
+	"pre-login-banner":  true,
+	"camouflage_secret": true,
+	"camouflage_realm":  true,
+}
+
+// CommandRunner runs an external command and returns its combined output.
+type CommandRunner interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands on the local host.
+type ExecRunner struct{}
+
+func (ExecRunner) Run(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+type OcservServer struct {
+	path      string
+	backupDir string
+	runner    CommandRunner
+}
+
+type OcservServerInterface interface {
+	Config() (*models.OcservServerConfig, error)
+	Preview(config *models.OcservServerConfig) (string, error)
+	Update(config *models.OcservServerConfig) (string, error)
+}
+
+// NewOcservServer manages utils.OcservConfigFile, checking new versions with the given runner.
+func NewOcservServer(runner CommandRunner) *OcservServer {
+	return &OcservServer{path: utils.OcservConfigFile, backupDir: utils.ConfigBackupDir, runner: runner}
+}
+
+// Config parses the managed directives of ocserv.conf.
+func (s *OcservServer) Config() (*models.OcservServerConfig, error) {
+	doc, err := ocservconf.ParseFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return decode(doc)
+}
+
+// Preview returns the unified diff the update would apply to ocserv.conf, without writing it.
+func (s *OcservServer) Preview(config *models.OcservServerConfig) (string, error) {
+	current, updated, err := s.render(config)
+	if err != nil {
+		return "", err
+	}
+	return ocservconf.Diff(current, updated, s.path, s.path), nil
+}
+
+// Update applies the config to ocserv.conf and returns the applied diff. The new version is
+// checked with "ocserv --test-config" before it replaces the file, keeping its permissions, and
+// the previous version is backed up to utils.ConfigBackupDir. A rejected version leaves the file
+// untouched. Reloading ocserv is left to the caller.
+func (s *OcservServer) Update(config *models.OcservServerConfig) (string, error) {
+	current, updated, err := s.render(config)
+	if err != nil {
+		return "", err
+	}
+
+	diff := ocservconf.Diff(current, updated, s.path, s.path)
+	if diff == "" {
+		return "", nil
+	}
+
+	// the candidate lives next to ocserv.conf so that relative paths resolve the same way
+	candidate := filepath.Join(filepath.Dir(s.path), ".ocserv.conf.candidate")
+	if err = utils.WriteFileAtomic(candidate, updated, 0600); err != nil {
+		return "", err
+	}
+	defer os.Remove(candidate)
+
+	if out, err := s.runner.Run(utils.OcservExec, "--test-config", "--config", candidate); err != nil {
+		return "", fmt.Errorf("ocserv rejected the config: %s", strings.TrimSpace(string(out)))
+	}
+
+	// ocserv.conf may hold secrets such as camouflage_secret, it is never made world-readable
+	perm := os.FileMode(0640)
+	if info, err := os.Stat(s.path); err == nil {
+		perm = info.Mode().Perm() &^ 0007
+	}
+
+	if err = s.backup(current); err != nil {
+		return "", err
+	}
+	if err = utils.WriteFileAtomic(s.path, updated, perm); err != nil {
+		return "", err
+	}
+	return diff, nil
+}
+
+// render validates the config and returns the current and the updated ocserv.conf.
+func (s *OcservServer) render(config *models.OcservServerConfig) ([]byte, []byte, error) {
+	values := utils.ToMap(config)
+	if values == nil {
+		return nil, nil, errors.New("invalid ocserv config")
+	}
+	if err := utils.ValidateConfig(values, utils.ServerConfigKeys); err != nil {
+		return nil, nil, err
+	}
+
+	current, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc := ocservconf.ParseBytes(current)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		if value == nil {
+			continue
+		}
+		if lines := encode(key, value); len(lines) > 0 {
+			doc.SetAll(key, lines)
+		} else {
+			doc.Delete(key)
+		}
+	}
+	return current, doc.Bytes(), nil
+}
+
+// backup copies the current ocserv.conf to the backup directory and prunes the oldest backups.
+func (s *OcservServer) backup(current []byte) error {
+	if err := os.MkdirAll(s.backupDir, 0750); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("ocserv.conf.%s", time.Now().UTC().Format("20060102T150405.000Z"))
+	if err := utils.WriteFileAtomic(filepath.Join(s.backupDir, name), current, 0600); err != nil {
+		return err
+	}
+
+	backups, err := filepath.Glob(filepath.Join(s.backupDir, "ocserv.conf.*"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(backups)
+	for len(backups) > maxBackups {
+		_ = os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// encode returns the lines to write for a value decoded by utils.ToMap. Unlike group configs,
+// false is written explicitly since ocserv.conf defaults are not always false.
+func encode(key string, value interface{}) []string {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	lines := make([]string, 0, len(values))
+	for _, v := range values {
+		var line string
+		switch t := v.(type) {
+		case bool:
+			line = strconv.FormatBool(t)
+		case float64:
+			line = strconv.FormatFloat(t, 'f', -1, 64)
+		default:
+			line = fmt.Sprint(t)
+		}
+		if line == "" {
+			continue
+		}
+		if quotedKeys[key] {
+			line = strconv.Quote(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// decode fills the config from the directives of the document, matching fields by json tag.
+func decode(doc *ocservconf.Document) (*models.OcservServerConfig, error) {
+	config := &models.OcservServerConfig{}
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		values := doc.GetAll(key)
+		if len(values) == 0 {
+			continue
+		}
+		for j := range values {
+			values[j] = unquote(values[j])
+		}
+
+		field := v.Field(i)
+		switch field.Interface().(type) {
+		case *models.CSVStringList:
+			list := models.CSVStringList(values)
+			field.Set(reflect.ValueOf(&list))
+		case *string:
+			field.Set(reflect.ValueOf(&values[0]))
+		case *int:
+			n, err := strconv.Atoi(values[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q in %s", key, values[0], utils.OcservConfigFile)
+			}
+			field.Set(reflect.ValueOf(&n))
+		case *bool:
+			b, err := strconv.ParseBool(values[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q in %s", key, values[0], utils.OcservConfigFile)
+			}
+			field.Set(reflect.ValueOf(&b))
+		}
+	}
+	return config, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if s, err := strconv.Unquote(value); err == nil {
+			return s
+		}
+		return value[1 : len(value)-1]
+	}
+	return value
+}
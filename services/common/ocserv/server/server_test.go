@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeRunner struct {
+	out   string
+	err   error
+	calls [][]string
+}
+
+func (r *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return []byte(r.out), r.err
+}
+
+func newTestServer(t *testing.T, runner CommandRunner, perm os.FileMode) *OcservServer {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ocserv.conf")
+	if err := os.WriteFile(path, []byte("tcp-port = 443\nmax-clients = 16\n"), perm); err != nil {
+		t.Fatal(err)
+	}
+	return &OcservServer{path: path, backupDir: filepath.Join(dir, "backups"), runner: runner}
+}
+
+func TestUpdateRejectedConfig(t *testing.T) {
+	runner := &fakeRunner{out: "error: invalid max-clients\n", err: errors.New("exit status 1")}
+	s := newTestServer(t, runner, 0600)
+
+	clients := 64
+	diff, err := s.Update(&models.OcservServerConfig{MaxClients: &clients})
+	assert.EqualError(t, err, "ocserv rejected the config: error: invalid max-clients")
+	assert.Empty(t, diff)
+
+	candidate := filepath.Join(filepath.Dir(s.path), ".ocserv.conf.candidate")
+	if assert.Len(t, runner.calls, 1) {
+		assert.Equal(t, []string{"ocserv", "--test-config", "--config", candidate}, runner.calls[0])
+	}
+
+	// the live file is untouched, the candidate removed and nothing backed up
+	data, err := os.ReadFile(s.path)
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp-port = 443\nmax-clients = 16\n", string(data))
+	assert.NoFileExists(t, candidate)
+	assert.NoDirExists(t, s.backupDir)
+}
+
+func TestUpdateKeepsPermissions(t *testing.T) {
+	for _, perm := range []os.FileMode{0600, 0640, 0644} {
+		t.Run(perm.String(), func(t *testing.T) {
+			runner := &fakeRunner{}
+			s := newTestServer(t, runner, perm)
+
+			clients := 64
+			diff, err := s.Update(&models.OcservServerConfig{MaxClients: &clients})
+			assert.NoError(t, err)
+			assert.Contains(t, diff, "+max-clients = 64")
+
+			info, err := os.Stat(s.path)
+			assert.NoError(t, err)
+			assert.Equal(t, perm&^0007, info.Mode().Perm())
+
+			backups, err := filepath.Glob(filepath.Join(s.backupDir, "ocserv.conf.*"))
+			assert.NoError(t, err)
+			if assert.Len(t, backups, 1) {
+				data, _ := os.ReadFile(backups[0])
+				assert.Equal(t, "tcp-port = 443\nmax-clients = 16\n", string(data))
+			}
+
+			// an unchanged config is neither checked nor written
+			diff, err = s.Update(&models.OcservServerConfig{MaxClients: &clients})
+			assert.NoError(t, err)
+			assert.Empty(t, diff)
+			assert.Len(t, runner.calls, 1)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/pkg/ocservconf"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"os"
+	"slices"
+)
+
+// Config layers of a user, in the order they are applied.
+const (
+	LayerGlobal   = "global"
+	LayerDefaults = "defaults"
+	LayerGroup    = "group"
+	LayerUser     = "user"
+)
+
+// EffectiveConfig resolves the settings of a user the way ocserv does: the directives of ocserv.conf
+// that a group can set, then the group file, or defaults/group.conf when the user is in the defaults
+// group or its group has no file, and last the per-user file. A later layer overrides the
+// single-valued directives of the earlier ones, while the values of multi-valued directives such as
+// route and dns add up like the repeated lines ocserv reads.
+func (u *OcservUser) EffectiveConfig(username, group string) (*EffectiveConfig, error) {
+	layers := []EffectiveConfigLayer{{Name: LayerGlobal, Path: utils.OcservConfigFile}}
+	groupFile := false
+	if group != "" && group != "defaults" && group != "*" {
+		layer := EffectiveConfigLayer{Name: LayerGroup, Path: utils.GroupConfigFilePathCreator(group)}
+		_, err := os.Stat(layer.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		groupFile = err == nil
+		layers = append(layers, layer)
+	}
+	if !groupFile {
+		layers = append(layers, EffectiveConfigLayer{Name: LayerDefaults, Path: utils.DefaultGroupFile})
+	}
+	layers = append(layers, EffectiveConfigLayer{Name: LayerUser, Path: utils.UserConfigFilePathCreator(username)})
+
+	config := &EffectiveConfig{Username: username, Group: group, Directives: []EffectiveDirective{}}
+	index := make(map[string]int)
+
+	for i := range layers {
+		layer := &layers[i]
+		if _, err := os.Stat(layer.Path); err == nil {
+			layer.Exists = true
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		doc, err := ocservconf.ParseFile(layer.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range doc.Keys() {
+			if layer.Name == LayerGlobal && !utils.GroupConfigKeys[key] {
+				// server settings a group cannot change
+				continue
+			}
+			values := make([]EffectiveValue, 0)
+			for _, value := range doc.GetAll(key) {
+				values = append(values, EffectiveValue{Value: value, Source: layer.Name})
+			}
+
+			pos, ok := index[key]
+			if !ok {
+				index[key] = len(config.Directives)
+				config.Directives = append(config.Directives, EffectiveDirective{Key: key, Values: values})
+				continue
+			}
+
+			directive := &config.Directives[pos]
+			if utils.IsListKey(key) {
+				for _, value := range values {
+					if !slices.ContainsFunc(directive.Values, func(v EffectiveValue) bool { return v.Value == value.Value }) {
+						directive.Values = append(directive.Values, value)
+					}
+				}
+				continue
+			}
+			directive.Overridden = append(directive.Overridden, directive.Values...)
+			directive.Values = values
+		}
+	}
+
+	config.Layers = layers
+	return config, nil
+}
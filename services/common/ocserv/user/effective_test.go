@@ -0,0 +1,125 @@
+package user_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupConfigFiles points the ocserv config files to a temporary directory and writes the given
+// files, named relative to it.
+func setupConfigFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := []*string{&utils.OcservConfigFile, &utils.DefaultGroupFile, &utils.ConfigGroupBaseDir, &utils.ConfigUserBaseDir}
+	previous := make([]string, len(paths))
+	for i, p := range paths {
+		previous[i] = *p
+	}
+	t.Cleanup(func() {
+		for i, p := range paths {
+			*p = previous[i]
+		}
+	})
+
+	utils.OcservConfigFile = filepath.Join(dir, "ocserv.conf")
+	utils.DefaultGroupFile = filepath.Join(dir, "defaults", "group.conf")
+	utils.ConfigGroupBaseDir = filepath.Join(dir, "groups") + "/"
+	utils.ConfigUserBaseDir = filepath.Join(dir, "users") + "/"
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0640))
+	}
+}
+
+// values returns the values of each directive, and the layers they come from.
+func values(config *user.EffectiveConfig) (map[string][]string, map[string][]string) {
+	values := make(map[string][]string)
+	sources := make(map[string][]string)
+	for _, d := range config.Directives {
+		for _, v := range d.Values {
+			values[d.Key] = append(values[d.Key], v.Value)
+			sources[d.Key] = append(sources[d.Key], v.Source)
+		}
+	}
+	return values, sources
+}
+
+func layerNames(config *user.EffectiveConfig) []string {
+	var names []string
+	for _, layer := range config.Layers {
+		names = append(names, layer.Name)
+	}
+	return names
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	files := map[string]string{
+		"ocserv.conf":         "tcp-port = 443\nmax-same-clients = 2\ndns = 1.1.1.1\nidle-timeout = 1200\n",
+		"defaults/group.conf": "max-same-clients = 3\nroute = 10.0.0.0/8\n",
+		"groups/vip":          "max-same-clients = 5\ndns = 8.8.8.8\n",
+		"users/alice":         "max-same-clients = 1\ndns = 1.1.1.1\n",
+	}
+
+	t.Run("group file replaces the defaults group", func(t *testing.T) {
+		setupConfigFiles(t, files)
+		config, err := user.NewOcservUser().EffectiveConfig("alice", "vip")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{user.LayerGlobal, user.LayerGroup, user.LayerUser}, layerNames(config))
+		got, sources := values(config)
+		assert.NotContains(t, got, "tcp-port", "server settings are not per user")
+		assert.NotContains(t, got, "route", "defaults/group.conf does not apply to a group with a file")
+		assert.Equal(t, []string{"1"}, got["max-same-clients"])
+		assert.Equal(t, []string{user.LayerUser}, sources["max-same-clients"])
+		assert.Equal(t, []string{"1.1.1.1", "8.8.8.8"}, got["dns"])
+		assert.Equal(t, []string{user.LayerGlobal, user.LayerGroup}, sources["dns"])
+		assert.Equal(t, []string{"1200"}, got["idle-timeout"])
+
+		for _, d := range config.Directives {
+			if d.Key == "max-same-clients" {
+				assert.Len(t, d.Overridden, 2)
+			}
+		}
+	})
+
+	t.Run("defaults group", func(t *testing.T) {
+		setupConfigFiles(t, files)
+		config, err := user.NewOcservUser().EffectiveConfig("bob", "defaults")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{user.LayerGlobal, user.LayerDefaults, user.LayerUser}, layerNames(config))
+		assert.False(t, config.Layers[2].Exists)
+		got, sources := values(config)
+		assert.Equal(t, []string{"3"}, got["max-same-clients"])
+		assert.Equal(t, []string{user.LayerDefaults}, sources["max-same-clients"])
+		assert.Equal(t, []string{"10.0.0.0/8"}, got["route"])
+	})
+
+	t.Run("group without a file", func(t *testing.T) {
+		setupConfigFiles(t, files)
+		config, err := user.NewOcservUser().EffectiveConfig("bob", "staff")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{user.LayerGlobal, user.LayerGroup, user.LayerDefaults, user.LayerUser}, layerNames(config))
+		assert.False(t, config.Layers[1].Exists)
+		assert.True(t, config.Layers[2].Exists)
+		got, _ := values(config)
+		assert.Equal(t, []string{"3"}, got["max-same-clients"])
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		setupConfigFiles(t, nil)
+		config, err := user.NewOcservUser().EffectiveConfig("bob", "defaults")
+		require.NoError(t, err)
+		assert.Empty(t, config.Directives)
+		assert.NotNil(t, config.Directives)
+	})
+}
@@ -3,4 +3,29 @@ package user
 type Ocpasswd struct {
 	Username string `json:"username"`
 	Group    string `json:"group"`
+	Locked   bool   `json:"locked"`
+}
+
+type EffectiveConfigLayer struct {
+	Name   string `json:"name" enums:"global,defaults,group,user"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+type EffectiveValue struct {
+	Value  string `json:"value"`
+	Source string `json:"source" enums:"global,defaults,group,user"` // layer the value comes from
+}
+
+type EffectiveDirective struct {
+	Key        string           `json:"key"`
+	Values     []EffectiveValue `json:"values"`
+	Overridden []EffectiveValue `json:"overridden,omitempty"` // values of earlier layers replaced by a later one
+}
+
+type EffectiveConfig struct {
+	Username   string                 `json:"username"`
+	Group      string                 `json:"group"`
+	Layers     []EffectiveConfigLayer `json:"layers"`
+	Directives []EffectiveDirective   `json:"directives"`
 }
@@ -8,10 +8,14 @@ import (
 	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
 )
 
+// ocpasswdMu serializes the writes to the ocpasswd file, by ocpasswd or by SetGroup, so that
+// a rewrite by SetGroup never drops an entry ocpasswd wrote in between.
+var ocpasswdMu sync.Mutex
+
 type OcservUser struct{}
 
 type OcservUserManagement interface {
@@ -19,11 +23,13 @@ type OcservUserManagement interface {
 	Lock(username string) (string, error)
 	UnLock(username string) (string, error)
 	Delete(username string) (string, error)
+	SetGroup(group string, usernames ...string) error
 }
 
 type OcservUserConfigManagement interface {
 	CreateConfig(username string, config *models.OcservUserConfig) error
 	DeleteConfig(username string) error
+	EffectiveConfig(username, group string) (*EffectiveConfig, error)
 }
 type OcservUserPasswords interface {
 	Ocpasswd(ctx context.Context) (*[]Ocpasswd, int, error)
@@ -40,46 +46,52 @@ func NewOcservUser() *OcservUser {
 }
 
 // Create creates a new ocserv user with the given username, group, and password.
-// It runs the ocpasswd command to register the user. If a config is provided,
-// a per-user configuration file is also written into ocserv.ConfigUserBaseDir
-// with permission 0640. Returns an error if user creation fails.
+// The config is validated and, if provided, written atomically into ocserv.ConfigUserBaseDir with
+// permission 0640 before ocpasswd runs to register the user, so that a failed write leaves no
+// ocpasswd entry behind. If ocpasswd fails, the previous config file is restored.
 func (u *OcservUser) Create(group, username, password string, config *models.OcservUserConfig) error {
-	args := []string{"-c", utils.OcpasswdPath, username}
-	if group != "" && group != "defaults" {
-		args = append([]string{"-g", group}, args...)
-	}
-	cmd := exec.Command(utils.OcpasswdExec, args...)
-
-	cmd.Stdin = bytes.NewBufferString(password + "\n" + password + "\n")
-	_, err := cmd.CombinedOutput()
-	if err != nil {
+	configMap := utils.ToMap(config)
+	if err := utils.ValidateConfig(configMap, utils.UserConfigKeys); err != nil {
 		return err
 	}
 
-	if config != nil {
-		filename := filepath.Join(utils.ConfigUserBaseDir, username)
-
-		var file *os.File
+	ocpasswdMu.Lock()
+	defer ocpasswdMu.Unlock()
 
-		// Open file with create, truncate, write-only flags and permission 0640
-		file, err = os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
-		if err != nil {
+	path := utils.UserConfigFilePathCreator(username)
+	previous, readErr := os.ReadFile(path)
+	if config != nil {
+		if err := utils.WriteConfigFile(path, configMap, utils.UserConfigKeys); err != nil {
 			return err
 		}
-		defer file.Close()
+	}
 
-		err = utils.ConfigWriter(file, utils.ToMap(config))
-		if err != nil {
-			return err
-		}
+	args := []string{"-c", utils.OcpasswdPath, username}
+	if group != "" && group != "defaults" {
+		args = append([]string{"-g", group}, args...)
 	}
+	cmd := exec.Command(utils.OcpasswdExec, args...)
 
+	cmd.Stdin = bytes.NewBufferString(password + "\n" + password + "\n")
+	if _, err := cmd.CombinedOutput(); err != nil {
+		if config != nil {
+			if readErr == nil {
+				_ = utils.WriteFileAtomic(path, previous, 0640)
+			} else {
+				_ = os.Remove(path)
+			}
+		}
+		return err
+	}
 	return nil
 }
 
 // Lock disables a user account by running ocpasswd with the -l flag.
 // Returns the command output or an error.
 func (u *OcservUser) Lock(username string) (string, error) {
+	ocpasswdMu.Lock()
+	defer ocpasswdMu.Unlock()
+
 	output, err := utils.RunOcpasswd("-l", "-c", utils.OcpasswdPath, username)
 	if err != nil {
 		return "", err
@@ -90,6 +102,9 @@ func (u *OcservUser) Lock(username string) (string, error) {
 // UnLock re-enables a previously locked user account by running ocpasswd
 // with the -u flag. Returns the command output or an error.
 func (u *OcservUser) UnLock(username string) (string, error) {
+	ocpasswdMu.Lock()
+	defer ocpasswdMu.Unlock()
+
 	output, err := utils.RunOcpasswd("-u", "-c", utils.OcpasswdPath, username)
 	if err != nil {
 		return "", err
@@ -100,6 +115,9 @@ func (u *OcservUser) UnLock(username string) (string, error) {
 // Delete removes a user account from ocserv by running ocpasswd with the -d flag.
 // Returns the command output or an error.
 func (u *OcservUser) Delete(username string) (string, error) {
+	ocpasswdMu.Lock()
+	defer ocpasswdMu.Unlock()
+
 	output, err := utils.RunOcpasswd("-d", "-c", utils.OcpasswdPath, username)
 	if err != nil {
 		return "", err
@@ -107,21 +125,51 @@ func (u *OcservUser) Delete(username string) (string, error) {
 	return output, nil
 }
 
-// CreateConfig writes a per-user configuration file for the given username.
-// The configuration is serialized from OcservUserConfig using pkg.ConfigWriter.
-// The file is created with permission 0640 and stored in the user config directory.
-func (u *OcservUser) CreateConfig(username string, config *models.OcservUserConfig) error {
-	filename := utils.UserConfigFilePathCreator(username)
-	// Open file with create, truncate, write-only flags and permission 0640
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+// SetGroup assigns the given users to group by rewriting their ocpasswd entries in place.
+// Password hashes and lock markers are kept, and "defaults" is written as "*". ocpasswd
+// itself cannot change a group without resetting the password. The file is replaced
+// atomically; usernames without an entry are ignored.
+func (u *OcservUser) SetGroup(group string, usernames ...string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+	if group == "" || group == "defaults" {
+		group = "*"
+	}
+
+	names := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		names[username] = true
+	}
+
+	ocpasswdMu.Lock()
+	defer ocpasswdMu.Unlock()
+
+	info, err := os.Stat(utils.OcpasswdPath)
 	if err != nil {
 		return err
 	}
-	err = utils.ConfigWriter(file, utils.ToMap(config))
+	content, err := os.ReadFile(utils.OcpasswdPath)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) < 3 || strings.HasPrefix(strings.TrimSpace(line), "#") || !names[parts[0]] {
+			continue
+		}
+		lines[i] = parts[0] + ":" + group + ":" + parts[2]
+	}
+	return utils.WriteFileAtomic(utils.OcpasswdPath, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
+
+// CreateConfig writes a per-user configuration file for the given username.
+// The configuration is serialized from OcservUserConfig using utils.WriteConfigFile,
+// which validates it and atomically replaces the file in the user config directory.
+func (u *OcservUser) CreateConfig(username string, config *models.OcservUserConfig) error {
+	return utils.WriteConfigFile(utils.UserConfigFilePathCreator(username), utils.ToMap(config), utils.UserConfigKeys)
 }
 
 // DeleteConfig removes the per-user configuration file for the given username.
@@ -186,6 +234,7 @@ func (u *OcservUser) Ocpasswd(ctx context.Context) (*[]Ocpasswd, int, error) {
 		users = append(users, Ocpasswd{
 			Username: username,
 			Group:    group,
+			Locked:   strings.HasPrefix(parts[2], "!"),
 		})
 
 	}
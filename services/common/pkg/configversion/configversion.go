@@ -0,0 +1,105 @@
+// Package configversion records the versions of the group, defaults group and user configs, for the
+// API and for the services that rewrite configs on their own, like the throttling of users.
+package configversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/ocservconf"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// Record stores current as the next version of the config when it differs from the latest one,
+// and returns the latest version. When the config has no history yet, previous is stored first as
+// an authorless baseline, so the state before the first tracked change can be rolled back to.
+// Empty configs without history are not recorded and yield a nil version.
+func Record(db *gorm.DB, kind, name string, previous, current interface{}, author, note string) (*models.ConfigVersion, error) {
+	configJSON, content, err := Render(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var version *models.ConfigVersion
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var latest models.ConfigVersion
+		err := tx.Where("kind = ? AND name = ?", kind, name).Order("version DESC").Limit(1).Find(&latest).Error
+		if err != nil {
+			return err
+		}
+
+		if latest.ID == 0 {
+			prevJSON, prevContent, err := Render(previous)
+			if err != nil {
+				return err
+			}
+			if prevContent != "" {
+				latest = models.ConfigVersion{
+					Kind:    kind,
+					Name:    name,
+					Version: 1,
+					Config:  prevJSON,
+					Content: prevContent,
+					Diff:    ocservconf.Diff(nil, []byte(prevContent), "/dev/null", "v1"),
+					Note:    "baseline",
+				}
+				if err = tx.Create(&latest).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if latest.ID != 0 && latest.Content == content {
+			version = &latest
+			return nil
+		}
+		if latest.ID == 0 && content == "" {
+			// nothing to track for a config that never had any directive
+			return nil
+		}
+
+		version = &models.ConfigVersion{
+			Kind:    kind,
+			Name:    name,
+			Version: latest.Version + 1,
+			Config:  configJSON,
+			Content: content,
+			Diff: ocservconf.Diff(
+				[]byte(latest.Content), []byte(content),
+				fmt.Sprintf("v%d", latest.Version), fmt.Sprintf("v%d", latest.Version+1),
+			),
+			Author: author,
+			Note:   note,
+		}
+		return tx.Create(version).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// RecordChange records a change already applied to the config files. A failure is only logged,
+// since the change itself cannot be undone.
+func RecordChange(db *gorm.DB, kind, name string, previous, current interface{}, author, note string) {
+	if _, err := Record(db, kind, name, previous, current, author, note); err != nil {
+		logger.Error("Failed to record %s %s config version: %v", kind, name, err)
+	}
+}
+
+// Render returns the JSON of the config model and the config file written from it.
+func Render(config interface{}) (string, string, error) {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	if err = utils.ConfigWriter(&buf, utils.ToMap(config)); err != nil {
+		return "", "", err
+	}
+	return string(configJSON), buf.String(), nil
+}
@@ -0,0 +1,75 @@
+package configversion_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/configversion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+)
+
+func setupDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.ConfigVersion{}))
+	return db
+}
+
+func rate(n int) *models.OcservUserConfig {
+	return &models.OcservUserConfig{RxDataPerSec: &n}
+}
+
+func TestRecord(t *testing.T) {
+	db := setupDB(t)
+
+	version, err := configversion.Record(db, models.ConfigKindUser, "alice", nil, &models.OcservUserConfig{}, "admin", "")
+	require.NoError(t, err)
+	assert.Nil(t, version, "empty config without history")
+
+	version, err = configversion.Record(db, models.ConfigKindUser, "alice", rate(100), rate(200), "admin", "faster")
+	require.NoError(t, err)
+	require.NotNil(t, version)
+	assert.Equal(t, 2, version.Version)
+	assert.Equal(t, "admin", version.Author)
+	assert.Equal(t, "faster", version.Note)
+	assert.Contains(t, version.Diff, "-rx-data-per-sec=100")
+	assert.Contains(t, version.Diff, "+rx-data-per-sec=200")
+
+	var baseline models.ConfigVersion
+	require.NoError(t, db.Where("name = ? AND version = 1", "alice").First(&baseline).Error)
+	assert.Equal(t, "baseline", baseline.Note)
+	assert.Empty(t, baseline.Author)
+	assert.Contains(t, baseline.Content, "rx-data-per-sec=100")
+
+	unchanged, err := configversion.Record(db, models.ConfigKindUser, "alice", rate(200), rate(200), "admin", "")
+	require.NoError(t, err)
+	assert.Equal(t, version.ID, unchanged.ID, "unchanged config is not recorded again")
+
+	version, err = configversion.Record(db, models.ConfigKindUser, "alice", rate(200), rate(50), "", "throttled over quota")
+	require.NoError(t, err)
+	assert.Equal(t, 3, version.Version)
+
+	other, err := configversion.Record(db, models.ConfigKindGroup, "alice", nil, rate(10), "admin", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.Version, "kinds have separate histories")
+
+	var count int64
+	require.NoError(t, db.Model(&models.ConfigVersion{}).Count(&count).Error)
+	assert.EqualValues(t, 4, count)
+}
+
+func TestRecordChangeLogsFailure(t *testing.T) {
+	db := setupDB(t)
+	require.NoError(t, db.Migrator().DropTable(&models.ConfigVersion{}))
+
+	assert.NotPanics(t, func() {
+		configversion.RecordChange(db, models.ConfigKindUser, "alice", nil, rate(100), "admin", "")
+	})
+}
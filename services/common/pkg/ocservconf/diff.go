@@ -0,0 +1,21 @@
+package ocservconf
+
+import (
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff returns the unified diff between two versions of a config file, or an empty
+// string when they are identical.
+func Diff(from, to []byte, fromName, toName string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(from)),
+		B:        difflib.SplitLines(string(to)),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	return diff
+}
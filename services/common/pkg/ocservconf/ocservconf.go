@@ -0,0 +1,251 @@
+// Package ocservconf parses ocserv config files into an ordered document that keeps
+// comments, blank lines, unknown directives and the original formatting, so that
+// targeted updates of known keys leave the rest of a hand-edited file untouched.
+package ocservconf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// line is one line of the document without its newline. For directives, key and value
+// hold the trimmed parts and valueStart/valueEnd the position of the value in raw,
+// so that an update keeps the spacing around it.
+type line struct {
+	raw        string
+	key        string
+	value      string
+	valueStart int
+	valueEnd   int
+}
+
+func (l *line) isDirective() bool {
+	return l.key != ""
+}
+
+func (l *line) setValue(value string) {
+	l.raw = l.raw[:l.valueStart] + value + l.raw[l.valueEnd:]
+	l.valueEnd = l.valueStart + len(value)
+	l.value = value
+}
+
+// Document is a parsed ocserv config file. Serializing an unchanged document returns
+// the exact bytes it was parsed from.
+type Document struct {
+	lines []line
+	// crlf is set when the file ends its lines with \r\n, which inserted lines reuse.
+	crlf bool
+}
+
+// Parse reads an ocserv config from r.
+func Parse(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBytes(data), nil
+}
+
+// ParseBytes parses an ocserv config held in memory.
+func ParseBytes(data []byte) *Document {
+	rawLines := strings.Split(string(data), "\n")
+	doc := &Document{
+		lines: make([]line, 0, len(rawLines)),
+		crlf:  len(rawLines) > 1 && strings.HasSuffix(rawLines[0], "\r"),
+	}
+	for _, raw := range rawLines {
+		doc.lines = append(doc.lines, parseLine(raw))
+	}
+	return doc
+}
+
+// ParseFile parses the ocserv config file at path. A missing file yields an empty document.
+func ParseFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ParseBytes(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseBytes(data), nil
+}
+
+func parseLine(raw string) line {
+	l := line{raw: raw}
+
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return l
+	}
+
+	eq := strings.Index(raw, "=")
+	if eq < 0 {
+		return l
+	}
+	key := strings.TrimSpace(raw[:eq])
+	if key == "" {
+		return l
+	}
+
+	rest := raw[eq+1:]
+	value := strings.TrimSpace(rest)
+	start := eq + 1 + (len(rest) - len(strings.TrimLeftFunc(rest, unicode.IsSpace)))
+	if value == "" {
+		start = eq + 1
+	}
+
+	l.key = key
+	l.value = value
+	l.valueStart = start
+	l.valueEnd = start + len(value)
+	return l
+}
+
+// Bytes serializes the document.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, l := range d.lines {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(l.raw)
+	}
+	return buf.Bytes()
+}
+
+// WriteTo writes the serialized document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(d.Bytes())
+	return int64(n), err
+}
+
+// Keys returns the directive keys in order of first appearance.
+func (d *Document) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, l := range d.lines {
+		if l.isDirective() && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Get returns the value of the first occurrence of key.
+func (d *Document) Get(key string) (string, bool) {
+	for _, l := range d.lines {
+		if l.key == key {
+			return l.value, true
+		}
+	}
+	return "", false
+}
+
+// GetAll returns the values of every occurrence of key, in order.
+func (d *Document) GetAll(key string) []string {
+	var values []string
+	for _, l := range d.lines {
+		if l.key == key {
+			values = append(values, l.value)
+		}
+	}
+	return values
+}
+
+// Set sets a single-valued key. The first occurrence is updated in place and
+// further occurrences are removed; a missing key is appended.
+func (d *Document) Set(key, value string) {
+	d.SetAll(key, []string{value})
+}
+
+// SetAll sets the values of a multi-valued key. Existing occurrences are updated in
+// place, surplus occurrences are removed and extra values are inserted after the last
+// occurrence, or appended when the key is missing.
+func (d *Document) SetAll(key string, values []string) {
+	positions := d.positions(key)
+
+	for i := 0; i < len(positions) && i < len(values); i++ {
+		d.lines[positions[i]].setValue(values[i])
+	}
+
+	if len(values) < len(positions) {
+		d.remove(positions[len(values):])
+		return
+	}
+
+	extra := values[len(positions):]
+	if len(extra) == 0 {
+		return
+	}
+
+	at := d.appendPosition()
+	if len(positions) > 0 {
+		at = positions[len(positions)-1] + 1
+	}
+
+	cr := ""
+	if d.crlf {
+		cr = "\r"
+	}
+	inserted := make([]line, 0, len(extra))
+	for _, value := range extra {
+		inserted = append(inserted, parseLine(key+"="+value+cr))
+	}
+	if d.crlf && at == len(d.lines) {
+		// appended after a last line without newline: that line gets one and the new
+		// last line goes without
+		if previous := &d.lines[at-1]; !strings.HasSuffix(previous.raw, cr) {
+			previous.raw += cr
+		}
+		last := &inserted[len(inserted)-1]
+		last.raw = strings.TrimSuffix(last.raw, cr)
+	}
+	d.lines = append(d.lines[:at], append(inserted, d.lines[at:]...)...)
+}
+
+// Delete removes every occurrence of key.
+func (d *Document) Delete(key string) {
+	d.remove(d.positions(key))
+}
+
+func (d *Document) positions(key string) []int {
+	var positions []int
+	for i, l := range d.lines {
+		if l.key == key {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// remove drops the lines at the given ascending positions.
+func (d *Document) remove(positions []int) {
+	if len(positions) == 0 {
+		return
+	}
+	lines := d.lines[:0]
+	next := 0
+	for i, l := range d.lines {
+		if next < len(positions) && positions[next] == i {
+			next++
+			continue
+		}
+		lines = append(lines, l)
+	}
+	d.lines = lines
+}
+
+// appendPosition returns where new lines are appended: before the empty last
+// line that stands for the trailing newline of the file, if there is one.
+func (d *Document) appendPosition() int {
+	n := len(d.lines)
+	if n > 0 && d.lines[n-1].raw == "" {
+		return n - 1
+	}
+	return n
+}
@@ -0,0 +1,125 @@
+package ocservconf_test
+
+import (
+	"flag"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/ocservconf"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func parseTestdata(t *testing.T, name string) (*ocservconf.Document, []byte) {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ocservconf.ParseBytes(data), data
+}
+
+func TestRoundTripIsByteIdentical(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, files)
+
+	for _, file := range files {
+		doc, data := parseTestdata(t, filepath.Base(file))
+		assert.Equal(t, string(data), string(doc.Bytes()), file)
+	}
+}
+
+func TestGet(t *testing.T) {
+	doc, _ := parseTestdata(t, "group.conf")
+
+	value, ok := doc.Get("tx-data-per-sec")
+	assert.True(t, ok)
+	assert.Equal(t, "2000000", value)
+
+	_, ok = doc.Get("mtu")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"8.8.8.8", "1.1.1.1"}, doc.GetAll("dns"))
+	assert.Equal(t, `"tcp(443), udp(53)"`, doc.GetAll("restrict-user-to-ports")[0])
+	assert.Equal(t, []string{
+		"dns", "route", "no-route", "ipv4-network", "rx-data-per-sec", "tx-data-per-sec",
+		"hostname", "restrict-user-to-ports",
+	}, doc.Keys())
+}
+
+func TestCRLFInsertedLines(t *testing.T) {
+	doc := ocservconf.ParseBytes([]byte("dns=8.8.8.8\r\n# comment\r\n"))
+	doc.SetAll("dns", []string{"1.1.1.1", "9.9.9.9"})
+	doc.SetAll("route", []string{"10.0.0.0/8", "default"})
+	assert.Equal(t, "dns=1.1.1.1\r\ndns=9.9.9.9\r\n# comment\r\nroute=10.0.0.0/8\r\nroute=default\r\n", string(doc.Bytes()))
+
+	// LF files stay LF
+	doc = ocservconf.ParseBytes([]byte("dns=8.8.8.8\n"))
+	doc.Set("mtu", "1400")
+	assert.Equal(t, "dns=8.8.8.8\nmtu=1400\n", string(doc.Bytes()))
+}
+
+func TestUpdateGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(doc *ocservconf.Document)
+	}{
+		{
+			name: "group.conf",
+			update: func(doc *ocservconf.Document) {
+				doc.SetAll("dns", []string{"9.9.9.9"})
+				doc.SetAll("route", []string{"10.10.0.0/16", "192.168.10.0/255.255.255.0", "10.20.0.0/16"})
+				doc.Set("rx-data-per-sec", "4000000")
+				doc.Set("tx-data-per-sec", "4000000")
+				doc.Delete("no-route")
+				doc.Set("mtu", "1400")
+			},
+		},
+		{
+			name: "crlf.conf",
+			update: func(doc *ocservconf.Document) {
+				doc.Set("idle-timeout", "900")
+				doc.Set("dns", "9.9.9.9")
+				doc.Set("mtu", "1400")
+			},
+		},
+		{
+			name: "empty.conf",
+			update: func(doc *ocservconf.Document) {
+				doc.Set("dns", "9.9.9.9")
+				doc.SetAll("route", []string{"10.0.0.0/8", "default"})
+			},
+		},
+		{
+			name: "defaults.conf",
+			update: func(doc *ocservconf.Document) {
+				doc.Delete("no-udp")
+				doc.Set("keepalive", "300")
+				doc.Delete("not-present")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, _ := parseTestdata(t, tt.name)
+			tt.update(doc)
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, doc.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, string(want), string(doc.Bytes()))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Built-in deliveries of scheduled reports.
+const (
+	DeliveryStore   = "store"
+	DeliveryWebhook = "webhook"
+)
+
+// Delivery sends a generated report to the target of its schedule. Reports are stored for download
+// before they are delivered, so a delivery only needs to send them elsewhere.
+type Delivery interface {
+	Deliver(ctx context.Context, schedule *models.ReportSchedule, report *models.UsageReport) error
+}
+
+// The deliveries are the same in every service; which of them are enabled is read from the
+// environment shared by the api, which validates the schedules, and the user expiry service, which
+// delivers the reports.
+var deliveries = map[string]Delivery{
+	DeliveryStore: storeDelivery{},
+	DeliveryWebhook: webhookDelivery{client: &http.Client{
+		Timeout: 30 * time.Second,
+		// a redirect could lead the report to a host that is not allowed
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}},
+}
+
+// GetDelivery returns the delivery enabled under name.
+func GetDelivery(name string) (Delivery, bool) {
+	if !slices.Contains(Deliveries(), name) {
+		return nil, false
+	}
+	delivery, ok := deliveries[name]
+	return delivery, ok
+}
+
+// Deliveries returns the sorted names of the enabled deliveries: store, and webhook once
+// REPORT_WEBHOOK_HOSTS allows hosts to post reports to.
+func Deliveries() []string {
+	names := []string{DeliveryStore}
+	if len(webhookHosts()) > 0 {
+		names = append(names, DeliveryWebhook)
+	}
+	return names
+}
+
+// ValidateTarget checks the target of a schedule delivered with delivery. Webhook targets must be
+// http or https URLs on a host of REPORT_WEBHOOK_HOSTS.
+func ValidateTarget(delivery, target string) error {
+	if delivery != DeliveryWebhook {
+		return nil
+	}
+	if target == "" {
+		return errors.New("target is required for webhook delivery")
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("invalid webhook target %q", target)
+	}
+	if !slices.Contains(webhookHosts(), strings.ToLower(u.Hostname())) {
+		return fmt.Errorf("webhook target host %s is not allowed by REPORT_WEBHOOK_HOSTS", u.Hostname())
+	}
+	return nil
+}
+
+// webhookHosts returns the hosts of REPORT_WEBHOOK_HOSTS, a comma-separated list.
+func webhookHosts() []string {
+	var hosts []string
+	for _, host := range strings.Split(os.Getenv("REPORT_WEBHOOK_HOSTS"), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// storeDelivery only keeps the stored report for download.
+type storeDelivery struct{}
+
+func (storeDelivery) Deliver(context.Context, *models.ReportSchedule, *models.UsageReport) error {
+	return nil
+}
+
+// webhookDelivery posts the report file to the target URL of the schedule. The target is checked
+// again before posting, as the allowed hosts may have changed since the schedule was saved.
+type webhookDelivery struct {
+	client *http.Client
+}
+
+func (d webhookDelivery) Deliver(ctx context.Context, schedule *models.ReportSchedule, report *models.UsageReport) error {
+	if err := ValidateTarget(DeliveryWebhook, schedule.Target); err != nil {
+		return fmt.Errorf("report schedule %s: %w", schedule.Name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, schedule.Target, bytes.NewReader(report.Content))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", ContentType(report.Format))
+	req.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.Filename))
+	req.Header.Set("X-Report-Schedule", schedule.Name)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("call report webhook: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("report webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
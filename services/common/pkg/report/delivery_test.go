@@ -0,0 +1,102 @@
+package report_test
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeliveries(t *testing.T) {
+	t.Setenv("REPORT_WEBHOOK_HOSTS", "")
+	assert.Equal(t, []string{report.DeliveryStore}, report.Deliveries())
+	_, ok := report.GetDelivery(report.DeliveryWebhook)
+	assert.False(t, ok)
+
+	t.Setenv("REPORT_WEBHOOK_HOSTS", " reports.example.com, ")
+	assert.Equal(t, []string{report.DeliveryStore, report.DeliveryWebhook}, report.Deliveries())
+	_, ok = report.GetDelivery(report.DeliveryWebhook)
+	assert.True(t, ok)
+	_, ok = report.GetDelivery("email")
+	assert.False(t, ok)
+}
+
+func TestValidateTarget(t *testing.T) {
+	t.Setenv("REPORT_WEBHOOK_HOSTS", "reports.example.com,10.0.0.5")
+
+	tests := []struct {
+		name     string
+		delivery string
+		target   string
+		err      string
+	}{
+		{name: "store ignores the target", delivery: report.DeliveryStore, target: "anything"},
+		{name: "allowed host", delivery: report.DeliveryWebhook, target: "https://reports.example.com/hook"},
+		{name: "allowed host in another case", delivery: report.DeliveryWebhook, target: "https://Reports.Example.com:8443/hook"},
+		{name: "allowed address", delivery: report.DeliveryWebhook, target: "http://10.0.0.5/hook"},
+		{name: "missing target", delivery: report.DeliveryWebhook, err: "target is required for webhook delivery"},
+		{
+			name:     "other host",
+			delivery: report.DeliveryWebhook,
+			target:   "http://169.254.169.254/latest/meta-data",
+			err:      "webhook target host 169.254.169.254 is not allowed by REPORT_WEBHOOK_HOSTS",
+		},
+		{
+			name:     "subdomain of an allowed host",
+			delivery: report.DeliveryWebhook,
+			target:   "https://evil.reports.example.com/hook",
+			err:      "webhook target host evil.reports.example.com is not allowed by REPORT_WEBHOOK_HOSTS",
+		},
+		{
+			name:     "other scheme",
+			delivery: report.DeliveryWebhook,
+			target:   "file://reports.example.com/etc/passwd",
+			err:      `invalid webhook target "file://reports.example.com/etc/passwd"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := report.ValidateTarget(tt.delivery, tt.target)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+			return
+		}
+		assert.Equal(t, "text/csv", r.Header.Get("Content-Type"))
+		assert.Equal(t, "weekly", r.Header.Get("X-Report-Schedule"))
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	usageReport := &models.UsageReport{Format: models.ReportFormatCSV, Filename: "usage-report.csv", Content: []byte("username\n")}
+	schedule := &models.ReportSchedule{Name: "weekly", Delivery: report.DeliveryWebhook, Target: server.URL + "/hook"}
+
+	t.Setenv("REPORT_WEBHOOK_HOSTS", "127.0.0.1")
+	delivery, ok := report.GetDelivery(report.DeliveryWebhook)
+	require.True(t, ok)
+	require.NoError(t, delivery.Deliver(context.Background(), schedule, usageReport))
+	assert.Equal(t, "username\n", string(body))
+
+	schedule.Target = server.URL + "/redirect"
+	assert.EqualError(t, delivery.Deliver(context.Background(), schedule, usageReport), "report webhook returned status 302")
+
+	t.Setenv("REPORT_WEBHOOK_HOSTS", "reports.example.com")
+	schedule.Target = server.URL + "/hook"
+	assert.ErrorContains(t, delivery.Deliver(context.Background(), schedule, usageReport), "is not allowed by REPORT_WEBHOOK_HOSTS")
+}
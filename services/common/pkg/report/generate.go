@@ -0,0 +1,63 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Build collects and writes the report of owner over the range, without storing it.
+func Build(ctx context.Context, db *gorm.DB, owner, format string, dateStart, dateEnd time.Time) (*models.UsageReport, error) {
+	data, err := Collect(ctx, db, owner, dateStart, dateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = Write(&buf, format, data); err != nil {
+		return nil, err
+	}
+
+	return &models.UsageReport{
+		Owner:     owner,
+		Format:    format,
+		DateStart: data.DateStart,
+		DateEnd:   data.DateEnd,
+		Filename:  Filename(data, format),
+		Size:      buf.Len(),
+		Content:   buf.Bytes(),
+	}, nil
+}
+
+// Generate builds the report of the schedule for the period before at, stores it and delivers it. A
+// failed delivery is recorded on the stored report rather than returned.
+func Generate(ctx context.Context, db *gorm.DB, schedule *models.ReportSchedule, at time.Time) (*models.UsageReport, error) {
+	dateStart, dateEnd := schedule.Range(at)
+
+	report, err := Build(ctx, db, schedule.Owner, schedule.Format, dateStart, dateEnd)
+	if err != nil {
+		return nil, err
+	}
+	report.ScheduleID = &schedule.ID
+
+	if err = db.WithContext(ctx).Create(report).Error; err != nil {
+		return nil, err
+	}
+
+	delivery, ok := GetDelivery(schedule.Delivery)
+	if !ok {
+		err = fmt.Errorf("report delivery %q is not enabled", schedule.Delivery)
+	} else {
+		err = delivery.Deliver(ctx, schedule, report)
+	}
+	if err != nil {
+		report.DeliveryError = err.Error()
+		if err = db.WithContext(ctx).Model(report).Update("delivery_error", report.DeliveryError).Error; err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"gorm.io/gorm"
+	"io"
+	"time"
+)
+
+// UserTraffic is the traffic of a user over the range of a report, in GiB.
+type UserTraffic struct {
+	Username string
+	Owner    string
+	Group    string
+	Rx       float64
+	Tx       float64
+}
+
+// Data is the content of a usage report: the traffic of every user with traffic in the range, the
+// daily traffic of all of them and its totals. The range is cut in UTC days and its end is exclusive.
+type Data struct {
+	Owner     string
+	DateStart time.Time
+	DateEnd   time.Time
+	Users     []UserTraffic
+	Daily     []models.DailyTraffic
+	Rx        float64
+	Tx        float64
+}
+
+// Collect reads the report data from the daily rollup. An empty owner covers every user, otherwise
+// only the users the owner owns.
+func Collect(ctx context.Context, db *gorm.DB, owner string, dateStart, dateEnd time.Time) (*Data, error) {
+	data := &Data{Owner: owner, DateStart: dateStart.UTC(), DateEnd: dateEnd.UTC()}
+
+	query := func() *gorm.DB {
+		q := db.WithContext(ctx).
+			Table(models.OcservUserTrafficDaily{}.TableName()+" AS t").
+			Joins("JOIN ocserv_users ou ON ou.id = t.oc_user_id").
+			Where("t.start >= ? AND t.start < ?", data.DateStart, data.DateEnd)
+		if owner != "" {
+			q = q.Where(`ou.id IN (
+				SELECT ocserv_user_owners.ocserv_user_id FROM ocserv_user_owners
+				JOIN users ON users.id = ocserv_user_owners.user_id
+				WHERE users.username = ?)`, owner)
+		}
+		return q
+	}
+
+	err := query().
+		Select("ou.username AS username, ou.owner AS owner, ou.`group` AS `group`, " +
+			"SUM(t.rx) / 1073741824.0 AS rx, SUM(t.tx) / 1073741824.0 AS tx").
+		Group("ou.id, ou.username, ou.owner, ou.`group`").
+		Order("SUM(t.rx) + SUM(t.tx) DESC, ou.username").
+		Scan(&data.Users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var days []struct {
+		Start time.Time
+		Rx    int64
+		Tx    int64
+	}
+	err = query().
+		Select("t.start AS start, SUM(t.rx) AS rx, SUM(t.tx) AS tx").
+		Group("t.start").
+		Order("t.start").
+		Scan(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	data.Daily = make([]models.DailyTraffic, 0, len(days))
+	for _, day := range days {
+		traffic := models.DailyTraffic{
+			Date: models.BucketLabel(day.Start.UTC(), models.GranularityDay),
+			Rx:   float64(day.Rx) / (1 << 30),
+			Tx:   float64(day.Tx) / (1 << 30),
+		}
+		data.Daily = append(data.Daily, traffic)
+		data.Rx += traffic.Rx
+		data.Tx += traffic.Tx
+	}
+	return data, nil
+}
+
+// Write writes the report in the format, one of models.ReportFormatCSV, ReportFormatXLSX and ReportFormatPDF.
+func Write(w io.Writer, format string, data *Data) error {
+	switch format {
+	case models.ReportFormatCSV:
+		return writeCSV(w, data)
+	case models.ReportFormatXLSX:
+		return writeXLSX(w, data)
+	case models.ReportFormatPDF:
+		return writePDF(w, data)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func ContentType(format string) string {
+	switch format {
+	case models.ReportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case models.ReportFormatPDF:
+		return "application/pdf"
+	default:
+		return "text/csv"
+	}
+}
+
+// Filename returns the download name of the report, naming its owner and the days it covers.
+func Filename(data *Data, format string) string {
+	name := "usage-report"
+	if data.Owner != "" {
+		name += "-" + data.Owner
+	}
+	last := data.DateEnd.AddDate(0, 0, -1)
+	return fmt.Sprintf("%s-%s-%s.%s", name, data.DateStart.Format("20060102"), last.Format("20060102"), format)
+}
@@ -0,0 +1,150 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+func setupDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.OcservUser{}, &models.OcservUserTrafficDaily{}))
+	// owned by the api service
+	require.NoError(t, db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, username TEXT)").Error)
+	require.NoError(t, db.Exec("CREATE TABLE ocserv_user_owners (ocserv_user_id INTEGER, user_id INTEGER)").Error)
+	return db
+}
+
+const gib = 1 << 30
+
+func TestCollect(t *testing.T) {
+	db := setupDB(t)
+	day := func(d int) time.Time {
+		return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	for i, username := range []string{"alice", "bob", "carol"} {
+		require.NoError(t, db.Create(&models.OcservUser{
+			ID: uint(i + 1), UID: username, Username: username, Password: "secret", Owner: "admin", Group: "defaults",
+			TrafficType: models.Free,
+		}).Error)
+	}
+	require.NoError(t, db.Exec("INSERT INTO users (id, username) VALUES (1, 'staff')").Error)
+	require.NoError(t, db.Exec("INSERT INTO ocserv_user_owners (ocserv_user_id, user_id) VALUES (2, 1)").Error)
+
+	for _, b := range []models.TrafficBucket{
+		{OcUserID: 1, Start: day(1), Rx: 1 * gib, Tx: 1 * gib},
+		{OcUserID: 1, NodeID: 1, Start: day(1), Rx: 1 * gib},
+		{OcUserID: 2, Start: day(2), Rx: 4 * gib, Tx: 2 * gib},
+		// outside of the range
+		{OcUserID: 1, Start: day(3), Rx: 8 * gib},
+		{OcUserID: 3, Start: day(3), Rx: 8 * gib},
+	} {
+		require.NoError(t, db.Create(&models.OcservUserTrafficDaily{TrafficBucket: b}).Error)
+	}
+
+	data, err := report.Collect(context.Background(), db, "", day(1), day(3))
+	require.NoError(t, err)
+	assert.Equal(t, []report.UserTraffic{
+		{Username: "bob", Owner: "admin", Group: "defaults", Rx: 4, Tx: 2},
+		{Username: "alice", Owner: "admin", Group: "defaults", Rx: 2, Tx: 1},
+	}, data.Users)
+	assert.Equal(t, []models.DailyTraffic{
+		{Date: "2025-01-01", Rx: 2, Tx: 1},
+		{Date: "2025-01-02", Rx: 4, Tx: 2},
+	}, data.Daily)
+	assert.Equal(t, 6.0, data.Rx)
+	assert.Equal(t, 3.0, data.Tx)
+
+	data, err = report.Collect(context.Background(), db, "staff", day(1), day(3))
+	require.NoError(t, err)
+	require.Len(t, data.Users, 1)
+	assert.Equal(t, "bob", data.Users[0].Username)
+	assert.Equal(t, 4.0, data.Rx)
+
+	data, err = report.Collect(context.Background(), db, "", day(10), day(11))
+	require.NoError(t, err)
+	assert.Empty(t, data.Users)
+	assert.Empty(t, data.Daily)
+}
+
+func reportData() *report.Data {
+	return &report.Data{
+		DateStart: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		DateEnd:   time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC),
+		Users: []report.UserTraffic{
+			{Username: "alice", Owner: "admin", Group: "defaults", Rx: 1.5, Tx: 0.25},
+			{Username: "bob", Owner: "=HYPERLINK(\"http://example.com\")", Group: "@vip", Rx: 1, Tx: 0},
+		},
+		Daily: []models.DailyTraffic{{Date: "2025-01-01", Rx: 2.5, Tx: 0.25}},
+		Rx:    2.5,
+		Tx:    0.25,
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf, models.ReportFormatCSV, reportData()))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"username", "owner", "group", "rx_gib", "tx_gib", "total_gib"},
+		{"alice", "admin", "defaults", "1.500", "0.250", "1.750"},
+		{"bob", "'=HYPERLINK(\"http://example.com\")", "'@vip", "1.000", "0.000", "1.000"},
+		{"TOTAL", "", "", "2.500", "0.250", "2.750"},
+	}, records)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf, models.ReportFormatXLSX, reportData()))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	users, err := f.GetRows("Users")
+	require.NoError(t, err)
+	require.Len(t, users, 4)
+	assert.Equal(t, []string{"bob", "'=HYPERLINK(\"http://example.com\")", "'@vip", "1", "0", "1"}, users[2])
+	assert.Equal(t, "TOTAL", users[3][0])
+
+	daily, err := f.GetRows("Daily")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"date", "rx_gib", "tx_gib", "total_gib"}, {"2025-01-01", "2.5", "0.25", "2.75"}}, daily)
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf, models.ReportFormatPDF, reportData()))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF")))
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	assert.EqualError(t, report.Write(&bytes.Buffer{}, "doc", reportData()), `unsupported report format "doc"`)
+}
+
+func TestFilename(t *testing.T) {
+	data := reportData()
+	assert.Equal(t, "usage-report-20250101-20250102.csv", report.Filename(data, models.ReportFormatCSV))
+	data.Owner = "staff"
+	assert.Equal(t, "usage-report-staff-20250101-20250102.pdf", report.Filename(data, models.ReportFormatPDF))
+}
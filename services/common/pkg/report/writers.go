@@ -0,0 +1,163 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strconv"
+)
+
+var (
+	usersHeader = []string{"username", "owner", "group", "rx_gib", "tx_gib", "total_gib"}
+	dailyHeader = []string{"date", "rx_gib", "tx_gib", "total_gib"}
+)
+
+func gib(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
+
+func userRecord(u UserTraffic) []string {
+	return []string{u.Username, u.Owner, u.Group, gib(u.Rx), gib(u.Tx), gib(u.Rx + u.Tx)}
+}
+
+func totalRecord(data *Data) []string {
+	return []string{"TOTAL", "", "", gib(data.Rx), gib(data.Tx), gib(data.Rx + data.Tx)}
+}
+
+// writeCSV writes the traffic of the users followed by a total row. Names are escaped against formulas.
+func writeCSV(w io.Writer, data *Data) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(usersHeader); err != nil {
+		return err
+	}
+	for _, u := range data.Users {
+		record := userRecord(u)
+		for i, value := range record {
+			record[i] = utils.EscapeFormula(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := writer.Write(totalRecord(data)); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeXLSX writes a Users sheet as in writeCSV and a Daily sheet with the traffic of every day.
+func writeXLSX(w io.Writer, data *Data) error {
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if err := f.SetSheetName("Sheet1", "Users"); err != nil {
+		return err
+	}
+	if _, err := f.NewSheet("Daily"); err != nil {
+		return err
+	}
+
+	users := [][]interface{}{toCells(usersHeader)}
+	for _, u := range data.Users {
+		users = append(users, []interface{}{
+			utils.EscapeFormula(u.Username), utils.EscapeFormula(u.Owner), utils.EscapeFormula(u.Group), u.Rx, u.Tx, u.Rx + u.Tx,
+		})
+	}
+	users = append(users, []interface{}{"TOTAL", "", "", data.Rx, data.Tx, data.Rx + data.Tx})
+
+	daily := [][]interface{}{toCells(dailyHeader)}
+	for _, d := range data.Daily {
+		daily = append(daily, []interface{}{d.Date, d.Rx, d.Tx, d.Rx + d.Tx})
+	}
+
+	for sheet, rows := range map[string][][]interface{}{"Users": users, "Daily": daily} {
+		sw, err := f.NewStreamWriter(sheet)
+		if err != nil {
+			return err
+		}
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err = sw.SetRow(cell, row); err != nil {
+				return err
+			}
+		}
+		if err = sw.Flush(); err != nil {
+			return err
+		}
+	}
+	return f.Write(w)
+}
+
+func toCells(values []string) []interface{} {
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = v
+	}
+	return row
+}
+
+// writePDF writes a summary of the totals followed by the daily and per-user tables.
+func writePDF(w io.Writer, data *Data) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle("Usage report", true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Usage report", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	scope := "All users"
+	if data.Owner != "" {
+		scope = "Users of " + data.Owner
+	}
+	pdf.CellFormat(0, 6, tr(scope), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("%s to %s (UTC)",
+		data.DateStart.Format("2006-01-02"), data.DateEnd.AddDate(0, 0, -1).Format("2006-01-02")), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Total: %s GiB received, %s GiB sent, %d users",
+		gib(data.Rx), gib(data.Tx), len(data.Users)), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	table := func(title string, header []string, widths []float64, rows [][]string) {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", 9)
+		for i, h := range header {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+		for _, row := range rows {
+			for i, v := range row {
+				align := "R"
+				if i < len(row)-3 {
+					align = "L"
+				}
+				pdf.CellFormat(widths[i], 6, tr(v), "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.Ln(4)
+	}
+
+	daily := make([][]string, 0, len(data.Daily))
+	for _, d := range data.Daily {
+		daily = append(daily, []string{d.Date, gib(d.Rx), gib(d.Tx), gib(d.Rx + d.Tx)})
+	}
+	table("Daily traffic", dailyHeader, []float64{40, 35, 35, 35}, daily)
+
+	users := make([][]string, 0, len(data.Users)+1)
+	for _, u := range data.Users {
+		users = append(users, userRecord(u))
+	}
+	users = append(users, totalRecord(data))
+	table("Users", usersHeader, []float64{40, 30, 30, 30, 30, 30}, users)
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/ocservconf"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// UserConfigKeys are the directives allowed in a per-user config file.
+	UserConfigKeys = configKeys(models.OcservUserConfig{})
+
+	// GroupConfigKeys are the directives allowed in a group or the defaults group config file.
+	GroupConfigKeys = configKeys(models.OcservGroupConfig{})
+
+	// ServerConfigKeys are the directives of the main ocserv.conf managed by the dashboard.
+	ServerConfigKeys = configKeys(models.OcservServerConfig{})
+)
+
+// legacyKeys are directives the dashboard used to write under a name ocserv does not read.
+// They are removed whenever a config file is rewritten.
+var legacyKeys = []string{"restrict-to-routes", "restrict-to-ports"}
+
+var (
+	authRegex     = regexp.MustCompile(`^(plain|certificate|pam|radius|gssapi|oidc)(\[.+\])?$`)
+	hostnameRegex = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,62})$`)
+	portRuleRegex = regexp.MustCompile(`^(tcp|udp|sctp)\((\d{1,5})\)$|^(icmp|icmpv6)\(\)$`)
+	domainRegex   = regexp.MustCompile(`^(?i)[a-z0-9_]([a-z0-9_-]{0,62}\.)*[a-z0-9_-]{1,63}\.?$`)
+)
+
+// configKeys returns the json tag names of the config struct fields.
+func configKeys(config interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(config)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// WriteConfigFile validates the config against the allowed keys and atomically replaces the
+// file at path. An existing file is updated in place through ocservconf: allowed keys are set
+// from the config or removed when empty, while comments, ordering and other directives are kept.
+// The content is written to a temp file in the same directory, synced and renamed over the
+// target, so ocserv never reads a truncated or partially written file.
+func WriteConfigFile(path string, config map[string]interface{}, allowed map[string]bool) error {
+	if err := ValidateConfig(config, allowed); err != nil {
+		return err
+	}
+
+	doc, err := ocservconf.ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(allowed))
+	for key := range allowed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if values := configValues(config[key]); len(values) > 0 {
+			doc.SetAll(key, values)
+		} else {
+			doc.Delete(key)
+		}
+	}
+	for _, key := range legacyKeys {
+		doc.Delete(key)
+	}
+	return WriteFileAtomic(path, doc.Bytes(), 0640)
+}
+
+// ConfigMatches reports whether the allowed keys of the config file at path hold the values of
+// config. Other directives and comments are ignored; a missing file matches an empty config.
+func ConfigMatches(path string, config map[string]interface{}, allowed map[string]bool) (bool, error) {
+	doc, err := ocservconf.ParseFile(path)
+	if err != nil {
+		return false, err
+	}
+	for key := range allowed {
+		if !slices.Equal(doc.GetAll(key), configValues(config[key])) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// WriteFileAtomic writes data to a temp file next to path and renames it over path once synced.
+// The temp file is removed if any step fails.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write to file: %w", err)
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// sync the directory so the rename itself survives a crash
+	if d, dirErr := os.Open(dir); dirErr == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
+
+// ValidateConfig checks the config map produced by ToMap before it is written. It rejects keys
+// that are not allowed, values spanning several lines and malformed addresses, networks, domains
+// and port rules.
+func ValidateConfig(config map[string]interface{}, allowed map[string]bool) error {
+	for key, value := range config {
+		if !allowed[key] {
+			return fmt.Errorf("unknown config key %s", key)
+		}
+		if value == nil {
+			continue
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			if err := validateConfigValue(key, v); err != nil {
+				return fmt.Errorf("invalid %s %q: %w", key, fmt.Sprint(v), err)
+			}
+		}
+	}
+	return validateAddressing(config)
+}
+
+// validateAddressing checks that static addresses lie in the IPv4 and IPv6 pools set in the
+// same config, and that the IPv6 subnet of each client is smaller than the IPv6 pool.
+func validateAddressing(config map[string]interface{}) error {
+	value := func(key string) string {
+		s, _ := config[key].(string)
+		return strings.TrimSpace(s)
+	}
+
+	for _, pair := range [][2]string{{"explicit-ipv4", "ipv4-network"}, {"explicit-ipv6", "ipv6-network"}} {
+		explicit, pool := value(pair[0]), value(pair[1])
+		if explicit == "" || pool == "" {
+			continue
+		}
+		network, err := ParseIPNet(pool)
+		if err != nil {
+			// a bare ipv4-network is completed by ipv4-netmask in ocserv.conf
+			continue
+		}
+		if !network.Contains(net.ParseIP(explicit)) {
+			return fmt.Errorf("%s %s is outside %s %s", pair[0], explicit, pair[1], pool)
+		}
+	}
+
+	if prefix, ok := config["ipv6-subnet-prefix"].(float64); ok {
+		if network, err := ParseIPNet(value("ipv6-network")); err == nil {
+			if ones, _ := network.Mask.Size(); int(prefix) <= ones {
+				return fmt.Errorf("ipv6-subnet-prefix %d must be longer than the /%d ipv6-network", int(prefix), ones)
+			}
+		}
+	}
+	return nil
+}
+
+func validateConfigValue(key string, value interface{}) error {
+	switch v := value.(type) {
+	case bool:
+		return nil
+	case float64:
+		if v < 0 || v != float64(int64(v)) {
+			return fmt.Errorf("must be a positive integer")
+		}
+		if (key == "tcp-port" || key == "udp-port") && (v < 1 || v > 65535) {
+			return fmt.Errorf("must be a port between 1 and 65535")
+		}
+		if key == "ipv6-subnet-prefix" && (v < 1 || v > 128) {
+			return fmt.Errorf("must be a prefix length between 1 and 128")
+		}
+		return nil
+	case string:
+		// banners are written quoted with escaped newlines
+		if strings.ContainsAny(v, "\r\n") && key != "banner" && key != "pre-login-banner" {
+			return fmt.Errorf("must be a single line")
+		}
+		if v == "" {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported value type %T", value)
+	}
+
+	s := strings.TrimSpace(value.(string))
+	switch key {
+	case "explicit-ipv4":
+		if ip := net.ParseIP(s); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("must be an IPv4 address")
+		}
+	case "explicit-ipv6":
+		if ip := net.ParseIP(s); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("must be an IPv6 address")
+		}
+	case "dns", "nbns":
+		if net.ParseIP(s) == nil {
+			return fmt.Errorf("must be an IP address")
+		}
+	case "ipv4-network":
+		if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+			return nil
+		}
+		if ip, err := parseNetwork(s); err != nil || ip.To4() == nil {
+			return fmt.Errorf("must be an IPv4 network")
+		}
+	case "ipv6-network":
+		if ip, _, err := net.ParseCIDR(s); err != nil || ip.To4() != nil {
+			return fmt.Errorf("must be an IPv6 network in CIDR notation")
+		}
+	case "route":
+		if s == "default" {
+			return nil
+		}
+		if _, err := parseNetwork(s); err != nil {
+			return err
+		}
+	case "no-route", "iroute":
+		if _, err := parseNetwork(s); err != nil {
+			return err
+		}
+	case "split-dns":
+		if !domainRegex.MatchString(s) {
+			return fmt.Errorf("must be a domain name")
+		}
+	case "hostname":
+		if !hostnameRegex.MatchString(s) {
+			return fmt.Errorf("must be a host name")
+		}
+	case "restrict-user-to-ports":
+		return validatePortRules(s)
+	case "auth":
+		if !authRegex.MatchString(strings.Trim(s, `"`)) {
+			return fmt.Errorf("must be an ocserv auth method like plain[passwd=/etc/ocserv/ocpasswd]")
+		}
+	case "server-cert", "server-key", "ca-cert":
+		if !filepath.IsAbs(s) {
+			return fmt.Errorf("must be an absolute path")
+		}
+	}
+	return nil
+}
+
+// parseNetwork accepts a network either in CIDR notation (10.0.0.0/8) or
+// as address and netmask (10.0.0.0/255.0.0.0), as ocserv does.
+func parseNetwork(s string) (net.IP, error) {
+	if ip, _, err := net.ParseCIDR(s); err == nil {
+		return ip, nil
+	}
+	network, err := ParseIPNet(s)
+	if err != nil {
+		return nil, err
+	}
+	return network.IP, nil
+}
+
+// ParseIPNet parses a network in CIDR or IPv4 address/netmask notation and returns it with its mask.
+func ParseIPNet(s string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network, nil
+	}
+	addr, mask, ok := strings.Cut(s, "/")
+	ip, m := net.ParseIP(addr), net.ParseIP(mask)
+	if ok && ip != nil && ip.To4() != nil && m != nil && m.To4() != nil {
+		ipMask := net.IPMask(m.To4())
+		if _, bits := ipMask.Size(); bits != 0 {
+			return &net.IPNet{IP: ip.To4().Mask(ipMask), Mask: ipMask}, nil
+		}
+	}
+	return nil, fmt.Errorf("must be a network in CIDR notation")
+}
+
+// validatePortRules checks a restrict-to-ports value like 'tcp(443), udp(53)', optionally
+// negated as '!(tcp(22), udp(1194))'.
+func validatePortRules(s string) error {
+	s = strings.Trim(s, `"`)
+	if strings.HasPrefix(s, "!(") && strings.HasSuffix(s, ")") {
+		s = s[2 : len(s)-1]
+	}
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.ReplaceAll(strings.TrimSpace(rule), " ", "")
+		m := portRuleRegex.FindStringSubmatch(rule)
+		if m == nil {
+			return fmt.Errorf("invalid port rule %q", rule)
+		}
+		if m[2] != "" {
+			if port, _ := strconv.Atoi(m[2]); port < 1 || port > 65535 {
+				return fmt.Errorf("invalid port %s", m[2])
+			}
+		}
+	}
+	return nil
+}
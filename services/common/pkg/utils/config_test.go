@@ -0,0 +1,157 @@
+package utils_test
+
+import (
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "alice")
+
+	assert.NoError(t, utils.WriteFileAtomic(path, []byte("dns = 1.1.1.1\n"), 0640))
+	assert.NoError(t, utils.WriteFileAtomic(path, []byte("dns = 8.8.8.8\n"), 0640))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "dns = 8.8.8.8\n", string(data))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// no temp file is left behind
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// a failed write leaves the target untouched
+	assert.Error(t, utils.WriteFileAtomic(filepath.Join(dir, "missing", "bob"), []byte("x"), 0640))
+	assert.NoFileExists(t, filepath.Join(dir, "missing", "bob"))
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    string
+	}{
+		{
+			name: "valid",
+			config: map[string]interface{}{
+				"dns":                    []interface{}{"1.1.1.1", "2606:4700:4700::1111"},
+				"route":                  []interface{}{"default", "10.0.0.0/8", "192.168.0.0/255.255.0.0"},
+				"no-route":               []interface{}{"10.1.0.0/16"},
+				"split-dns":              []interface{}{"example.com"},
+				"restrict-user-to-ports": "!(tcp(22), udp(1194))",
+				"rx-data-per-sec":        float64(1024),
+				"deny-roaming":           true,
+				"ipv4-network":           "192.168.1.0/24",
+				"explicit-ipv4":          "192.168.1.10",
+				"cgroup":                 nil,
+			},
+		},
+		{
+			name:   "unknown key",
+			config: map[string]interface{}{"run-as-user": "root"},
+			err:    "unknown config key run-as-user",
+		},
+		{
+			name:   "line break",
+			config: map[string]interface{}{"cgroup": "cpuset\nroute = 0.0.0.0/0"},
+			err:    `invalid cgroup "cpuset\nroute = 0.0.0.0/0": must be a single line`,
+		},
+		{
+			name:   "negative number",
+			config: map[string]interface{}{"rx-data-per-sec": float64(-1)},
+			err:    `invalid rx-data-per-sec "-1": must be a positive integer`,
+		},
+		{
+			name:   "dns",
+			config: map[string]interface{}{"dns": []interface{}{"1.1.1.1", "dns.example"}},
+			err:    `invalid dns "dns.example": must be an IP address`,
+		},
+		{
+			name:   "route",
+			config: map[string]interface{}{"route": []interface{}{"10.0.0.0/33"}},
+			err:    `invalid route "10.0.0.0/33": must be a network in CIDR notation`,
+		},
+		{
+			name:   "port rule",
+			config: map[string]interface{}{"restrict-user-to-ports": "tcp(70000)"},
+			err:    `invalid restrict-user-to-ports "tcp(70000)": invalid port 70000`,
+		},
+		{
+			name:   "explicit address outside the pool",
+			config: map[string]interface{}{"ipv4-network": "192.168.1.0/24", "explicit-ipv4": "10.0.0.1"},
+			err:    "explicit-ipv4 10.0.0.1 is outside ipv4-network 192.168.1.0/24",
+		},
+		{
+			name: "valid ipv6",
+			config: map[string]interface{}{
+				"ipv6-network":       "fd00:24::/48",
+				"explicit-ipv6":      "fd00:24::10",
+				"ipv6-subnet-prefix": float64(64),
+				"hostname":           "alice-laptop",
+			},
+		},
+		{
+			name:   "hostname",
+			config: map[string]interface{}{"hostname": "alice.example"},
+			err:    `invalid hostname "alice.example": must be a host name`,
+		},
+		{
+			name:   "hostname too long",
+			config: map[string]interface{}{"hostname": strings.Repeat("a", 64)},
+			err:    fmt.Sprintf("invalid hostname %q: must be a host name", strings.Repeat("a", 64)),
+		},
+		{
+			name:   "ipv6-network without prefix",
+			config: map[string]interface{}{"ipv6-network": "fd00:24::"},
+			err:    `invalid ipv6-network "fd00:24::": must be an IPv6 network in CIDR notation`,
+		},
+		{
+			name:   "ipv6-network given an ipv4 network",
+			config: map[string]interface{}{"ipv6-network": "10.0.0.0/8"},
+			err:    `invalid ipv6-network "10.0.0.0/8": must be an IPv6 network in CIDR notation`,
+		},
+		{
+			name:   "explicit-ipv6 given an ipv4 address",
+			config: map[string]interface{}{"explicit-ipv6": "10.0.0.1"},
+			err:    `invalid explicit-ipv6 "10.0.0.1": must be an IPv6 address`,
+		},
+		{
+			name:   "explicit-ipv6 outside the pool",
+			config: map[string]interface{}{"ipv6-network": "fd00:24::/48", "explicit-ipv6": "fd00:25::10"},
+			err:    "explicit-ipv6 fd00:25::10 is outside ipv6-network fd00:24::/48",
+		},
+		{
+			name:   "ipv6-subnet-prefix out of range",
+			config: map[string]interface{}{"ipv6-subnet-prefix": float64(129)},
+			err:    `invalid ipv6-subnet-prefix "129": must be a prefix length between 1 and 128`,
+		},
+		{
+			name:   "ipv6-subnet-prefix not longer than the pool",
+			config: map[string]interface{}{"ipv6-network": "fd00:24::/64", "ipv6-subnet-prefix": float64(64)},
+			err:    "ipv6-subnet-prefix 64 must be longer than the /64 ipv6-network",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := utils.ValidateConfig(tt.config, utils.UserConfigKeys)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+
+	// banners are written quoted, line breaks are allowed
+	assert.NoError(t, utils.ValidateConfig(map[string]interface{}{"banner": "Welcome\nto the VPN"}, utils.ServerConfigKeys))
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Address families reported next to the addresses parsed from occtl.
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// ParseAddress parses an address as occtl and ocserv print it: bare, with a port
+// (1.2.3.4:443, [2001:db8::1]:443), bracketed or with an IPv6 zone (fe80::1%eth0).
+// IPv4-mapped IPv6 addresses are returned as IPv4. It returns nil for anything else.
+func ParseAddress(s string) net.IP {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		return unmap(ip)
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if host, _, ok := strings.Cut(s, "%"); ok {
+		s = host
+	}
+	return unmap(net.ParseIP(s))
+}
+
+// AddressFamily returns FamilyIPv4 or FamilyIPv6 for an address accepted by ParseAddress,
+// or an empty string.
+func AddressFamily(s string) string {
+	ip := ParseAddress(s)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return FamilyIPv4
+	default:
+		return FamilyIPv6
+	}
+}
+
+// CanonicalAddress returns the canonical text of an address accepted by ParseAddress,
+// or s unchanged when it is not an address.
+func CanonicalAddress(s string) string {
+	if ip := ParseAddress(s); ip != nil {
+		return ip.String()
+	}
+	return s
+}
+
+// CanonicalNetwork converts a network in CIDR or address/netmask notation, as occtl prints
+// IPv4 iroutes, to canonical CIDR notation. A bare address becomes a host network.
+func CanonicalNetwork(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if network, err := ParseIPNet(s); err == nil {
+		return network.String(), nil
+	}
+	if ip := ParseAddress(s); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+		return (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String(), nil
+	}
+	return "", fmt.Errorf("invalid network %q", s)
+}
+
+func unmap(ip net.IP) net.IP {
+	if ip == nil {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
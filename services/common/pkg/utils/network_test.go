@@ -0,0 +1,125 @@
+package utils_test
+
+import (
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		family string
+	}{
+		{"1.2.3.4", "1.2.3.4", utils.FamilyIPv4},
+		{" 1.2.3.4 ", "1.2.3.4", utils.FamilyIPv4},
+		{"1.2.3.4:443", "1.2.3.4", utils.FamilyIPv4},
+		{"2001:db8::1", "2001:db8::1", utils.FamilyIPv6},
+		{"[2001:db8::1]", "2001:db8::1", utils.FamilyIPv6},
+		{"[2001:db8::1]:443", "2001:db8::1", utils.FamilyIPv6},
+		{"fe80::1%eth0", "fe80::1", utils.FamilyIPv6},
+		{"[fe80::1%eth0]:443", "fe80::1", utils.FamilyIPv6},
+		{"::ffff:10.0.0.1", "10.0.0.1", utils.FamilyIPv4},
+		{"2001:DB8:0:0::1", "2001:db8::1", utils.FamilyIPv6},
+		{"", "", ""},
+		{"vpn.example.com", "", ""},
+		{"vpn.example.com:443", "", ""},
+		{"1.2.3.256", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			ip := utils.ParseAddress(tt.in)
+			if tt.want == "" {
+				assert.Nil(t, ip)
+				assert.Equal(t, tt.in, utils.CanonicalAddress(tt.in))
+			} else {
+				assert.Equal(t, tt.want, ip.String())
+				assert.Equal(t, tt.want, utils.CanonicalAddress(tt.in))
+			}
+			assert.Equal(t, tt.family, utils.AddressFamily(tt.in))
+		})
+	}
+}
+
+func TestCanonicalNetwork(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "10.0.0.0/8", want: "10.0.0.0/8"},
+		{in: "10.1.2.3/8", want: "10.0.0.0/8"},
+		{in: "192.168.1.0/255.255.255.0", want: "192.168.1.0/24"},
+		{in: " 172.16.0.0/255.240.0.0 ", want: "172.16.0.0/12"},
+		{in: "10.0.0.1", want: "10.0.0.1/32"},
+		{in: "fd00:24::/48", want: "fd00:24::/48"},
+		{in: "FD00:24:0::1/64", want: "fd00:24::/64"},
+		{in: "fd00::1", want: "fd00::1/128"},
+		{in: "[fd00::1]", want: "fd00::1/128"},
+		{in: "10.0.0.0/33", err: true},
+		{in: "10.0.0.0/255.0.255.0", err: true},
+		{in: "example.com", err: true},
+		{in: "", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := utils.CanonicalNetwork(tt.in)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateAddressing(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    string
+	}{
+		{
+			name:   "explicit-ipv4 in a netmask pool",
+			config: map[string]interface{}{"ipv4-network": "192.168.1.0/255.255.255.0", "explicit-ipv4": "192.168.1.20"},
+		},
+		{
+			name:   "explicit-ipv4 outside a netmask pool",
+			config: map[string]interface{}{"ipv4-network": "192.168.1.0/255.255.255.0", "explicit-ipv4": "192.168.2.20"},
+			err:    "explicit-ipv4 192.168.2.20 is outside ipv4-network 192.168.1.0/255.255.255.0",
+		},
+		{
+			name:   "bare ipv4-network is completed by ocserv.conf",
+			config: map[string]interface{}{"ipv4-network": "192.168.1.0", "explicit-ipv4": "10.0.0.1"},
+		},
+		{
+			name:   "explicit address without a pool",
+			config: map[string]interface{}{"explicit-ipv4": "10.0.0.1", "explicit-ipv6": "fd00::1"},
+		},
+		{
+			name:   "explicit-ipv6 in the pool",
+			config: map[string]interface{}{"ipv6-network": "fd00:24::/48", "explicit-ipv6": "fd00:24:0:1::10"},
+		},
+		{
+			name:   "ipv6-subnet-prefix without a pool",
+			config: map[string]interface{}{"ipv6-subnet-prefix": float64(64)},
+		},
+		{
+			name:   "ipv6-subnet-prefix shorter than the pool",
+			config: map[string]interface{}{"ipv6-network": "fd00:24::/64", "ipv6-subnet-prefix": float64(48)},
+			err:    "ipv6-subnet-prefix 48 must be longer than the /64 ipv6-network",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := utils.ValidateConfig(tt.config, utils.UserConfigKeys)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -7,29 +7,42 @@ import (
 	"fmt"
 	"github.com/mmtaee/ocserv-users-management/common/models"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-const (
+// Paths of the ocserv files. They are variables so that tests can point them to a temporary directory.
+var (
 	OcpasswdPath       = "/etc/ocserv/ocpasswd"
 	OcpasswdExec       = "/usr/bin/ocpasswd"
 	ConfigGroupBaseDir = "/etc/ocserv/groups/"
 	DefaultGroupFile   = "/etc/ocserv/defaults/group.conf"
 	ConfigUserBaseDir  = "/etc/ocserv/users/"
+	OcservConfigFile   = "/etc/ocserv/ocserv.conf"
+	ConfigBackupDir    = "/etc/ocserv/backups/"
+	OcservExec         = "ocserv"
 )
 
 var listKeys = map[string]bool{
 	"dns":       true,
+	"iroute":    true,
+	"nbns":      true,
 	"no-route":  true,
 	"route":     true,
 	"split-dns": true,
 }
 
+// IsListKey reports whether the directive may appear on several lines, each adding a value.
+func IsListKey(key string) bool {
+	return listKeys[key]
+}
+
 // ToMap converts any struct or value into a map[string]interface{}.
 // It marshals the value into JSON and then unmarshals it into a map.
 // Returns nil if marshaling or unmarshaling fails.
@@ -47,53 +60,21 @@ func ToMap(data interface{}) map[string]interface{} {
 	return result
 }
 
-// ConfigWriter writes key-value pairs from a configuration map to the given file.
-// It skips nil values and boolean false values. For keys like dns, route, no-route,
+// ConfigWriter writes key-value pairs from a configuration map to the given writer.
+// Keys are written in sorted order so the same config always produces the same file.
+// It skips nil, empty and boolean false values. For keys like dns, route, no-route,
 // and split-dns, it writes multiple lines for each entry. Other keys are written
 // as "key=value". Returns an error if writing fails.
-func ConfigWriter(file *os.File, config map[string]interface{}) error {
-	for k, v := range config {
-		if b, ok := v.(bool); ok && !b {
-			continue
-		}
-		if v == nil {
-			continue
-		}
-
-		if v == "" {
-			continue
-		}
+func ConfigWriter(w io.Writer, config map[string]interface{}) error {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-		if k == "dns" {
-			for _, dns := range v.([]interface{}) {
-				if _, err := file.WriteString(fmt.Sprintf("dns=%s\n", dns)); err != nil {
-					return fmt.Errorf("failed to write to file: %w", err)
-				}
-			}
-			continue
-		} else if k == "route" {
-			for _, route := range v.([]interface{}) {
-				if _, err := file.WriteString(fmt.Sprintf("route=%s\n", route)); err != nil {
-					return fmt.Errorf("failed to write to file: %w", err)
-				}
-			}
-			continue
-		} else if k == "no-route" {
-			for _, route := range v.([]interface{}) {
-				if _, err := file.WriteString(fmt.Sprintf("no-route=%s\n", route)); err != nil {
-					return fmt.Errorf("failed to write to file: %w", err)
-				}
-			}
-			continue
-		} else if k == "split-dns" {
-			for _, dns := range v.([]interface{}) {
-				if _, err := file.WriteString(fmt.Sprintf("split-dns=%s\n", dns)); err != nil {
-					return fmt.Errorf("failed to write to file: %w", err)
-				}
-			}
-			continue
-		} else {
-			if _, err := file.WriteString(fmt.Sprintf("%s=%v\n", k, v)); err != nil {
+	for _, k := range keys {
+		for _, value := range configValues(config[k]) {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", k, value); err != nil {
 				return fmt.Errorf("failed to write to file: %w", err)
 			}
 		}
@@ -101,44 +82,34 @@ func ConfigWriter(file *os.File, config map[string]interface{}) error {
 	return nil
 }
 
-// GetUsersByGroup parses the ocpasswd file and returns usernames
-// belonging to the given group. It ignores comments, empty lines,
-// and malformed entries. Assumes group is stored as the third field
-// in colon-separated records. Returns an error if scanning fails.
-func GetUsersByGroup(groupName string) ([]string, error) {
-	file, err := os.Open(OcpasswdPath)
-	if err != nil {
-		return nil, err
+// configValues returns the lines to write for a config value decoded by ToMap.
+// Nil, empty and boolean false values yield none, lists yield one value per entry.
+func configValues(v interface{}) []string {
+	if b, ok := v.(bool); ok && !b {
+		return nil
 	}
-	defer file.Close()
-
-	var users []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.Split(line, ":")
-		if len(parts) < 3 {
-			continue // skip malformed lines
-		}
-
-		username := parts[0]
-		group := parts[2]
-
-		if group == groupName {
-			users = append(users, username)
-		}
+	if v == nil || v == "" {
+		return nil
 	}
 
-	if err = scanner.Err(); err != nil {
-		return nil, err
+	values, ok := v.([]interface{})
+	if !ok {
+		values = []interface{}{v}
 	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, formatConfigValue(value))
+	}
+	return result
+}
 
-	return users, nil
+// formatConfigValue formats a value decoded from JSON. Numbers are written without exponent,
+// so 1000000 is not written as 1e+06.
+func formatConfigValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
 }
 
 // ParseOcservConfigFile parses an ocserv config file into a map[string]interface{}.
@@ -302,6 +273,19 @@ func RunOcpasswd(args ...string) (string, error) {
 	return output, nil
 }
 
+// namePattern matches the usernames and group names that are safe in ocpasswd, where ':' separates
+// the fields, and as config file names, which must not contain path separators.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.@-]{0,31}$`)
+
+// ValidateName checks that a username or group name is safe to write to ocpasswd and to use as the
+// name of its config file, so that a name such as ../../etc/passwd cannot escape the config directory.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid name %q: use up to 32 letters, digits and _.@-, not starting with . or -", name)
+	}
+	return nil
+}
+
 // UserConfigFilePathCreator constructs the absolute file path for a
 // user-specific config file using ConfigUserBaseDir.
 func UserConfigFilePathCreator(username string) string {
@@ -314,6 +298,22 @@ func GroupConfigFilePathCreator(groupName string) string {
 	return filepath.Join(ConfigGroupBaseDir, groupName)
 }
 
+// UserConfigToModel converts a parsed user configuration into models.OcservUserConfig,
+// the same way GroupConfigToModel does for groups.
+func UserConfigToModel(configInterface interface{}) (models.OcservUserConfig, error) {
+	configJson, err := json.Marshal(configInterface)
+	if err != nil {
+		return models.OcservUserConfig{}, err
+	}
+
+	var config models.OcservUserConfig
+
+	if err = json.Unmarshal(configJson, &config); err != nil {
+		return models.OcservUserConfig{}, err
+	}
+	return config, nil
+}
+
 // GroupConfigToModel converts a generic interface{} containing a group configuration
 // into a strongly-typed models.OcservGroupConfig structure.
 // It first marshals the interface to JSON, then unmarshals it into the target struct.
@@ -339,3 +339,21 @@ func GroupConfigToModel(configInterface interface{}) (models.OcservGroupConfig,
 //	re := regexp.MustCompile(`("in_use"\s*:\s*\d+)\s*,`)
 //	return re.ReplaceAll(jsonBytes, []byte("$1"))
 //}
+
+// EscapeFormula prefixes a spreadsheet cell starting with = + - or @ with a quote, so that
+// spreadsheet applications opening an exported CSV or XLSX show it as text instead of running it as
+// a formula.
+func EscapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// UnescapeFormula reverts EscapeFormula, so that exported files can be imported again.
+func UnescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
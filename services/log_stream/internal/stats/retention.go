@@ -0,0 +1,38 @@
+package stats
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"time"
+)
+
+// PruneRawStatistics deletes the raw statistics older than retention every day. The rollups are kept.
+func PruneRawStatistics(ctx context.Context, retention time.Duration) {
+	prune := func() {
+		before := time.Now().Add(-retention)
+		result := database.GetConnection().WithContext(ctx).
+			Where("created_at < ?", before).
+			Delete(&models.OcservUserTrafficStatistics{})
+		if result.Error != nil {
+			logger.Error("Failed to prune raw traffic statistics: %v", result.Error)
+			return
+		}
+		if result.RowsAffected > 0 {
+			logger.Info("Pruned %d raw traffic statistics older than %s", result.RowsAffected, before.Format(time.DateOnly))
+		}
+	}
+
+	prune()
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prune()
+		}
+	}
+}
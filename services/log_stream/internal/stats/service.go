@@ -5,8 +5,10 @@ import (
 	"errors"
 	"github.com/mmtaee/ocserv-users-management/common/models"
 	occtlDocker "github.com/mmtaee/ocserv-users-management/common/occtl_docker"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/occtl"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/configversion"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
 	"gorm.io/gorm"
@@ -25,15 +27,26 @@ type StatService struct {
 	ocservOcctlRepo occtl.OcservOcctlInterface
 	occtlDockerRepo occtlDocker.OcservOcctlUsersDocker
 	dockerMode      bool
+	nodeID          *uint
 }
 
-func NewStatService(ctx context.Context, stream chan string, dockerMode bool) *StatService {
+// NewStatService creates the stats consumer. A non-empty nodeName stamps the saved
+// traffic with that node, for a log stream reading the ocserv of a remote node.
+func NewStatService(ctx context.Context, stream chan string, dockerMode bool, nodeName string) *StatService {
 	s := &StatService{
 		ctx:        ctx,
 		stream:     stream,
 		dockerMode: dockerMode,
 	}
 
+	if nodeName != "" {
+		var n models.Node
+		if err := database.GetConnection().Where("name = ?", nodeName).First(&n).Error; err != nil {
+			logger.Fatal("Node %s not found: %v", nodeName, err)
+		}
+		s.nodeID = &n.ID
+	}
+
 	if dockerMode {
 		s.occtlDockerRepo = occtlDocker.NewOcservOcctlDocker()
 	} else {
@@ -93,44 +106,60 @@ func (s *StatService) save(ctx context.Context, u UserStats) error {
 		OcUserID: ocUser.ID,
 		Rx:       u.RX,
 		Tx:       u.TX,
+		NodeID:   s.nodeID,
 	}
 
-	err = db.Create(&traffic).Error
+	// the counters are incremented in place, the log streams of the nodes and the API write the same row
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err2 := tx.Create(&traffic).Error; err2 != nil {
+			return err2
+		}
+		if err2 := models.AddTrafficRollups(tx, traffic); err2 != nil {
+			return err2
+		}
+		err2 := tx.Model(&ocUser).UpdateColumns(map[string]interface{}{
+			"rx": gorm.Expr("rx + ?", u.RX),
+			"tx": gorm.Expr("tx + ?", u.TX),
+		}).Error
+		if err2 != nil {
+			return err2
+		}
+		return tx.First(&ocUser, ocUser.ID).Error
+	})
 	if err != nil {
 		logger.Error("Error creating traffic stats: %v", err)
 		return err
 	}
 
-	ocUser.Rx += u.RX
-	ocUser.Tx += u.TX
-
-	var trafficSizeBytes = ocUser.TrafficSize * (1 << 30)
-
-	totalMonthStats, err := s.getCurrentMonthTotals(db, ocUser.ID)
+	now := time.Now()
+	usage, err := ocUser.TrafficUsage(db, now)
 	if err != nil {
-		logger.Error("Error getting current month stats: %v", err)
+		logger.Error("Error getting traffic usage: %v", err)
 		return err
 	}
 
-	switch ocUser.TrafficType {
-	case models.TotallyTransmit:
-		ocUser.IsLocked = ocUser.Tx >= trafficSizeBytes
-
-	case models.TotallyReceive:
-		ocUser.IsLocked = ocUser.Rx >= trafficSizeBytes
-
-	case models.MonthlyTransmit:
-		ocUser.IsLocked = totalMonthStats.TotalTx >= trafficSizeBytes
-
-	case models.MonthlyReceive:
-		ocUser.IsLocked = totalMonthStats.TotalRx >= trafficSizeBytes
-
-	default:
-		logger.Error("Unknown traffic type: %v", ocUser.TrafficType)
+	if usage == nil || !usage.Exceeded {
+		return nil
 	}
 
-	now := time.Now()
-	if ocUser.IsLocked {
+	// only the lock and throttle columns are written, the rest of the row may have changed since it was read
+	var columns map[string]interface{}
+	if ocUser.OverQuotaAction == models.OverQuotaThrottle {
+		if ocUser.ThrottledAt != nil {
+			return nil
+		}
+		// the throttle is retried at the next disconnect unless the local config is written
+		ocUser.ThrottledAt = &now
+		if err = s.throttle(db, ocUser); err != nil {
+			logger.Error("Error throttling user: %v", err)
+			return nil
+		}
+		if err = s.throttleOnNodes(db, ocUser); err != nil {
+			logger.Error("Error throttling user on nodes: %v", err)
+		}
+		columns = map[string]interface{}{"throttled_at": now}
+	} else {
+		ocUser.IsLocked = true
 		var lockFunc func(username string) (string, error)
 		if s.dockerMode {
 			lockFunc = s.occtlDockerRepo.Lock
@@ -141,9 +170,16 @@ func (s *StatService) save(ctx context.Context, u UserStats) error {
 		if err != nil {
 			logger.Error("Error locking user: %v", err)
 		}
-		ocUser.DeactivatedAt = &now
+		if err = s.lockOnNodes(db, ocUser); err != nil {
+			logger.Error("Error locking user on nodes: %v", err)
+		}
+		columns = map[string]interface{}{
+			"is_locked":          true,
+			"deactivated_at":     now,
+			"deactivated_reason": models.DeactivatedQuota,
+		}
 	}
-	err = db.Save(&ocUser).Error
+	err = db.Model(&ocUser).UpdateColumns(columns).Error
 	if err != nil {
 		logger.Error("Error updating user stats: %v", err)
 		return err
@@ -151,18 +187,57 @@ func (s *StatService) save(ctx context.Context, u UserStats) error {
 	return nil
 }
 
-func (s *StatService) getCurrentMonthTotals(db *gorm.DB, userID uint) (Totals, error) {
-	now := time.Now()
-	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	endOfMonth := startOfMonth.AddDate(0, 1, 0)
+// lockOnNodes locks the user on every node it is assigned to, since the quota is shared between nodes.
+func (s *StatService) lockOnNodes(db *gorm.DB, ocUser models.OcservUser) error {
+	nodes, err := node.UserNodes(db, ocUser.ID)
+	if err != nil {
+		return err
+	}
+	return node.FanOut(nodes, func(agent node.AgentInterface) error {
+		_, err2 := agent.Lock(ocUser.Username)
+		return err2
+	})
+}
 
-	var result Totals
-	err := db.Model(&models.OcservUserTrafficStatistics{}).
-		Select("SUM(rx) as total_rx, SUM(tx) as total_tx").
-		Where("oc_user_id = ? AND created_at >= ? AND created_at < ?", userID, startOfMonth, endOfMonth).
-		Scan(&result).Error
+// throttle writes the throttled config of the user on the local ocserv, then disconnects the user so
+// the reduced rates apply to the next session. The throttled config is recorded as a version of the
+// user config.
+func (s *StatService) throttle(db *gorm.DB, ocUser models.OcservUser) error {
+	config := ocUser.AppliedConfig()
 
-	return result, err
+	if s.dockerMode {
+		if _, err := s.occtlDockerRepo.UpdateConfig(ocUser.Username, config); err != nil {
+			return err
+		}
+		_, _ = s.occtlDockerRepo.DisconnectUser(ocUser.Username)
+	} else {
+		if err := s.ocservUserRepo.CreateConfig(ocUser.Username, config); err != nil {
+			return err
+		}
+		if _, err := s.ocservOcctlRepo.ReloadConfigs(); err != nil {
+			return err
+		}
+		_, _ = s.ocservOcctlRepo.DisconnectUser(ocUser.Username)
+	}
+	configversion.RecordChange(db, models.ConfigKindUser, ocUser.Username, ocUser.Config, config, "", "throttled over quota")
+	return nil
+}
+
+// throttleOnNodes writes the throttled config of the user on every node it is assigned to.
+func (s *StatService) throttleOnNodes(db *gorm.DB, ocUser models.OcservUser) error {
+	config := ocUser.AppliedConfig()
+
+	nodes, err := node.UserNodes(db, ocUser.ID)
+	if err != nil {
+		return err
+	}
+	return node.FanOut(nodes, func(agent node.AgentInterface) error {
+		if err2 := agent.UpdateConfig(ocUser.Username, config); err2 != nil {
+			return err2
+		}
+		_, _ = agent.DisconnectUser(ocUser.Username)
+		return nil
+	})
 }
 
 func (s *StatService) extractUser(text string) (UserStats, error) {
@@ -5,8 +5,3 @@ type UserStats struct {
 	RX       int
 	TX       int
 }
-
-type Totals struct {
-	TotalRx int
-	TotalTx int
-}
@@ -14,7 +14,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 )
 
 var (
@@ -22,6 +24,7 @@ var (
 	host       string
 	port       int
 	dockerMode bool
+	nodeName   string
 )
 
 func main() {
@@ -29,6 +32,7 @@ func main() {
 	flag.StringVar(&host, "h", "0.0.0.0", "Server Host")
 	flag.IntVar(&port, "p", 8080, "Server Port")
 	flag.BoolVar(&dockerMode, "docker-mode", false, "Docker Mode")
+	flag.StringVar(&nodeName, "node", "", "Name of the node whose ocserv logs are read (empty for the local ocserv)")
 	flag.Parse()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -65,7 +69,12 @@ func main() {
 		}()
 	}
 
-	statService := stats.NewStatService(ctx, lineLogChan, dockerMode)
+	// the log streams of the nodes share the database, the local one prunes it
+	if retention := rawRetention(); retention > 0 && nodeName == "" {
+		go stats.PruneRawStatistics(ctx, retention)
+	}
+
+	statService := stats.NewStatService(ctx, lineLogChan, dockerMode, nodeName)
 	go func() {
 		statService.CalculateUserStats()
 	}()
@@ -100,6 +109,21 @@ func main() {
 	logger.Info("Log stream service shutting down successfully")
 }
 
+// rawRetention returns how long raw traffic statistics are kept, from STATS_RAW_RETENTION_DAYS.
+// Raw statistics are kept forever when it is not set.
+func rawRetention() time.Duration {
+	value := os.Getenv("STATS_RAW_RETENTION_DAYS")
+	if value == "" {
+		return 0
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days <= 0 {
+		logger.Warn("Warning: invalid STATS_RAW_RETENTION_DAYS %q, raw traffic statistics are kept", value)
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 func start(ctx context.Context, streamText <-chan string, broadcaster, lineLogChan chan<- string) {
 	for {
 		select {
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/report"
+	"gorm.io/gorm"
+	"time"
+)
+
+// RunReportSchedules generates the reports of the active schedules whose next run is due. The periods
+// missed while the service was down are generated one by one, oldest first.
+func (c *CornService) RunReportSchedules(ctx context.Context, db *gorm.DB) {
+	now := time.Now()
+
+	var schedules []models.ReportSchedule
+	err := db.WithContext(ctx).
+		Where("is_active = ?", true).
+		Where("next_run_at <= ?", now.UTC()).
+		Find(&schedules).Error
+	if err != nil {
+		logger.Error("Failed to get report schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		at := schedule.NextRunAt
+		for !at.After(now) {
+			usageReport, err2 := report.Generate(ctx, db, &schedule, at)
+			if err2 != nil {
+				logger.Error("Failed to generate report %s: %v", schedule.Name, err2)
+				break
+			}
+			if usageReport.DeliveryError != "" {
+				logger.Warn("Failed to deliver report %s: %s", schedule.Name, usageReport.DeliveryError)
+			}
+			at = schedule.NextRun(at)
+		}
+
+		err2 := db.WithContext(ctx).Model(&schedule).Updates(map[string]interface{}{
+			"last_run_at": now,
+			"next_run_at": at,
+		}).Error
+		if err2 != nil {
+			logger.Error("Failed to update report schedule %s: %v", schedule.Name, err2)
+		}
+	}
+}
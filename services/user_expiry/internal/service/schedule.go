@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+// EnforceSchedules locks and disconnects the users outside the windows of their access schedule, and
+// unlocks the users it locked once a window opens again. Users locked for another reason, such as
+// being expired or over quota, stay locked. The group schedules are loaded once per run.
+func (c *CornService) EnforceSchedules(ctx context.Context, db *gorm.DB) {
+	now := time.Now()
+
+	var groups []models.OcservGroup
+	err := db.WithContext(ctx).Select("name", "schedule").Where("schedule IS NOT NULL").Find(&groups).Error
+	if err != nil {
+		logger.Error("Failed to get scheduled groups: %v", err)
+		return
+	}
+	groupSchedules := make(map[string]*models.AccessSchedule, len(groups))
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		groupSchedules[g.Name] = g.Schedule
+		names = append(names, g.Name)
+	}
+
+	var users []models.OcservUser
+	query := db.WithContext(ctx).Where("schedule IS NOT NULL OR schedule_locked_at IS NOT NULL")
+	if len(names) > 0 {
+		query = query.Or("`group` IN ?", names)
+	}
+	if err = query.Find(&users).Error; err != nil {
+		logger.Error("Failed to get scheduled users: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+
+	for _, u := range users {
+		schedule := u.Schedule
+		if schedule == nil {
+			schedule = groupSchedules[u.Group]
+		}
+
+		allowed := schedule == nil || schedule.Allows(now)
+		if (allowed && u.ScheduleLockedAt == nil) || (!allowed && u.IsLocked) {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(u models.OcservUser) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if allowed {
+				c.scheduleUnlock(db, u)
+			} else {
+				c.scheduleLock(db, u, now)
+			}
+		}(u)
+	}
+
+	wg.Wait()
+}
+
+// scheduleLock locks the user outside of its schedule and disconnects its active sessions. The lock
+// is recorded only once ocpasswd has it, so a failed lock is tried again by the next run.
+func (c *CornService) scheduleLock(db *gorm.DB, u models.OcservUser, now time.Time) {
+	var lock, disconnect func(string) (string, error)
+
+	if c.dockerMode {
+		lock = c.occtlDockerRepo.Lock
+		disconnect = c.occtlDockerRepo.DisconnectUser
+	} else {
+		lock = c.ocservUserHandler.Lock
+		disconnect = c.occtlHandler.DisconnectUser
+	}
+	if _, err := lock(u.Username); err != nil {
+		logger.Error("Failed to lock user %s: %v", u.Username, err)
+		return
+	}
+
+	updates := map[string]interface{}{"is_locked": true}
+	if u.ScheduleLockedAt == nil {
+		updates["schedule_locked_at"] = now
+	}
+	if err := db.Model(&u).Updates(updates).Error; err != nil {
+		logger.Error("Failed to update user %s: %v", u.Username, err)
+		return
+	}
+
+	if _, err := disconnect(u.Username); err != nil {
+		logger.Error("Failed to disconnect user %s: %v", u.Username, err)
+	}
+	if err := onNodes(db, u, func(agent node.AgentInterface) error {
+		_, err2 := agent.Lock(u.Username)
+		_, _ = agent.DisconnectUser(u.Username)
+		return err2
+	}); err != nil {
+		logger.Error("Failed to lock user %s on nodes: %v", u.Username, err)
+	}
+}
+
+// scheduleUnlock releases the schedule lock of the user, unlocking it unless it was deactivated meanwhile.
+func (c *CornService) scheduleUnlock(db *gorm.DB, u models.OcservUser) {
+	updates := map[string]interface{}{"schedule_locked_at": nil}
+	if u.DeactivatedAt == nil {
+		updates["is_locked"] = false
+	}
+	if err := db.Model(&u).Updates(updates).Error; err != nil {
+		logger.Error("Failed to update user %s: %v", u.Username, err)
+		return
+	}
+	if u.DeactivatedAt != nil {
+		return
+	}
+
+	var unlock func(string) (string, error)
+
+	if c.dockerMode {
+		unlock = c.occtlDockerRepo.Unlock
+	} else {
+		unlock = c.ocservUserHandler.UnLock
+	}
+	if _, err := unlock(u.Username); err != nil {
+		logger.Error("Failed to unlock user %s: %v", u.Username, err)
+	}
+	if err := onNodes(db, u, func(agent node.AgentInterface) error {
+		_, err2 := agent.UnLock(u.Username)
+		return err2
+	}); err != nil {
+		logger.Error("Failed to unlock user %s on nodes: %v", u.Username, err)
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEnforceSchedulesLock(t *testing.T) {
+	c, docker, db := setupService(t)
+	// a window three days away does not allow connections now
+	days := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	day := days[(int(time.Now().UTC().Weekday())+3)%7]
+	closed := &models.AccessSchedule{Windows: []models.ScheduleWindow{{Days: []string{day}, Start: "09:00", End: "17:00"}}}
+	u := createUser(t, db, models.OcservUser{Username: "alice", Schedule: closed})
+
+	docker.err = errors.New("ocserv is down")
+	c.EnforceSchedules(context.Background(), db)
+
+	assert.Equal(t, []string{"lock alice"}, docker.calls)
+	require.NoError(t, db.First(&u, u.ID).Error)
+	assert.False(t, u.IsLocked, "a failed lock is not recorded")
+	assert.Nil(t, u.ScheduleLockedAt)
+
+	// the next run tries again
+	docker.err, docker.calls = nil, nil
+	c.EnforceSchedules(context.Background(), db)
+
+	assert.Equal(t, []string{"lock alice", "disconnect alice"}, docker.calls)
+	require.NoError(t, db.First(&u, u.ID).Error)
+	assert.True(t, u.IsLocked)
+	assert.NotNil(t, u.ScheduleLockedAt)
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"gorm.io/gorm"
+	"time"
+)
+
+// RunScheduledActions runs the pending actions scheduled on users whose run time has come, oldest
+// first, and records whether they succeeded. The actions missed while the service was down run late.
+// Each action is claimed by moving it from pending to running first, so an action cancelled or run by
+// an overlapping run in the meantime is skipped.
+func (c *CornService) RunScheduledActions(ctx context.Context, db *gorm.DB) {
+	now := time.Now()
+
+	var actions []models.ScheduledAction
+	err := db.WithContext(ctx).
+		Where("status = ?", models.ScheduledPending).
+		Where("run_at <= ?", now.UTC()).
+		Order("run_at, id").
+		Find(&actions).Error
+	if err != nil {
+		logger.Error("Failed to get scheduled actions: %v", err)
+		return
+	}
+
+	// one by one, so that the actions of a user apply in order
+	for _, action := range actions {
+		claim := db.WithContext(ctx).Model(&models.ScheduledAction{}).
+			Where("id = ? AND status = ?", action.ID, models.ScheduledPending).
+			Update("status", models.ScheduledRunning)
+		if claim.Error != nil {
+			logger.Error("Failed to claim scheduled action %d: %v", action.ID, claim.Error)
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		updates := map[string]interface{}{
+			"status":      models.ScheduledDone,
+			"result":      "",
+			"executed_at": time.Now(),
+		}
+		if err2 := c.runScheduledAction(db.WithContext(ctx), &action, now); err2 != nil {
+			logger.Error("Failed to run scheduled action %d (%s): %v", action.ID, action.Action, err2)
+			updates["status"] = models.ScheduledFailed
+			updates["result"] = err2.Error()
+		}
+
+		// the running action is kept when it deletes its user
+		if err2 := db.WithContext(ctx).Model(&action).Updates(updates).Error; err2 != nil {
+			logger.Error("Failed to update scheduled action %d: %v", action.ID, err2)
+		}
+	}
+}
+
+// FailInterruptedActions marks the actions left running by a run of the service that stopped before
+// recording their result as failed, as they may have been applied partly.
+func (c *CornService) FailInterruptedActions(ctx context.Context, db *gorm.DB) {
+	err := db.WithContext(ctx).Model(&models.ScheduledAction{}).
+		Where("status = ?", models.ScheduledRunning).
+		Updates(map[string]interface{}{
+			"status":      models.ScheduledFailed,
+			"result":      "interrupted",
+			"executed_at": time.Now(),
+		}).Error
+	if err != nil {
+		logger.Error("Failed to fail interrupted scheduled actions: %v", err)
+	}
+}
+
+func (c *CornService) runScheduledAction(db *gorm.DB, action *models.ScheduledAction, now time.Time) error {
+	var u models.OcservUser
+	if err := db.Where("id = ?", action.OcUserID).First(&u).Error; err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	switch action.Action {
+	case models.ScheduledLock:
+		return c.lockUser(db, u)
+	case models.ScheduledUnlock:
+		return c.unlockUser(db, u)
+	case models.ScheduledDelete:
+		return c.deleteUser(db, u)
+	case models.ScheduledChangeGroup:
+		return c.changeGroup(db, u, action.Group)
+	case models.ScheduledChangePlan:
+		if action.PlanID == nil {
+			return errors.New("plan_id is required to change the plan")
+		}
+		return c.changePlan(db, u, *action.PlanID, now)
+	case models.ScheduledResetTraffic:
+		return c.resetTraffic(db, u, now)
+	}
+	return fmt.Errorf("invalid action %s", action.Action)
+}
+
+// lockUser locks the user and disconnects its active sessions.
+func (c *CornService) lockUser(db *gorm.DB, u models.OcservUser) error {
+	if err := db.Model(&u).Update("is_locked", true).Error; err != nil {
+		return err
+	}
+
+	var lock, disconnect func(string) (string, error)
+
+	if c.dockerMode {
+		lock = c.occtlDockerRepo.Lock
+		disconnect = c.occtlDockerRepo.DisconnectUser
+	} else {
+		lock = c.ocservUserHandler.Lock
+		disconnect = c.occtlHandler.DisconnectUser
+	}
+	if _, err := lock(u.Username); err != nil {
+		return err
+	}
+	_, _ = disconnect(u.Username)
+
+	return onNodes(db, u, func(agent node.AgentInterface) error {
+		_, err := agent.Lock(u.Username)
+		_, _ = agent.DisconnectUser(u.Username)
+		return err
+	})
+}
+
+// unlockUser unlocks the user, as the unlock endpoint does.
+func (c *CornService) unlockUser(db *gorm.DB, u models.OcservUser) error {
+	if err := db.Model(&u).Update("is_locked", false).Error; err != nil {
+		return err
+	}
+
+	var unlock func(string) (string, error)
+
+	if c.dockerMode {
+		unlock = c.occtlDockerRepo.Unlock
+	} else {
+		unlock = c.ocservUserHandler.UnLock
+	}
+	if _, err := unlock(u.Username); err != nil {
+		return err
+	}
+
+	return onNodes(db, u, func(agent node.AgentInterface) error {
+		_, err := agent.UnLock(u.Username)
+		return err
+	})
+}
+
+// deleteUser deletes the user with its owners, top-ups, scheduled actions and labels, as the delete
+// endpoint does, and removes it from the local ocserv and its nodes. The running action is kept to
+// record its result.
+func (c *CornService) deleteUser(db *gorm.DB, u models.OcservUser) error {
+	nodes, err := node.UserNodes(db, u.ID)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return models.DeleteOcservUser(tx, &u)
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.dockerMode {
+		_, _ = c.occtlDockerRepo.DisconnectUser(u.Username)
+		if _, err = c.occtlDockerRepo.Delete(u.Username); err != nil {
+			return err
+		}
+	} else {
+		_, _ = c.occtlHandler.DisconnectUser(u.Username)
+		if _, err = c.ocservUserHandler.Delete(u.Username); err != nil {
+			return err
+		}
+		_, _ = c.occtlHandler.ReloadConfigs()
+	}
+
+	return node.FanOut(nodes, func(agent node.AgentInterface) error {
+		_, _ = agent.DisconnectUser(u.Username)
+		_, err2 := agent.Delete(u.Username)
+		return err2
+	})
+}
+
+// changeGroup moves the user to the group, keeping its password and lock.
+func (c *CornService) changeGroup(db *gorm.DB, u models.OcservUser, group string) error {
+	if err := groupExists(db, group); err != nil {
+		return err
+	}
+
+	if err := db.Model(&u).Update("group", group).Error; err != nil {
+		return err
+	}
+	u.Group = group
+
+	if c.dockerMode {
+		// the webhook rewrites the ocpasswd entry, which unlocks it
+		if err := c.recreateUser(u); err != nil {
+			return err
+		}
+	} else {
+		if err := c.ocservUserHandler.SetGroup(group, u.Username); err != nil {
+			return err
+		}
+		_, _ = c.occtlHandler.ReloadConfigs()
+	}
+
+	return onNodes(db, u, func(agent node.AgentInterface) error {
+		if err := agent.Create(u.Group, u.Username, u.Password, u.AppliedConfig()); err != nil {
+			return err
+		}
+		if u.IsLocked {
+			_, err := agent.Lock(u.Username)
+			return err
+		}
+		return nil
+	})
+}
+
+// changePlan renews the user on the plan, as the renew endpoint does.
+func (c *CornService) changePlan(db *gorm.DB, u models.OcservUser, planID uint, now time.Time) error {
+	var plan models.Plan
+	if err := db.Where("id = ?", planID).First(&plan).Error; err != nil {
+		return fmt.Errorf("invalid plan: %w", err)
+	}
+	if !plan.IsActive {
+		return errors.New("plan is not active")
+	}
+	if err := groupExists(db, plan.Group); err != nil {
+		return err
+	}
+
+	u.ApplyPlan(&plan, now)
+	if err := db.Omit("Nodes").Save(&u).Error; err != nil {
+		return err
+	}
+
+	if c.dockerMode {
+		if err := c.recreateUser(u); err != nil {
+			return err
+		}
+	} else {
+		if err := c.ocservUserHandler.Create(u.Group, u.Username, u.Password, u.AppliedConfig()); err != nil {
+			return err
+		}
+		if _, err := c.ocservUserHandler.UnLock(u.Username); err != nil {
+			return err
+		}
+		_, _ = c.occtlHandler.ReloadConfigs()
+	}
+
+	return onNodes(db, u, func(agent node.AgentInterface) error {
+		if err := agent.Create(u.Group, u.Username, u.Password, u.AppliedConfig()); err != nil {
+			return err
+		}
+		_, err := agent.UnLock(u.Username)
+		return err
+	})
+}
+
+// resetTraffic resets the counters of the user. A user locked or throttled for exceeding its quota is
+// unlocked or gets its full rates back; users locked for being expired stay locked.
+func (c *CornService) resetTraffic(db *gorm.DB, u models.OcservUser, now time.Time) error {
+	today := now.Truncate(24 * time.Hour)
+	locked := u.DeactivatedReason == models.DeactivatedQuota && (u.ExpireAt == nil || u.ExpireAt.After(today))
+	throttled := u.ThrottledAt != nil
+
+	updates := map[string]interface{}{
+		"rx":               0,
+		"tx":               0,
+		"throttled_at":     nil,
+		"traffic_reset_at": now,
+	}
+	if locked {
+		updates["deactivated_at"] = nil
+		updates["deactivated_reason"] = ""
+		updates["is_locked"] = false
+	}
+	if err := db.Model(&u).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if throttled {
+		u.ThrottledAt = nil
+		if err := c.restoreConfig(db, u); err != nil {
+			return err
+		}
+	}
+	if locked {
+		u.DeactivatedAt = nil
+		return c.unlockUser(db, u)
+	}
+	return nil
+}
+
+// groupExists returns an error unless the group is "defaults" or a group of the database.
+func groupExists(db *gorm.DB, group string) error {
+	if group == "defaults" {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.OcservGroup{}).Where("name = ?", group).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("group %s not found", group)
+	}
+	return nil
+}
+
+// recreateUser rewrites the user on the docker ocserv with its group and config, keeping its lock.
+func (c *CornService) recreateUser(u models.OcservUser) error {
+	if _, err := c.occtlDockerRepo.Create(u.Group, u.Username, u.Password, u.AppliedConfig()); err != nil {
+		return err
+	}
+	if u.IsLocked {
+		_, err := c.occtlDockerRepo.Lock(u.Username)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/mmtaee/ocserv-users-management/common/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+// fakeDocker records the calls to the docker ocserv.
+type fakeDocker struct {
+	calls []string
+	err   error
+}
+
+func (d *fakeDocker) call(name, username string) (string, error) {
+	d.calls = append(d.calls, name+" "+username)
+	return "", d.err
+}
+
+func (d *fakeDocker) DisconnectUser(username string) (string, error) {
+	return d.call("disconnect", username)
+}
+
+func (d *fakeDocker) Lock(username string) (string, error) {
+	return d.call("lock", username)
+}
+
+func (d *fakeDocker) Unlock(username string) (string, error) {
+	return d.call("unlock", username)
+}
+
+func (d *fakeDocker) UpdateConfig(username string, _ *models.OcservUserConfig) (string, error) {
+	return d.call("config", username)
+}
+
+func (d *fakeDocker) Create(group, username, _ string, _ *models.OcservUserConfig) (string, error) {
+	return d.call("create "+group, username)
+}
+
+func (d *fakeDocker) Delete(username string) (string, error) {
+	return d.call("delete", username)
+}
+
+// setupService returns a docker mode service on an in-memory database with the tables of the actions.
+func setupService(t *testing.T) (*CornService, *fakeDocker, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// every connection to :memory: opens its own database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Node{}, &models.OcservUser{}, &models.OcservGroup{}, &models.Plan{}, &models.TrafficTopUp{},
+		&models.ScheduledAction{}, &models.LabelTag{}, &models.LabelAttribute{}, &models.ConfigVersion{},
+	))
+	// the owners model belongs to the api
+	require.NoError(t, db.Exec("CREATE TABLE ocserv_user_owners (ocserv_user_id integer, user_id integer)").Error)
+
+	docker := &fakeDocker{}
+	return &CornService{dockerMode: true, occtlDockerRepo: docker}, docker, db
+}
+
+func createUser(t *testing.T, db *gorm.DB, u models.OcservUser) models.OcservUser {
+	t.Helper()
+	u.UID, u.Owner, u.Password = u.Username, "admin", "secret"
+	if u.Group == "" {
+		u.Group = "defaults"
+	}
+	if u.TrafficType == "" {
+		u.TrafficType, u.TrafficSize = models.MonthlyTransmit, 10
+	}
+	require.NoError(t, db.Create(&u).Error)
+	return u
+}
+
+func TestRunScheduledAction(t *testing.T) {
+	now := time.Now()
+	planID := uint(1)
+	missingPlan := uint(9)
+
+	tests := []struct {
+		name   string
+		action models.ScheduledAction
+		calls  []string
+		err    string
+		check  func(t *testing.T, db *gorm.DB, u models.OcservUser)
+	}{
+		{
+			name:   "lock",
+			action: models.ScheduledAction{Action: models.ScheduledLock},
+			calls:  []string{"lock alice", "disconnect alice"},
+			check: func(t *testing.T, db *gorm.DB, u models.OcservUser) {
+				assert.True(t, u.IsLocked)
+			},
+		},
+		{
+			name:   "unlock",
+			action: models.ScheduledAction{Action: models.ScheduledUnlock},
+			calls:  []string{"unlock alice"},
+			check: func(t *testing.T, db *gorm.DB, u models.OcservUser) {
+				assert.False(t, u.IsLocked)
+			},
+		},
+		{
+			name:   "change group",
+			action: models.ScheduledAction{Action: models.ScheduledChangeGroup, Group: "vip"},
+			// rewriting the ocpasswd entry unlocks it, so the lock is set again
+			calls: []string{"create vip alice", "lock alice"},
+			check: func(t *testing.T, db *gorm.DB, u models.OcservUser) {
+				assert.Equal(t, "vip", u.Group)
+				assert.True(t, u.IsLocked)
+			},
+		},
+		{
+			name:   "change to a missing group",
+			action: models.ScheduledAction{Action: models.ScheduledChangeGroup, Group: "removed"},
+			err:    "group removed not found",
+		},
+		{
+			name:   "change plan",
+			action: models.ScheduledAction{Action: models.ScheduledChangePlan, PlanID: &planID},
+			calls:  []string{"create vip alice"},
+			check: func(t *testing.T, db *gorm.DB, u models.OcservUser) {
+				assert.False(t, u.IsLocked)
+				assert.Equal(t, planID, *u.PlanID)
+				assert.Equal(t, 50, u.TrafficSize)
+				assert.Zero(t, u.Tx)
+			},
+		},
+		{
+			name:   "change to a missing plan",
+			action: models.ScheduledAction{Action: models.ScheduledChangePlan, PlanID: &missingPlan},
+			err:    "invalid plan: record not found",
+		},
+		{
+			name:   "change plan without a plan",
+			action: models.ScheduledAction{Action: models.ScheduledChangePlan},
+			err:    "plan_id is required to change the plan",
+		},
+		{
+			name:   "reset traffic",
+			action: models.ScheduledAction{Action: models.ScheduledResetTraffic},
+			check: func(t *testing.T, db *gorm.DB, u models.OcservUser) {
+				assert.Zero(t, u.Rx)
+				assert.Zero(t, u.Tx)
+				assert.True(t, u.IsLocked, "locked by an admin, not by its quota")
+			},
+		},
+		{
+			name:   "invalid",
+			action: models.ScheduledAction{Action: "rename"},
+			err:    "invalid action rename",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, docker, db := setupService(t)
+			require.NoError(t, db.Create(&models.OcservGroup{Name: "vip", Owner: "admin"}).Error)
+			require.NoError(t, db.Create(&models.Plan{
+				ID: planID, Name: "gold", Group: "vip", TrafficType: models.MonthlyTransmit, TrafficSize: 50,
+				DurationDays: 30, IsActive: true,
+			}).Error)
+			u := createUser(t, db, models.OcservUser{Username: "alice", IsLocked: true, Tx: 1 << 30})
+
+			action := tt.action
+			action.OcUserID = u.ID
+			err := c.runScheduledAction(db, &action, now)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.calls, docker.calls)
+
+			require.NoError(t, db.First(&u, u.ID).Error)
+			tt.check(t, db, u)
+		})
+	}
+
+	t.Run("missing user", func(t *testing.T) {
+		c, _, db := setupService(t)
+		err := c.runScheduledAction(db, &models.ScheduledAction{OcUserID: 1, Action: models.ScheduledLock}, now)
+		assert.EqualError(t, err, "user not found: record not found")
+	})
+}
+
+func TestResetTraffic(t *testing.T) {
+	now := time.Now()
+	yesterday, nextMonth := now.AddDate(0, 0, -1), now.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name     string
+		user     models.OcservUser
+		calls    []string
+		unlocked bool
+	}{
+		{
+			name:     "over quota",
+			user:     models.OcservUser{IsLocked: true, DeactivatedAt: &yesterday, DeactivatedReason: models.DeactivatedQuota, ExpireAt: &nextMonth},
+			calls:    []string{"unlock alice"},
+			unlocked: true,
+		},
+		{
+			name: "over quota and expired",
+			user: models.OcservUser{IsLocked: true, DeactivatedAt: &yesterday, DeactivatedReason: models.DeactivatedQuota, ExpireAt: &yesterday},
+		},
+		{
+			name: "expired",
+			user: models.OcservUser{IsLocked: true, DeactivatedAt: &yesterday, DeactivatedReason: models.DeactivatedExpired, ExpireAt: &nextMonth},
+		},
+		{
+			name:  "throttled",
+			user:  models.OcservUser{ThrottledAt: &yesterday},
+			calls: []string{"config alice", "disconnect alice"},
+		},
+		{
+			name: "active",
+			user: models.OcservUser{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, docker, db := setupService(t)
+			tt.user.Username, tt.user.Rx, tt.user.Tx = "alice", 1<<30, 1<<30
+			u := createUser(t, db, tt.user)
+
+			require.NoError(t, c.resetTraffic(db, u, now))
+			assert.Equal(t, tt.calls, docker.calls)
+
+			var saved models.OcservUser
+			require.NoError(t, db.First(&saved, u.ID).Error)
+			assert.Zero(t, saved.Rx)
+			assert.Zero(t, saved.Tx)
+			assert.WithinDuration(t, now, *saved.TrafficResetAt, time.Second)
+			assert.Nil(t, saved.ThrottledAt)
+			assert.Equal(t, tt.unlocked, !saved.IsLocked && tt.user.IsLocked)
+			if tt.unlocked {
+				assert.Nil(t, saved.DeactivatedAt)
+				assert.Empty(t, saved.DeactivatedReason)
+			} else {
+				assert.Equal(t, tt.user.DeactivatedReason, saved.DeactivatedReason)
+			}
+		})
+	}
+}
+
+func TestRunScheduledActions(t *testing.T) {
+	c, docker, db := setupService(t)
+	alice := createUser(t, db, models.OcservUser{Username: "alice"})
+	bob := createUser(t, db, models.OcservUser{Username: "bob"})
+
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	actions := []models.ScheduledAction{
+		{OcUserID: alice.ID, Action: models.ScheduledLock, RunAt: past, CreatedBy: "admin"},
+		{OcUserID: alice.ID, Action: models.ScheduledDelete, RunAt: past.Add(time.Second), CreatedBy: "admin"},
+		{OcUserID: alice.ID, Action: models.ScheduledUnlock, RunAt: future, CreatedBy: "admin"},
+		// claimed by another run
+		{OcUserID: bob.ID, Action: models.ScheduledLock, RunAt: past, CreatedBy: "admin", Status: models.ScheduledRunning},
+		{OcUserID: bob.ID, Action: models.ScheduledChangeGroup, Group: "removed", RunAt: past, CreatedBy: "admin"},
+	}
+	require.NoError(t, db.Create(&actions).Error)
+
+	c.RunScheduledActions(context.Background(), db)
+
+	assert.Equal(t, []string{"lock alice", "disconnect alice", "disconnect alice", "delete alice"}, docker.calls)
+
+	var saved []models.ScheduledAction
+	require.NoError(t, db.Order("id").Find(&saved).Error)
+	require.Len(t, saved, 3, "the pending actions of the deleted user are deleted with it")
+
+	assert.Equal(t, actions[1].ID, saved[0].ID, "the delete action is kept")
+	assert.Equal(t, models.ScheduledDone, saved[0].Status)
+	assert.NotNil(t, saved[0].ExecutedAt)
+
+	assert.Equal(t, models.ScheduledRunning, saved[1].Status, "actions claimed by another run are left to it")
+
+	assert.Equal(t, models.ScheduledFailed, saved[2].Status)
+	assert.Equal(t, "group removed not found", saved[2].Result)
+
+	var users int64
+	require.NoError(t, db.Model(&models.OcservUser{}).Count(&users).Error)
+	assert.EqualValues(t, 1, users)
+
+	t.Run("interrupted", func(t *testing.T) {
+		c.FailInterruptedActions(context.Background(), db)
+
+		var action models.ScheduledAction
+		require.NoError(t, db.First(&action, saved[1].ID).Error)
+		assert.Equal(t, models.ScheduledFailed, action.Status)
+		assert.Equal(t, "interrupted", action.Result)
+	})
+
+	t.Run("failing ocserv", func(t *testing.T) {
+		docker.err = errors.New("ocserv is down")
+		require.NoError(t, db.Create(&models.ScheduledAction{
+			OcUserID: bob.ID, Action: models.ScheduledUnlock, RunAt: past, CreatedBy: "admin",
+		}).Error)
+
+		c.RunScheduledActions(context.Background(), db)
+
+		var action models.ScheduledAction
+		require.NoError(t, db.Last(&action).Error)
+		assert.Equal(t, models.ScheduledFailed, action.Status)
+		assert.Equal(t, "ocserv is down", action.Result)
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"github.com/mmtaee/ocserv-users-management/common/models"
 	occtlDocker "github.com/mmtaee/ocserv-users-management/common/occtl_docker"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/occtl"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/configversion"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/database"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
 	stateManager "github.com/mmtaee/ocserv-users-management/user_expiry/pkg/state"
@@ -54,17 +56,24 @@ func (c *CornService) MissedCron() {
 	}
 	logger.Info("Checking missing daily cron jobs completed")
 
-	// monthly missed job
-	logger.Info("start checking missing monthly cron jobs completed")
-	firstDay := today.Day() == 1
-	newMonth := state.MonthlyLastRun.IsZero() || state.MonthlyLastRun.Month() != today.Month()
-
-	if firstDay && newMonth {
-		logger.Info("Running missed MONTHLY cron...")
-		c.ActiveMonthlyUsers(context.Background(), db)
-		state.MonthlyLastRun = today
+	// traffic period missed job
+	logger.Info("Start checking missing traffic period cron jobs")
+	if state.TrafficLastRun.IsZero() || state.TrafficLastRun.Truncate(24*time.Hour).Before(today) {
+		logger.Info("Running missed TRAFFIC PERIOD cron...")
+		c.ResetTrafficPeriods(context.Background(), db)
+		state.TrafficLastRun = today
 	}
-	logger.Info("Checking missing monthly cron jobs completed")
+	logger.Info("Checking missing traffic period cron jobs completed")
+
+	// schedules changed state while the service was down
+	c.EnforceSchedules(context.Background(), db)
+
+	// reports due while the service was down
+	c.RunReportSchedules(context.Background(), db)
+
+	// actions scheduled on users while the service was down, after those it was running when it stopped
+	c.FailInterruptedActions(context.Background(), db)
+	c.RunScheduledActions(context.Background(), db)
 
 	if err := state.Save(); err != nil {
 		logger.Fatal("Failed to save state: %v", err)
@@ -73,7 +82,8 @@ func (c *CornService) MissedCron() {
 }
 
 func (c *CornService) UserExpiryCron(ctx context.Context) {
-	cronJob := cron.New(cron.WithSeconds())
+	// a job still running when it is due again, such as a long batch of scheduled actions, is skipped
+	cronJob := cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
 	db := database.GetConnection()
 
 	state := stateManager.NewCronState()
@@ -92,11 +102,11 @@ func (c *CornService) UserExpiryCron(ctx context.Context) {
 	}
 	logger.Info("Running user expiry cron...")
 
-	// First and second day of each month at 00:01:00 — activate monthly users
-	_, err = cronJob.AddFunc("0 1 0 1,2 * *", func() {
-		c.ActiveMonthlyUsers(ctx, db)
+	// Every day at 00:02:00 — reactivate users whose traffic period was reset
+	_, err = cronJob.AddFunc("0 2 0 * * *", func() {
+		c.ResetTrafficPeriods(ctx, db)
 
-		state.MonthlyLastRun = time.Now().Truncate(24 * time.Hour)
+		state.TrafficLastRun = time.Now().Truncate(24 * time.Hour)
 		if err = state.Save(); err != nil {
 			logger.Error("Failed to update state: %v", err)
 		}
@@ -105,11 +115,35 @@ func (c *CornService) UserExpiryCron(ctx context.Context) {
 		logger.Fatal("Failed to add cron job: %v", err)
 	}
 
+	// Every minute — lock and unlock users following their access schedule
+	_, err = cronJob.AddFunc("0 * * * * *", func() {
+		c.EnforceSchedules(ctx, db)
+	})
+	if err != nil {
+		logger.Fatal("Failed to add cron job: %v", err)
+	}
+
+	// Every minute at second 30 — run the due actions scheduled on users
+	_, err = cronJob.AddFunc("30 * * * * *", func() {
+		c.RunScheduledActions(ctx, db)
+	})
+	if err != nil {
+		logger.Fatal("Failed to add cron job: %v", err)
+	}
+
+	// Every hour at minute 5 — generate the due usage reports
+	_, err = cronJob.AddFunc("0 5 * * * *", func() {
+		c.RunReportSchedules(ctx, db)
+	})
+	if err != nil {
+		logger.Fatal("Failed to add cron job: %v", err)
+	}
+
 	logger.Info("User activating Cron starting...")
 
 	//// Test: run every minute at second 0
 	//_, err = c.AddFunc("0 * * * * *", func() {
-	//	ActiveMonthlyUsers(ctx, db)
+	//	ResetTrafficPeriods(ctx, db)
 	//})
 
 	cronJob.Start()
@@ -146,8 +180,9 @@ func (c *CornService) ExpireUsers(ctx context.Context, db *gorm.DB) {
 
 			// Update DB user
 			if err2 := db.Model(&u).Updates(map[string]interface{}{ // CHANGED: using &u (copied)
-				"deactivated_at": time.Now(),
-				"is_locked":      true,
+				"deactivated_at":     time.Now(),
+				"deactivated_reason": models.DeactivatedExpired,
+				"is_locked":          true,
 			}).Error; err2 != nil {
 				logger.Error("Failed to update user: %v", err2)
 				return
@@ -172,6 +207,13 @@ func (c *CornService) ExpireUsers(ctx context.Context, db *gorm.DB) {
 			if _, err4 := lock(u.Username); err4 != nil {
 				logger.Error("Failed to lock user %s: %v", u.Username, err4)
 			}
+			if err5 := onNodes(db, u, func(agent node.AgentInterface) error {
+				_, _ = agent.DisconnectUser(u.Username)
+				_, err6 := agent.Lock(u.Username)
+				return err6
+			}); err5 != nil {
+				logger.Error("Failed to lock user %s on nodes: %v", u.Username, err5)
+			}
 			return
 		}(u)
 	}
@@ -179,17 +221,24 @@ func (c *CornService) ExpireUsers(ctx context.Context, db *gorm.DB) {
 	wg.Wait()
 }
 
-func (c *CornService) ActiveMonthlyUsers(ctx context.Context, db *gorm.DB) {
+// ResetTrafficPeriods reactivates the users locked or throttled by a daily, weekly or monthly traffic
+// policy once the usage of their current period is back under the limit, resetting their counters.
+// Throttled users get their original config back. Lifetime quotas never reset, so their throttle is
+// only lifted, keeping the counters, once a top-up or a larger limit brings the usage under it.
+func (c *CornService) ResetTrafficPeriods(ctx context.Context, db *gorm.DB) {
 	var users []models.OcservUser
-	today := time.Now().Truncate(24 * time.Hour)
+	now := time.Now()
+	today := now.Truncate(24 * time.Hour)
 
 	err := db.WithContext(ctx).
 		Where("(expire_at IS NULL OR expire_at > ?)", today).
-		Where("deactivated_at IS NOT NULL").
-		Where("traffic_type IN ?", []string{
-			models.MonthlyReceive,
-			models.MonthlyTransmit,
-		}).
+		Where("(deactivated_reason = ? OR throttled_at IS NOT NULL)", models.DeactivatedQuota).
+		Where(
+			db.Where("traffic_period IN ?", []string{models.TrafficDaily, models.TrafficWeekly, models.TrafficMonthly}).
+				// rows saved before traffic periods existed
+				Or("traffic_period = '' AND traffic_type IN ?", []string{models.MonthlyReceive, models.MonthlyTransmit}).
+				Or("throttled_at IS NOT NULL"),
+		).
 		Find(&users).Error
 	if err != nil {
 		logger.Error("Failed to get users: %v", err)
@@ -200,6 +249,21 @@ func (c *CornService) ActiveMonthlyUsers(ctx context.Context, db *gorm.DB) {
 	sem := make(chan struct{}, 10)
 
 	for _, u := range users {
+		usage, err := u.TrafficUsage(db.WithContext(ctx), now)
+		if err != nil {
+			logger.Error("Failed to get traffic usage of user %s: %v", u.Username, err)
+			continue
+		}
+		if usage == nil || usage.Exceeded {
+			continue
+		}
+		if usage.Period == models.TrafficLifetime {
+			if u.ThrottledAt != nil {
+				c.liftThrottle(db, u)
+			}
+			continue
+		}
+
 		wg.Add(1)
 		sem <- struct{}{}
 
@@ -207,16 +271,31 @@ func (c *CornService) ActiveMonthlyUsers(ctx context.Context, db *gorm.DB) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			if err2 := db.Model(&u).Updates(map[string]interface{}{
-				"rx":             0,
-				"tx":             0,
-				"deactivated_at": nil,
-				"is_locked":      false,
-			}).Error; err2 != nil {
+			updates := map[string]interface{}{
+				"rx":           0,
+				"tx":           0,
+				"throttled_at": nil,
+			}
+			if u.DeactivatedReason == models.DeactivatedQuota {
+				updates["deactivated_at"] = nil
+				updates["deactivated_reason"] = ""
+				updates["is_locked"] = false
+			}
+			if err2 := db.Model(&u).Updates(updates).Error; err2 != nil {
 				logger.Error("Failed to update user %s: %v", u.Username, err2)
 				return
 			}
 
+			if u.ThrottledAt != nil {
+				u.ThrottledAt = nil
+				if err2 := c.restoreConfig(db, u); err2 != nil {
+					logger.Error("Failed to restore config of user %s: %v", u.Username, err2)
+				}
+			}
+			if u.DeactivatedReason != models.DeactivatedQuota {
+				return
+			}
+
 			var unlock func(string) (string, error)
 
 			if c.dockerMode {
@@ -227,9 +306,67 @@ func (c *CornService) ActiveMonthlyUsers(ctx context.Context, db *gorm.DB) {
 			if _, err2 := unlock(u.Username); err2 != nil {
 				logger.Error("Failed to unlock user %s: %v", u.Username, err2)
 			}
+			if err3 := onNodes(db, u, func(agent node.AgentInterface) error {
+				_, err4 := agent.UnLock(u.Username)
+				return err4
+			}); err3 != nil {
+				logger.Error("Failed to unlock user %s on nodes: %v", u.Username, err3)
+			}
 
 		}(u)
 	}
 
 	wg.Wait()
 }
+
+// liftThrottle restores the config of a throttled user without resetting its counters.
+func (c *CornService) liftThrottle(db *gorm.DB, u models.OcservUser) {
+	if err := db.Model(&u).Update("throttled_at", nil).Error; err != nil {
+		logger.Error("Failed to update user %s: %v", u.Username, err)
+		return
+	}
+	u.ThrottledAt = nil
+	if err := c.restoreConfig(db, u); err != nil {
+		logger.Error("Failed to restore config of user %s: %v", u.Username, err)
+	}
+}
+
+// restoreConfig writes the unthrottled config of the user on the local ocserv and its nodes, and
+// disconnects the user so the full rates apply to the next session. The restored config is recorded
+// as a version of the user config.
+func (c *CornService) restoreConfig(db *gorm.DB, u models.OcservUser) error {
+	config := u.AppliedConfig()
+
+	if c.dockerMode {
+		if _, err := c.occtlDockerRepo.UpdateConfig(u.Username, config); err != nil {
+			return err
+		}
+		_, _ = c.occtlDockerRepo.DisconnectUser(u.Username)
+	} else {
+		if err := c.ocservUserHandler.CreateConfig(u.Username, config); err != nil {
+			return err
+		}
+		if _, err := c.occtlHandler.ReloadConfigs(); err != nil {
+			return err
+		}
+		_, _ = c.occtlHandler.DisconnectUser(u.Username)
+	}
+	configversion.RecordChange(db, models.ConfigKindUser, u.Username, config, config, "", "throttle lifted")
+
+	return onNodes(db, u, func(agent node.AgentInterface) error {
+		if err := agent.UpdateConfig(u.Username, config); err != nil {
+			return err
+		}
+		_, _ = agent.DisconnectUser(u.Username)
+		return nil
+	})
+}
+
+// onNodes runs fn against the agent of every node the user is assigned to.
+func onNodes(db *gorm.DB, u models.OcservUser, fn func(agent node.AgentInterface) error) error {
+	nodes, err := node.UserNodes(db, u.ID)
+	if err != nil {
+		return err
+	}
+	return node.FanOut(nodes, fn)
+}
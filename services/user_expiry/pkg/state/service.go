@@ -16,7 +16,7 @@ var stateMu sync.Mutex
 
 type CronState struct {
 	DailyLastRun   time.Time
-	MonthlyLastRun time.Time
+	TrafficLastRun time.Time
 }
 
 func NewCronState() *CronState {
@@ -40,7 +40,7 @@ func ensureStateFile() error {
 
 	// Create the file if it does not exist
 	if _, err = os.Stat(absPath); os.IsNotExist(err) {
-		defaultContent := "daily_last_run=0\nmonthly_last_run=0\n"
+		defaultContent := "daily_last_run=0\ntraffic_last_run=0\n"
 		if err = os.WriteFile(absPath, []byte(defaultContent), 0644); err != nil {
 			logger.Error("Failed to write state file %s: %v", absPath, err)
 			return err
@@ -73,7 +73,7 @@ func LoadStateOrDefault() *CronState {
 	parse := func(s string) time.Time {
 		defaultTime := time.Now().UTC().AddDate(0, -2, 0).Truncate(24 * time.Hour)
 		if s == "0" || s == "" {
-			// Fallback: 2 days ago for daily, 2 months ago for monthly
+			// Fallback: 2 months ago
 			return defaultTime
 		}
 		t, err := time.Parse("2006-01-02", s)
@@ -88,8 +88,8 @@ func LoadStateOrDefault() *CronState {
 		if strings.HasPrefix(l, "daily_last_run=") {
 			state.DailyLastRun = parse(strings.TrimPrefix(l, "daily_last_run="))
 		}
-		if strings.HasPrefix(l, "monthly_last_run=") {
-			state.MonthlyLastRun = parse(strings.TrimPrefix(l, "monthly_last_run="))
+		if strings.HasPrefix(l, "traffic_last_run=") {
+			state.TrafficLastRun = parse(strings.TrimPrefix(l, "traffic_last_run="))
 		}
 	}
 	return state
@@ -100,9 +100,9 @@ func (s *CronState) Save() error {
 	defer stateMu.Unlock()
 
 	content := fmt.Sprintf(
-		"daily_last_run=%s\nmonthly_last_run=%s\n",
+		"daily_last_run=%s\ntraffic_last_run=%s\n",
 		formatTime(s.DailyLastRun),
-		formatTime(s.MonthlyLastRun),
+		formatTime(s.TrafficLastRun),
 	)
 	return os.WriteFile(stateFile, []byte(content), 0644)
 }
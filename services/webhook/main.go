@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	occtlDocker "github.com/mmtaee/ocserv-users-management/common/occtl_docker"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/group"
+	"github.com/mmtaee/ocserv-users-management/common/ocserv/node"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/occtl"
 	"github.com/mmtaee/ocserv-users-management/common/ocserv/user"
 	"github.com/mmtaee/ocserv-users-management/common/pkg/logger"
+	"github.com/mmtaee/ocserv-users-management/common/pkg/utils"
 	"net/http"
 	"os"
 	"os/signal"
@@ -23,16 +27,35 @@ type Webhook struct {
 }
 
 var (
-	occtlHandler      occtl.OcservOcctlInterface
-	ocservUserHandler user.OcservUserInterface
+	occtlHandler       occtl.OcservOcctlInterface
+	ocservUserHandler  user.OcservUserInterface
+	ocservGroupHandler group.OcservGroupInterface
+	agentToken         string
 )
 
+// userActions are the actions that operate on a single ocserv user and need a username.
+var userActions = map[string]struct{}{
+	"disconnect": {},
+	"lock":       {},
+	"unlock":     {},
+	"create":     {},
+	"delete":     {},
+	"config":     {},
+}
+
 func init() {
 	occtlHandler = occtl.NewOcservOcctl()
 	ocservUserHandler = user.NewOcservUser()
+	ocservGroupHandler = group.NewOcservGroup()
+	agentToken = os.Getenv("AGENT_TOKEN")
 }
 
 func main() {
+	// the agent creates and deletes users and writes config files, it never runs unauthenticated
+	if agentToken == "" {
+		logger.Fatal("AGENT_TOKEN is required to start the webhook server")
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/webhook/", webhookHandler)
 
@@ -41,12 +64,23 @@ func main() {
 		Handler: mux,
 	}
 
+	// remote agents receive passwords and must serve TLS, the local docker agent may stay on the
+	// private docker network without it
+	certFile, keyFile := os.Getenv("AGENT_TLS_CERT"), os.Getenv("AGENT_TLS_KEY")
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
-		logger.Info("Webhook server listening on: %s ", server.Addr)
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		var err error
+		if certFile != "" && keyFile != "" {
+			logger.Info("Webhook server listening with TLS on: %s ", server.Addr)
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			logger.Warn("Webhook server listening without TLS on: %s ", server.Addr)
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Fatal("Failed to start webhook server: %v", err)
 		}
 	}()
@@ -71,6 +105,11 @@ func webhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(node.TokenHeader)), []byte(agentToken)) != 1 {
+		http.Error(w, "Invalid agent token", http.StatusUnauthorized)
+		return
+	}
+
 	payload := occtlDocker.WebhookPayload{}
 	defer r.Body.Close()
 	decoder := json.NewDecoder(r.Body)
@@ -79,11 +118,6 @@ func webhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if payload.Username == "" {
-		http.Error(w, "Username is required", http.StatusBadRequest)
-		return
-	}
-
 	// Extract action from path: /webhook/<action>
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(parts) < 2 {
@@ -92,6 +126,24 @@ func webhookHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	action := strings.ToLower(parts[1])
 
+	if _, ok := userActions[action]; ok {
+		if payload.Username == "" {
+			http.Error(w, "Username is required", http.StatusBadRequest)
+			return
+		}
+		if err := utils.ValidateName(payload.Username); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	// group names are config file names too
+	if payload.Group != "" {
+		if err := utils.ValidateName(payload.Group); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	logger.Info("Received webhook action: %s for username %s", action, payload.Username)
 
 	switch action {
@@ -119,7 +171,100 @@ func webhookHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		_, _ = fmt.Fprintf(w, "User %s unlocked successfully. message: %s", payload.Username, msg)
 
+	case "create":
+		if payload.Password == "" {
+			http.Error(w, "Password is required", http.StatusBadRequest)
+			return
+		}
+		if err := ocservUserHandler.Create(payload.Group, payload.Username, payload.Password, payload.UserConfig); err != nil {
+			http.Error(w, "Failed to create user: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		reload()
+		_, _ = fmt.Fprintf(w, "User %s created successfully", payload.Username)
+
+	case "delete":
+		msg, err := ocservUserHandler.Delete(payload.Username)
+		if err != nil {
+			http.Error(w, "Failed to delete user: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		reload()
+		_, _ = fmt.Fprintf(w, "User %s deleted successfully. message: %s", payload.Username, msg)
+
+	case "config":
+		if err := ocservUserHandler.CreateConfig(payload.Username, payload.UserConfig); err != nil {
+			http.Error(w, "Failed to update user config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		reload()
+		_, _ = fmt.Fprintf(w, "User %s config updated successfully", payload.Username)
+
+	case "group-create":
+		if payload.Group == "" {
+			http.Error(w, "Group is required", http.StatusBadRequest)
+			return
+		}
+		if err := ocservGroupHandler.Create(payload.Group, payload.GroupConfig); err != nil {
+			http.Error(w, "Failed to create group: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		reload()
+		_, _ = fmt.Fprintf(w, "Group %s created successfully", payload.Group)
+
+	case "group-delete":
+		if payload.Group == "" {
+			http.Error(w, "Group is required", http.StatusBadRequest)
+			return
+		}
+		if err := ocservGroupHandler.Delete(payload.Group); err != nil {
+			http.Error(w, "Failed to delete group: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		reload()
+		_, _ = fmt.Fprintf(w, "Group %s deleted successfully", payload.Group)
+
+	case "group-defaults":
+		if err := ocservGroupHandler.UpdateDefaultsGroup(payload.GroupConfig); err != nil {
+			http.Error(w, "Failed to update defaults group: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		reload()
+		_, _ = fmt.Fprint(w, "Defaults group updated successfully")
+
+	case "sessions":
+		sessions, err := occtlHandler.OnlineSessions()
+		if err != nil {
+			http.Error(w, "Failed to get online sessions: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, sessions)
+
+	case "status":
+		status, err := occtlHandler.ShowStatus(false)
+		if err != nil {
+			http.Error(w, "Failed to get server status: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, status)
+
 	default:
 		http.Error(w, "Unknown action: "+action, http.StatusBadRequest)
 	}
 }
+
+// reload asks ocserv to pick up the changed files without blocking the response.
+func reload() {
+	go func() {
+		if _, err := occtlHandler.ReloadConfigs(); err != nil {
+			logger.Error("Failed to reload ocserv configs: %v", err)
+		}
+	}()
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode webhook response: %v", err)
+	}
+}